@@ -136,6 +136,9 @@ func (r HTTPRequest) String() string {
 }
 
 // ============= Example 3: SQL Query Builder =============
+// Навчальний приклад: Where склеює умову в SQL як є, тож значення від
+// користувача - пряма SQL injection. Безпечна версія з плейсхолдерами -
+// week_14/practice/05_query_builder.
 
 type SQLQuery struct {
 	Table   string
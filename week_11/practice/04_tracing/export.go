@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============= Export =============
+
+// SpanData - завершений span; формат JSON-рядка у файлі
+type SpanData struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	Name       string         `json:"name"`
+	Service    string         `json:"service"`
+	Kind       SpanKind       `json:"kind"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	Duration   time.Duration  `json:"duration_ns"`
+	Attrs      map[string]any `json:"attrs,omitempty"`
+	Events     []SpanEvent    `json:"events,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Sampled    bool           `json:"-"`
+	TraceState string         `json:"tracestate,omitempty"`
+}
+
+type Exporter interface {
+	Export(span SpanData)
+}
+
+// ============= Memory =============
+
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (e *MemoryExporter) Export(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func (e *MemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+func (e *MemoryExporter) Trace(traceID string) []SpanData {
+	var result []SpanData
+	for _, span := range e.Spans() {
+		if span.TraceID == traceID {
+			result = append(result, span)
+		}
+	}
+	return result
+}
+
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// ============= JSON Lines =============
+
+// JSONLinesExporter пише один span на рядок - зручно для jq, grep
+// і злиття файлів з кількох сервісів: cat *.jsonl | jq 'select(.trace_id=="...")'
+type JSONLinesExporter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+	f   *os.File
+}
+
+func NewJSONLinesExporter(w io.Writer) *JSONLinesExporter {
+	return &JSONLinesExporter{w: w, enc: json.NewEncoder(w)}
+}
+
+// NewFileExporter дописує в кінець файлу, тож кілька запусків
+// накопичуються в одному файлі
+func NewFileExporter(path string) (*JSONLinesExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	e := NewJSONLinesExporter(f)
+	e.f = f
+	return e, nil
+}
+
+func (e *JSONLinesExporter) Export(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enc.Encode(span)
+}
+
+func (e *JSONLinesExporter) Close() error {
+	if e.f == nil {
+		return nil
+	}
+	return e.f.Close()
+}
+
+func ReadJSONLines(r io.Reader) ([]SpanData, error) {
+	var spans []SpanData
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var span SpanData
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, scanner.Err()
+}
+
+// ============= Tree =============
+
+// PrintTree друкує трейс деревом з відступами і тривалістю - видно,
+// де саме в fan-out загубився час:
+//
+//	GET /report [api] 152ms
+//	  fetch users [api] 12ms
+//	  process job 3 [worker] 131ms  <- ось він
+func PrintTree(w io.Writer, spans []SpanData) {
+	ids := make(map[string]bool, len(spans))
+	children := make(map[string][]SpanData)
+	for _, s := range spans {
+		ids[s.SpanID] = true
+	}
+	var roots []SpanData
+	for _, s := range spans {
+		if s.ParentID == "" || !ids[s.ParentID] {
+			roots = append(roots, s)
+		} else {
+			children[s.ParentID] = append(children[s.ParentID], s)
+		}
+	}
+
+	byStart := func(list []SpanData) {
+		sort.Slice(list, func(i, j int) bool { return list[i].Start.Before(list[j].Start) })
+	}
+	var walk func(s SpanData, depth int)
+	walk = func(s SpanData, depth int) {
+		line := fmt.Sprintf("%s%s [%s] %v", strings.Repeat("  ", depth), s.Name, s.Service, s.Duration.Round(time.Microsecond*100))
+		if s.Error != "" {
+			line += " ❌ " + s.Error
+		}
+		fmt.Fprintln(w, line)
+		kids := children[s.SpanID]
+		byStart(kids)
+		for _, child := range kids {
+			walk(child, depth+1)
+		}
+	}
+
+	byStart(roots)
+	for _, root := range roots {
+		walk(root, 0)
+	}
+}
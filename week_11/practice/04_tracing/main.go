@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   Distributed Tracing (W3C)            ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	// Усі "сервіси" в одному процесі, але спілкуються лише через мережу -
+	// контекст трейсу переходить тільки в заголовках і префіксах рядків
+	memory := NewMemoryExporter()
+	path := filepath.Join(os.TempDir(), "traces.jsonl")
+	os.Remove(path)
+	file, err := NewFileExporter(path)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	exporter := multiExporter{memory, file}
+
+	fmt.Println("\n🚀 1. Сервіси:")
+
+	// inventory - TCP, рядковий протокол
+	inventoryTracer := NewTracer("inventory", exporter)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go ServeLines(context.Background(), inventoryTracer, conn, func(ctx context.Context, line string) string {
+				time.Sleep(2 * time.Millisecond)
+				return "STOCK " + strconv.Itoa(len(line)*7)
+			})
+		}
+	}()
+	fmt.Println("   inventory (tcp): ", listener.Addr())
+
+	// users - HTTP; користувач 3 повільний
+	usersTracer := NewTracer("users", exporter)
+	usersMux := http.NewServeMux()
+	usersMux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "3" {
+			_, span := usersTracer.Start(r.Context(), "db query (cold cache)")
+			time.Sleep(80 * time.Millisecond)
+			span.End()
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": r.PathValue("id")})
+	})
+	users := httptest.NewServer(Middleware(usersTracer, usersMux))
+	defer users.Close()
+	fmt.Println("   users (http):    ", users.URL)
+
+	// api - fan-out через worker pool
+	apiTracer := NewTracer("api", exporter)
+	apiClient := NewClient(apiTracer)
+	inventory, err := DialLines(listener.Addr().String(), apiTracer)
+	if err != nil {
+		panic(err)
+	}
+	defer inventory.Close()
+
+	apiMux := http.NewServeMux()
+	apiMux.HandleFunc("GET /report", func(w http.ResponseWriter, r *http.Request) {
+		var jobs []Job
+		for id := 1; id <= 5; id++ {
+			jobs = append(jobs, NewJob(r.Context(), id, id))
+		}
+		results := RunPool(r.Context(), apiTracer, 3, jobs, func(ctx context.Context, job Job) (int, error) {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, users.URL+"/users/"+strconv.Itoa(job.Value), nil)
+			resp, err := apiClient.Do(req)
+			if err != nil {
+				return 0, err
+			}
+			resp.Body.Close()
+
+			reply, err := inventory.Call(ctx, "STOCK sku-"+strconv.Itoa(job.Value))
+			if err != nil {
+				return 0, err
+			}
+			n, _ := strconv.Atoi(strings.TrimPrefix(reply, "STOCK "))
+			return n, nil
+		})
+		fmt.Fprintf(w, "%d rows\n", len(results))
+	})
+	api := httptest.NewServer(Middleware(apiTracer, apiMux))
+	defer api.Close()
+	fmt.Println("   api (http):      ", api.URL)
+
+	fmt.Println("\n📡 2. Клієнт викликає GET /report:")
+	clientTracer := NewTracer("client", exporter)
+	ctx, root := clientTracer.Start(context.Background(), "build report")
+	root.SetTraceState("client", "demo")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.URL+"/report", nil)
+	resp, err := NewClient(clientTracer).Do(req)
+	if err != nil {
+		panic(err)
+	}
+	resp.Body.Close()
+	root.End()
+
+	traceID := root.SpanContext().TraceID.String()
+	fmt.Println("   status:     ", resp.Status)
+	fmt.Println("   traceparent:", resp.Header.Get("Traceparent"))
+
+	time.Sleep(20 * time.Millisecond) // server span'и завершуються після відповіді
+
+	spans := memory.Trace(traceID)
+	fmt.Printf("\n🌳 3. Трейс %s (%d span'ів, 4 сервіси):\n", traceID, len(spans))
+	var tree strings.Builder
+	PrintTree(&tree, spans)
+	for line := range strings.SplitSeq(strings.TrimRight(tree.String(), "\n"), "\n") {
+		fmt.Println("   " + line)
+	}
+
+	fmt.Println("\n🐢 4. Найповільніший листовий span:")
+	parents := map[string]bool{}
+	for _, s := range spans {
+		parents[s.ParentID] = true
+	}
+	var slowest SpanData
+	for _, s := range spans {
+		if !parents[s.SpanID] && s.Duration > slowest.Duration {
+			slowest = s
+		}
+	}
+	fmt.Printf("   %s [%s] %v\n", slowest.Name, slowest.Service, slowest.Duration.Round(time.Millisecond))
+
+	fmt.Println("\n📄 5. JSON Lines:", path)
+	fmt.Printf("   jq 'select(.trace_id==\"%s\") | [.service, .name, .duration_ns]' %s\n", traceID, path)
+
+	fmt.Println("\n✅ Demo completed!")
+}
+
+// multiExporter - той самий span у кілька місць
+type multiExporter []Exporter
+
+func (m multiExporter) Export(span SpanData) {
+	for _, e := range m {
+		e.Export(span)
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{"valid sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"valid not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true},
+		{"future version with extra field", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what", true},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x", false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"bad separator", "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"too short", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tt.input)
+			if (err == nil) != tt.valid {
+				t.Fatalf("Expected valid=%v, got err=%v", tt.valid, err)
+			}
+			if tt.valid && !strings.HasPrefix(tt.input, "cc") && sc.Traceparent() != tt.input {
+				t.Errorf("Expected %s, got %s", tt.input, sc.Traceparent())
+			}
+		})
+	}
+}
+
+func TestParseTraceparent_FutureVersionFlags(t *testing.T) {
+	sc, err := ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-ff-what")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Flags != FlagSampled {
+		t.Errorf("Expected flags %02x, got %02x", FlagSampled, sc.Flags)
+	}
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; sc.Traceparent() != want {
+		t.Errorf("Expected %s, got %s", want, sc.Traceparent())
+	}
+}
+
+func TestTraceState(t *testing.T) {
+	ts, err := ParseTraceState("rojo=00f067aa0ba902b7, congo=t61rcWkgMzE,,tenant@vendor=x y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Get("congo") != "t61rcWkgMzE" || ts.Get("tenant@vendor") != "x y" || ts.Len() != 3 {
+		t.Errorf("Expected 3 parsed members, got %s", ts)
+	}
+
+	// Оновлений запис переїжджає ліворуч
+	ts, _ = ts.Insert("congo", "new")
+	if want := "congo=new,rojo=00f067aa0ba902b7,tenant@vendor=x y"; ts.String() != want {
+		t.Errorf("Expected %s, got %s", want, ts)
+	}
+
+	invalid := []string{
+		"a=1,a=2", // дублікат
+		"Upper=1", // великі літери в ключі
+		"a=1=2",   // '=' у значенні
+		"novalue", // без '='
+	}
+	for _, s := range invalid {
+		if _, err := ParseTraceState(s); !errors.Is(err, ErrInvalidTracestate) {
+			t.Errorf("%q: Expected ErrInvalidTracestate, got %v", s, err)
+		}
+	}
+
+	var many []string
+	for i := range 33 {
+		many = append(many, "k"+strings.Repeat("x", i)+"=v")
+	}
+	if _, err := ParseTraceState(strings.Join(many, ",")); err == nil {
+		t.Error("Expected error for more than 32 members")
+	}
+
+	// Insert у повний список відкидає найстаріший
+	full, _ := ParseTraceState(strings.Join(many[:32], ","))
+	full, _ = full.Insert("fresh", "1")
+	if full.Len() != 32 || full.Get("fresh") != "1" || full.Get(many[31][:len(many[31])-2]) != "" {
+		t.Errorf("Expected oldest member dropped, got %d members", full.Len())
+	}
+}
+
+func TestSpanParentChild(t *testing.T) {
+	exp := NewMemoryExporter()
+	tracer := NewTracer("svc", exp)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttr("k", "v")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	child.End() // повторний End ігнорується
+	root.End()
+
+	spans := exp.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if c.TraceID != r.TraceID || c.ParentID != r.SpanID || r.ParentID != "" {
+		t.Errorf("Expected child of root in the same trace, got %+v / %+v", c, r)
+	}
+	if c.Attrs["k"] != "v" || c.Error != "boom" || c.Service != "svc" {
+		t.Errorf("Expected attrs and error recorded, got %+v", c)
+	}
+
+	// Новий корінь - новий трейс
+	_, other := tracer.Start(context.Background(), "other")
+	if other.SpanContext().TraceID == root.SpanContext().TraceID {
+		t.Error("Expected a new trace id for a new root")
+	}
+}
+
+func TestSampling(t *testing.T) {
+	exp := NewMemoryExporter()
+	tracer := NewTracer("svc", exp)
+	tracer.Sampler = RatioSampler(0)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	root.End()
+
+	if len(exp.Spans()) != 0 {
+		t.Errorf("Expected nothing exported, got %d", len(exp.Spans()))
+	}
+
+	// Рішення "не записувати" передається далі у flags=00
+	carrier := MapCarrier{}
+	Inject(ctx, carrier)
+	if !strings.HasSuffix(carrier["traceparent"], "-00") {
+		t.Errorf("Expected not-sampled flags, got %s", carrier["traceparent"])
+	}
+
+	// Віддалений sampled батько перемагає локальний Sampler
+	remote := Extract(context.Background(), MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+	_, span := tracer.Start(remote, "server")
+	span.End()
+	if spans := exp.Spans(); len(spans) != 1 || spans[0].ParentID != "00f067aa0ba902b7" {
+		t.Errorf("Expected span with remote parent, got %+v", spans)
+	}
+}
+
+func TestHTTPPropagation(t *testing.T) {
+	exp := NewMemoryExporter()
+	serverTracer := NewTracer("server", exp)
+	clientTracer := NewTracer("client", exp)
+
+	var gotState string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gotState = r.Header.Get("tracestate")
+	})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(Middleware(serverTracer, mux))
+	defer srv.Close()
+
+	ctx, root := clientTracer.Start(context.Background(), "root")
+	root.SetTraceState("client", "abc")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/users/42", nil)
+	resp, err := NewClient(clientTracer).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	root.End()
+
+	if gotState != "client=abc" {
+		t.Errorf("Expected tracestate client=abc, got %q", gotState)
+	}
+	if !strings.Contains(resp.Header.Get("Traceparent"), root.SpanContext().TraceID.String()) {
+		t.Errorf("Expected trace id in response header, got %s", resp.Header.Get("Traceparent"))
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	spans := exp.Trace(root.SpanContext().TraceID.String())
+	byName := map[string]SpanData{}
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	server, ok := byName["GET /users/{id}"]
+	if !ok {
+		t.Fatalf("Expected server span named by route pattern, got %v", spans)
+	}
+	client := byName["HTTP GET "+strings.TrimPrefix(srv.URL, "http://")]
+	if server.ParentID != client.SpanID || client.ParentID != root.SpanContext().SpanID.String() {
+		t.Errorf("Expected root -> client -> server chain")
+	}
+	if server.Kind != KindServer || server.Attrs["http.status_code"] != 200 {
+		t.Errorf("Expected server span with status 200, got %+v", server)
+	}
+
+	// 5xx - помилка і на сервері, і на клієнті
+	exp.Reset()
+	resp, _ = NewClient(clientTracer).Get(srv.URL + "/fail")
+	resp.Body.Close()
+	time.Sleep(10 * time.Millisecond)
+	for _, s := range exp.Spans() {
+		if s.Error == "" {
+			t.Errorf("Expected error on %s", s.Name)
+		}
+	}
+}
+
+func TestMiddlewareIgnoresInvalidTraceparent(t *testing.T) {
+	exp := NewMemoryExporter()
+	handler := Middleware(NewTracer("server", exp), http.NotFoundHandler())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-INVALID")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if spans := exp.Spans(); len(spans) != 1 || spans[0].ParentID != "" {
+		t.Errorf("Expected a new root span, got %+v", spans)
+	}
+}
+
+func TestInjectExtractLine(t *testing.T) {
+	tracer := NewTracer("svc", nil)
+	ctx, span := tracer.Start(context.Background(), "root")
+	if err := span.SetTraceState("a", "1,2"); err == nil {
+		t.Error("Expected ',' in tracestate value to be rejected")
+	}
+	span.SetTraceState("vendor", "x y")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		payload string
+		traced  bool
+	}{
+		{"traced", ctx, "ECHO hello world", true},
+		{"untraced passes through", context.Background(), "ECHO hi", false},
+		{"payload starting with @ is escaped", context.Background(), "@user hi", false},
+		{"traced payload with @", ctx, "@user hi", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := InjectLine(tt.ctx, tt.payload)
+			if !tt.traced && !strings.HasPrefix(tt.payload, "@") && line != tt.payload {
+				t.Errorf("Expected unchanged line, got %q", line)
+			}
+			gotCtx, payload := ExtractLine(context.Background(), line)
+			if payload != tt.payload {
+				t.Errorf("Expected payload %q, got %q", tt.payload, payload)
+			}
+			sc := SpanContextFromContext(gotCtx)
+			if sc.IsValid() != tt.traced {
+				t.Errorf("Expected traced=%v", tt.traced)
+			}
+			if tt.traced && (sc.SpanID != span.SpanContext().SpanID || sc.TraceState.Get("vendor") != "x y") {
+				t.Errorf("Expected span context of root, got %+v", sc)
+			}
+		})
+	}
+}
+
+// Старий клієнт не екранує "@": без пробілу чи без валідного traceparent
+// це payload, а не префікс
+func TestExtractLine_LegacyAtPayload(t *testing.T) {
+	for _, line := range []string{
+		"@alice",
+		"@alice hello",
+		"@traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"@traceparent=garbage hello",
+		"@tracestate=a%3D1 hello",
+		"@%zz hello",
+	} {
+		ctx, payload := ExtractLine(context.Background(), line)
+		if payload != line {
+			t.Errorf("Expected payload %q, got %q", line, payload)
+		}
+		if SpanContextFromContext(ctx).IsValid() {
+			t.Errorf("Expected no span context for %q", line)
+		}
+	}
+}
+
+func TestTCPPropagation(t *testing.T) {
+	exp := NewMemoryExporter()
+	serverTracer := NewTracer("tcp-server", exp)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		ServeLines(context.Background(), serverTracer, conn, func(ctx context.Context, line string) string {
+			return TraceIDFromContext(ctx) + " " + line
+		})
+	}()
+
+	clientTracer := NewTracer("tcp-client", exp)
+	client, err := DialLines(listener.Addr().String(), clientTracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, root := clientTracer.Start(context.Background(), "root")
+	reply, err := client.Call(ctx, "echo hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.End()
+
+	traceID := root.SpanContext().TraceID.String()
+	if want := traceID + " echo hello"; reply != want {
+		t.Errorf("Expected %q, got %q", want, reply)
+	}
+
+	var server, clientSpan SpanData
+	for _, s := range exp.Trace(traceID) {
+		switch s.Service {
+		case "tcp-server":
+			server = s
+		case "tcp-client":
+			if s.Kind == KindClient {
+				clientSpan = s
+			}
+		}
+	}
+	if server.ParentID == "" || server.ParentID != clientSpan.SpanID || server.Name != "tcp ECHO" {
+		t.Errorf("Expected server span child of client span, got %+v / %+v", server, clientSpan)
+	}
+}
+
+func TestWorkerPoolPropagation(t *testing.T) {
+	exp := NewMemoryExporter()
+	tracer := NewTracer("pool", exp)
+
+	ctx, root := tracer.Start(context.Background(), "fan-out")
+	var jobs []Job
+	for i := 1; i <= 6; i++ {
+		jobs = append(jobs, NewJob(ctx, i, i))
+	}
+
+	results := RunPool(context.Background(), tracer, 3, jobs, func(ctx context.Context, job Job) (int, error) {
+		if job.ID == 4 {
+			return 0, errors.New("bad job")
+		}
+		_, span := tracer.Start(ctx, "work")
+		span.End()
+		return job.Value * 2, nil
+	})
+	root.End()
+
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 results, got %d", len(results))
+	}
+
+	rootID := root.SpanContext().SpanID.String()
+	var consumers, work int
+	for _, s := range exp.Trace(root.SpanContext().TraceID.String()) {
+		switch {
+		case s.Kind == KindConsumer:
+			consumers++
+			if s.ParentID != rootID {
+				t.Errorf("Expected %s parent %s, got %s", s.Name, rootID, s.ParentID)
+			}
+			if _, ok := s.Attrs["queue.wait_ms"]; !ok {
+				t.Errorf("Expected queue.wait_ms on %s", s.Name)
+			}
+			if (s.Name == "process job 4") != (s.Error != "") {
+				t.Errorf("Expected error only on job 4, got %q on %s", s.Error, s.Name)
+			}
+		case s.Name == "work":
+			work++
+		}
+	}
+	if consumers != 6 || work != 5 {
+		t.Errorf("Expected 6 consumer and 5 work spans, got %d and %d", consumers, work)
+	}
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	// Два "запуски" дописують в той самий файл
+	for run := range 2 {
+		exp, err := NewFileExporter(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tracer := NewTracer("svc", exp)
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := tracer.Start(ctx, "child", WithAttributes(map[string]any{"run": run}))
+		child.End()
+		root.End()
+		exp.Close()
+	}
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	spans, err := ReadJSONLines(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	if spans[0].Name != "child" || spans[0].ParentID != spans[1].SpanID || spans[0].Attrs["run"] != float64(0) {
+		t.Errorf("Expected child with parent and attrs, got %+v", spans[0])
+	}
+	if spans[0].Duration <= 0 || spans[0].End.Before(spans[0].Start) {
+		t.Errorf("Expected valid timing, got %+v", spans[0])
+	}
+}
+
+func TestPrintTree(t *testing.T) {
+	start := time.Now()
+	spans := []SpanData{
+		{SpanID: "b", ParentID: "a", Name: "child-2", Service: "s", Start: start.Add(2), Duration: time.Millisecond},
+		{SpanID: "a", ParentID: "remote", Name: "root", Service: "s", Start: start, Duration: 3 * time.Millisecond},
+		{SpanID: "c", ParentID: "a", Name: "child-1", Service: "s", Start: start.Add(1), Duration: time.Millisecond, Error: "boom"},
+	}
+	var buf bytes.Buffer
+	PrintTree(&buf, spans)
+
+	want := "root [s] 3ms\n  child-1 [s] 1ms ❌ boom\n  child-2 [s] 1ms\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
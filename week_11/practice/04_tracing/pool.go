@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ============= Worker Pool =============
+// Job/Result як у week_5/practice/worker_pool, плюс Trace - carrier з
+// контекстом того, хто поставив задачу. Канал не передає context.Context,
+// тому контекст їде в самій задачі, як заголовки в HTTP.
+
+type Job struct {
+	ID    int
+	Value int
+	Trace MapCarrier
+
+	enqueued time.Time
+}
+
+type Result struct {
+	JobID  int
+	Result int
+	Worker int
+	Err    error
+}
+
+// NewJob запам'ятовує поточний span як батька майбутньої обробки
+func NewJob(ctx context.Context, id, value int) Job {
+	job := Job{ID: id, Value: value, Trace: MapCarrier{}, enqueued: time.Now()}
+	Inject(ctx, job.Trace)
+	return job
+}
+
+type JobFunc func(ctx context.Context, job Job) (int, error)
+
+// RunPool обробляє jobs у workers горутинах. Кожна задача - consumer span,
+// дочірній до span'а, в якому вона створена; час у черзі - атрибут
+// queue.wait_ms (повільний запит часто чекав воркера, а не працював).
+func RunPool(ctx context.Context, tracer *Tracer, workers int, jobs []Job, fn JobFunc) []Result {
+	queue := make(chan Job)
+	results := make(chan Result, len(jobs))
+
+	var wg sync.WaitGroup
+	for w := 1; w <= workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				jobCtx := Extract(ctx, job.Trace)
+				jobCtx, span := tracer.Start(jobCtx, "process job "+strconv.Itoa(job.ID), WithKind(KindConsumer), WithAttributes(map[string]any{
+					"job.id": job.ID,
+					"worker": w,
+				}))
+				if !job.enqueued.IsZero() {
+					span.SetAttr("queue.wait_ms", time.Since(job.enqueued).Milliseconds())
+				}
+
+				value, err := fn(jobCtx, job)
+				span.RecordError(err)
+				span.End()
+				results <- Result{JobID: job.ID, Result: value, Worker: w, Err: err}
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	close(results)
+
+	collected := make([]Result, 0, len(jobs))
+	for r := range results {
+		collected = append(collected, r)
+	}
+	return collected
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// ============= Propagation =============
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+// Carrier - куди пишеться і звідки читається контекст: HTTP-заголовки,
+// поле Job, префікс рядка TCP-протоколу
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+type HeaderCarrier http.Header
+
+func (c HeaderCarrier) Get(key string) string { return http.Header(c).Get(key) }
+func (c HeaderCarrier) Set(key, value string) { http.Header(c).Set(key, value) }
+
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) string { return c[key] }
+func (c MapCarrier) Set(key, value string) { c[key] = value }
+
+// Inject записує контекст поточного span'а в carrier
+func Inject(ctx context.Context, carrier Carrier) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	carrier.Set(traceparentHeader, sc.Traceparent())
+	if sc.TraceState.Len() > 0 {
+		carrier.Set(tracestateHeader, sc.TraceState.String())
+	}
+}
+
+// Extract повертає ctx з віддаленим батьком. Невалідний traceparent
+// ігнорується (почнеться новий трейс), невалідний tracestate - теж,
+// але traceparent при цьому зберігається.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	sc, err := ParseTraceparent(carrier.Get(traceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	if ts, err := ParseTraceState(carrier.Get(tracestateHeader)); err == nil {
+		sc.TraceState = ts
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// ============= HTTP =============
+
+// Middleware - server span на кожен запит. Ім'я уточнюється шаблоном
+// маршруту ServeMux ("GET /users/{id}"), якщо він є.
+func Middleware(tracer *Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := Extract(r.Context(), HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path, WithKind(KindServer), WithAttributes(map[string]any{
+			"http.method": r.Method,
+			"http.target": r.URL.RequestURI(),
+		}))
+		defer span.End()
+
+		// Trace-ID у відповіді: клієнт може назвати його в баг-репорті
+		w.Header().Set("Traceparent", span.SpanContext().Traceparent())
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+		}
+		span.SetAttr("http.status_code", rec.status)
+		if rec.status >= 500 {
+			span.RecordError(&httpStatusError{rec.status})
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+type httpStatusError struct{ code int }
+
+func (e *httpStatusError) Error() string {
+	return "HTTP " + strconv.Itoa(e.code) + " " + http.StatusText(e.code)
+}
+
+// Transport - client span на кожен вихідний запит і traceparent у заголовках
+type Transport struct {
+	Tracer *Tracer
+	Base   http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.Tracer.Start(req.Context(), "HTTP "+req.Method+" "+req.URL.Host, WithKind(KindClient), WithAttributes(map[string]any{
+		"http.method": req.Method,
+		"http.url":    req.URL.String(),
+	}))
+	defer span.End()
+
+	// RoundTripper не повинен змінювати вхідний запит
+	req = req.Clone(ctx)
+	Inject(ctx, HeaderCarrier(req.Header))
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttr("http.status_code", resp.StatusCode)
+	if resp.StatusCode >= 500 {
+		span.RecordError(&httpStatusError{resp.StatusCode})
+	}
+	return resp, nil
+}
+
+// NewClient - http.Client з трасуванням
+func NewClient(tracer *Tracer) *http.Client {
+	return &http.Client{Transport: &Transport{Tracer: tracer}}
+}
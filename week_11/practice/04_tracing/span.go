@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ============= Tracer & Span =============
+
+type SpanKind string
+
+const (
+	KindInternal SpanKind = "internal"
+	KindServer   SpanKind = "server"
+	KindClient   SpanKind = "client"
+	KindProducer SpanKind = "producer" // поставив задачу в чергу
+	KindConsumer SpanKind = "consumer" // обробив задачу з черги
+)
+
+// Sampler вирішує для кореневого span'а, чи записувати трейс.
+// Дочірні span'и успадковують рішення через прапорець sampled.
+type Sampler func(TraceID) bool
+
+func AlwaysSample(TraceID) bool { return true }
+
+// RatioSampler - детермінований за trace-id: усі сервіси, що бачать
+// той самий трейс, приймуть однакове рішення
+func RatioSampler(ratio float64) Sampler {
+	threshold := uint64(ratio * (1 << 63))
+	return func(id TraceID) bool {
+		return binary.BigEndian.Uint64(id[8:])>>1 < threshold
+	}
+}
+
+type Tracer struct {
+	Service  string
+	Exporter Exporter
+	Sampler  Sampler
+}
+
+func NewTracer(service string, exporter Exporter) *Tracer {
+	return &Tracer{Service: service, Exporter: exporter, Sampler: AlwaysSample}
+}
+
+type StartOption func(*Span)
+
+func WithKind(kind SpanKind) StartOption {
+	return func(s *Span) { s.kind = kind }
+}
+
+func WithAttributes(attrs map[string]any) StartOption {
+	return func(s *Span) {
+		for k, v := range attrs {
+			s.attrs[k] = v
+		}
+	}
+}
+
+// Start створює span - дочірній до span'а з ctx (локального або
+// отриманого з мережі через Extract), інакше - корінь нового трейсу
+func (t *Tracer) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		if t.Sampler == nil || t.Sampler(sc.TraceID) {
+			sc.Flags = FlagSampled
+		}
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		sc:     sc,
+		kind:   KindInternal,
+		start:  time.Now(),
+		attrs:  make(map[string]any),
+	}
+	if parent.IsValid() {
+		span.parent = parent.SpanID
+	}
+	for _, opt := range opts {
+		opt(span)
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+type Span struct {
+	tracer *Tracer
+	parent SpanID
+
+	mu     sync.Mutex
+	name   string
+	sc     SpanContext
+	kind   SpanKind
+	start  time.Time
+	end    time.Time
+	attrs  map[string]any
+	events []SpanEvent
+	status string // "" - ok, інакше текст помилки
+	ended  bool
+}
+
+type SpanEvent struct {
+	Name  string         `json:"name"`
+	Time  time.Time      `json:"time"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+func (s *Span) SpanContext() SpanContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sc
+}
+
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+func (s *Span) SetAttr(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+func (s *Span) AddEvent(name string, attrs map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, SpanEvent{Name: name, Time: time.Now(), Attrs: attrs})
+}
+
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = err.Error()
+	s.events = append(s.events, SpanEvent{Name: "error", Time: time.Now(), Attrs: map[string]any{"message": err.Error()}})
+}
+
+// SetTraceState додає запис своєї системи в tracestate; його побачать
+// дочірні span'и і наступні сервіси
+func (s *Span) SetTraceState(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts, err := s.sc.TraceState.Insert(key, value)
+	if err != nil {
+		return err
+	}
+	s.sc.TraceState = ts
+	return nil
+}
+
+// End фіксує тривалість і віддає span в Exporter. Повторний виклик ігнорується.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	data := s.dataLocked()
+	s.mu.Unlock()
+
+	if data.Sampled && s.tracer.Exporter != nil {
+		s.tracer.Exporter.Export(data)
+	}
+}
+
+func (s *Span) dataLocked() SpanData {
+	data := SpanData{
+		TraceID:    s.sc.TraceID.String(),
+		SpanID:     s.sc.SpanID.String(),
+		Name:       s.name,
+		Service:    s.tracer.Service,
+		Kind:       s.kind,
+		Start:      s.start,
+		End:        s.end,
+		Duration:   s.end.Sub(s.start),
+		Attrs:      s.attrs,
+		Events:     s.events,
+		Error:      s.status,
+		Sampled:    s.sc.Sampled(),
+		TraceState: s.sc.TraceState.String(),
+	}
+	if s.parent.IsValid() {
+		data.ParentID = s.parent.String()
+	}
+	return data
+}
+
+// ============= Context =============
+
+type spanKey struct{}
+type remoteKey struct{}
+
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext - батько з іншого процесу
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext: локальний span має пріоритет над віддаленим
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// TraceIDFromContext - для логів: поле на зразок LogEntry.RequestID
+// з week_11/practice/03_cloudwatch, але спільне для всіх сервісів
+func TraceIDFromContext(ctx context.Context) string {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.TraceID.String()
+	}
+	return ""
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ============= TCP (line protocol) =============
+// Рядковий протокол як у week_6/practice/13_tcp_server: один запит - один
+// рядок. Контекст трейсу - необов'язковий префікс:
+//
+//	@traceparent=00-...-01&tracestate=a%3D1 ECHO hello
+//
+// Рядок без "@" обробляється як раніше, тож старі клієнти не ламаються.
+// Payload, що сам починається з "@", InjectLine екранує порожнім префіксом "@ ".
+
+// InjectLine додає до payload префікс з контекстом ctx
+func InjectLine(ctx context.Context, payload string) string {
+	carrier := MapCarrier{}
+	Inject(ctx, carrier)
+	if len(carrier) == 0 && !strings.HasPrefix(payload, "@") {
+		return payload
+	}
+
+	values := url.Values{}
+	for k, v := range carrier {
+		values.Set(k, v)
+	}
+	return "@" + values.Encode() + " " + payload
+}
+
+// ExtractLine відокремлює префікс і повертає ctx з віддаленим батьком.
+// Префікс - це "@ " (екранування) або "@..." з валідним traceparent;
+// решта ("@alice hello" від старого клієнта) повертається без змін.
+func ExtractLine(ctx context.Context, line string) (context.Context, string) {
+	if !strings.HasPrefix(line, "@") {
+		return ctx, line
+	}
+	prefix, payload, ok := strings.Cut(line[1:], " ")
+	if !ok {
+		return ctx, line
+	}
+	if prefix == "" {
+		return ctx, payload
+	}
+	values, err := url.ParseQuery(prefix)
+	if err != nil {
+		return ctx, line
+	}
+	if _, err := ParseTraceparent(values.Get("traceparent")); err != nil {
+		return ctx, line
+	}
+	carrier := MapCarrier{}
+	for k := range values {
+		carrier[k] = values.Get(k)
+	}
+	return Extract(ctx, carrier), payload
+}
+
+// LineHandler обробляє один рядок і повертає рядок-відповідь
+type LineHandler func(ctx context.Context, line string) string
+
+// ServeLines - цикл з'єднання: server span на кожен рядок
+func ServeLines(ctx context.Context, tracer *Tracer, conn net.Conn, handle LineHandler) error {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		lineCtx, payload := ExtractLine(ctx, strings.TrimRight(line, "\r\n"))
+
+		command, _, _ := strings.Cut(payload, " ")
+		lineCtx, span := tracer.Start(lineCtx, "tcp "+strings.ToUpper(command), WithKind(KindServer), WithAttributes(map[string]any{
+			"net.peer": conn.RemoteAddr().String(),
+		}))
+		reply := handle(lineCtx, payload)
+		span.End()
+
+		if _, err := conn.Write([]byte(reply + "\n")); err != nil {
+			return err
+		}
+	}
+}
+
+// LineClient - запит-відповідь поверх одного з'єднання
+type LineClient struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	conn   net.Conn
+	r      *bufio.Reader
+}
+
+func DialLines(addr string, tracer *Tracer) (*LineClient, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &LineClient{tracer: tracer, conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (c *LineClient) Call(ctx context.Context, payload string) (string, error) {
+	command, _, _ := strings.Cut(payload, " ")
+	ctx, span := c.tracer.Start(ctx, "tcp "+strings.ToUpper(command), WithKind(KindClient), WithAttributes(map[string]any{
+		"net.peer": c.conn.RemoteAddr().String(),
+	}))
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.conn.Write([]byte(InjectLine(ctx, payload) + "\n")); err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	reply, err := c.r.ReadString('\n')
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	return strings.TrimRight(reply, "\r\n"), nil
+}
+
+func (c *LineClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ============= W3C Trace Context =============
+// https://www.w3.org/TR/trace-context/
+//
+//	traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+//	             ^^ ^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^ ^^^^^^^^^^^^^^^^ ^^
+//	        version          trace-id (16 байт)      parent-id (8)   flags
+//
+//	tracestate:  vendor1=value1,vendor2=value2 - дані окремих систем, до 32 записів
+
+var (
+	ErrInvalidTraceparent = errors.New("invalid traceparent")
+	ErrInvalidTracestate  = errors.New("invalid tracestate")
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+
+// IsValid: усі нулі - заборонене значення
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+
+const FlagSampled byte = 0x01
+
+// SpanContext - те, що передається між процесами
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState TraceState
+	Remote     bool // отриманий з мережі, а не створений тут
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent - значення заголовка; завжди версія 00
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent розбирає заголовок за правилами специфікації: невідома
+// версія (крім ff) приймається, якщо перші 55 символів мають формат 00;
+// з її flags береться лише FlagSampled
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	if len(s) < 55 {
+		return sc, ErrInvalidTraceparent
+	}
+
+	version := s[:2]
+	if !isLowerHex(version) || version == "ff" {
+		return sc, ErrInvalidTraceparent
+	}
+	if version == "00" && len(s) != 55 {
+		return sc, ErrInvalidTraceparent
+	}
+	if len(s) > 55 && s[55] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	if s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+
+	traceID, spanID, flags := s[3:35], s[36:52], s[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return sc, ErrInvalidTraceparent
+	}
+	hex.Decode(sc.TraceID[:], []byte(traceID))
+	hex.Decode(sc.SpanID[:], []byte(spanID))
+	var f [1]byte
+	hex.Decode(f[:], []byte(flags))
+	sc.Flags = f[0]
+	if version != "00" {
+		// Traceparent пише версію 00, а значення інших бітів у новій
+		// версії невідоме - специфікація велить лишити тільки sampled
+		sc.Flags &= FlagSampled
+	}
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Remote = true
+	return sc, nil
+}
+
+// Великі літери специфікація забороняє
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ============= tracestate =============
+
+// TraceState - впорядкований список; найсвіжіше оновлення - ліворуч
+type TraceState struct {
+	members []stateMember
+}
+
+type stateMember struct {
+	key, value string
+}
+
+const maxTraceStateMembers = 32
+
+var (
+	stateKeyRe   = regexp.MustCompile(`^([a-z][a-z0-9_\-*/]{0,255}|[a-z0-9][a-z0-9_\-*/]{0,240}@[a-z][a-z0-9_\-*/]{0,13})$`)
+	stateValueRe = regexp.MustCompile(`^[\x20-\x2b\x2d-\x3c\x3e-\x7e]{0,255}[\x21-\x2b\x2d-\x3c\x3e-\x7e]$`)
+)
+
+// ParseTraceState: невалідний tracestate відкидається повністю
+// (traceparent при цьому лишається)
+func ParseTraceState(s string) (TraceState, error) {
+	var ts TraceState
+	seen := make(map[string]bool)
+	for raw := range strings.SplitSeq(s, ",") {
+		member := strings.Trim(raw, " \t")
+		if member == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(member, "=")
+		if !ok || !stateKeyRe.MatchString(key) || !stateValueRe.MatchString(value) || seen[key] {
+			return TraceState{}, ErrInvalidTracestate
+		}
+		seen[key] = true
+		ts.members = append(ts.members, stateMember{key, value})
+	}
+	if len(ts.members) > maxTraceStateMembers {
+		return TraceState{}, ErrInvalidTracestate
+	}
+	return ts, nil
+}
+
+func (ts TraceState) Get(key string) string {
+	for _, m := range ts.members {
+		if m.key == key {
+			return m.value
+		}
+	}
+	return ""
+}
+
+// Insert повертає новий TraceState, де key - першим. Якщо записів
+// забагато, відкидається найстаріший (крайній праворуч).
+func (ts TraceState) Insert(key, value string) (TraceState, error) {
+	if !stateKeyRe.MatchString(key) || !stateValueRe.MatchString(value) {
+		return ts, ErrInvalidTracestate
+	}
+	members := []stateMember{{key, value}}
+	for _, m := range ts.members {
+		if m.key != key {
+			members = append(members, m)
+		}
+	}
+	if len(members) > maxTraceStateMembers {
+		members = members[:maxTraceStateMembers]
+	}
+	return TraceState{members: members}, nil
+}
+
+func (ts TraceState) Len() int {
+	return len(ts.members)
+}
+
+func (ts TraceState) String() string {
+	parts := make([]string, len(ts.members))
+	for i, m := range ts.members {
+		parts[i] = m.key + "=" + m.value
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,1230 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============= fakepg: in-process SQL driver =============
+// Щоб тести і демо не потребували живого Postgres, тут - маленький
+// рушій за інтерфейсами database/sql/driver. Він розуміє лише ту
+// підмножину SQL, яку пишуть сервіс і міграції:
+//
+//	CREATE TABLE [IF NOT EXISTS] t (col TYPE [PRIMARY KEY|UNIQUE|NOT NULL|DEFAULT v], ...)
+//	DROP TABLE [IF EXISTS] t
+//	CREATE UNIQUE INDEX [IF NOT EXISTS] i ON t (col | lower(col))
+//	DROP INDEX [IF EXISTS] i
+//	INSERT INTO t (cols) VALUES (vals) [RETURNING cols]
+//	SELECT cols | COUNT(*) | [COALESCE(]MAX(col)[, v)] FROM t [WHERE c = v [AND ...]] [ORDER BY c [DESC]] [FOR UPDATE]
+//	UPDATE t SET c = v, ... [WHERE ...]
+//	DELETE FROM t [WHERE ...]
+//
+// Невідомий SQL - помилка, а не мовчазний успіх: якщо запит у сервісі
+// зміниться, тест це покаже. Помилки обмежень мають SQLSTATE і текст
+// як у Postgres ("23505 duplicate key value violates unique constraint").
+// Транзакція тримає ексклюзивний лок бази до Commit/Rollback
+// (serializable "з запасом"); Rollback повертає знімок, зроблений на Begin.
+
+const fakeDriverName = "fakepg"
+
+func init() {
+	sql.Register(fakeDriverName, &fakeDriver{dbs: make(map[string]*fakeDB)})
+}
+
+// FakeError - як *pgconn.PgError / *pq.Error: SQLState() для перевірки коду
+type FakeError struct {
+	Code    string
+	Message string
+}
+
+func (e *FakeError) Error() string    { return e.Message }
+func (e *FakeError) SQLState() string { return e.Code }
+
+func sqlError(code, format string, args ...any) error {
+	return &FakeError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+type fakeDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*fakeDB // DSN - ім'я бази; з'єднання з одним DSN бачать одні дані
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	db, ok := d.dbs[dsn]
+	if !ok {
+		db = &fakeDB{tables: make(map[string]*fakeTable), indexes: make(map[string]string)}
+		d.dbs[dsn] = db
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeDB struct {
+	mu      sync.Mutex
+	tables  map[string]*fakeTable
+	indexes map[string]string // ім'я індексу -> таблиця
+}
+
+type fakeColumn struct {
+	name    string
+	typ     string
+	notNull bool
+	serial  bool
+	def     driver.Value
+	defNow  bool
+}
+
+type fakeUnique struct {
+	name  string
+	col   string
+	lower bool
+}
+
+type fakeTable struct {
+	name    string
+	cols    []fakeColumn
+	uniques []fakeUnique
+	rows    []map[string]driver.Value
+	seq     int64
+}
+
+func (t *fakeTable) column(name string) (*fakeColumn, error) {
+	for i := range t.cols {
+		if t.cols[i].name == name {
+			return &t.cols[i], nil
+		}
+	}
+	return nil, sqlError("42703", "column %q of relation %q does not exist", name, t.name)
+}
+
+func (t *fakeTable) clone() *fakeTable {
+	c := *t
+	c.cols = slices.Clone(t.cols)
+	c.uniques = slices.Clone(t.uniques)
+	c.rows = make([]map[string]driver.Value, len(t.rows))
+	for i, row := range t.rows {
+		c.rows[i] = make(map[string]driver.Value, len(row))
+		for k, v := range row {
+			c.rows[i][k] = v
+		}
+	}
+	return &c
+}
+
+func (db *fakeDB) snapshot() (map[string]*fakeTable, map[string]string) {
+	tables := make(map[string]*fakeTable, len(db.tables))
+	for name, t := range db.tables {
+		tables[name] = t.clone()
+	}
+	indexes := make(map[string]string, len(db.indexes))
+	for k, v := range db.indexes {
+		indexes[k] = v
+	}
+	return tables, indexes
+}
+
+func (db *fakeDB) table(name string) (*fakeTable, error) {
+	t, ok := db.tables[name]
+	if !ok {
+		return nil, sqlError("42P01", "relation %q does not exist", name)
+	}
+	return t, nil
+}
+
+// ============= Conn / Tx / Stmt =============
+
+type fakeConn struct {
+	db   *fakeDB
+	inTx bool
+
+	savedTables  map[string]*fakeTable
+	savedIndexes map[string]string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	if c.inTx {
+		c.rollback()
+	}
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.inTx {
+		return nil, errors.New("fakepg: nested transaction")
+	}
+	c.db.mu.Lock()
+	c.inTx = true
+	c.savedTables, c.savedIndexes = c.db.snapshot()
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.inTx = false
+	c.savedTables, c.savedIndexes = nil, nil
+	c.db.mu.Unlock()
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.rollback()
+	return nil
+}
+
+func (c *fakeConn) rollback() {
+	c.db.tables, c.db.indexes = c.savedTables, c.savedIndexes
+	c.inTx = false
+	c.db.mu.Unlock()
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock()
+	defer c.unlock()
+
+	// Міграції - кілька операторів через ';' без параметрів
+	var result fakeResult
+	for _, stmt := range splitStatements(query) {
+		res, _, err := c.db.exec(stmt, args)
+		if err != nil {
+			return nil, err
+		}
+		result.affected += res.affected
+	}
+	return result, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock()
+	defer c.unlock()
+	_, rows, err := c.db.exec(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = &fakeRows{}
+	}
+	return rows, nil
+}
+
+// Поза транзакцією кожен оператор бере лок сам; у транзакції лок уже взято
+func (c *fakeConn) lock() {
+	if !c.inTx {
+		c.db.mu.Lock()
+	}
+}
+
+func (c *fakeConn) unlock() {
+	if !c.inTx {
+		c.db.mu.Unlock()
+	}
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, named(args))
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, named(args))
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+	out := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		out[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return out
+}
+
+type fakeResult struct {
+	affected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) {
+	return 0, errors.New("fakepg: LastInsertId is not supported, use RETURNING")
+}
+
+func (r fakeResult) RowsAffected() (int64, error) {
+	return r.affected, nil
+}
+
+type fakeRows struct {
+	cols []string
+	data [][]driver.Value
+	pos  int
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.pos])
+	r.pos++
+	return nil
+}
+
+// ============= Tokenizer =============
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokParam
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string // ідентифікатори - у нижньому регістрі
+}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case isIdentStart(ch):
+			j := i
+			for j < len(query) && (isIdentStart(query[j]) || isDigit(query[j])) {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, strings.ToLower(query[i:j])})
+			i = j
+		case isDigit(ch):
+			j := i
+			for j < len(query) && (isDigit(query[j]) || query[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, query[i:j]})
+			i = j
+		case ch == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(query); j++ {
+				if query[j] == '\'' {
+					if j+1 < len(query) && query[j+1] == '\'' {
+						sb.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteByte(query[j])
+			}
+			if j >= len(query) {
+				return nil, sqlError("42601", "unterminated string literal")
+			}
+			tokens = append(tokens, token{tokString, sb.String()})
+			i = j + 1
+		case ch == '$':
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokParam, query[i:j]})
+			i = j
+		case ch == '?':
+			tokens = append(tokens, token{tokParam, "?"})
+			i++
+		case strings.IndexByte("(),=*;", ch) >= 0:
+			tokens = append(tokens, token{tokPunct, string(ch)})
+			i++
+		default:
+			return nil, sqlError("42601", "syntax error at or near %q", string(ch))
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func splitStatements(query string) []string {
+	var stmts []string
+	inString := false
+	start := 0
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '\'':
+			inString = !inString
+		case ';':
+			if !inString {
+				stmts = append(stmts, query[start:i])
+				start = i + 1
+			}
+		}
+	}
+	stmts = append(stmts, query[start:])
+
+	out := stmts[:0]
+	for _, s := range stmts {
+		if strings.TrimSpace(stripComments(s)) != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func stripComments(s string) string {
+	var sb strings.Builder
+	for line := range strings.SplitSeq(s, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// ============= Parser =============
+
+type parser struct {
+	tokens []token
+	pos    int
+	args   []driver.NamedValue
+	nextQ  int // для '?' - наступний позиційний аргумент
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{kind: tokPunct, text: ""}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens) || (p.peek().text == ";" && p.pos == len(p.tokens)-1)
+}
+
+// accept - наступні токени збігаються з words (без урахування регістру)
+func (p *parser) accept(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.tokens) || p.tokens[p.pos+i].text != w {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *parser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return p.syntaxError()
+	}
+	return nil
+}
+
+func (p *parser) syntaxError() error {
+	return sqlError("42601", "syntax error at or near %q", p.peek().text)
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		p.pos--
+		return "", p.syntaxError()
+	}
+	return t.text, nil
+}
+
+func (p *parser) identList() ([]string, error) {
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.accept(",") {
+			return names, nil
+		}
+	}
+}
+
+// value - параметр або літерал
+func (p *parser) value() (driver.Value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokParam:
+		n := 0
+		if t.text == "?" {
+			n = p.nextQ
+			p.nextQ++
+		} else {
+			idx, err := strconv.Atoi(t.text[1:])
+			if err != nil || idx < 1 {
+				return nil, sqlError("42P02", "invalid parameter %s", t.text)
+			}
+			n = idx - 1
+		}
+		if n >= len(p.args) {
+			return nil, sqlError("08P01", "there is no parameter %s", t.text)
+		}
+		return p.args[n].Value, nil
+	case tokNumber:
+		if strings.Contains(t.text, ".") {
+			return strconv.ParseFloat(t.text, 64)
+		}
+		return strconv.ParseInt(t.text, 10, 64)
+	case tokString:
+		return t.text, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	p.pos--
+	return nil, p.syntaxError()
+}
+
+type condition struct {
+	col   string
+	value driver.Value
+}
+
+func (p *parser) where() ([]condition, error) {
+	if !p.accept("where") {
+		return nil, nil
+	}
+	var conds []condition
+	for {
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, condition{col, v})
+		if !p.accept("and") {
+			return conds, nil
+		}
+	}
+}
+
+// ============= Execution =============
+
+func (db *fakeDB) exec(query string, args []driver.NamedValue) (fakeResult, *fakeRows, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return fakeResult{}, nil, err
+	}
+	p := &parser{tokens: tokens, args: args}
+
+	var res fakeResult
+	var rows *fakeRows
+	switch {
+	case p.accept("create", "table"):
+		err = db.createTable(p)
+	case p.accept("drop", "table"):
+		err = db.dropTable(p)
+	case p.accept("create", "unique", "index"):
+		err = db.createIndex(p)
+	case p.accept("drop", "index"):
+		err = db.dropIndex(p)
+	case p.accept("insert", "into"):
+		res, rows, err = db.insert(p)
+	case p.accept("select"):
+		rows, err = db.selectRows(p)
+	case p.accept("update"):
+		res, err = db.update(p)
+	case p.accept("delete", "from"):
+		res, err = db.delete(p)
+	default:
+		return res, nil, sqlError("0A000", "fakepg: unsupported statement: %s", strings.Join(strings.Fields(query), " "))
+	}
+	if err != nil {
+		return res, nil, err
+	}
+	if !p.done() {
+		return res, nil, p.syntaxError()
+	}
+	return res, rows, nil
+}
+
+func (db *fakeDB) createTable(p *parser) error {
+	ifNotExists := p.accept("if", "not", "exists")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, exists := db.tables[name]; exists {
+		if ifNotExists {
+			for !p.done() {
+				p.next()
+			}
+			return nil
+		}
+		return sqlError("42P07", "relation %q already exists", name)
+	}
+
+	t := &fakeTable{name: name}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		col, err := p.columnDef(t)
+		if err != nil {
+			return err
+		}
+		t.cols = append(t.cols, col)
+		if p.accept(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+	db.tables[name] = t
+	return nil
+}
+
+func (p *parser) columnDef(t *fakeTable) (fakeColumn, error) {
+	var col fakeColumn
+	var err error
+	if col.name, err = p.ident(); err != nil {
+		return col, err
+	}
+	if col.typ, err = p.ident(); err != nil {
+		return col, err
+	}
+	col.serial = col.typ == "serial" || col.typ == "bigserial"
+
+	for {
+		switch {
+		case p.accept("primary", "key"):
+			col.notNull = true
+			t.uniques = append(t.uniques, fakeUnique{name: t.name + "_pkey", col: col.name})
+		case p.accept("unique"):
+			t.uniques = append(t.uniques, fakeUnique{name: t.name + "_" + col.name + "_key", col: col.name})
+		case p.accept("not", "null"):
+			col.notNull = true
+		case p.accept("default"):
+			if p.accept("now", "(", ")") {
+				col.defNow = true
+				continue
+			}
+			if col.def, err = p.value(); err != nil {
+				return col, err
+			}
+		default:
+			return col, nil
+		}
+	}
+}
+
+func (db *fakeDB) dropTable(p *parser) error {
+	ifExists := p.accept("if", "exists")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := db.tables[name]; !ok {
+		if ifExists {
+			return nil
+		}
+		return sqlError("42P01", "table %q does not exist", name)
+	}
+	delete(db.tables, name)
+	for idx, table := range db.indexes {
+		if table == name {
+			delete(db.indexes, idx)
+		}
+	}
+	return nil
+}
+
+func (db *fakeDB) createIndex(p *parser) error {
+	ifNotExists := p.accept("if", "not", "exists")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("on"); err != nil {
+		return err
+	}
+	tableName, err := p.ident()
+	if err != nil {
+		return err
+	}
+	t, err := db.table(tableName)
+	if err != nil {
+		return err
+	}
+
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	u := fakeUnique{name: name, lower: p.accept("lower", "(")}
+	if u.col, err = p.ident(); err != nil {
+		return err
+	}
+	if _, err := t.column(u.col); err != nil {
+		return err
+	}
+	if u.lower {
+		if err := p.expect(")"); err != nil {
+			return err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return err
+	}
+
+	if _, exists := db.indexes[name]; exists {
+		if ifNotExists {
+			return nil
+		}
+		return sqlError("42P07", "relation %q already exists", name)
+	}
+	// Як і Postgres, не створюємо унікальний індекс поверх дублікатів
+	for i, row := range t.rows {
+		if err := t.checkUnique(u, row, i); err != nil {
+			return err
+		}
+	}
+	t.uniques = append(t.uniques, u)
+	db.indexes[name] = tableName
+	return nil
+}
+
+func (db *fakeDB) dropIndex(p *parser) error {
+	ifExists := p.accept("if", "exists")
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	tableName, ok := db.indexes[name]
+	if !ok {
+		if ifExists {
+			return nil
+		}
+		return sqlError("42704", "index %q does not exist", name)
+	}
+	t := db.tables[tableName]
+	t.uniques = slices.DeleteFunc(t.uniques, func(u fakeUnique) bool { return u.name == name })
+	delete(db.indexes, name)
+	return nil
+}
+
+func (db *fakeDB) insert(p *parser) (fakeResult, *fakeRows, error) {
+	tableName, err := p.ident()
+	if err != nil {
+		return fakeResult{}, nil, err
+	}
+	t, err := db.table(tableName)
+	if err != nil {
+		return fakeResult{}, nil, err
+	}
+
+	if err := p.expect("("); err != nil {
+		return fakeResult{}, nil, err
+	}
+	cols, err := p.identList()
+	if err != nil {
+		return fakeResult{}, nil, err
+	}
+	if err := p.expect(")", "values", "("); err != nil {
+		return fakeResult{}, nil, err
+	}
+	var values []driver.Value
+	for {
+		v, err := p.value()
+		if err != nil {
+			return fakeResult{}, nil, err
+		}
+		values = append(values, v)
+		if p.accept(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return fakeResult{}, nil, err
+		}
+	}
+	if len(values) != len(cols) {
+		return fakeResult{}, nil, sqlError("42601", "INSERT has %d target columns but %d expressions", len(cols), len(values))
+	}
+
+	row := make(map[string]driver.Value, len(t.cols))
+	for i, name := range cols {
+		if _, err := t.column(name); err != nil {
+			return fakeResult{}, nil, err
+		}
+		row[name] = normalize(values[i])
+	}
+	seq := t.seq
+	for _, col := range t.cols {
+		if _, set := row[col.name]; set {
+			continue
+		}
+		switch {
+		case col.serial:
+			seq++
+			row[col.name] = seq
+		case col.defNow:
+			row[col.name] = time.Now()
+		default:
+			row[col.name] = col.def
+		}
+	}
+	if err := t.validate(row, -1); err != nil {
+		return fakeResult{}, nil, err
+	}
+	t.seq = seq
+	t.rows = append(t.rows, row)
+
+	var rows *fakeRows
+	if p.accept("returning") {
+		ret, err := p.identList()
+		if err != nil {
+			return fakeResult{}, nil, err
+		}
+		rows = &fakeRows{cols: ret, data: [][]driver.Value{project(row, ret)}}
+	}
+	return fakeResult{affected: 1}, rows, nil
+}
+
+// validate перевіряє NOT NULL і унікальність; self - індекс рядка, що
+// оновлюється (-1 для вставки)
+func (t *fakeTable) validate(row map[string]driver.Value, self int) error {
+	for _, col := range t.cols {
+		if col.notNull && row[col.name] == nil {
+			return sqlError("23502", "null value in column %q of relation %q violates not-null constraint", col.name, t.name)
+		}
+	}
+	for _, u := range t.uniques {
+		if err := t.checkUnique(u, row, self); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *fakeTable) checkUnique(u fakeUnique, row map[string]driver.Value, self int) error {
+	key := uniqueKey(u, row[u.col])
+	if row[u.col] == nil {
+		return nil // NULL не конфліктує
+	}
+	for i, other := range t.rows {
+		if i != self && other[u.col] != nil && uniqueKey(u, other[u.col]) == key {
+			return sqlError("23505", "duplicate key value violates unique constraint %q", u.name)
+		}
+	}
+	return nil
+}
+
+func uniqueKey(u fakeUnique, v driver.Value) string {
+	s := fmt.Sprint(v)
+	if u.lower {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+type selectItem struct {
+	col      string
+	agg      string // "", "max", "count"
+	fallback driver.Value
+	coalesce bool
+}
+
+func (db *fakeDB) selectRows(p *parser) (*fakeRows, error) {
+	var items []selectItem
+	star := false
+	for {
+		var item selectItem
+		switch {
+		case p.accept("*"):
+			star = true
+		case p.accept("count", "(", "*", ")"):
+			item = selectItem{col: "count", agg: "count"}
+		case p.accept("coalesce", "("):
+			inner, err := p.maxItem()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			if inner.fallback, err = p.value(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			inner.coalesce = true
+			item = inner
+		case p.peek().text == "max":
+			inner, err := p.maxItem()
+			if err != nil {
+				return nil, err
+			}
+			item = inner
+		default:
+			col, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			item = selectItem{col: col}
+		}
+		if !star {
+			items = append(items, item)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if err := p.expect("from"); err != nil {
+		return nil, err
+	}
+	tableName, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	t, err := db.table(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if star {
+		for _, col := range t.cols {
+			items = append(items, selectItem{col: col.name})
+		}
+	}
+	conds, err := p.where()
+	if err != nil {
+		return nil, err
+	}
+	matched, err := t.filter(conds)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept("order", "by") {
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.column(col); err != nil {
+			return nil, err
+		}
+		desc := p.accept("desc")
+		if !desc {
+			p.accept("asc")
+		}
+		slices.SortStableFunc(matched, func(a, b int) int {
+			c := compareValues(t.rows[a][col], t.rows[b][col])
+			if desc {
+				return -c
+			}
+			return c
+		})
+	}
+	p.accept("for", "update") // лок і так ексклюзивний
+
+	cols := make([]string, len(items))
+	for i, item := range items {
+		cols[i] = item.col
+		if item.agg == "" {
+			if _, err := t.column(item.col); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if items[0].agg != "" {
+		row := make([]driver.Value, len(items))
+		for i, item := range items {
+			switch item.agg {
+			case "count":
+				row[i] = int64(len(matched))
+			case "max":
+				var best driver.Value
+				for _, idx := range matched {
+					if v := t.rows[idx][item.col]; v != nil && (best == nil || compareValues(v, best) > 0) {
+						best = v
+					}
+				}
+				if best == nil && item.coalesce {
+					best = item.fallback
+				}
+				row[i] = best
+			}
+		}
+		return &fakeRows{cols: cols, data: [][]driver.Value{row}}, nil
+	}
+
+	rows := &fakeRows{cols: cols}
+	for _, idx := range matched {
+		rows.data = append(rows.data, project(t.rows[idx], cols))
+	}
+	return rows, nil
+}
+
+func (p *parser) maxItem() (selectItem, error) {
+	if err := p.expect("max", "("); err != nil {
+		return selectItem{}, err
+	}
+	col, err := p.ident()
+	if err != nil {
+		return selectItem{}, err
+	}
+	if err := p.expect(")"); err != nil {
+		return selectItem{}, err
+	}
+	return selectItem{col: col, agg: "max"}, nil
+}
+
+func (db *fakeDB) update(p *parser) (fakeResult, error) {
+	tableName, err := p.ident()
+	if err != nil {
+		return fakeResult{}, err
+	}
+	t, err := db.table(tableName)
+	if err != nil {
+		return fakeResult{}, err
+	}
+	if err := p.expect("set"); err != nil {
+		return fakeResult{}, err
+	}
+
+	set := map[string]driver.Value{}
+	for {
+		col, err := p.ident()
+		if err != nil {
+			return fakeResult{}, err
+		}
+		if _, err := t.column(col); err != nil {
+			return fakeResult{}, err
+		}
+		if err := p.expect("="); err != nil {
+			return fakeResult{}, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return fakeResult{}, err
+		}
+		set[col] = normalize(v)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	conds, err := p.where()
+	if err != nil {
+		return fakeResult{}, err
+	}
+	matched, err := t.filter(conds)
+	if err != nil {
+		return fakeResult{}, err
+	}
+
+	// Спершу перевірити всі рядки, потім змінити: оператор атомарний
+	updated := make(map[int]map[string]driver.Value, len(matched))
+	for _, idx := range matched {
+		row := make(map[string]driver.Value, len(t.rows[idx]))
+		for k, v := range t.rows[idx] {
+			row[k] = v
+		}
+		for k, v := range set {
+			row[k] = v
+		}
+		if err := t.validate(row, idx); err != nil {
+			return fakeResult{}, err
+		}
+		updated[idx] = row
+	}
+	for idx, row := range updated {
+		t.rows[idx] = row
+	}
+	return fakeResult{affected: int64(len(matched))}, nil
+}
+
+func (db *fakeDB) delete(p *parser) (fakeResult, error) {
+	tableName, err := p.ident()
+	if err != nil {
+		return fakeResult{}, err
+	}
+	t, err := db.table(tableName)
+	if err != nil {
+		return fakeResult{}, err
+	}
+	conds, err := p.where()
+	if err != nil {
+		return fakeResult{}, err
+	}
+	matched, err := t.filter(conds)
+	if err != nil {
+		return fakeResult{}, err
+	}
+
+	remove := make(map[int]bool, len(matched))
+	for _, idx := range matched {
+		remove[idx] = true
+	}
+	kept := t.rows[:0:0]
+	for i, row := range t.rows {
+		if !remove[i] {
+			kept = append(kept, row)
+		}
+	}
+	t.rows = kept
+	return fakeResult{affected: int64(len(matched))}, nil
+}
+
+func (t *fakeTable) filter(conds []condition) ([]int, error) {
+	for _, c := range conds {
+		if _, err := t.column(c.col); err != nil {
+			return nil, err
+		}
+	}
+	var matched []int
+	for i, row := range t.rows {
+		ok := true
+		for _, c := range conds {
+			// col = NULL у SQL ніколи не істина
+			if row[c.col] == nil || c.value == nil || compareValues(row[c.col], normalize(c.value)) != 0 {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, i)
+		}
+	}
+	return matched, nil
+}
+
+func project(row map[string]driver.Value, cols []string) []driver.Value {
+	out := make([]driver.Value, len(cols))
+	for i, col := range cols {
+		out[i] = row[col]
+	}
+	return out
+}
+
+// normalize зводить значення до типів, які повертає драйвер
+func normalize(v driver.Value) driver.Value {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case int:
+		return int64(x)
+	case int32:
+		return int64(x)
+	default:
+		return v
+	}
+}
+
+func compareValues(a, b driver.Value) int {
+	switch x := normalize(a).(type) {
+	case int64:
+		switch y := normalize(b).(type) {
+		case int64:
+			return cmpOrdered(x, y)
+		case float64:
+			return cmpOrdered(float64(x), y)
+		}
+	case float64:
+		switch y := normalize(b).(type) {
+		case float64:
+			return cmpOrdered(x, y)
+		case int64:
+			return cmpOrdered(x, float64(y))
+		}
+	case string:
+		if y, ok := normalize(b).(string); ok {
+			return strings.Compare(x, y)
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func cmpOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// За замовчуванням демо працює на fakepg і не потребує бази. Для
+// справжнього Postgres додайте драйвер (наприклад,
+// _ "github.com/jackc/pgx/v5/stdlib" і -driver pgx) та -dsn.
+
+func main() {
+	driverName := flag.String("driver", fakeDriverName, "database/sql driver")
+	dsn := flag.String("dsn", "demo", "data source name")
+	flag.Parse()
+
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   UserService on database/sql          ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	db, err := sql.Open(*driverName, *dsn)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		panic(err)
+	}
+
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	migrator := NewMigrator(db, migrations)
+
+	fmt.Println("\n📜 1. Міграції:")
+	for _, m := range migrations {
+		fmt.Printf("   %04d_%s\n", m.Version, m.Name)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		panic(err)
+	}
+	version, _ := migrator.Version(ctx)
+	fmt.Printf("   застосовано %v, версія схеми: %d\n", applied, version)
+	applied, _ = migrator.Up(ctx)
+	fmt.Printf("   повторний Up: %d нових\n", len(applied))
+
+	var service UserService = NewPostgresUserService(db)
+
+	fmt.Println("\n👤 2. CRUD через UserService:")
+	alice, _ := service.Create("alice", "alice@mail.com")
+	bob, _ := service.Create("bob", "bob@mail.com")
+	fmt.Println("  ", alice)
+	fmt.Println("  ", bob)
+	service.Update(bob.ID, "bobby", "")
+	service.Deactivate(alice.ID)
+	users, _ := service.GetAll()
+	for _, u := range users {
+		fmt.Println("  ", u)
+	}
+
+	fmt.Println("\n🚫 3. Обмеження бази і DatabaseError:")
+	_, err = service.Create("alice", "other@mail.com")
+	fmt.Println("   дубль username:", err)
+	_, err = service.Create("carol", "ALICE@mail.com")
+	fmt.Println("   дубль email (lower):", errors.Is(err, ErrDuplicate))
+	var dbErr *DatabaseError
+	if errors.As(err, &dbErr) {
+		fmt.Printf("   op=%q code=%s constraint=%s\n", dbErr.Op, dbErr.Code, dbErr.Constraint)
+	}
+	_, err = service.GetByID(42)
+	fmt.Println("   не знайдено:", err, errors.Is(err, ErrNotFound))
+
+	fmt.Println("\n⏱️  4. Context:")
+	svc := NewPostgresUserService(db)
+	cancelled, stop := context.WithCancel(ctx)
+	stop()
+	_, err = svc.GetAllContext(cancelled)
+	fmt.Println("   скасований context:", err)
+
+	fmt.Println("\n⏪ 5. Відкат міграцій:")
+	rolledBack, err := migrator.Down(ctx, 1)
+	fmt.Printf("   Down(1): %v, err=%v\n", rolledBack, err)
+	_, err = service.Create("dave", "BOB@mail.com")
+	fmt.Println("   без індексу lower(email) регістр знову важить:", err == nil)
+	_, err = migrator.Up(ctx)
+	fmt.Println("   Up поверх дублікатів:", err)
+
+	fmt.Println("\n✅ Demo completed!")
+}
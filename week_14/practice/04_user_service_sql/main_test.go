@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+)
+
+// ============= Helpers =============
+
+var dbCounter atomic.Int64
+
+// openDB - окрема fakepg база на тест (з -count=N теж)
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("%s-%d", t.Name(), dbCounter.Add(1))
+	db, err := sql.Open(fakeDriverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newService(t *testing.T) *PostgresUserService {
+	t.Helper()
+	db := openDB(t)
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMigrator(db, migrations).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewPostgresUserService(db)
+}
+
+// ============= Migrations =============
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_users" {
+		t.Errorf("Expected 0001_create_users first, got %04d_%s", migrations[0].Version, migrations[0].Name)
+	}
+	for _, m := range migrations {
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("Expected up and down for %d", m.Version)
+		}
+	}
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"gap", fstest.MapFS{
+			"m/0001_a.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER)")}, "m/0001_a.down.sql": {Data: []byte("DROP TABLE a")},
+			"m/0003_c.up.sql": {Data: []byte("CREATE TABLE c (id INTEGER)")}, "m/0003_c.down.sql": {Data: []byte("DROP TABLE c")},
+		}},
+		{"missing down", fstest.MapFS{
+			"m/0001_a.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER)")},
+		}},
+		{"bad name", fstest.MapFS{
+			"m/first.sql": {Data: []byte("CREATE TABLE a (id INTEGER)")},
+		}},
+		{"bad version", fstest.MapFS{
+			"m/x_a.up.sql": {Data: []byte("SELECT 1")}, "m/x_a.down.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"two names", fstest.MapFS{
+			"m/0001_a.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER)")}, "m/0001_b.down.sql": {Data: []byte("DROP TABLE a")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.files, "m")
+			if !errors.Is(err, ErrBadMigration) {
+				t.Errorf("Expected ErrBadMigration, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	migrations, _ := LoadMigrations(migrationFiles, "migrations")
+	m := NewMigrator(db, migrations)
+
+	if v, err := m.Version(ctx); err != nil || v != 0 {
+		t.Fatalf("Expected version 0 on empty db, got %d, %v", v, err)
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(applied) != "[1 2]" {
+		t.Errorf("Expected [1 2] applied, got %v", applied)
+	}
+	if applied, _ := m.Up(ctx); len(applied) != 0 {
+		t.Errorf("Expected Up to be idempotent, got %v", applied)
+	}
+
+	reverted, err := m.Down(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(reverted) != "[2 1]" {
+		t.Errorf("Expected [2 1] reverted, got %v", reverted)
+	}
+	if _, err := db.Exec(`SELECT id FROM users`); err == nil {
+		t.Error("Expected users table to be dropped")
+	}
+	if v, _ := m.Version(ctx); v != 0 {
+		t.Errorf("Expected version 0, got %d", v)
+	}
+
+	if _, err := m.To(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Version(ctx); v != 1 {
+		t.Errorf("Expected version 1, got %d", v)
+	}
+	if _, err := m.To(ctx, 3); !errors.Is(err, ErrBadMigration) {
+		t.Errorf("Expected ErrBadMigration for unknown target, got %v", err)
+	}
+}
+
+// Упала друга половина міграції - ні таблиці, ні запису версії
+func TestMigrationIsTransactional(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"m/0001_ok.up.sql":     {Data: []byte("CREATE TABLE a (id INTEGER)")},
+		"m/0001_ok.down.sql":   {Data: []byte("DROP TABLE a")},
+		"m/0002_half.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);\nCREATE TABLE a (id INTEGER);")},
+		"m/0002_half.down.sql": {Data: []byte("DROP TABLE b")},
+	}
+	migrations, err := LoadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMigrator(db, migrations)
+
+	applied, err := m.Up(ctx)
+	var dbErr *DatabaseError
+	if !errors.As(err, &dbErr) {
+		t.Fatalf("Expected DatabaseError, got %v", err)
+	}
+	if !strings.Contains(dbErr.Op, "0002_half") || dbErr.Code != "42P07" {
+		t.Errorf("Expected op with 0002_half and code 42P07, got %q %q", dbErr.Op, dbErr.Code)
+	}
+	if fmt.Sprint(applied) != "[1]" {
+		t.Errorf("Expected only [1] applied, got %v", applied)
+	}
+	if v, _ := m.Version(ctx); v != 1 {
+		t.Errorf("Expected version 1, got %d", v)
+	}
+	if _, err := db.Exec(`SELECT id FROM b`); err == nil {
+		t.Error("Expected table b to be rolled back")
+	}
+}
+
+func TestMigratorRejectsNewerDatabase(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	migrations, _ := LoadMigrations(migrationFiles, "migrations")
+	if _, err := NewMigrator(db, migrations).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMigrator(db, migrations[:1]).Up(ctx); !errors.Is(err, ErrBadMigration) {
+		t.Errorf("Expected ErrBadMigration for older binary, got %v", err)
+	}
+}
+
+// ============= UserService =============
+
+func TestCreateAndGet(t *testing.T) {
+	s := newService(t)
+
+	user, err := s.Create("alice", "alice@mail.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 || !user.IsActive {
+		t.Errorf("Expected active user with ID 1, got %v", user)
+	}
+
+	got, err := s.GetByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Username != "alice" || got.Email != "alice@mail.com" || !got.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("Expected %v, got %v", user, got)
+	}
+}
+
+func TestCreateValidation(t *testing.T) {
+	s := newService(t)
+	tests := []struct {
+		username, email, want string
+	}{
+		{"", "a@mail.com", "username cannot be empty"},
+		{"alice", "nope", "invalid email format"},
+	}
+	for _, tt := range tests {
+		_, err := s.Create(tt.username, tt.email)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Expected %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestCreateDuplicates(t *testing.T) {
+	s := newService(t)
+	if _, err := s.Create("alice", "alice@mail.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, username, email, prefix, constraint string
+	}{
+		{"username", "alice", "other@mail.com", "username already exists", "users_username_key"},
+		{"email", "bob", "alice@mail.com", "email already exists", "users_email_lower_idx"},
+		{"email case", "bob", "ALICE@Mail.com", "email already exists", "users_email_lower_idx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Create(tt.username, tt.email)
+			if !errors.Is(err, ErrDuplicate) {
+				t.Fatalf("Expected ErrDuplicate, got %v", err)
+			}
+			if !strings.HasPrefix(err.Error(), tt.prefix) {
+				t.Errorf("Expected %q prefix, got %q", tt.prefix, err)
+			}
+			var dbErr *DatabaseError
+			if !errors.As(err, &dbErr) {
+				t.Fatalf("Expected DatabaseError, got %T", err)
+			}
+			if dbErr.Code != codeUniqueViolation || dbErr.Constraint != tt.constraint || dbErr.Op != "create user" {
+				t.Errorf("Expected 23505 on %s, got %+v", tt.constraint, dbErr)
+			}
+			var fake *FakeError
+			if !errors.As(err, &fake) {
+				t.Error("Expected driver error to be reachable via errors.As")
+			}
+		})
+	}
+
+	// Невдалий INSERT не лишив рядків
+	if users, _ := s.GetAll(); len(users) != 1 {
+		t.Errorf("Expected 1 user, got %d", len(users))
+	}
+}
+
+func TestGetAllOrdered(t *testing.T) {
+	s := newService(t)
+	if users, err := s.GetAll(); err != nil || users == nil || len(users) != 0 {
+		t.Errorf("Expected empty non-nil slice, got %v, %v", users, err)
+	}
+	for _, name := range []string{"carol", "alice", "bob"} {
+		if _, err := s.Create(name, name+"@mail.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	users, err := s.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	if strings.Join(names, ",") != "carol,alice,bob" {
+		t.Errorf("Expected insertion (id) order, got %v", names)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s := newService(t)
+	alice, _ := s.Create("alice", "alice@mail.com")
+	s.Create("bob", "bob@mail.com")
+
+	tests := []struct {
+		name, username, email   string
+		wantUsername, wantEmail string
+	}{
+		{"both", "alice2", "a2@mail.com", "alice2", "a2@mail.com"},
+		{"empty username keeps", "", "a3@mail.com", "alice2", "a3@mail.com"},
+		{"invalid email keeps", "alice3", "nope", "alice3", "a3@mail.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Update(alice.ID, tt.username, tt.email); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := s.GetByID(alice.ID)
+			if got.Username != tt.wantUsername || got.Email != tt.wantEmail {
+				t.Errorf("Expected %s <%s>, got %s <%s>", tt.wantUsername, tt.wantEmail, got.Username, got.Email)
+			}
+		})
+	}
+
+	err := s.Update(alice.ID, "bob", "")
+	if !errors.Is(err, ErrDuplicate) || !strings.HasPrefix(err.Error(), "username already exists") {
+		t.Errorf("Expected username duplicate, got %v", err)
+	}
+	if got, _ := s.GetByID(alice.ID); got.Username != "alice3" {
+		t.Errorf("Expected failed update to be rolled back, got %s", got.Username)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	s := newService(t)
+	ops := map[string]func() error{
+		"GetByID":    func() error { _, err := s.GetByID(7); return err },
+		"Update":     func() error { return s.Update(7, "x", "x@mail.com") },
+		"Delete":     func() error { return s.Delete(7) },
+		"Activate":   func() error { return s.Activate(7) },
+		"Deactivate": func() error { return s.Deactivate(7) },
+	}
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			err := op()
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Expected ErrNotFound, got %v", err)
+			}
+			if err.Error() != "user with id 7 not found" {
+				t.Errorf("Expected original message, got %q", err)
+			}
+		})
+	}
+}
+
+func TestActivateDeactivateDelete(t *testing.T) {
+	s := newService(t)
+	user, _ := s.Create("alice", "alice@mail.com")
+
+	if err := s.Deactivate(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetByID(user.ID); got.IsActive {
+		t.Error("Expected user to be inactive")
+	}
+	if err := s.Activate(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetByID(user.ID); !got.IsActive {
+		t.Error("Expected user to be active")
+	}
+
+	if err := s.Delete(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetByID(user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	// BIGSERIAL не перевикористовує id
+	next, _ := s.Create("bob", "bob@mail.com")
+	if next.ID != 2 {
+		t.Errorf("Expected ID 2, got %d", next.ID)
+	}
+}
+
+func TestContextCancelled(t *testing.T) {
+	s := newService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.CreateContext(ctx, "alice", "alice@mail.com")
+	var dbErr *DatabaseError
+	if !errors.As(err, &dbErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected DatabaseError wrapping context.Canceled, got %v", err)
+	}
+	if dbErr.Op != "create user" || !strings.HasPrefix(dbErr.Query, "INSERT INTO users") {
+		t.Errorf("Expected op and query in error, got %+v", dbErr)
+	}
+	if err := s.UpdateContext(ctx, 1, "x", ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Update, got %v", err)
+	}
+}
+
+// Паралельні Update одного рядка не губляться завдяки транзакції з FOR UPDATE
+func TestConcurrentUpdates(t *testing.T) {
+	s := newService(t)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Create(fmt.Sprintf("user%d", i), fmt.Sprintf("user%d@mail.com", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Update(i+1, fmt.Sprintf("renamed%d", i), ""); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	users, _ := s.GetAll()
+	if len(users) != 20 {
+		t.Fatalf("Expected 20 users, got %d", len(users))
+	}
+	for _, u := range users {
+		if !strings.HasPrefix(u.Username, "renamed") {
+			t.Errorf("Expected renamed user, got %v", u)
+		}
+	}
+}
+
+// ============= fakepg =============
+
+func TestFakeRejectsUnknownSQL(t *testing.T) {
+	db := openDB(t)
+	_, err := db.Exec(`TRUNCATE users`)
+	var fake *FakeError
+	if !errors.As(err, &fake) || fake.SQLState() != "0A000" {
+		t.Errorf("Expected 0A000 for unsupported statement, got %v", err)
+	}
+}
+
+func TestFakeTransactionRollback(t *testing.T) {
+	db := openDB(t)
+	if _, err := db.Exec(`CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`INSERT INTO t (id, name) VALUES (?, ?)`, 1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM t`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 rows after rollback, got %d", count)
+	}
+
+	_, err = db.Exec(`INSERT INTO t (id) VALUES ($1)`, 1)
+	var fake *FakeError
+	if !errors.As(err, &fake) || fake.Code != codeNotNullViolation {
+		t.Errorf("Expected not-null violation, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============= Migrations =============
+// Файли migrations/NNNN_name.up.sql і NNNN_name.down.sql вшиваються в
+// бінарник через embed. Застосовані версії - у таблиці schema_version;
+// кожна міграція разом із записом версії виконується в одній транзакції
+// (Postgres уміє транзакційний DDL), тож збій посередині нічого не лишає.
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var ErrBadMigration = errors.New("bad migration")
+
+// LoadMigrations читає пари up/down і перевіряє, що версії йдуть
+// 1, 2, 3... без пропусків і кожна має обидва напрями
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(file, ".sql") {
+			continue
+		}
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("%w: %s: expected NNNN_name.up.sql or .down.sql", ErrBadMigration, file)
+		}
+		num, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s: bad version %q", ErrBadMigration, file, num)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("%w: version %d has two names: %s and %s", ErrBadMigration, version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("%w: expected version %d, got %d", ErrBadMigration, i+1, m.Version)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("%w: version %d needs both up and down", ErrBadMigration, m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+const (
+	createSchemaVersion = `CREATE TABLE IF NOT EXISTS schema_version (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL
+)`
+	selectSchemaVersion = `SELECT COALESCE(MAX(version), 0) FROM schema_version`
+	insertSchemaVersion = `INSERT INTO schema_version (version, name, applied_at) VALUES ($1, $2, $3)`
+	deleteSchemaVersion = `DELETE FROM schema_version WHERE version = $1`
+)
+
+// Version - остання застосована міграція (0 - порожня база)
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if _, err := m.db.ExecContext(ctx, createSchemaVersion); err != nil {
+		return 0, newDatabaseError("create schema_version", createSchemaVersion, err)
+	}
+	var version int
+	if err := m.db.QueryRowContext(ctx, selectSchemaVersion).Scan(&version); err != nil {
+		return 0, newDatabaseError("read schema version", selectSchemaVersion, err)
+	}
+	if version > len(m.migrations) {
+		return version, fmt.Errorf("%w: database is at version %d, binary knows only %d", ErrBadMigration, version, len(m.migrations))
+	}
+	return version, nil
+}
+
+// Up застосовує всі нові міграції і повертає їхні версії
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	return m.To(ctx, len(m.migrations))
+}
+
+// Down відкочує steps останніх міграцій
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.To(ctx, max(current-steps, 0))
+}
+
+// To веде схему до target вгору або вниз; повертає версії, які
+// застосовано (або відкочено) по черзі
+func (m *Migrator) To(ctx context.Context, target int) ([]int, error) {
+	if target < 0 || target > len(m.migrations) {
+		return nil, fmt.Errorf("%w: unknown target version %d", ErrBadMigration, target)
+	}
+	current, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	for current < target {
+		mig := m.migrations[current]
+		if err := m.step(ctx, mig, true); err != nil {
+			return done, err
+		}
+		done = append(done, mig.Version)
+		current++
+	}
+	for current > target {
+		mig := m.migrations[current-1]
+		if err := m.step(ctx, mig, false); err != nil {
+			return done, err
+		}
+		done = append(done, mig.Version)
+		current--
+	}
+	return done, nil
+}
+
+func (m *Migrator) step(ctx context.Context, mig Migration, up bool) (err error) {
+	op := fmt.Sprintf("migrate up %04d_%s", mig.Version, mig.Name)
+	script := mig.Up
+	if !up {
+		op = fmt.Sprintf("migrate down %04d_%s", mig.Version, mig.Name)
+		script = mig.Down
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return newDatabaseError(op, "", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return newDatabaseError(op, script, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, insertSchemaVersion, mig.Version, mig.Name, time.Now().UTC())
+		if err != nil {
+			return newDatabaseError(op, insertSchemaVersion, err)
+		}
+	} else {
+		if _, err = tx.ExecContext(ctx, deleteSchemaVersion, mig.Version); err != nil {
+			return newDatabaseError(op, deleteSchemaVersion, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return newDatabaseError(op, "", err)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+-- Таблиця з week_2/practice/user_service: ті самі поля, що й у User
+CREATE TABLE users (
+    id         BIGSERIAL PRIMARY KEY,
+    username   TEXT NOT NULL UNIQUE,
+    email      TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    is_active  BOOLEAN NOT NULL DEFAULT TRUE
+);
@@ -0,0 +1,2 @@
+-- Alice@Mail.com і alice@mail.com - одна адреса
+CREATE UNIQUE INDEX users_email_lower_idx ON users (lower(email));
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============= database/sql Implementation =============
+// PostgresUserService реалізує UserService поверх *sql.DB. Кожен метод
+// має варіант ...Context, як QueryContext/ExecContext у database/sql;
+// методи інтерфейсу викликають їх з Timeout.
+
+type PostgresUserService struct {
+	db      *sql.DB
+	Timeout time.Duration
+	now     func() time.Time
+}
+
+func NewPostgresUserService(db *sql.DB) *PostgresUserService {
+	return &PostgresUserService{
+		db:      db,
+		Timeout: 5 * time.Second,
+		now:     time.Now,
+	}
+}
+
+// Compile-time перевірка
+var _ UserService = (*PostgresUserService)(nil)
+
+const (
+	userColumns = `id, username, email, created_at, is_active`
+
+	insertUser     = `INSERT INTO users (username, email, created_at, is_active) VALUES ($1, $2, $3, $4) RETURNING id`
+	selectUserByID = `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	selectForLock  = `SELECT ` + userColumns + ` FROM users WHERE id = $1 FOR UPDATE`
+	selectUsers    = `SELECT ` + userColumns + ` FROM users ORDER BY id`
+	updateUser     = `UPDATE users SET username = $1, email = $2 WHERE id = $3`
+	updateActive   = `UPDATE users SET is_active = $1 WHERE id = $2`
+	deleteUser     = `DELETE FROM users WHERE id = $1`
+)
+
+func (s *PostgresUserService) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.Timeout)
+}
+
+func notFound(id int) error {
+	return fmt.Errorf("user with id %d %w", id, ErrNotFound)
+}
+
+// duplicate перетворює 23505 на "username already exists" з оригіналу,
+// не гублячи DatabaseError всередині
+func duplicate(err *DatabaseError) error {
+	if !errors.Is(err, ErrDuplicate) {
+		return err
+	}
+	field := "value"
+	switch {
+	case strings.Contains(err.Constraint, "username"):
+		field = "username"
+	case strings.Contains(err.Constraint, "email"):
+		field = "email"
+	}
+	return fmt.Errorf("%s already exists: %w", field, err)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
+	var u User
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt, &u.IsActive); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func validate(username, email string) error {
+	if username == "" {
+		return errors.New("username cannot be empty")
+	}
+	if !strings.Contains(email, "@") {
+		return errors.New("invalid email format")
+	}
+	return nil
+}
+
+// ============= Context-aware methods =============
+
+func (s *PostgresUserService) CreateContext(ctx context.Context, username, email string) (*User, error) {
+	if err := validate(username, email); err != nil {
+		return nil, err
+	}
+
+	// Дублікати ловить UNIQUE у базі, а не SELECT перед INSERT:
+	// між ними інший запит міг би встигнути
+	user := &User{
+		Username:  username,
+		Email:     email,
+		CreatedAt: s.now().UTC(),
+		IsActive:  true,
+	}
+	err := s.db.QueryRowContext(ctx, insertUser, user.Username, user.Email, user.CreatedAt, user.IsActive).Scan(&user.ID)
+	if err != nil {
+		return nil, duplicate(newDatabaseError("create user", insertUser, err))
+	}
+	return user, nil
+}
+
+func (s *PostgresUserService) GetByIDContext(ctx context.Context, id int) (*User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, selectUserByID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound(id)
+	}
+	if err != nil {
+		return nil, newDatabaseError("get user", selectUserByID, err)
+	}
+	return user, nil
+}
+
+func (s *PostgresUserService) GetAllContext(ctx context.Context) ([]*User, error) {
+	rows, err := s.db.QueryContext(ctx, selectUsers)
+	if err != nil {
+		return nil, newDatabaseError("list users", selectUsers, err)
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, newDatabaseError("list users", selectUsers, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, newDatabaseError("list users", selectUsers, err)
+	}
+	return users, nil
+}
+
+// UpdateContext зберігає семантику оригіналу: порожній username і email
+// без "@" не змінюють поле. Читання і запис - в одній транзакції з
+// FOR UPDATE, щоб паралельний Update не загубився.
+func (s *PostgresUserService) UpdateContext(ctx context.Context, id int, username, email string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return newDatabaseError("update user", "", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	user, err := scanUser(tx.QueryRowContext(ctx, selectForLock, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(id)
+	}
+	if err != nil {
+		return newDatabaseError("update user", selectForLock, err)
+	}
+
+	if username != "" {
+		user.Username = username
+	}
+	if email != "" && strings.Contains(email, "@") {
+		user.Email = email
+	}
+	if _, err = tx.ExecContext(ctx, updateUser, user.Username, user.Email, id); err != nil {
+		return duplicate(newDatabaseError("update user", updateUser, err))
+	}
+	if err = tx.Commit(); err != nil {
+		return newDatabaseError("update user", "", err)
+	}
+	return nil
+}
+
+func (s *PostgresUserService) DeleteContext(ctx context.Context, id int) error {
+	return s.execOne(ctx, "delete user", id, deleteUser, id)
+}
+
+func (s *PostgresUserService) ActivateContext(ctx context.Context, id int) error {
+	return s.execOne(ctx, "activate user", id, updateActive, true, id)
+}
+
+func (s *PostgresUserService) DeactivateContext(ctx context.Context, id int) error {
+	return s.execOne(ctx, "deactivate user", id, updateActive, false, id)
+}
+
+// execOne - запит, що має зачепити рівно один рядок; 0 рядків - not found
+func (s *PostgresUserService) execOne(ctx context.Context, op string, id int, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return newDatabaseError(op, query, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return newDatabaseError(op, query, err)
+	}
+	if n == 0 {
+		return notFound(id)
+	}
+	return nil
+}
+
+// ============= UserService =============
+
+func (s *PostgresUserService) Create(username, email string) (*User, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.CreateContext(ctx, username, email)
+}
+
+func (s *PostgresUserService) GetByID(id int) (*User, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.GetByIDContext(ctx, id)
+}
+
+func (s *PostgresUserService) GetAll() ([]*User, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.GetAllContext(ctx)
+}
+
+func (s *PostgresUserService) Update(id int, username, email string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.UpdateContext(ctx, id, username, email)
+}
+
+func (s *PostgresUserService) Delete(id int) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.DeleteContext(ctx, id)
+}
+
+func (s *PostgresUserService) Activate(id int) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.ActivateContext(ctx, id)
+}
+
+func (s *PostgresUserService) Deactivate(id int) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.DeactivateContext(ctx, id)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ============= Domain Model =============
+// User і UserService - копія з week_2/practice/user_service
+
+type User struct {
+	ID        int
+	Username  string
+	Email     string
+	CreatedAt time.Time
+	IsActive  bool
+}
+
+func (u User) String() string {
+	status := "активний"
+	if !u.IsActive {
+		status = "неактивний"
+	}
+	return fmt.Sprintf("[%d] %s <%s> - %s", u.ID, u.Username, u.Email, status)
+}
+
+// UserService визначає операції над користувачами
+type UserService interface {
+	Create(username, email string) (*User, error)
+	GetByID(id int) (*User, error)
+	GetAll() ([]*User, error)
+	Update(id int, username, email string) error
+	Delete(id int) error
+	Activate(id int) error
+	Deactivate(id int) error
+}
+
+// ============= Errors =============
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrDuplicate = errors.New("already exists")
+)
+
+// SQLSTATE коди Postgres, які сервіс розрізняє
+const (
+	codeUniqueViolation  = "23505"
+	codeNotNullViolation = "23502"
+)
+
+// DatabaseError - як у week_15/practice/01_error_handling, плюс Op і
+// SQLSTATE. Помилку драйвера видно через errors.As/Unwrap, а
+// порушення унікальності - ще й через errors.Is(err, ErrDuplicate).
+type DatabaseError struct {
+	Op         string
+	Query      string
+	Code       string // SQLSTATE, якщо драйвер його дав
+	Constraint string // ім'я обмеження для 23505
+	Err        error
+}
+
+// sqlStater - *pgconn.PgError (pgx), *pq.Error (lib/pq) і *FakeError
+type sqlStater interface {
+	SQLState() string
+}
+
+var constraintName = regexp.MustCompile(`constraint "([^"]+)"`)
+
+func newDatabaseError(op, query string, err error) *DatabaseError {
+	dbErr := &DatabaseError{Op: op, Query: compactQuery(query), Err: err}
+	var state sqlStater
+	if errors.As(err, &state) {
+		dbErr.Code = state.SQLState()
+	}
+	// Текст помилки однаковий у всіх драйверах - його формує сам Postgres
+	if m := constraintName.FindStringSubmatch(err.Error()); m != nil {
+		dbErr.Constraint = m[1]
+	}
+	return dbErr
+}
+
+func (e *DatabaseError) Error() string {
+	if e.Query == "" {
+		return fmt.Sprintf("database error: %s: %s", e.Op, e.Err)
+	}
+	return fmt.Sprintf("database error: %s: %s (query: %s)", e.Op, e.Err, compactQuery(e.Query))
+}
+
+func (e *DatabaseError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DatabaseError) Is(target error) bool {
+	return target == ErrDuplicate && e.Code == codeUniqueViolation
+}
+
+// compactQuery - запит в один рядок для логів
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
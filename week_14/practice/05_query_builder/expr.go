@@ -0,0 +1,448 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ============= Dialect =============
+// Ті самі запити для Postgres ($1, $2...) і MySQL/SQLite (?). Значення
+// ніколи не потрапляють у текст SQL - лише в args.
+
+type Dialect int
+
+const (
+	Dollar   Dialect = iota // Postgres: $1, $2
+	Question                // MySQL, SQLite: ?
+)
+
+func (d Dialect) String() string {
+	if d == Question {
+		return "question"
+	}
+	return "dollar"
+}
+
+var (
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+	ErrInvalidQuery      = errors.New("invalid query")
+)
+
+// ============= Writer =============
+
+type sqlWriter struct {
+	sb      strings.Builder
+	dialect Dialect
+	args    []any
+	err     error
+}
+
+func (w *sqlWriter) write(s string) {
+	w.sb.WriteString(s)
+}
+
+func (w *sqlWriter) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *sqlWriter) arg(v any) {
+	w.args = append(w.args, v)
+	if w.dialect == Question {
+		w.write("?")
+		return
+	}
+	w.write("$" + strconv.Itoa(len(w.args)))
+}
+
+// Ідентифікатор: name, t.name, t.* або *. Лапки й пробіли не
+// пропускаємо - ім'я колонки з query-параметра не стане SQL.
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validIdent(name string) bool {
+	return identRe.MatchString(name)
+}
+
+func (w *sqlWriter) ident(name string) {
+	if name == "*" {
+		w.write(name)
+		return
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		w.fail(fmt.Errorf("%w: %q", ErrInvalidIdentifier, name))
+		return
+	}
+	for i, part := range parts {
+		if !validIdent(part) && !(i == 1 && part == "*") {
+			w.fail(fmt.Errorf("%w: %q", ErrInvalidIdentifier, name))
+			return
+		}
+	}
+	w.write(name)
+}
+
+// table - "users" або "users u" (таблиця з аліасом)
+func (w *sqlWriter) table(spec string) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 || strings.Contains(fields[0], "*") {
+		w.fail(fmt.Errorf("%w: table %q", ErrInvalidIdentifier, spec))
+		return
+	}
+	w.ident(fields[0])
+	if len(fields) == 2 {
+		w.write(" ")
+		w.alias(fields[1])
+	}
+}
+
+func (w *sqlWriter) alias(name string) {
+	if !validIdent(name) {
+		w.fail(fmt.Errorf("%w: alias %q", ErrInvalidIdentifier, name))
+		return
+	}
+	w.write(name)
+}
+
+func (w *sqlWriter) list(items []Expr, sep string) {
+	for i, e := range items {
+		if i > 0 {
+			w.write(sep)
+		}
+		e.writeSQL(w)
+	}
+}
+
+// conds з'єднує умови через AND/OR. Пріоритет операторів у Raw невідомий:
+// "a OR b" без дужок поглинув би сусідні умови, тож Raw серед кількох
+// умов завжди береться в дужки.
+func (w *sqlWriter) conds(items []Expr, op string) {
+	for i, e := range items {
+		if i > 0 {
+			w.write(" " + op + " ")
+		}
+		if _, ok := e.(raw); ok && len(items) > 1 {
+			w.write("(")
+			e.writeSQL(w)
+			w.write(")")
+			continue
+		}
+		e.writeSQL(w)
+	}
+}
+
+// ============= Expressions =============
+// Expr має неекспортований метод - реалізувати його можуть лише типи
+// цього пакета, тож "сирий" рядок у запит потрапляє тільки через Raw.
+
+type Expr interface {
+	writeSQL(w *sqlWriter)
+}
+
+// value: Expr як є, решта - параметр
+func value(v any) Expr {
+	if e, ok := v.(Expr); ok {
+		return e
+	}
+	return param{v}
+}
+
+// columnOrExpr: рядок - ім'я колонки, Expr - як є
+func columnOrExpr(v any) Expr {
+	switch x := v.(type) {
+	case string:
+		return Col(x)
+	case Expr:
+		return x
+	default:
+		return invalid{fmt.Errorf("%w: expected column name or Expr, got %T", ErrInvalidQuery, v)}
+	}
+}
+
+type invalid struct{ err error }
+
+func (e invalid) writeSQL(w *sqlWriter) { w.fail(e.err) }
+
+type column struct{ name string }
+
+// Col - посилання на колонку там, де інакше було б значення:
+// Eq("o.user_id", Col("u.id"))
+func Col(name string) Expr { return column{name} }
+
+func (c column) writeSQL(w *sqlWriter) { w.ident(c.name) }
+
+type param struct{ v any }
+
+// Val - явний параметр (наприклад, у Select або Func)
+func Val(v any) Expr { return param{v} }
+
+func (p param) writeSQL(w *sqlWriter) { w.arg(p.v) }
+
+type raw struct {
+	sql  string
+	args []any
+}
+
+// Raw - свідомий вихід за межі білдера. Кожен ? (і в лапках теж) стає
+// плейсхолдером діалекту, ?? - буквальним знаком питання для
+// jsonb-операторів Postgres. У діалекті Question буквальний ? драйвер
+// сприйняв би як плейсхолдер, тому там ?? - помилка.
+func Raw(sql string, args ...any) Expr { return raw{sql, args} }
+
+func (r raw) writeSQL(w *sqlWriter) {
+	n := 0
+	for i := 0; i < len(r.sql); i++ {
+		if r.sql[i] != '?' {
+			w.sb.WriteByte(r.sql[i])
+			continue
+		}
+		if i+1 < len(r.sql) && r.sql[i+1] == '?' {
+			if w.dialect == Question {
+				w.fail(fmt.Errorf("%w: Raw(%q): literal ? is not expressible with ? placeholders", ErrInvalidQuery, r.sql))
+				return
+			}
+			w.write("?")
+			i++
+			continue
+		}
+		if n >= len(r.args) {
+			w.fail(fmt.Errorf("%w: Raw(%q) has more placeholders than args", ErrInvalidQuery, r.sql))
+			return
+		}
+		value(r.args[n]).writeSQL(w)
+		n++
+	}
+	if n != len(r.args) {
+		w.fail(fmt.Errorf("%w: Raw(%q) got %d args for %d placeholders", ErrInvalidQuery, r.sql, len(r.args), n))
+	}
+}
+
+type binary struct {
+	left  Expr
+	op    string
+	right Expr
+}
+
+func (b binary) writeSQL(w *sqlWriter) {
+	b.left.writeSQL(w)
+	w.write(" " + b.op + " ")
+	b.right.writeSQL(w)
+}
+
+func compare(col any, op string, v any) Expr {
+	return binary{columnOrExpr(col), op, value(v)}
+}
+
+// Ліва частина порівнянь - ім'я колонки або Expr (Gt(Count("o.id"), 2)
+// у HAVING). Eq(col, nil) - IS NULL, бо "= NULL" у SQL ніколи не істина
+func Eq(col any, v any) Expr {
+	if v == nil {
+		return IsNull(col)
+	}
+	return compare(col, "=", v)
+}
+
+func Ne(col any, v any) Expr {
+	if v == nil {
+		return IsNotNull(col)
+	}
+	return compare(col, "<>", v)
+}
+
+func Gt(col any, v any) Expr   { return compare(col, ">", v) }
+func Gte(col any, v any) Expr  { return compare(col, ">=", v) }
+func Lt(col any, v any) Expr   { return compare(col, "<", v) }
+func Lte(col any, v any) Expr  { return compare(col, "<=", v) }
+func Like(col any, v any) Expr { return compare(col, "LIKE", v) }
+
+// On - умова JOIN між двома колонками: On("u.id", "o.user_id")
+func On(left, right string) Expr {
+	return binary{Col(left), "=", Col(right)}
+}
+
+type nullCheck struct {
+	col Expr
+	not bool
+}
+
+func IsNull(col any) Expr    { return nullCheck{columnOrExpr(col), false} }
+func IsNotNull(col any) Expr { return nullCheck{columnOrExpr(col), true} }
+
+func (n nullCheck) writeSQL(w *sqlWriter) {
+	n.col.writeSQL(w)
+	if n.not {
+		w.write(" IS NOT NULL")
+	} else {
+		w.write(" IS NULL")
+	}
+}
+
+type logical struct {
+	op    string
+	items []Expr
+}
+
+func And(items ...Expr) Expr { return logical{"AND", items} }
+func Or(items ...Expr) Expr  { return logical{"OR", items} }
+
+func (l logical) writeSQL(w *sqlWriter) {
+	switch len(l.items) {
+	case 0:
+		// Порожній AND - істина, порожній OR - хибність
+		if l.op == "AND" {
+			w.write("1 = 1")
+		} else {
+			w.write("1 = 0")
+		}
+	case 1:
+		l.items[0].writeSQL(w)
+	default:
+		w.write("(")
+		w.conds(l.items, l.op)
+		w.write(")")
+	}
+}
+
+type not struct{ e Expr }
+
+func Not(e Expr) Expr { return not{e} }
+
+func (n not) writeSQL(w *sqlWriter) {
+	w.write("NOT (")
+	n.e.writeSQL(w)
+	w.write(")")
+}
+
+type in struct {
+	col    Expr
+	values []any
+	sub    *SelectBuilder
+	not    bool
+}
+
+// In(col, 1, 2, 3) або In(col, subquery)
+func In(col any, values ...any) Expr {
+	if len(values) == 1 {
+		if sub, ok := values[0].(*SelectBuilder); ok {
+			return in{col: columnOrExpr(col), sub: sub}
+		}
+	}
+	return in{col: columnOrExpr(col), values: values}
+}
+
+func NotIn(col any, values ...any) Expr {
+	e := In(col, values...).(in)
+	e.not = true
+	return e
+}
+
+func (e in) writeSQL(w *sqlWriter) {
+	// "IN ()" - синтаксична помилка; порожній список - завжди хибність
+	if e.sub == nil && len(e.values) == 0 {
+		if e.not {
+			w.write("1 = 1")
+		} else {
+			w.write("1 = 0")
+		}
+		return
+	}
+	e.col.writeSQL(w)
+	if e.not {
+		w.write(" NOT")
+	}
+	w.write(" IN ")
+	if e.sub != nil {
+		e.sub.writeSQL(w)
+		return
+	}
+	w.write("(")
+	for i, v := range e.values {
+		if i > 0 {
+			w.write(", ")
+		}
+		value(v).writeSQL(w)
+	}
+	w.write(")")
+}
+
+type exists struct {
+	sub *SelectBuilder
+	not bool
+}
+
+func Exists(sub *SelectBuilder) Expr    { return exists{sub, false} }
+func NotExists(sub *SelectBuilder) Expr { return exists{sub, true} }
+
+func (e exists) writeSQL(w *sqlWriter) {
+	if e.not {
+		w.write("NOT ")
+	}
+	w.write("EXISTS ")
+	e.sub.writeSQL(w)
+}
+
+type function struct {
+	name string
+	args []Expr
+}
+
+// Func - виклик функції; аргументи - Expr або значення (стануть параметрами)
+func Func(name string, args ...any) Expr {
+	exprs := make([]Expr, len(args))
+	for i, a := range args {
+		exprs[i] = value(a)
+	}
+	return function{name, exprs}
+}
+
+// Агрегати приймають ім'я колонки або Expr: Count("o.id"), Sum("o.total")
+func Count(col any) Expr { return function{"COUNT", []Expr{columnOrExpr(col)}} }
+func Sum(col any) Expr   { return function{"SUM", []Expr{columnOrExpr(col)}} }
+func Avg(col any) Expr   { return function{"AVG", []Expr{columnOrExpr(col)}} }
+func Min(col any) Expr   { return function{"MIN", []Expr{columnOrExpr(col)}} }
+func Max(col any) Expr   { return function{"MAX", []Expr{columnOrExpr(col)}} }
+
+func Coalesce(args ...any) Expr { return Func("COALESCE", args...) }
+
+func (f function) writeSQL(w *sqlWriter) {
+	if !validIdent(f.name) {
+		w.fail(fmt.Errorf("%w: function %q", ErrInvalidIdentifier, f.name))
+		return
+	}
+	w.write(strings.ToUpper(f.name) + "(")
+	w.list(f.args, ", ")
+	w.write(")")
+}
+
+type aliased struct {
+	e    Expr
+	name string
+}
+
+// As - "expr AS name"; рядок - ім'я колонки: As("o.id", "order_id")
+func As(e any, name string) Expr { return aliased{columnOrExpr(e), name} }
+
+func (a aliased) writeSQL(w *sqlWriter) {
+	a.e.writeSQL(w)
+	w.write(" AS ")
+	w.alias(a.name)
+}
+
+type ordering struct {
+	e    Expr
+	desc bool
+}
+
+func Asc(col any) Expr  { return ordering{columnOrExpr(col), false} }
+func Desc(col any) Expr { return ordering{columnOrExpr(col), true} }
+
+func (o ordering) writeSQL(w *sqlWriter) {
+	o.e.writeSQL(w)
+	if o.desc {
+		w.write(" DESC")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func show(title string, b Builder) {
+	fmt.Println("   " + title)
+	for _, d := range []Dialect{Dollar, Question} {
+		sql, args, err := b.Build(d)
+		if err != nil {
+			fmt.Printf("     %-8s error: %v\n", d, err)
+			continue
+		}
+		fmt.Printf("     %-8s %s\n", d, sql)
+		fmt.Printf("     %-8s args: %v\n", "", args)
+	}
+}
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════════════╗")
+	fmt.Println("║   SQL Query Builder ($n / ?)                   ║")
+	fmt.Println("╚════════════════════════════════════════════════╝")
+
+	fmt.Println("\n💉 1. Чому не рядки (QueryBuilder з design_patterns/creational/builder):")
+	name := "x' OR '1'='1"
+	fmt.Println("   Where(\"name = '\" + name + \"'\") ->", "SELECT * FROM users WHERE name = '"+name+"'")
+	show("Eq(\"name\", name):", Select().From("users").Where(Eq("name", name)))
+
+	fmt.Println("\n🔗 2. JOINs з week_14:")
+	show("INNER JOIN:", Select("u.name", As("o.id", "order_id"), "o.total", "o.status").
+		From("users u").
+		Join("orders o", On("u.id", "o.user_id")).
+		OrderBy("u.name", "o.id"))
+	show("LEFT JOIN ... IS NULL:", Select("u.name").
+		From("users u").
+		LeftJoin("orders o", On("u.id", "o.user_id")).
+		Where(IsNull("o.id")))
+
+	fmt.Println("\n📊 3. GROUP BY / HAVING:")
+	show("Клієнти з понад 2 замовленнями:", Select("u.name", As(Count("o.id"), "order_count"), As(Coalesce(Sum("o.total"), 0), "total_spent")).
+		From("users u").
+		LeftJoin("orders o", On("u.id", "o.user_id")).
+		Where(Ne("o.status", "cancelled")).
+		GroupBy("u.id", "u.name").
+		Having(Gt(Count("o.id"), 2)).
+		OrderBy(Desc("total_spent")).
+		Limit(10))
+
+	fmt.Println("\n🪆 4. Підзапити (нумерація $n наскрізна):")
+	big := Select("user_id").From("orders").Where(Gt("total", 100))
+	show("IN (SELECT ...):", Select("name").From("users").Where(Eq("active", true), In("id", big)))
+
+	fmt.Println("\n✏️  5. INSERT / UPDATE / DELETE:")
+	show("INSERT:", InsertInto("users").Columns("name", "email").
+		Values("alice", "alice@mail.com").
+		Values("bob", "bob@mail.com").
+		Returning("id"))
+	show("UPDATE:", Update("orders").Set("status", "shipped").Where(Eq("id", 42)))
+	show("DELETE без WHERE:", DeleteFrom("orders"))
+
+	fmt.Println("\n🛡️  6. Ідентифікатори перевіряються:")
+	sortBy := "name; DROP TABLE users"
+	show("OrderBy(sortBy) з query-параметра:", Select().From("users").OrderBy(sortBy))
+
+	fmt.Println("\n" + strings.Repeat("─", 50))
+	fmt.Println("✅ Demo completed!")
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// go test -update перезаписує testdata/*.golden
+var update = flag.Bool("update", false, "update golden files")
+
+// ============= Golden cases =============
+// Перші п'ять - запити з week_14/practice/03_go_joins
+
+func goldenCases() map[string]Builder {
+	return map[string]Builder{
+		"inner_join": Select("u.name", As("o.id", "order_id"), "o.total", "o.status").
+			From("users u").
+			Join("orders o", On("u.id", "o.user_id")).
+			OrderBy("u.name", "o.id"),
+
+		"left_join": Select("u.name", As("o.id", "order_id"), "o.total", "o.status").
+			From("users u").
+			LeftJoin("orders o", On("u.id", "o.user_id")).
+			OrderBy("u.name", "o.id"),
+
+		"left_join_is_null": Select("u.name").
+			From("users u").
+			LeftJoin("orders o", On("u.id", "o.user_id")).
+			Where(IsNull("o.id")),
+
+		"multiple_joins": Select(As("u.name", "customer"), As("o.id", "order_id"), As("o.total", "order_total"),
+			As("p.name", "product"), "oi.quantity", As("oi.price", "item_price")).
+			From("users u").
+			Join("orders o", On("u.id", "o.user_id")).
+			Join("order_items oi", On("o.id", "oi.order_id")).
+			Join("products p", On("oi.product_id", "p.id")).
+			OrderBy("u.name", "o.id", "p.name"),
+
+		"group_by": Select("u.name", As(Count("o.id"), "order_count"), As(Coalesce(Sum("o.total"), 0), "total_spent")).
+			From("users u").
+			LeftJoin("orders o", On("u.id", "o.user_id")).
+			GroupBy("u.id", "u.name").
+			OrderBy(Desc("total_spent")),
+
+		"having": Select("u.name", As(Count("o.id"), "order_count")).
+			From("users u").
+			Join("orders o", And(On("u.id", "o.user_id"), Eq("o.status", "completed"))).
+			Where(Gte("o.total", 10)).
+			GroupBy("u.id", "u.name").
+			Having(Gt(Count("o.id"), 2), Lt(Sum("o.total"), 1000)).
+			Limit(10).
+			Offset(20),
+
+		"where_or_in": Select().
+			From("users").
+			Where(
+				Or(Like("email", "%@mail.com"), Eq("name", "O'Brien")),
+				In("status", "active", "trial"),
+				NotIn("id"),
+				Eq("deleted_at", nil),
+				Not(Eq("role", "admin")),
+			),
+
+		"subquery_in": Select("name").
+			From("users").
+			Where(Eq("active", true), In("id", Select("user_id").From("orders").Where(Gt("total", 100)))).
+			OrderBy("name"),
+
+		"subquery_exists": Select("u.name").
+			From("users u").
+			Where(NotExists(Select(Val(1)).From("orders o").Where(On("o.user_id", "u.id"), Eq("o.status", "pending")))),
+
+		"subquery_from_and_join": Select("t.user_id", "t.spent", "u.name").
+			FromSelect(Select("user_id", As(Sum("total"), "spent")).From("orders").Where(Eq("status", "completed")).GroupBy("user_id"), "t").
+			JoinSelect(Select("id", "name").From("users").Where(Eq("active", true)), "u", On("u.id", "t.user_id")).
+			Where(Gt("t.spent", 500)),
+
+		"distinct_cross_join": Select("c.name", "s.size").Distinct().
+			From("colors c").
+			CrossJoin("sizes s").
+			OrderBy("c.name", Desc("s.size")),
+
+		"insert_multi_row": InsertInto("users").
+			Columns("name", "email").
+			Values("alice", "alice@mail.com").
+			Values("bob", "bob@mail.com").
+			Returning("id"),
+
+		"insert_select": InsertInto("archived_orders").
+			Columns("id", "user_id", "total").
+			FromSelect(Select("id", "user_id", "total").From("orders").Where(Lt("created_at", "2024-01-01"))),
+
+		"update": Update("orders").
+			Set("status", "shipped").
+			Set("total", Raw("total * ?", 0.9)).
+			Set("shipped_at", Func("now")).
+			Where(Eq("id", 42), Eq("status", "paid")).
+			Returning("id", "total"),
+
+		"delete": DeleteFrom("sessions").
+			Where(Or(Lt("expires_at", "2024-06-01"), Eq("revoked", true))),
+
+		"raw": Select("id", As(Raw("data->>'name'"), "name")).
+			From("documents").
+			Where(Raw("lower(email) = lower(?)", "Alice@Mail.com"), Raw("created_at > now() - ? * interval '1 day'", 7)),
+
+		// Без дужок навколо Raw фільтр tenant_id діяв би лише на role = 'b'
+		"raw_or_condition": Select("id").
+			From("users").
+			Where(Raw("role = ? OR role = ?", "a", "b"), Eq("tenant_id", 7)),
+
+		"raw_having_and_or": Select("user_id").
+			From("orders").
+			Where(Or(Raw("status = ? AND total > ?", "paid", 0), Eq("refunded", true)), Raw("user_id = ?", 5)).
+			GroupBy("user_id").
+			Having(Raw("count(*) > ? OR sum(total) > ?", 5, 1000), Gt(Count("*"), 1)),
+	}
+}
+
+func formatGolden(sql string, args []any) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = fmt.Sprintf("%#v", a)
+	}
+	return sql + "\n-- args: [" + strings.Join(parts, ", ") + "]\n"
+}
+
+func TestGolden(t *testing.T) {
+	for name, b := range goldenCases() {
+		for _, d := range []Dialect{Dollar, Question} {
+			t.Run(name+"/"+d.String(), func(t *testing.T) {
+				sql, args, err := b.Build(d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := formatGolden(sql, args)
+
+				path := filepath.Join("testdata", name+"."+d.String()+".golden")
+				if *update {
+					if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("%v (run go test -update)", err)
+				}
+				if got != string(want) {
+					t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+				}
+			})
+		}
+	}
+}
+
+// ============= Placeholders =============
+
+func TestPlaceholderNumbering(t *testing.T) {
+	// Параметри підзапиту нумеруються після зовнішніх, HAVING - після WHERE
+	b := Select("user_id", As(Count("*"), "n")).
+		From("orders").
+		Where(Eq("status", "paid"), In("user_id", Select("id").From("users").Where(Eq("country", "UA")))).
+		GroupBy("user_id").
+		Having(Gt(Count("*"), 3))
+
+	sql, args, err := b.Build(Dollar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT user_id, COUNT(*) AS n FROM orders WHERE status = $1 AND user_id IN (SELECT id FROM users WHERE country = $2) GROUP BY user_id HAVING COUNT(*) > $3"
+	if sql != want {
+		t.Errorf("Expected %s, got %s", want, sql)
+	}
+	if fmt.Sprint(args) != "[paid UA 3]" {
+		t.Errorf("Expected [paid UA 3], got %v", args)
+	}
+
+	// Той самий білдер можна зібрати ще раз - нумерація з нуля
+	q, args2, _ := b.Build(Question)
+	if strings.Contains(q, "$") || len(args2) != 3 {
+		t.Errorf("Expected ? placeholders and 3 args, got %s %v", q, args2)
+	}
+}
+
+func TestValuesNeverInlined(t *testing.T) {
+	evil := "x'; DROP TABLE users; --"
+	sql, args, err := Select().From("users").Where(Eq("name", evil)).Build(Dollar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sql, "DROP") {
+		t.Errorf("Expected value to stay out of SQL, got %s", sql)
+	}
+	if len(args) != 1 || args[0] != evil {
+		t.Errorf("Expected value in args, got %v", args)
+	}
+}
+
+// ============= Errors =============
+
+func TestInvalidIdentifiers(t *testing.T) {
+	tests := map[string]Builder{
+		"column injection": Select("name; DROP TABLE users").From("users"),
+		"order by injection": Select().From("users").
+			OrderBy("name DESC, (SELECT password FROM admins)"),
+		"table":         Select().From("users; --"),
+		"table alias":   Select().From("users u x"),
+		"alias":         Select(As("name", "n m")).From("users"),
+		"function":      Select(Func("sleep(10); count")).From("users"),
+		"where column":  Select().From("users").Where(Eq("1=1 OR name", "x")),
+		"three parts":   Select("a.b.c").From("users"),
+		"insert column": InsertInto("users").Columns("name", "email)").Values("a", "b"),
+		"update column": Update("users").Set("role = 'admin', name", "x").All(),
+		"returning":     DeleteFrom("users").Where(Eq("id", 1)).Returning("*; --"),
+		"subquery alias": Select().
+			FromSelect(Select().From("orders"), "t;"),
+	}
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			sql, args, err := b.Build(Dollar)
+			if !errors.Is(err, ErrInvalidIdentifier) {
+				t.Fatalf("Expected ErrInvalidIdentifier, got %v (%s)", err, sql)
+			}
+			if sql != "" || args != nil {
+				t.Errorf("Expected no SQL on error, got %q %v", sql, args)
+			}
+		})
+	}
+}
+
+func TestInvalidQueries(t *testing.T) {
+	tests := map[string]Builder{
+		"update without where": Update("users").Set("active", false),
+		"update without set":   Update("users").Where(Eq("id", 1)),
+		"delete without where": DeleteFrom("users"),
+		"insert no columns":    InsertInto("users").Values("a"),
+		"insert no values":     InsertInto("users").Columns("name"),
+		"insert both sources": InsertInto("users").Columns("name").Values("a").
+			FromSelect(Select("name").From("guests")),
+		"insert row mismatch": InsertInto("users").Columns("name", "email").Values("a"),
+		"raw too few args":    Select().From("users").Where(Raw("id = ? AND name = ?", 1)),
+		"raw too many args":   Select().From("users").Where(Raw("id = ?", 1, 2)),
+		"having without group": Select(Count("*")).From("users").
+			Having(Gt(Count("*"), 1)),
+		"join without on":    Select().From("users u").Join("orders o", nil),
+		"join without from":  Select().Join("orders o", On("a", "b")),
+		"aggregate of value": Select(Sum(42)).From("orders"),
+	}
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := b.Build(Question); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("Expected ErrInvalidQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAllowsExplicitFullTableWrites(t *testing.T) {
+	sql, _, err := Update("users").Set("active", false).All().Build(Dollar)
+	if err != nil || sql != "UPDATE users SET active = $1" {
+		t.Errorf("Expected full-table UPDATE, got %q, %v", sql, err)
+	}
+	sql, _, err = DeleteFrom("sessions").All().Build(Dollar)
+	if err != nil || sql != "DELETE FROM sessions" {
+		t.Errorf("Expected full-table DELETE, got %q, %v", sql, err)
+	}
+}
+
+func TestEmptyLogical(t *testing.T) {
+	tests := []struct {
+		expr Expr
+		want string
+	}{
+		{And(), "SELECT * FROM t WHERE 1 = 1"},
+		{Or(), "SELECT * FROM t WHERE 1 = 0"},
+		{In("id"), "SELECT * FROM t WHERE 1 = 0"},
+		{NotIn("id"), "SELECT * FROM t WHERE 1 = 1"},
+		{Or(Eq("a", 1)), "SELECT * FROM t WHERE a = ?"},
+		{Ne("a", nil), "SELECT * FROM t WHERE a IS NOT NULL"},
+	}
+	for _, tt := range tests {
+		sql, _, err := Select().From("t").Where(tt.expr).Build(Question)
+		if err != nil || sql != tt.want {
+			t.Errorf("Expected %q, got %q (%v)", tt.want, sql, err)
+		}
+	}
+}
+
+func TestRawLiteralQuestionMark(t *testing.T) {
+	b := Select("id").From("documents").Where(Raw("data ?? ?", "tags"))
+
+	sql, args, err := b.Build(Dollar)
+	if err != nil || sql != "SELECT id FROM documents WHERE data ? $1" || len(args) != 1 {
+		t.Errorf("Expected jsonb ? operator with $1, got %q %v %v", sql, args, err)
+	}
+	if _, _, err := b.Build(Question); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery for ?? in question dialect, got %v", err)
+	}
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ============= Builder =============
+// Будь-який білдер збирається в (sql, args, err) під потрібний діалект.
+// Помилка - невалідний ідентифікатор або неповний запит; SQL при ній
+// не повертається, щоб його випадково не виконали.
+
+type Builder interface {
+	Build(d Dialect) (string, []any, error)
+}
+
+func build(d Dialect, write func(w *sqlWriter)) (string, []any, error) {
+	w := &sqlWriter{dialect: d}
+	write(w)
+	if w.err != nil {
+		return "", nil, w.err
+	}
+	return w.sb.String(), w.args, nil
+}
+
+func writeWhere(w *sqlWriter, where []Expr) {
+	if len(where) == 0 {
+		return
+	}
+	w.write(" WHERE ")
+	w.conds(where, "AND")
+}
+
+func writeReturning(w *sqlWriter, cols []string) {
+	if len(cols) == 0 {
+		return
+	}
+	w.write(" RETURNING ")
+	for i, col := range cols {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.ident(col)
+	}
+}
+
+// ============= SELECT =============
+
+type join struct {
+	kind  string
+	table string
+	sub   *SelectBuilder
+	alias string
+	on    Expr
+}
+
+type SelectBuilder struct {
+	distinct  bool
+	columns   []Expr
+	from      string
+	fromSub   *SelectBuilder
+	fromAlias string
+	joins     []join
+	where     []Expr
+	groupBy   []Expr
+	having    []Expr
+	orderBy   []Expr
+	limit     int
+	offset    int
+}
+
+// Select приймає імена колонок ("u.name", "o.*") або Expr (As, Count...).
+// Без колонок - SELECT *.
+func Select(columns ...any) *SelectBuilder {
+	b := &SelectBuilder{limit: -1, offset: -1}
+	for _, c := range columns {
+		b.columns = append(b.columns, columnOrExpr(c))
+	}
+	return b
+}
+
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// From - "users" або "users u"
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	b.fromSub = nil
+	return b
+}
+
+// FromSelect - підзапит у FROM; аліас обов'язковий і в Postgres, і в MySQL
+func (b *SelectBuilder) FromSelect(sub *SelectBuilder, alias string) *SelectBuilder {
+	b.fromSub, b.fromAlias = sub, alias
+	b.from = ""
+	return b
+}
+
+func (b *SelectBuilder) Join(table string, on Expr) *SelectBuilder {
+	return b.addJoin("INNER JOIN", table, on)
+}
+
+func (b *SelectBuilder) LeftJoin(table string, on Expr) *SelectBuilder {
+	return b.addJoin("LEFT JOIN", table, on)
+}
+
+func (b *SelectBuilder) RightJoin(table string, on Expr) *SelectBuilder {
+	return b.addJoin("RIGHT JOIN", table, on)
+}
+
+func (b *SelectBuilder) FullJoin(table string, on Expr) *SelectBuilder {
+	return b.addJoin("FULL OUTER JOIN", table, on)
+}
+
+func (b *SelectBuilder) CrossJoin(table string) *SelectBuilder {
+	return b.addJoin("CROSS JOIN", table, nil)
+}
+
+// JoinSelect - INNER JOIN на підзапит: JoinSelect(totals, "t", On("u.id", "t.user_id"))
+func (b *SelectBuilder) JoinSelect(sub *SelectBuilder, alias string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "INNER JOIN", sub: sub, alias: alias, on: on})
+	return b
+}
+
+func (b *SelectBuilder) addJoin(kind, table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: kind, table: table, on: on})
+	return b
+}
+
+// Where - умови через AND; для OR - Or(...)
+func (b *SelectBuilder) Where(conds ...Expr) *SelectBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+func (b *SelectBuilder) GroupBy(columns ...any) *SelectBuilder {
+	for _, c := range columns {
+		b.groupBy = append(b.groupBy, columnOrExpr(c))
+	}
+	return b
+}
+
+func (b *SelectBuilder) Having(conds ...Expr) *SelectBuilder {
+	b.having = append(b.having, conds...)
+	return b
+}
+
+// OrderBy приймає імена колонок (ASC) або Asc/Desc
+func (b *SelectBuilder) OrderBy(columns ...any) *SelectBuilder {
+	for _, c := range columns {
+		b.orderBy = append(b.orderBy, columnOrExpr(c))
+	}
+	return b
+}
+
+// Limit і Offset - цілі числа, тож пишуться в SQL як є: MySQL не
+// приймає плейсхолдери в LIMIT у деяких режимах
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+	return b
+}
+
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	b.offset = n
+	return b
+}
+
+func (b *SelectBuilder) Build(d Dialect) (string, []any, error) {
+	return build(d, b.write)
+}
+
+// writeSQL - SelectBuilder як підзапит у дужках
+func (b *SelectBuilder) writeSQL(w *sqlWriter) {
+	w.write("(")
+	b.write(w)
+	w.write(")")
+}
+
+func (b *SelectBuilder) write(w *sqlWriter) {
+	w.write("SELECT ")
+	if b.distinct {
+		w.write("DISTINCT ")
+	}
+	if len(b.columns) == 0 {
+		w.write("*")
+	} else {
+		w.list(b.columns, ", ")
+	}
+
+	switch {
+	case b.fromSub != nil:
+		w.write(" FROM ")
+		b.fromSub.writeSQL(w)
+		w.write(" ")
+		w.alias(b.fromAlias)
+	case b.from != "":
+		w.write(" FROM ")
+		w.table(b.from)
+	case len(b.joins) > 0:
+		w.fail(fmt.Errorf("%w: JOIN without FROM", ErrInvalidQuery))
+	}
+
+	for _, j := range b.joins {
+		w.write(" " + j.kind + " ")
+		if j.sub != nil {
+			j.sub.writeSQL(w)
+			w.write(" ")
+			w.alias(j.alias)
+		} else {
+			w.table(j.table)
+		}
+		if j.kind == "CROSS JOIN" {
+			continue
+		}
+		if j.on == nil {
+			w.fail(fmt.Errorf("%w: %s %s without ON", ErrInvalidQuery, j.kind, j.table))
+			return
+		}
+		w.write(" ON ")
+		j.on.writeSQL(w)
+	}
+
+	writeWhere(w, b.where)
+	if len(b.groupBy) > 0 {
+		w.write(" GROUP BY ")
+		w.list(b.groupBy, ", ")
+	}
+	if len(b.having) > 0 {
+		if len(b.groupBy) == 0 {
+			w.fail(fmt.Errorf("%w: HAVING without GROUP BY", ErrInvalidQuery))
+		}
+		w.write(" HAVING ")
+		w.conds(b.having, "AND")
+	}
+	if len(b.orderBy) > 0 {
+		w.write(" ORDER BY ")
+		w.list(b.orderBy, ", ")
+	}
+	if b.limit >= 0 {
+		w.write(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	if b.offset >= 0 {
+		w.write(" OFFSET " + strconv.Itoa(b.offset))
+	}
+}
+
+// ============= INSERT =============
+
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]any
+	sub       *SelectBuilder
+	returning []string
+}
+
+func InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = columns
+	return b
+}
+
+// Values - один рядок; кілька викликів - multi-row INSERT
+func (b *InsertBuilder) Values(values ...any) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// FromSelect - INSERT ... SELECT замість VALUES
+func (b *InsertBuilder) FromSelect(sub *SelectBuilder) *InsertBuilder {
+	b.sub = sub
+	return b
+}
+
+// Returning - Postgres і SQLite; MySQL його не знає
+func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	b.returning = columns
+	return b
+}
+
+func (b *InsertBuilder) Build(d Dialect) (string, []any, error) {
+	return build(d, b.write)
+}
+
+func (b *InsertBuilder) write(w *sqlWriter) {
+	if len(b.columns) == 0 {
+		w.fail(fmt.Errorf("%w: INSERT without columns", ErrInvalidQuery))
+		return
+	}
+	if (len(b.rows) == 0) == (b.sub == nil) {
+		w.fail(fmt.Errorf("%w: INSERT needs either Values or FromSelect", ErrInvalidQuery))
+		return
+	}
+
+	w.write("INSERT INTO ")
+	w.table(b.table)
+	w.write(" (")
+	for i, col := range b.columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.ident(col)
+	}
+	w.write(")")
+
+	if b.sub != nil {
+		w.write(" ")
+		b.sub.write(w)
+	} else {
+		w.write(" VALUES ")
+		for i, row := range b.rows {
+			if len(row) != len(b.columns) {
+				w.fail(fmt.Errorf("%w: row %d has %d values for %d columns", ErrInvalidQuery, i+1, len(row), len(b.columns)))
+				return
+			}
+			if i > 0 {
+				w.write(", ")
+			}
+			w.write("(")
+			for j, v := range row {
+				if j > 0 {
+					w.write(", ")
+				}
+				value(v).writeSQL(w)
+			}
+			w.write(")")
+		}
+	}
+	writeReturning(w, b.returning)
+}
+
+// ============= UPDATE =============
+
+type assignment struct {
+	col   string
+	value Expr
+}
+
+type UpdateBuilder struct {
+	table     string
+	set       []assignment
+	where     []Expr
+	all       bool
+	returning []string
+}
+
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set - значення стає параметром; Expr - як є: Set("total", Raw("total * ?", 2))
+func (b *UpdateBuilder) Set(col string, v any) *UpdateBuilder {
+	b.set = append(b.set, assignment{col, value(v)})
+	return b
+}
+
+func (b *UpdateBuilder) Where(conds ...Expr) *UpdateBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+// All - явна згода оновити всю таблицю; без Where і All Build відмовить
+func (b *UpdateBuilder) All() *UpdateBuilder {
+	b.all = true
+	return b
+}
+
+func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	b.returning = columns
+	return b
+}
+
+func (b *UpdateBuilder) Build(d Dialect) (string, []any, error) {
+	return build(d, b.write)
+}
+
+func (b *UpdateBuilder) write(w *sqlWriter) {
+	if len(b.set) == 0 {
+		w.fail(fmt.Errorf("%w: UPDATE without SET", ErrInvalidQuery))
+		return
+	}
+	if len(b.where) == 0 && !b.all {
+		w.fail(fmt.Errorf("%w: UPDATE %s without WHERE; call All() to update every row", ErrInvalidQuery, b.table))
+		return
+	}
+
+	w.write("UPDATE ")
+	w.table(b.table)
+	w.write(" SET ")
+	for i, a := range b.set {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.ident(a.col)
+		w.write(" = ")
+		a.value.writeSQL(w)
+	}
+	writeWhere(w, b.where)
+	writeReturning(w, b.returning)
+}
+
+// ============= DELETE =============
+
+type DeleteBuilder struct {
+	table     string
+	where     []Expr
+	all       bool
+	returning []string
+}
+
+func DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+func (b *DeleteBuilder) Where(conds ...Expr) *DeleteBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+func (b *DeleteBuilder) All() *DeleteBuilder {
+	b.all = true
+	return b
+}
+
+func (b *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	b.returning = columns
+	return b
+}
+
+func (b *DeleteBuilder) Build(d Dialect) (string, []any, error) {
+	return build(d, b.write)
+}
+
+func (b *DeleteBuilder) write(w *sqlWriter) {
+	if len(b.where) == 0 && !b.all {
+		w.fail(fmt.Errorf("%w: DELETE FROM %s without WHERE; call All() to delete every row", ErrInvalidQuery, b.table))
+		return
+	}
+	w.write("DELETE FROM ")
+	w.table(b.table)
+	writeWhere(w, b.where)
+	writeReturning(w, b.returning)
+}
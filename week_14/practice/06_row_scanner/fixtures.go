@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ============= Fixture driver =============
+// Сканеру потрібні справжні *sql.Rows, а не живий Postgres. Драйвер
+// "fixture" повертає заздалегідь задані результати: DSN - ім'я набору,
+// запит порівнюється без урахування пробілів. Це не SQL-рушій - лише
+// "записана" відповідь бази на конкретний запит.
+
+const fixtureDriverName = "fixture"
+
+type Result struct {
+	Columns []string
+	Rows    [][]driver.Value
+}
+
+type fixtureDriver struct {
+	mu   sync.Mutex
+	sets map[string]map[string]Result
+}
+
+var fixtures = &fixtureDriver{sets: make(map[string]map[string]Result)}
+
+func init() {
+	sql.Register(fixtureDriverName, fixtures)
+}
+
+// RegisterFixture задає відповідь на query для бази dsn
+func RegisterFixture(dsn, query string, result Result) {
+	fixtures.mu.Lock()
+	defer fixtures.mu.Unlock()
+	if fixtures.sets[dsn] == nil {
+		fixtures.sets[dsn] = make(map[string]Result)
+	}
+	fixtures.sets[dsn][normalizeQuery(query)] = result
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func (d *fixtureDriver) Open(dsn string) (driver.Conn, error) {
+	return &fixtureConn{dsn: dsn}, nil
+}
+
+func (d *fixtureDriver) lookup(dsn, query string) (Result, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result, ok := d.sets[dsn][normalizeQuery(query)]
+	if !ok {
+		return Result{}, fmt.Errorf("fixture %q: no result for query %q", dsn, normalizeQuery(query))
+	}
+	return result, nil
+}
+
+type fixtureConn struct {
+	dsn string
+}
+
+func (c *fixtureConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fixture: Prepare is not supported")
+}
+
+func (c *fixtureConn) Close() error { return nil }
+
+func (c *fixtureConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fixture: transactions are not supported")
+}
+
+// Аргументи ігноруються: фікстура - відповідь на текст запиту
+func (c *fixtureConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result, err := fixtures.lookup(c.dsn, query)
+	if err != nil {
+		return nil, err
+	}
+	return &fixtureRows{result: result}, nil
+}
+
+type fixtureRows struct {
+	result Result
+	pos    int
+}
+
+func (r *fixtureRows) Columns() []string { return r.result.Columns }
+func (r *fixtureRows) Close() error      { return nil }
+
+func (r *fixtureRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.result.Rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.Rows[r.pos])
+	r.pos++
+	return nil
+}
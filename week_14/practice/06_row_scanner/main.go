@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════════════╗")
+	fmt.Println("║   Row Scanner: db tags, NULLs, nested joins    ║")
+	fmt.Println("╚════════════════════════════════════════════════╝")
+
+	// Відповіді бази записані у fixtures; на справжньому Postgres -
+	// sql.Open("pgx", dsn) і ті самі виклики
+	seedFixtures("demo")
+	db, err := sql.Open(fixtureDriverName, "demo")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	fmt.Println("\n📋 1. Плаский рядок, NULL у *int:")
+	orders, err := QueryAll[Order](ctx, db, queryOrders)
+	if err != nil {
+		panic(err)
+	}
+	for _, o := range orders {
+		owner := "guest"
+		if o.UserID != nil {
+			owner = fmt.Sprintf("user %d", *o.UserID)
+		}
+		fmt.Printf("   #%d %-8s $%8.2f  %s\n", o.ID, owner, o.Total, o.Status)
+	}
+
+	fmt.Println("\n👥 2. LEFT JOIN -> користувач з []Order:")
+	users, err := QueryAll[UserWithOrders](ctx, db, queryUsersWithOrders)
+	if err != nil {
+		panic(err)
+	}
+	for _, u := range users {
+		fmt.Printf("   %s - %d замовлень\n", u.Name, len(u.Orders))
+		for _, o := range u.Orders {
+			fmt.Printf("      #%d $%.2f %s\n", o.ID, o.Total, o.Status)
+		}
+	}
+
+	fmt.Println("\n🌊 3. Стрімінг замовлень з позиціями (рядки впорядковані за o.id):")
+	for d, err := range QueryIter[OrderDetails](ctx, db, queryOrderDetails) {
+		if err != nil {
+			panic(err)
+		}
+		customer := "Guest"
+		if d.Customer.Valid {
+			customer = d.Customer.String
+		}
+		fmt.Printf("   Order #%d (%s) - $%.2f\n", d.ID, customer, d.Total)
+		for _, item := range d.Items {
+			fmt.Printf("      %-9s x%d @ $%.2f\n", item.Product, item.Quantity, item.Price)
+		}
+	}
+
+	fmt.Println("\n🎯 4. Один об'єкт:")
+	first, err := QueryOne[UserWithOrders](ctx, db, queryUsersWithOrders)
+	fmt.Printf("   %s: %d замовлень, err=%v\n", first.Name, len(first.Orders), err)
+
+	fmt.Println("\n🚫 5. Колонка без поля - помилка, а не мовчазний пропуск:")
+	RegisterFixture("demo", "SELECT id, name, email FROM users", Result{Columns: []string{"id", "name", "email"}})
+	_, err = QueryAll[UserWithOrders](ctx, db, "SELECT id, name, email FROM users")
+	fmt.Println("  ", err)
+
+	fmt.Println("\n✅ Demo completed!")
+}
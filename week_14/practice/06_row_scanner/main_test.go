@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ============= Helpers =============
+
+var dsnCounter atomic.Int64
+
+func openFixtures(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	dsn := fmt.Sprintf("%s-%d", t.Name(), dsnCounter.Add(1))
+	seedFixtures(dsn)
+	db, err := sql.Open(fixtureDriverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, dsn
+}
+
+// query реєструє фікстуру і одразу виконує запит
+func query(t *testing.T, db *sql.DB, dsn string, result Result) *sql.Rows {
+	t.Helper()
+	q := "SELECT " + strings.Join(result.Columns, ", ")
+	RegisterFixture(dsn, q, result)
+	rows, err := db.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+// ============= Flat scanning =============
+
+type Account struct {
+	ID        int64           `db:"id"`
+	Login     string          // без тегу - "login"
+	FullName  sql.NullString  // "full_name"
+	Balance   sql.NullFloat64 `db:"balance"`
+	Age       *int            `db:"age"`
+	CreatedAt time.Time       `db:"created_at"`
+	DeletedAt sql.NullTime    `db:"deleted_at"`
+	Secret    string          `db:"-"`
+}
+
+func TestScanFlatWithNulls(t *testing.T) {
+	db, dsn := openFixtures(t)
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rows := query(t, db, dsn, Result{
+		Columns: []string{"id", "login", "full_name", "balance", "age", "created_at", "deleted_at"},
+		Rows: [][]driver.Value{
+			{int64(1), "alice", "Alice A.", 10.5, int64(30), created, nil},
+			{int64(2), "bob", nil, nil, nil, created, created},
+		},
+	})
+
+	accounts, err := ScanAll[Account](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, got %d", len(accounts))
+	}
+
+	alice, bob := accounts[0], accounts[1]
+	if alice.Login != "alice" || alice.FullName.String != "Alice A." || !alice.Balance.Valid || *alice.Age != 30 {
+		t.Errorf("Expected alice fully populated, got %+v", alice)
+	}
+	if !alice.CreatedAt.Equal(created) || alice.DeletedAt.Valid {
+		t.Errorf("Expected created_at set and deleted_at NULL, got %+v", alice)
+	}
+	if bob.FullName.Valid || bob.Balance.Valid || bob.Age != nil || !bob.DeletedAt.Valid {
+		t.Errorf("Expected NULLs for bob, got %+v", bob)
+	}
+}
+
+func TestScanOrdersFromSeed(t *testing.T) {
+	db, _ := openFixtures(t)
+	orders, err := QueryAll[Order](context.Background(), db, queryOrders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 4 {
+		t.Fatalf("Expected 4 orders, got %d", len(orders))
+	}
+	if orders[0].UserID == nil || *orders[0].UserID != 1 {
+		t.Errorf("Expected order 1 of user 1, got %+v", orders[0])
+	}
+	if orders[3].UserID != nil {
+		t.Errorf("Expected guest order with nil UserID, got %d", *orders[3].UserID)
+	}
+}
+
+type Timestamps struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type Post struct {
+	ID int `db:"id"`
+	Timestamps
+	Title string `db:"title"`
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	db, dsn := openFixtures(t)
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := query(t, db, dsn, Result{
+		Columns: []string{"id", "created_at", "title"},
+		Rows:    [][]driver.Value{{int64(7), created, "hello"}},
+	})
+	post, err := ScanOne[Post](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.ID != 7 || post.Title != "hello" || !post.CreatedAt.Equal(created) {
+		t.Errorf("Expected embedded fields to be filled, got %+v", post)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":        "id",
+		"UserID":    "user_id",
+		"CreatedAt": "created_at",
+		"HTTPCode":  "http_code",
+		"Name":      "name",
+		"URLPath2":  "url_path2",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+// ============= Nested mapping =============
+
+func TestNestedLeftJoin(t *testing.T) {
+	db, _ := openFixtures(t)
+	users, err := QueryAll[UserWithOrders](context.Background(), db, queryUsersWithOrders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]int{
+		"John Doe":   {1, 2},
+		"Jane Smith": {3},
+		"Bob Wilson": {},
+	}
+	if len(users) != len(want) {
+		t.Fatalf("Expected %d users, got %d", len(want), len(users))
+	}
+	for _, u := range users {
+		var ids []int
+		for _, o := range u.Orders {
+			ids = append(ids, o.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(want[u.Name]) {
+			t.Errorf("%s: expected orders %v, got %v", u.Name, want[u.Name], ids)
+		}
+		// Без замовлень - порожній слайс, не nil: JSON дасть [], а не null
+		if u.Orders == nil {
+			t.Errorf("%s: expected non-nil Orders", u.Name)
+		}
+	}
+}
+
+// ScanAll групує і неупорядковані рядки, зберігаючи порядок першої появи
+func TestScanAllGroupsUnorderedRows(t *testing.T) {
+	db, dsn := openFixtures(t)
+	rows := query(t, db, dsn, Result{
+		Columns: []string{"id", "name", "orders.id", "orders.total"},
+		Rows: [][]driver.Value{
+			{int64(2), "Jane", int64(3), 375.0},
+			{int64(1), "John", int64(1), 1300.0},
+			{int64(2), "Jane", int64(5), 10.0},
+			{int64(1), "John", int64(2), 25.0},
+		},
+	})
+	users, err := ScanAll[UserWithOrders](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Name != "Jane" || len(users[0].Orders) != 2 || len(users[1].Orders) != 2 {
+		t.Errorf("Expected Jane then John with 2 orders each, got %+v", users)
+	}
+}
+
+func TestIterStreamsNested(t *testing.T) {
+	db, _ := openFixtures(t)
+	var got []string
+	for d, err := range QueryIter[OrderDetails](context.Background(), db, queryOrderDetails) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		var products []string
+		for _, item := range d.Items {
+			products = append(products, item.Product)
+		}
+		customer := "guest"
+		if d.Customer.Valid {
+			customer = d.Customer.String
+		}
+		got = append(got, fmt.Sprintf("%d:%s:%s", d.ID, customer, strings.Join(products, "+")))
+	}
+	want := "1:John Doe:Laptop+Mouse 2:John Doe:Mouse 3:Jane Smith:Keyboard+Monitor 4:guest:Keyboard"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, " "))
+	}
+}
+
+func TestIterRejectsUnorderedRows(t *testing.T) {
+	db, dsn := openFixtures(t)
+	rows := query(t, db, dsn, Result{
+		Columns: []string{"id", "name", "orders.id"},
+		Rows: [][]driver.Value{
+			{int64(1), "John", int64(1)},
+			{int64(2), "Jane", int64(3)},
+			{int64(1), "John", int64(2)},
+		},
+	})
+	var yielded int
+	var lastErr error
+	for _, err := range Iter[UserWithOrders](rows) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		yielded++
+	}
+	if !errors.Is(lastErr, ErrUnordered) {
+		t.Errorf("Expected ErrUnordered, got %v", lastErr)
+	}
+	if yielded != 1 {
+		t.Errorf("Expected 1 user before the error, got %d", yielded)
+	}
+}
+
+func TestIterAcceptsDescendingOrder(t *testing.T) {
+	db, dsn := openFixtures(t)
+	rows := query(t, db, dsn, Result{
+		Columns: []string{"id", "name", "orders.id"},
+		Rows: [][]driver.Value{
+			{int64(3), "Bob", int64(5)},
+			{int64(2), "Jane", int64(3)},
+			{int64(2), "Jane", int64(4)},
+			{int64(1), "John", int64(1)},
+		},
+	})
+	var got []string
+	for u, err := range Iter[UserWithOrders](rows) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d:%d", u.ID, len(u.Orders)))
+	}
+	if want := "3:1 2:2 1:1"; strings.Join(got, " ") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, " "))
+	}
+}
+
+// break з range закриває rows і повертає з'єднання в пул
+func TestIterBreakReleasesConnection(t *testing.T) {
+	db, _ := openFixtures(t)
+	for range QueryIter[Order](context.Background(), db, queryOrders) {
+		break
+	}
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("Expected connection to be released, %d in use", inUse)
+	}
+}
+
+type Profile struct {
+	Bio     string `db:"bio"`
+	Website string `db:"website"`
+}
+
+type Author struct {
+	ID      int      `db:"id"`
+	Name    string   `db:"name"`
+	Profile *Profile `db:"profile"`
+	Address struct {
+		City string `db:"city"`
+	} `db:"address"`
+}
+
+func TestOneToOne(t *testing.T) {
+	db, dsn := openFixtures(t)
+	rows := query(t, db, dsn, Result{
+		Columns: []string{"id", "name", "profile.bio", "profile.website", "address.city"},
+		Rows: [][]driver.Value{
+			{int64(1), "alice", "writer", "alice.dev", "Kyiv"},
+			{int64(2), "bob", nil, nil, nil},
+		},
+	})
+	authors, err := ScanAll[Author](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authors[0].Profile == nil || authors[0].Profile.Website != "alice.dev" || authors[0].Address.City != "Kyiv" {
+		t.Errorf("Expected alice with profile and city, got %+v", authors[0])
+	}
+	if authors[1].Profile != nil || authors[1].Address.City != "" {
+		t.Errorf("Expected bob without profile, got %+v", authors[1])
+	}
+}
+
+// ============= Errors =============
+
+type NoPK struct {
+	ID     int     `db:"id"`
+	Orders []Order `db:"orders"`
+}
+
+type BytesPK struct {
+	ID     []byte  `db:"id,pk"`
+	Orders []Order `db:"orders"`
+}
+
+type Deep struct {
+	ID    int              `db:"id,pk"`
+	Users []UserWithOrders `db:"users"`
+}
+
+func TestScanErrors(t *testing.T) {
+	db, dsn := openFixtures(t)
+
+	tests := []struct {
+		name string
+		scan func(*sql.Rows) error
+		cols []string
+		row  []driver.Value
+		want error
+	}{
+		{"unmapped column", func(r *sql.Rows) error { _, err := ScanAll[Order](r); return err },
+			[]string{"id", "email"}, nil, ErrUnmappedColumn},
+		{"unmapped child column", func(r *sql.Rows) error { _, err := ScanAll[UserWithOrders](r); return err },
+			[]string{"id", "orders.color"}, nil, ErrUnmappedColumn},
+		{"skipped field", func(r *sql.Rows) error { _, err := ScanAll[Account](r); return err },
+			[]string{"id", "secret"}, nil, ErrUnmappedColumn},
+		{"duplicate column", func(r *sql.Rows) error { _, err := ScanAll[Order](r); return err },
+			[]string{"id", "total", "ID"}, nil, ErrDuplicateColumn},
+		{"no pk", func(r *sql.Rows) error { _, err := ScanAll[NoPK](r); return err },
+			[]string{"id"}, nil, ErrNoPrimaryKey},
+		{"non-comparable pk", func(r *sql.Rows) error { _, err := ScanAll[BytesPK](r); return err },
+			[]string{"id"}, nil, ErrUnsupportedType},
+		{"nested relation", func(r *sql.Rows) error { _, err := ScanAll[Deep](r); return err },
+			[]string{"id"}, nil, ErrUnsupportedType},
+		{"not a struct", func(r *sql.Rows) error { _, err := ScanAll[int](r); return err },
+			[]string{"id"}, nil, ErrUnsupportedType},
+		{"no rows", func(r *sql.Rows) error { _, err := ScanOne[Order](r); return err },
+			[]string{"id"}, nil, sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Result{Columns: tt.cols}
+			if tt.row != nil {
+				result.Rows = [][]driver.Value{tt.row}
+			}
+			err := tt.scan(query(t, db, dsn, result))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNullIntoNonNullableField(t *testing.T) {
+	db, dsn := openFixtures(t)
+	rows := query(t, db, dsn, Result{
+		Columns: []string{"id", "total"},
+		Rows:    [][]driver.Value{{int64(1), nil}},
+	})
+	_, err := ScanAll[Order](rows)
+	if err == nil || !strings.Contains(err.Error(), `"total"`) {
+		t.Errorf("Expected scan error naming the column, got %v", err)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	db, _ := openFixtures(t)
+	ctx := context.Background()
+	if _, err := QueryAll[Order](ctx, db, "SELECT nope"); err == nil {
+		t.Error("Expected error for unknown query")
+	}
+	if _, err := QueryOne[Order](ctx, db, "SELECT nope"); err == nil {
+		t.Error("Expected error for unknown query")
+	}
+	for _, err := range QueryIter[Order](ctx, db, "SELECT nope") {
+		if err == nil {
+			t.Error("Expected error for unknown query")
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := QueryAll[Order](cancelled, db, queryOrders); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// ============= Models =============
+// Ті самі дані, що й у week_14/practice/01_basic_joins/schema.sql
+
+type Order struct {
+	ID     int     `db:"id"`
+	UserID *int    `db:"user_id"` // NULL для гостьових замовлень
+	Total  float64 `db:"total"`
+	Status string  `db:"status"`
+}
+
+// UserWithOrders - замість плаского рядка з *int/*float64 на кожну
+// колонку замовлення: користувач і його замовлення слайсом
+type UserWithOrders struct {
+	ID     int     `db:"id,pk"`
+	Name   string  `db:"name"`
+	Orders []Order `db:"orders"`
+}
+
+type OrderItem struct {
+	Product  string  `db:"product"`
+	Quantity int     `db:"quantity"`
+	Price    float64 `db:"price"`
+}
+
+type OrderDetails struct {
+	ID       int            `db:"id,pk"`
+	Customer sql.NullString `db:"customer"` // LEFT JOIN users: у гостя NULL
+	Total    float64        `db:"total"`
+	Items    []OrderItem    `db:"items"`
+}
+
+// ============= Queries =============
+
+const (
+	queryOrders = `SELECT id, user_id, total, status FROM orders ORDER BY id`
+
+	queryUsersWithOrders = `
+		SELECT u.id, u.name,
+		       o.id AS "orders.id", o.total AS "orders.total", o.status AS "orders.status"
+		FROM users u
+		LEFT JOIN orders o ON u.id = o.user_id
+		ORDER BY u.id, o.id`
+
+	queryOrderDetails = `
+		SELECT o.id, u.name AS customer, o.total,
+		       p.name AS "items.product", oi.quantity AS "items.quantity", oi.price AS "items.price"
+		FROM orders o
+		LEFT JOIN users u ON o.user_id = u.id
+		INNER JOIN order_items oi ON o.id = oi.order_id
+		INNER JOIN products p ON oi.product_id = p.id
+		ORDER BY o.id, p.name`
+)
+
+// seedFixtures - відповіді Postgres на запити вище для даних зі schema.sql
+func seedFixtures(dsn string) {
+	RegisterFixture(dsn, queryOrders, Result{
+		Columns: []string{"id", "user_id", "total", "status"},
+		Rows: [][]driver.Value{
+			{int64(1), int64(1), 1300.0, "completed"},
+			{int64(2), int64(1), 25.0, "pending"},
+			{int64(3), int64(2), 375.0, "completed"},
+			{int64(4), nil, 75.0, "pending"},
+		},
+	})
+
+	RegisterFixture(dsn, queryUsersWithOrders, Result{
+		Columns: []string{"id", "name", "orders.id", "orders.total", "orders.status"},
+		Rows: [][]driver.Value{
+			{int64(1), "John Doe", int64(1), 1300.0, "completed"},
+			{int64(1), "John Doe", int64(2), 25.0, "pending"},
+			{int64(2), "Jane Smith", int64(3), 375.0, "completed"},
+			{int64(3), "Bob Wilson", nil, nil, nil},
+		},
+	})
+
+	RegisterFixture(dsn, queryOrderDetails, Result{
+		Columns: []string{"id", "customer", "total", "items.product", "items.quantity", "items.price"},
+		Rows: [][]driver.Value{
+			{int64(1), "John Doe", 1300.0, "Laptop", int64(1), 1200.0},
+			{int64(1), "John Doe", 1300.0, "Mouse", int64(4), 25.0},
+			{int64(2), "John Doe", 25.0, "Mouse", int64(1), 25.0},
+			{int64(3), "Jane Smith", 375.0, "Keyboard", int64(1), 75.0},
+			{int64(3), "Jane Smith", 375.0, "Monitor", int64(1), 300.0},
+			{int64(4), nil, 75.0, "Keyboard", int64(1), 75.0},
+		},
+	})
+}
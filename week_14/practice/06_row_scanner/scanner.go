@@ -0,0 +1,495 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ============= Row Scanner =============
+// Колонки мапляться на поля за тегом db (без тегу - snake_case імені
+// поля). NULL обробляє сам database/sql: sql.Null*, *T і будь-який
+// sql.Scanner працюють як у rows.Scan.
+//
+// Поле-структура або []структура з тегом - зв'язок: його колонки мають
+// префікс "тег." (o.id AS "orders.id"). Для []T батьківські рядки
+// групуються за полем з опцією pk (`db:"id,pk"`); LEFT JOIN без дітей
+// (усі колонки зв'язку NULL) дає порожній слайс, а для *T - nil.
+
+var (
+	ErrUnmappedColumn  = errors.New("column has no matching field")
+	ErrDuplicateColumn = errors.New("duplicate column")
+	ErrNoPrimaryKey    = errors.New("one-to-many mapping needs a pk field")
+	ErrUnordered       = errors.New("rows are not grouped by parent key")
+	ErrUnsupportedType = errors.New("unsupported destination type")
+)
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// ============= Struct metadata =============
+
+type field struct {
+	index []int
+	typ   reflect.Type
+}
+
+type relation struct {
+	name   string
+	index  []int
+	many   bool         // []T
+	ptr    bool         // *T
+	elem   reflect.Type // T
+	fields map[string]field
+}
+
+type structInfo struct {
+	fields    map[string]field
+	pk        *field
+	relations map[string]*relation
+}
+
+var structCache sync.Map // reflect.Type -> *structInfo or error
+
+func describe(t reflect.Type) (*structInfo, error) {
+	if cached, ok := structCache.Load(t); ok {
+		if err, isErr := cached.(error); isErr {
+			return nil, err
+		}
+		return cached.(*structInfo), nil
+	}
+	info, err := buildInfo(t)
+	if err != nil {
+		structCache.Store(t, err)
+		return nil, err
+	}
+	structCache.Store(t, info)
+	return info, nil
+}
+
+func buildInfo(t reflect.Type) (*structInfo, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s is not a struct", ErrUnsupportedType, t)
+	}
+	info := &structInfo{fields: map[string]field{}, relations: map[string]*relation{}}
+	err := walkFields(t, nil, func(name string, f reflect.StructField, index []int, opts string) error {
+		if rel, ok := relationOf(f.Type); ok {
+			rel.name, rel.index = name, index
+			children, err := flatFields(rel.elem)
+			if err != nil {
+				return err
+			}
+			rel.fields = children
+			info.relations[name] = rel
+			return nil
+		}
+		info.fields[name] = field{index: index, typ: f.Type}
+		if opts == "pk" {
+			// ключ порівнюється через == і кладеться в map; []byte чи
+			// map як pk панікували б на першому ж рядку
+			if !f.Type.Comparable() {
+				return fmt.Errorf("%w: pk %s.%s of type %s is not comparable", ErrUnsupportedType, t, name, f.Type)
+			}
+			info.pk = &field{index: index, typ: f.Type}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rel := range info.relations {
+		if rel.many && info.pk == nil {
+			return nil, fmt.Errorf("%w: %s.%s", ErrNoPrimaryKey, t, rel.name)
+		}
+	}
+	return info, nil
+}
+
+// flatFields - поля дочірньої структури; вкладені зв'язки там не підтримуються
+func flatFields(t reflect.Type) (map[string]field, error) {
+	fields := map[string]field{}
+	err := walkFields(t, nil, func(name string, f reflect.StructField, index []int, _ string) error {
+		if _, ok := relationOf(f.Type); ok {
+			return fmt.Errorf("%w: nested relation %s.%s", ErrUnsupportedType, t, f.Name)
+		}
+		fields[name] = field{index: index, typ: f.Type}
+		return nil
+	})
+	return fields, err
+}
+
+// walkFields обходить експортовані поля, розгортаючи вбудовані структури
+func walkFields(t reflect.Type, prefix []int, visit func(name string, f reflect.StructField, index []int, opts string) error) error {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		index := append(append([]int(nil), prefix...), i)
+		tag, hasTag := f.Tag.Lookup("db")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && !hasTag && f.Type.Kind() == reflect.Struct && !isValue(f.Type) {
+			if err := walkFields(f.Type, index, visit); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		if err := visit(strings.ToLower(name), f, index, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isValue - тип, який скануємо як одне значення, а не як набір колонок
+func isValue(t reflect.Type) bool {
+	return t == timeType || reflect.PointerTo(t).Implements(scannerType) || t.Implements(scannerType)
+}
+
+func relationOf(t reflect.Type) (*relation, bool) {
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct && !isValue(t.Elem()):
+		return &relation{many: true, elem: t.Elem()}, true
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct && !isValue(t.Elem()):
+		return &relation{ptr: true, elem: t.Elem()}, true
+	case t.Kind() == reflect.Struct && !isValue(t):
+		return &relation{elem: t}, true
+	}
+	return nil, false
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// ============= Plan =============
+// План - для конкретного набору колонок: яка колонка в яке поле
+
+type target struct {
+	rel   int // -1 - поле самого T, інакше індекс у plan.rels
+	field field
+}
+
+type plan struct {
+	typ     reflect.Type
+	info    *structInfo
+	targets []target
+	rels    []*relation
+	nested  bool // є []T - рядки групуються за pk
+}
+
+func newPlan(t reflect.Type, columns []string) (*plan, error) {
+	info, err := describe(t)
+	if err != nil {
+		return nil, err
+	}
+	p := &plan{typ: t, info: info}
+	relIndex := map[string]int{}
+	seen := map[string]bool{}
+
+	for _, col := range columns {
+		name := strings.ToLower(col)
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %q (alias one of them)", ErrDuplicateColumn, col)
+		}
+		seen[name] = true
+
+		if f, ok := info.fields[name]; ok {
+			p.targets = append(p.targets, target{rel: -1, field: f})
+			continue
+		}
+		prefix, child, ok := strings.Cut(name, ".")
+		rel, relOK := info.relations[prefix]
+		if !ok || !relOK {
+			return nil, fmt.Errorf("%w: %q in %s", ErrUnmappedColumn, col, t)
+		}
+		f, ok := rel.fields[child]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q in %s", ErrUnmappedColumn, col, rel.elem)
+		}
+		idx, ok := relIndex[prefix]
+		if !ok {
+			idx = len(p.rels)
+			relIndex[prefix] = idx
+			p.rels = append(p.rels, rel)
+			p.nested = p.nested || rel.many
+		}
+		p.targets = append(p.targets, target{rel: idx, field: f})
+	}
+	return p, nil
+}
+
+// row - один відсканований рядок: батько і по значенню на зв'язок
+type row struct {
+	parent   reflect.Value
+	children []reflect.Value // невалідний Value - усі колонки зв'язку NULL
+}
+
+func (p *plan) scan(rows *sql.Rows) (row, error) {
+	parent := reflect.New(p.typ).Elem()
+	holders := make([]reflect.Value, len(p.targets))
+	dest := make([]any, len(p.targets))
+	for i, t := range p.targets {
+		if t.rel < 0 {
+			dest[i] = parent.FieldByIndex(t.field.index).Addr().Interface()
+			continue
+		}
+		// **T: database/sql пише nil для NULL, інакше виділяє T -
+		// так видно, чи прийшла дитина взагалі
+		holders[i] = reflect.New(reflect.PointerTo(t.field.typ))
+		dest[i] = holders[i].Interface()
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return row{}, err
+	}
+
+	r := row{parent: parent, children: make([]reflect.Value, len(p.rels))}
+	for i, t := range p.targets {
+		if t.rel < 0 {
+			continue
+		}
+		v := holders[i].Elem()
+		if v.IsNil() {
+			continue
+		}
+		child := r.children[t.rel]
+		if !child.IsValid() {
+			child = reflect.New(p.rels[t.rel].elem).Elem()
+			r.children[t.rel] = child
+		}
+		child.FieldByIndex(t.field.index).Set(v.Elem())
+	}
+	return r, nil
+}
+
+// attach кладе дітей рядка в батька dst
+func (p *plan) attach(dst reflect.Value, r row) {
+	for i, rel := range p.rels {
+		child := r.children[i]
+		f := dst.FieldByIndex(rel.index)
+		switch {
+		case rel.many:
+			if f.IsNil() {
+				f.Set(reflect.MakeSlice(f.Type(), 0, 1))
+			}
+			if child.IsValid() {
+				f.Set(reflect.Append(f, child))
+			}
+		case !child.IsValid():
+			// один-до-одного без пари: *T лишається nil, T - нульовим
+		case rel.ptr:
+			ptr := reflect.New(rel.elem)
+			ptr.Elem().Set(child)
+			f.Set(ptr)
+		default:
+			f.Set(child)
+		}
+	}
+}
+
+func (p *plan) key(v reflect.Value) any {
+	return v.FieldByIndex(p.info.pk.index).Interface()
+}
+
+// compareKeys порівнює pk числових і рядкових типів; для решти
+// (масиви, структури) порядок невідомий і ok == false
+func compareKeys(a, b any) (c int, ok bool) {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(va.Int(), vb.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(va.Uint(), vb.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(va.Float(), vb.Float()), true
+	case reflect.String:
+		return cmp.Compare(va.String(), vb.String()), true
+	}
+	return 0, false
+}
+
+// ============= API =============
+
+// Iter стрімить рядки як T і закриває rows. Для []T-зв'язків батько
+// віддається, коли змінився pk, тому запит має бути впорядкований за
+// батьківським ключем (ORDER BY u.id або ORDER BY u.id DESC). Iter
+// пам'ятає лише попередній ключ: для числових і рядкових pk зміна
+// напрямку дає ErrUnordered, а для інших типів повтор ключа не
+// виявляється - батько прийде двічі.
+func Iter[T any](rows *sql.Rows) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer rows.Close()
+		var zero T
+
+		columns, err := rows.Columns()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		p, err := newPlan(reflect.TypeFor[T](), columns)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		var current reflect.Value
+		var currentKey any
+		var direction int // знак першої зміни ключа: 1 - ASC, -1 - DESC
+		for rows.Next() {
+			r, err := p.scan(rows)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !p.nested {
+				p.attach(r.parent, r)
+				if !yield(r.parent.Interface().(T), nil) {
+					return
+				}
+				continue
+			}
+
+			key := p.key(r.parent)
+			if current.IsValid() && key == currentKey {
+				p.attach(current, r)
+				continue
+			}
+			if current.IsValid() {
+				if c, ok := compareKeys(currentKey, key); ok {
+					if direction == 0 {
+						direction = c
+					} else if c != direction {
+						yield(zero, fmt.Errorf("%w: key %v after %v", ErrUnordered, key, currentKey))
+						return
+					}
+				}
+				if !yield(current.Interface().(T), nil) {
+					return
+				}
+			}
+			current, currentKey = r.parent, key
+			p.attach(current, r)
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+			return
+		}
+		if current.IsValid() {
+			yield(current.Interface().(T), nil)
+		}
+	}
+}
+
+// ScanAll читає всі рядки. На відміну від Iter, порядок рядків для
+// []T-зв'язків не важливий: батьки йдуть у порядку першої появи.
+func ScanAll[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	p, err := newPlan(reflect.TypeFor[T](), columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var parents []reflect.Value
+	byKey := map[any]int{}
+	for rows.Next() {
+		r, err := p.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !p.nested {
+			p.attach(r.parent, r)
+			parents = append(parents, r.parent)
+			continue
+		}
+		key := p.key(r.parent)
+		idx, ok := byKey[key]
+		if !ok {
+			idx = len(parents)
+			byKey[key] = idx
+			parents = append(parents, r.parent)
+		}
+		p.attach(parents[idx], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]T, len(parents))
+	for i, v := range parents {
+		out[i] = v.Interface().(T)
+	}
+	return out, nil
+}
+
+// ScanOne - перший T (з усіма дітьми); sql.ErrNoRows, якщо рядків немає
+func ScanOne[T any](rows *sql.Rows) (T, error) {
+	var zero T
+	for v, err := range Iter[T](rows) {
+		return v, err
+	}
+	return zero, sql.ErrNoRows
+}
+
+// Queryer - *sql.DB, *sql.Tx і *sql.Conn
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func QueryAll[T any](ctx context.Context, q Queryer, query string, args ...any) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ScanAll[T](rows)
+}
+
+func QueryOne[T any](ctx context.Context, q Queryer, query string, args ...any) (T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return ScanOne[T](rows)
+}
+
+func QueryIter[T any](ctx context.Context, q Queryer, query string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		Iter[T](rows)(yield)
+	}
+}
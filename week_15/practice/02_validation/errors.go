@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// ========================================
+// Error types (як у week_2/standard_interfaces/03_error_interface.go)
+// ========================================
+
+// ValidationError - помилка валідації одного поля.
+// Field містить повний шлях: "address.city", "items[2].sku".
+type ValidationError struct {
+	Field   string
+	Value   interface{}
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("validation failed on field '%s' (value: %v): %s",
+		e.Field, e.Value, e.Message)
+}
+
+// MultiError - кілька помилок валідації однієї структури
+type MultiError struct {
+	Errors []error
+}
+
+func (m MultiError) Error() string {
+	if len(m.Errors) == 0 {
+		return "no errors"
+	}
+
+	msg := fmt.Sprintf("multiple errors (%d):", len(m.Errors))
+	for i, err := range m.Errors {
+		msg += fmt.Sprintf("\n  %d. %v", i+1, err)
+	}
+	return msg
+}
+
+// Unwrap дозволяє errors.As знайти ValidationError всередині MultiError
+func (m MultiError) Unwrap() []error {
+	return m.Errors
+}
+
+// FieldErrors повертає помилки у вигляді field -> message для JSON-відповідей
+func (m MultiError) FieldErrors() map[string]string {
+	fields := make(map[string]string, len(m.Errors))
+	for _, err := range m.Errors {
+		if ve, ok := err.(ValidationError); ok {
+			fields[ve.Field] = ve.Message
+		}
+	}
+	return fields
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ========================================
+// Request DTOs - одна структура для HTTP і Lambda
+// ========================================
+
+type Phone struct {
+	Type   string `json:"type" validate:"required,oneof=home work mobile"`
+	Number string `json:"number" validate:"required,regexp=^[+]?[0-9]{7,15}$"`
+}
+
+type Address struct {
+	City string `json:"city" validate:"required,max=50"`
+	Zip  string `json:"zip" validate:"regexp=^[0-9]{5}$"`
+}
+
+type CreateUserRequest struct {
+	Username string   `json:"username" validate:"required,min=3,max=20,regexp=^[a-zA-Z0-9_]+$"`
+	Email    string   `json:"email" validate:"required,email"`
+	Password string   `json:"password" validate:"required,min=8,secret"`
+	Age      int      `json:"age" validate:"min=1,max=149"`
+	Role     string   `json:"role" validate:"oneof=admin user"`
+	Address  *Address `json:"address"`
+	Phones   []Phone  `json:"phones" validate:"max=3"`
+}
+
+type UserResponse struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+// ErrorResponse - однакове тіло помилки для обох транспортів
+type ErrorResponse struct {
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// ========================================
+// Shared business logic
+// ========================================
+
+var lastID atomic.Int64
+
+// createUser - спільна логіка: валідація за тегами + "збереження"
+func createUser(req CreateUserRequest) (UserResponse, error) {
+	if err := Validate(req); err != nil {
+		return UserResponse{}, err
+	}
+
+	if req.Role == "" {
+		req.Role = "user"
+	}
+
+	return UserResponse{
+		ID:       lastID.Add(1),
+		Username: req.Username,
+		Email:    req.Email,
+		Role:     req.Role,
+	}, nil
+}
+
+// errorResponse перетворює помилку на статус і тіло відповіді
+func errorResponse(err error) (int, ErrorResponse) {
+	var multiErr MultiError
+	if errors.As(err, &multiErr) {
+		return http.StatusBadRequest, ErrorResponse{
+			Error:  "validation failed",
+			Fields: multiErr.FieldErrors(),
+		}
+	}
+
+	log.Printf("ERROR: %v", err)
+	return http.StatusInternalServerError, ErrorResponse{Error: "internal server error"}
+}
+
+// ========================================
+// net/http handler
+// ========================================
+
+func handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "method not allowed"})
+		return
+	}
+
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	user, err := createUser(req)
+	if err != nil {
+		status, body := errorResponse(err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// ========================================
+// Lambda (API Gateway) handler
+// ========================================
+
+func handleCreateUserLambda(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var req CreateUserRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return jsonResponse(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	user, err := createUser(req)
+	if err != nil {
+		status, body := errorResponse(err)
+		return jsonResponse(status, body)
+	}
+
+	return jsonResponse(http.StatusCreated, user)
+}
+
+// jsonResponse creates API Gateway response with JSON body
+func jsonResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusInternalServerError,
+			Body:       `{"error":"internal server error"}`,
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(bodyJSON),
+	}, nil
+}
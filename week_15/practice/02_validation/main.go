@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ========================================
+// Examples
+// ========================================
+
+func example1_ValidStruct() {
+	fmt.Println("1️⃣ Example 1: Valid struct")
+	fmt.Println("─────────────────────────────────────────")
+
+	req := CreateUserRequest{
+		Username: "ivan_petro",
+		Email:    "ivan@example.com",
+		Password: "secret123",
+		Age:      25,
+		Address:  &Address{City: "Київ", Zip: "01001"},
+	}
+
+	if err := Validate(req); err != nil {
+		fmt.Printf("❌ %v\n", err)
+	} else {
+		fmt.Println("✅ CreateUserRequest is valid")
+	}
+	fmt.Println()
+}
+
+func example2_FieldPaths() {
+	fmt.Println("2️⃣ Example 2: Nested structs, slices and field paths")
+	fmt.Println("─────────────────────────────────────────")
+
+	req := CreateUserRequest{
+		Username: "iv",
+		Email:    "ivan@",
+		Password: "123",
+		Role:     "root",
+		Address:  &Address{Zip: "ABC"},
+		Phones: []Phone{
+			{Type: "home", Number: "+380441234567"},
+			{Type: "fax", Number: "12"},
+		},
+	}
+
+	err := Validate(req)
+	fmt.Printf("%v\n", err)
+
+	// errors.As знаходить перший ValidationError всередині MultiError
+	var valErr ValidationError
+	if errors.As(err, &valErr) {
+		fmt.Printf("\nFirst failed field: %s (%s)\n", valErr.Field, valErr.Message)
+	}
+	fmt.Println()
+}
+
+func example3_SameRulesEverywhere() {
+	fmt.Println("3️⃣ Example 3: Same struct, HTTP and Lambda")
+	fmt.Println("─────────────────────────────────────────")
+
+	body := `{"username":"maria","email":"maria-at-example.com","password":"password1"}`
+
+	// net/http
+	rec := httptest.NewRecorder()
+	handleCreateUser(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)))
+	fmt.Printf("HTTP   → %d %s", rec.Code, rec.Body.String())
+
+	// API Gateway Lambda
+	resp, _ := handleCreateUserLambda(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/users",
+		Body:       body,
+	})
+	fmt.Printf("Lambda → %d %s\n", resp.StatusCode, resp.Body)
+	fmt.Println()
+}
+
+// ========================================
+// Main
+// ========================================
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   Struct Tag Validation               ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+	fmt.Println()
+
+	example1_ValidStruct()
+	example2_FieldPaths()
+	example3_SameRulesEverywhere()
+
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   All Examples Completed! ✅           ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func validRequest() CreateUserRequest {
+	return CreateUserRequest{
+		Username: "ivan_petro",
+		Email:    "ivan@example.com",
+		Password: "secret123",
+		Age:      25,
+		Role:     "user",
+		Address:  &Address{City: "Kyiv", Zip: "01001"},
+		Phones:   []Phone{{Type: "mobile", Number: "+380501234567"}},
+	}
+}
+
+// ========================================
+// Test Validate
+// ========================================
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(r *CreateUserRequest)
+		wantField string
+		wantMsg   string
+	}{
+		{"valid", func(r *CreateUserRequest) {}, "", ""},
+		{"optional fields empty", func(r *CreateUserRequest) {
+			r.Age, r.Role, r.Address, r.Phones = 0, "", nil, nil
+		}, "", ""},
+		{"required", func(r *CreateUserRequest) { r.Username = "" }, "username", "is required"},
+		{"min length", func(r *CreateUserRequest) { r.Username = "iv" }, "username", "must be at least 3 characters"},
+		{"max length counts runes", func(r *CreateUserRequest) { r.Address.City = strings.Repeat("ї", 51) }, "address.city", "must be at most 50 characters"},
+		{"regexp", func(r *CreateUserRequest) { r.Username = "ivan petro" }, "username", "must match pattern ^[a-zA-Z0-9_]+$"},
+		{"email", func(r *CreateUserRequest) { r.Email = "ivan@localhost" }, "email", "must be a valid email address"},
+		{"number range", func(r *CreateUserRequest) { r.Age = 150 }, "age", "must be <= 149"},
+		{"oneof", func(r *CreateUserRequest) { r.Role = "root" }, "role", "must be one of [admin user]"},
+		{"nested pointer", func(r *CreateUserRequest) { r.Address.City = "" }, "address.city", "is required"},
+		{"slice element", func(r *CreateUserRequest) {
+			r.Phones = append(r.Phones, Phone{Type: "fax", Number: "+380501234567"})
+		}, "phones[1].type", "must be one of [home work mobile]"},
+		{"slice size", func(r *CreateUserRequest) {
+			r.Phones = make([]Phone, 4)
+		}, "phones", "must be at most 3 items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(&req)
+
+			err := Validate(&req)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var multiErr MultiError
+			if !errors.As(err, &multiErr) {
+				t.Fatalf("Expected MultiError, got %T: %v", err, err)
+			}
+			if got := multiErr.FieldErrors()[tt.wantField]; got != tt.wantMsg {
+				t.Errorf("Expected %s: %q, got %q (all: %v)", tt.wantField, tt.wantMsg, got, multiErr.FieldErrors())
+			}
+		})
+	}
+}
+
+func TestValidate_CollectsAllFields(t *testing.T) {
+	err := Validate(CreateUserRequest{})
+
+	var multiErr MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("Expected MultiError, got %v", err)
+	}
+	if len(multiErr.Errors) != 3 {
+		t.Errorf("Expected 3 errors (username, email, password), got %d: %v", len(multiErr.Errors), err)
+	}
+
+	var valErr ValidationError
+	if !errors.As(err, &valErr) || valErr.Field != "username" {
+		t.Errorf("Expected errors.As to find username ValidationError, got %+v", valErr)
+	}
+}
+
+type nestedIn struct {
+	City string `json:"city" validate:"required"`
+}
+
+type nestedOut struct {
+	A       nestedIn  `json:"a"`
+	Created time.Time `json:"created"`
+}
+
+func TestValidate_ZeroNestedStruct(t *testing.T) {
+	err := Validate(nestedOut{A: nestedIn{}})
+
+	var multiErr MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("Expected MultiError, got %v", err)
+	}
+	if got := multiErr.FieldErrors()["a.city"]; got != "is required" {
+		t.Errorf("Expected a.city: %q, got %q", "is required", got)
+	}
+	if len(multiErr.Errors) != 1 {
+		t.Errorf("Expected only a.city (time.Time is not descended into), got %v", err)
+	}
+
+	if err := Validate(nestedOut{A: nestedIn{City: "Kyiv"}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestValidate_SecretValueIsMasked(t *testing.T) {
+	req := validRequest()
+	req.Password = "short"
+
+	err := Validate(req)
+	if err == nil || strings.Contains(err.Error(), "short") {
+		t.Errorf("Expected masked password in error, got: %v", err)
+	}
+}
+
+func TestValidate_InvalidTags(t *testing.T) {
+	type badRule struct {
+		Name string `validate:"required,maxlen=3"`
+	}
+	type badKind struct {
+		Count int `validate:"email"`
+	}
+
+	for _, v := range []interface{}{badRule{}, badKind{}, "not a struct"} {
+		err := Validate(v)
+		if err == nil {
+			t.Errorf("Expected error for %T", v)
+			continue
+		}
+		var multiErr MultiError
+		if errors.As(err, &multiErr) {
+			t.Errorf("Expected tag error, got validation error for %T: %v", v, err)
+		}
+	}
+}
+
+// ========================================
+// Test handlers share the same rules
+// ========================================
+
+func TestHandlers_SameValidation(t *testing.T) {
+	body := `{"username":"maria","email":"maria-at-example.com","password":"password1"}`
+
+	rec := httptest.NewRecorder()
+	handleCreateUser(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)))
+
+	resp, err := handleCreateUserLambda(context.Background(), events.APIGatewayProxyRequest{Body: body})
+	if err != nil {
+		t.Fatalf("Lambda handler error: %v", err)
+	}
+
+	if rec.Code != http.StatusBadRequest || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 from both, got HTTP %d, Lambda %d", rec.Code, resp.StatusCode)
+	}
+
+	var httpBody, lambdaBody ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &httpBody)
+	json.Unmarshal([]byte(resp.Body), &lambdaBody)
+
+	if httpBody.Fields["email"] == "" || httpBody.Fields["email"] != lambdaBody.Fields["email"] {
+		t.Errorf("Expected same email error, got HTTP %v, Lambda %v", httpBody.Fields, lambdaBody.Fields)
+	}
+}
+
+func TestHandlers_Created(t *testing.T) {
+	body := `{"username":"maria","email":"maria@example.com","password":"password1"}`
+
+	resp, _ := handleCreateUserLambda(context.Background(), events.APIGatewayProxyRequest{Body: body})
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected 201, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ========================================
+// Struct-tag validator
+// ========================================
+//
+// Правила задаються тегом validate і перевіряються зліва направо;
+// для поля повертається перша помилка:
+//
+//	required      - значення не порожнє ("" / 0 / nil / порожній slice)
+//	min=N, max=N  - довжина рядка (в рунах), кількість елементів або число
+//	len=N         - точна довжина / кількість / значення
+//	email         - адреса у форматі name@domain
+//	oneof=a b c   - одне зі значень, розділених пробілом
+//	regexp=PATTERN - відповідність регулярному виразу; має бути останнім,
+//	                бо все після "regexp=" (разом з комами) - це шаблон
+//	secret        - не показувати значення в помилці (паролі, токени)
+//
+// Необов'язкові порожні поля не перевіряються. Вкладені структури,
+// вказівники на них, slice та map зі структурами перевіряються рекурсивно,
+// а шлях до поля будується з імен json: "address.city", "items[1].sku".
+// Структура за значенням перевіряється навіть нульова - інакше її required
+// поля ніхто не побачить. Виняток - "скалярні" структури: time.Time,
+// encoding.TextMarshaler, driver.Valuer.
+
+type check func(v reflect.Value) string
+
+type fieldRules struct {
+	index    []int
+	name     string
+	required bool
+	secret   bool
+	checks   []check
+}
+
+// rulesCache: reflect.Type -> []fieldRules. Теги розбираються один раз на тип.
+var rulesCache sync.Map
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	valuerType        = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// Validate перевіряє структуру (або вказівник на неї) за тегами validate.
+// Повертає nil, MultiError з ValidationError для кожного поля або звичайну
+// помилку, якщо самі теги некоректні.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return fmt.Errorf("validate: nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected struct, got %s", rv.Kind())
+	}
+
+	var errs []error
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return MultiError{Errors: errs}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, path string, errs *[]error) error {
+	rules, err := rulesFor(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range rules {
+		fv := v.FieldByIndex(f.index)
+		fieldPath := joinPath(path, f.name)
+
+		if isEmpty(fv) {
+			if f.required {
+				*errs = append(*errs, newError(f, fieldPath, fv, "is required"))
+			}
+			// Нульова структура за значенням - не "відсутнє" поле:
+			// її власні required-поля теж мають спрацювати
+			if fv.Kind() == reflect.Struct && !isScalarStruct(fv.Type()) {
+				if err := validateStruct(fv, fieldPath, errs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for _, c := range f.checks {
+			if msg := c(indirect(fv)); msg != "" {
+				*errs = append(*errs, newError(f, fieldPath, fv, msg))
+				break
+			}
+		}
+
+		if err := validateNested(fv, fieldPath, errs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateNested спускається у вкладені структури, slice та map
+func validateNested(v reflect.Value, path string, errs *[]error) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if isScalarStruct(v.Type()) {
+			return nil
+		}
+		return validateStruct(v, path, errs)
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, k := range keys {
+			if err := validateNested(v.MapIndex(k), fmt.Sprintf("%s[%v]", path, k), errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newError(f fieldRules, path string, v reflect.Value, msg string) ValidationError {
+	var value interface{} = "***"
+	if !f.secret {
+		if iv := indirect(v); iv.IsValid() {
+			value = iv.Interface()
+		} else {
+			value = nil
+		}
+	}
+	return ValidationError{Field: path, Value: value, Message: msg}
+}
+
+// ========================================
+// Tag parsing
+// ========================================
+
+func rulesFor(t reflect.Type) ([]fieldRules, error) {
+	if cached, ok := rulesCache.Load(t); ok {
+		return cached.([]fieldRules), nil
+	}
+
+	var rules []fieldRules
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "" {
+			continue
+		}
+
+		f := fieldRules{index: field.Index, name: name}
+		if err := parseTag(&f, field); err != nil {
+			return nil, fmt.Errorf("validate: %s.%s: %w", t.Name(), field.Name, err)
+		}
+		rules = append(rules, f)
+	}
+
+	rulesCache.Store(t, rules)
+	return rules, nil
+}
+
+func parseTag(f *fieldRules, field reflect.StructField) error {
+	tag := field.Tag.Get("validate")
+	kind := field.Type.Kind()
+	if kind == reflect.Pointer {
+		kind = field.Type.Elem().Kind()
+	}
+
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regexp=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch name {
+		case "":
+		case "required":
+			f.required = true
+		case "secret":
+			f.secret = true
+		case "min", "max", "len":
+			c, err := sizeCheck(name, arg, kind)
+			if err != nil {
+				return err
+			}
+			f.checks = append(f.checks, c)
+		case "email":
+			if kind != reflect.String {
+				return fmt.Errorf("email requires a string field, got %s", kind)
+			}
+			f.checks = append(f.checks, checkEmail)
+		case "oneof":
+			f.checks = append(f.checks, oneofCheck(strings.Fields(arg)))
+		case "regexp":
+			if kind != reflect.String {
+				return fmt.Errorf("regexp requires a string field, got %s", kind)
+			}
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				return fmt.Errorf("invalid regexp %q: %w", arg, err)
+			}
+			f.checks = append(f.checks, func(v reflect.Value) string {
+				if !re.MatchString(v.String()) {
+					return fmt.Sprintf("must match pattern %s", re)
+				}
+				return ""
+			})
+		default:
+			return fmt.Errorf("unknown rule %q", name)
+		}
+	}
+
+	return nil
+}
+
+// sizeCheck будує перевірку min/max/len залежно від типу поля
+func sizeCheck(rule, arg string, kind reflect.Kind) (check, error) {
+	n, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid number %q", rule, arg)
+	}
+
+	var (
+		measure func(v reflect.Value) float64
+		unit    string
+	)
+	switch kind {
+	case reflect.String:
+		measure = func(v reflect.Value) float64 { return float64(utf8.RuneCountInString(v.String())) }
+		unit = "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		measure = func(v reflect.Value) float64 { return float64(v.Len()) }
+		unit = "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		measure = func(v reflect.Value) float64 { return float64(v.Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		measure = func(v reflect.Value) float64 { return float64(v.Uint()) }
+	case reflect.Float32, reflect.Float64:
+		measure = func(v reflect.Value) float64 { return v.Float() }
+	default:
+		return nil, fmt.Errorf("%s is not supported for %s", rule, kind)
+	}
+
+	return func(v reflect.Value) string {
+		got := measure(v)
+		switch {
+		case rule == "min" && got < n:
+			return sizeMessage("at least", n, unit, ">=")
+		case rule == "max" && got > n:
+			return sizeMessage("at most", n, unit, "<=")
+		case rule == "len" && got != n:
+			return sizeMessage("exactly", n, unit, "")
+		}
+		return ""
+	}, nil
+}
+
+func sizeMessage(bound string, n float64, unit, op string) string {
+	switch {
+	case unit != "":
+		return fmt.Sprintf("must be %s %v %s", bound, n, unit)
+	case op != "":
+		return fmt.Sprintf("must be %s %v", op, n)
+	default:
+		return fmt.Sprintf("must be %v", n)
+	}
+}
+
+func checkEmail(v reflect.Value) string {
+	email := v.String()
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "must be a valid email address"
+	}
+	if domain := email[strings.LastIndex(email, "@")+1:]; !strings.Contains(domain, ".") {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+func oneofCheck(options []string) check {
+	return func(v reflect.Value) string {
+		got := fmt.Sprint(v.Interface())
+		for _, o := range options {
+			if got == o {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", strings.Join(options, " "))
+	}
+}
+
+// ========================================
+// Helpers
+// ========================================
+
+func fieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// isScalarStruct: структура, яка є одним значенням (час, гроші, sql.Null*),
+// а не набором полів для перевірки
+func isScalarStruct(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	for _, iface := range []reflect.Type{textMarshalerType, valuerType} {
+		if t.Implements(iface) || reflect.PointerTo(t).Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
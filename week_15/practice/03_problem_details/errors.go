@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ========================================
+// Sentinel Errors
+// ========================================
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+// ========================================
+// Custom Error Types (з week_2/standard_interfaces/03_error_interface.go)
+// ========================================
+
+// ValidationError - помилка валідації
+type ValidationError struct {
+	Field   string
+	Value   interface{}
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("validation failed on field '%s' (value: %v): %s",
+		e.Field, e.Value, e.Message)
+}
+
+// NotFoundError - ресурс не знайдено
+type NotFoundError struct {
+	Resource string
+	ID       int
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("%s with ID %d not found", e.Resource, e.ID)
+}
+
+// AuthError - помилка автентифікації
+type AuthError struct {
+	Username string
+	Reason   string
+}
+
+func (e AuthError) Error() string {
+	return fmt.Sprintf("authentication failed for user '%s': %s", e.Username, e.Reason)
+}
+
+// DBError - помилка бази даних
+type DBError struct {
+	Operation string
+	Table     string
+	Err       error
+}
+
+func (e DBError) Error() string {
+	return fmt.Sprintf("database error during %s on table '%s': %v",
+		e.Operation, e.Table, e.Err)
+}
+
+func (e DBError) Unwrap() error {
+	return e.Err
+}
+
+// APIError - помилка з HTTP-кодом
+type APIError struct {
+	Code    int
+	Message string
+	Details map[string]string
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("API Error %d: %s", e.Code, e.Message)
+}
+
+func (e APIError) IsClientError() bool {
+	return e.Code >= 400 && e.Code < 500
+}
+
+func (e APIError) IsServerError() bool {
+	return e.Code >= 500
+}
+
+// MultiError - кілька помилок (зазвичай ValidationError)
+type MultiError struct {
+	Errors []error
+}
+
+func (m MultiError) Error() string {
+	if len(m.Errors) == 0 {
+		return "no errors"
+	}
+
+	msg := fmt.Sprintf("multiple errors (%d):", len(m.Errors))
+	for i, err := range m.Errors {
+		msg += fmt.Sprintf("\n  %d. %v", i+1, err)
+	}
+	return msg
+}
+
+func (m MultiError) Unwrap() []error {
+	return m.Errors
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ========================================
+// API Gateway integration
+// ========================================
+
+// ProblemResponse - той самий Mapper для Lambda за API Gateway,
+// замість окремого jsonResponse з власним форматом помилок
+func (m *Mapper) ProblemResponse(request events.APIGatewayProxyRequest, err error) events.APIGatewayProxyResponse {
+	p := m.Map(err, request.Path)
+
+	body, marshalErr := json.Marshal(p)
+	if marshalErr != nil {
+		body = []byte(`{"type":"about:blank","title":"Internal Server Error","status":500}`)
+		p.Status = 500
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: p.Status,
+		Headers: map[string]string{
+			"Content-Type": ProblemContentType,
+		},
+		Body: string(body),
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ========================================
+// User API (кожен обробник лише повертає error)
+// ========================================
+
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type UserAPI struct {
+	mu     sync.RWMutex
+	users  map[int]User
+	nextID int
+}
+
+func NewUserAPI() *UserAPI {
+	return &UserAPI{users: make(map[int]User), nextID: 1}
+}
+
+func (a *UserAPI) Routes(m *Mapper) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", m.Handle(a.getUser))
+	mux.Handle("POST /users", m.Handle(a.createUser))
+	mux.Handle("POST /login", m.Handle(a.login))
+	mux.Handle("GET /reports", m.Handle(a.report))
+	return mux
+}
+
+func (a *UserAPI) getUser(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return fmt.Errorf("user id %q: %w", r.PathValue("id"), ErrInvalidInput)
+	}
+
+	// Симуляція падіння БД для id=500
+	if id == 500 {
+		return DBError{Operation: "select", Table: "users", Err: errors.New("dial tcp 10.0.3.7:5432: connection refused")}
+	}
+
+	a.mu.RLock()
+	user, ok := a.users[id]
+	a.mu.RUnlock()
+	if !ok {
+		return NotFoundError{Resource: "User", ID: id}
+	}
+
+	return writeJSON(w, http.StatusOK, user)
+}
+
+func (a *UserAPI) createUser(w http.ResponseWriter, r *http.Request) error {
+	var req struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return APIError{Code: http.StatusBadRequest, Message: "request body must be valid JSON"}
+	}
+
+	var errs []error
+	if req.Username == "" {
+		errs = append(errs, ValidationError{Field: "username", Value: req.Username, Message: "username is required"})
+	}
+	if !strings.Contains(req.Email, "@") {
+		errs = append(errs, ValidationError{Field: "email", Value: req.Email, Message: "email must contain @"})
+	}
+	if len(errs) > 0 {
+		return MultiError{Errors: errs}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, u := range a.users {
+		if u.Username == req.Username {
+			return fmt.Errorf("user %q: %w", req.Username, ErrAlreadyExists)
+		}
+	}
+
+	user := User{ID: a.nextID, Username: req.Username, Email: req.Email}
+	a.users[user.ID] = user
+	a.nextID++
+
+	return writeJSON(w, http.StatusCreated, user)
+}
+
+func (a *UserAPI) login(w http.ResponseWriter, r *http.Request) error {
+	username, password, _ := r.BasicAuth()
+	if password != "secret" {
+		return AuthError{Username: username, Reason: "invalid credentials"}
+	}
+	return writeJSON(w, http.StatusOK, map[string]string{"token": "demo-token"})
+}
+
+func (a *UserAPI) report(w http.ResponseWriter, r *http.Request) error {
+	ctx, cancel := context.WithTimeout(r.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	select {
+	case <-time.After(time.Second):
+		return writeJSON(w, http.StatusOK, map[string]string{"report": "ready"})
+	case <-ctx.Done():
+		return fmt.Errorf("building report: %w", ctx.Err())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(body)
+}
+
+// ========================================
+// Main
+// ========================================
+
+func main() {
+	fmt.Println("╔══════════════════════════════════════════╗")
+	fmt.Println("║   RFC 7807 Problem Details               ║")
+	fmt.Println("╚══════════════════════════════════════════╝")
+
+	api := NewUserAPI()
+	mapper := NewMapper("https://example.com/problems/")
+	server := httptest.NewServer(api.Routes(mapper))
+	defer server.Close()
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/users", `{"username":"ivan","email":"ivan@example.com"}`},
+		{"GET", "/users/1", ""},
+		{"GET", "/users/42", ""},
+		{"GET", "/users/abc", ""},
+		{"POST", "/users", `{"username":"","email":"nope"}`},
+		{"POST", "/users", `{"username":"ivan","email":"ivan2@example.com"}`},
+		{"POST", "/login", ""},
+		{"GET", "/users/500", ""},
+		{"GET", "/reports", ""},
+	}
+
+	for _, req := range requests {
+		fmt.Printf("\n🔹 %s %s\n", req.method, req.path)
+
+		httpReq, _ := http.NewRequest(req.method, server.URL+req.path, strings.NewReader(req.body))
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		fmt.Printf("   %d %s\n", resp.StatusCode, resp.Header.Get("Content-Type"))
+		fmt.Printf("   %s", body)
+	}
+}
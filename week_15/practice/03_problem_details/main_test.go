@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func newTestMapper(logged *[]string) *Mapper {
+	m := NewMapper("https://example.com/problems/")
+	m.Logf = func(format string, args ...interface{}) {
+		*logged = append(*logged, fmt.Sprintf(format, args...))
+	}
+	return m
+}
+
+// ========================================
+// Test Mapper.Map
+// ========================================
+
+func TestMapper_Map(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantDetail string
+	}{
+		{"not found type", NotFoundError{Resource: "User", ID: 7}, 404, "not-found", "User with ID 7 not found"},
+		{"validation", ValidationError{Field: "email", Message: "invalid"}, 400, "validation-error", "one or more fields are invalid"},
+		{"auth hides username", AuthError{Username: "admin", Reason: "invalid credentials"}, 401, "unauthorized", "invalid credentials"},
+		{"api client error", APIError{Code: 429, Message: "slow down"}, 429, "too-many-requests", "slow down"},
+		{"api server error", APIError{Code: 502, Message: "upstream 10.0.0.1 failed"}, 502, "bad-gateway", ""},
+		{"wrapped sentinel", fmt.Errorf("user 5: %w", ErrAlreadyExists), 409, "conflict", "already exists"},
+		{"wrapped sql.ErrNoRows hides query", fmt.Errorf("SELECT * FROM users WHERE id = 42: %w", sql.ErrNoRows), 404, "not-found", sql.ErrNoRows.Error()},
+		{"sentinel inside DBError", DBError{Operation: "select", Table: "users", Err: sql.ErrNoRows}, 404, "not-found", sql.ErrNoRows.Error()},
+		{"db error", DBError{Operation: "insert", Table: "users", Err: errors.New("disk full")}, 500, "internal-error", ""},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), 504, "timeout", ""},
+		{"client went away", fmt.Errorf("query: %w", context.Canceled), 499, "client-closed-request", ""},
+		{"unknown", errors.New("boom"), 500, "internal-error", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			p := newTestMapper(&logged).Map(tt.err, "/users/7")
+
+			if p.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, p.Status)
+			}
+			if p.Type != "https://example.com/problems/"+tt.wantType {
+				t.Errorf("Expected type %s, got %s", tt.wantType, p.Type)
+			}
+			if p.Detail != tt.wantDetail {
+				t.Errorf("Expected detail %q, got %q", tt.wantDetail, p.Detail)
+			}
+			if p.Instance != "/users/7" {
+				t.Errorf("Expected instance /users/7, got %s", p.Instance)
+			}
+			if tt.wantStatus >= 500 && len(logged) != 1 {
+				t.Errorf("Expected 5xx to be logged once, got %v", logged)
+			}
+			if strings.Contains(p.Detail, "admin") {
+				t.Errorf("Detail leaks username: %q", p.Detail)
+			}
+		})
+	}
+}
+
+func TestMapper_WrappedSentinelLoggedNotSent(t *testing.T) {
+	var logged []string
+	m := newTestMapper(&logged)
+	err := fmt.Errorf("get user 42 (tenant acme): %w", sql.ErrNoRows)
+
+	p := m.Map(err, "/users/42")
+	if p.Detail != sql.ErrNoRows.Error() {
+		t.Errorf("Expected detail %q, got %q", sql.ErrNoRows.Error(), p.Detail)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "tenant acme") {
+		t.Errorf("Expected full error in log, got %v", logged)
+	}
+
+	logged = nil
+	m.Map(ErrNotFound, "/users/42")
+	if len(logged) != 0 {
+		t.Errorf("Expected bare sentinel not to be logged, got %v", logged)
+	}
+}
+
+func TestMapper_WriteClientClosed(t *testing.T) {
+	var logged []string
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	newTestMapper(&logged).Write(rec, req, fmt.Errorf("query: %w", context.Canceled))
+	if rec.Code != StatusClientClosedRequest || rec.Body.Len() != 0 {
+		t.Errorf("Expected bare 499, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMapper_MultiErrorFields(t *testing.T) {
+	err := fmt.Errorf("create user: %w", MultiError{Errors: []error{
+		ValidationError{Field: "username", Message: "username is required"},
+		ValidationError{Field: "email", Message: "email must contain @"},
+	}})
+
+	var logged []string
+	p := newTestMapper(&logged).Map(err, "/users")
+
+	if p.Status != http.StatusBadRequest || len(p.Errors) != 2 {
+		t.Fatalf("Expected 400 with 2 field errors, got %d %+v", p.Status, p.Errors)
+	}
+	if p.Errors[1].Field != "email" || p.Errors[1].Detail != "email must contain @" {
+		t.Errorf("Unexpected field error: %+v", p.Errors[1])
+	}
+}
+
+func TestMapper_RegisterOverrides(t *testing.T) {
+	var logged []string
+	m := newTestMapper(&logged)
+	m.Register(ErrNotFound, http.StatusGone, "gone", "Gone")
+
+	if p := m.Map(ErrNotFound, ""); p.Status != http.StatusGone {
+		t.Errorf("Expected later registration to win, got %d", p.Status)
+	}
+}
+
+// ========================================
+// Test HTTP and Lambda integration
+// ========================================
+
+func TestHTTP_ProblemJSON(t *testing.T) {
+	var logged []string
+	srv := httptest.NewServer(NewUserAPI().Routes(newTestMapper(&logged)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/users/500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Expected %s, got %s", ProblemContentType, ct)
+	}
+
+	var raw map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&raw)
+	for _, key := range []string{"type", "title", "status", "instance"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("Expected %q in problem body, got %v", key, raw)
+		}
+	}
+
+	body, _ := json.Marshal(raw)
+	for _, secret := range []string{"10.0.3.7", "connection refused", "select"} {
+		if strings.Contains(string(body), secret) {
+			t.Errorf("5xx body leaks %q: %s", secret, body)
+		}
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "connection refused") {
+		t.Errorf("Expected full error in logs, got %v", logged)
+	}
+}
+
+func TestLambda_ProblemResponse(t *testing.T) {
+	var logged []string
+	m := newTestMapper(&logged)
+
+	resp := m.ProblemResponse(events.APIGatewayProxyRequest{Path: "/users/9"}, NotFoundError{Resource: "User", ID: 9})
+
+	if resp.StatusCode != http.StatusNotFound || resp.Headers["Content-Type"] != ProblemContentType {
+		t.Fatalf("Unexpected response: %d %v", resp.StatusCode, resp.Headers)
+	}
+
+	var p Problem
+	if err := json.Unmarshal([]byte(resp.Body), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Instance != "/users/9" || p.Detail != "User with ID 9 not found" {
+		t.Errorf("Unexpected problem: %+v", p)
+	}
+}
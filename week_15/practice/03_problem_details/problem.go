@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ========================================
+// RFC 7807 Problem Details
+// ========================================
+
+const ProblemContentType = "application/problem+json"
+
+// StatusClientClosedRequest - клієнт закрив з'єднання до відповіді
+// (нестандартний код nginx). Відповідати вже нікому, але статус
+// потрібен для логів і метрик.
+const StatusClientClosedRequest = 499
+
+// Problem - тіло відповіді application/problem+json
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Errors   []FieldProblem `json:"errors,omitempty"`
+
+	redacted bool // Detail - лише текст sentinel, повна помилка - в лог
+}
+
+// FieldProblem - помилка конкретного поля (розширення RFC 7807)
+type FieldProblem struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// sentinel - відповідність sentinel-помилки статусу
+type sentinel struct {
+	target error
+	status int
+	slug   string
+	title  string
+}
+
+// ========================================
+// Mapper - єдине місце, де помилка стає HTTP-відповіддю
+// ========================================
+
+// Mapper перетворює помилки на Problem. Порядок перевірок:
+//  1. типізовані помилки через errors.As (MultiError, ValidationError,
+//     NotFoundError, AuthError, APIError)
+//  2. зареєстровані sentinel-помилки через errors.Is
+//  3. context.DeadlineExceeded / context.Canceled (499, без тіла)
+//  4. DBError та все інше - 500 без внутрішніх деталей
+type Mapper struct {
+	// BaseURI - префікс для поля type: BaseURI + "not-found"
+	BaseURI string
+	// Logf отримує повну помилку для 5xx (у відповідь вона не потрапляє)
+	Logf func(format string, args ...interface{})
+
+	sentinels []sentinel
+}
+
+// NewMapper створює Mapper зі стандартними sentinel-помилками
+func NewMapper(baseURI string) *Mapper {
+	m := &Mapper{BaseURI: baseURI, Logf: log.Printf}
+
+	m.Register(ErrNotFound, http.StatusNotFound, "not-found", "Resource not found")
+	m.Register(sql.ErrNoRows, http.StatusNotFound, "not-found", "Resource not found")
+	m.Register(ErrUnauthorized, http.StatusUnauthorized, "unauthorized", "Authentication required")
+	m.Register(ErrForbidden, http.StatusForbidden, "forbidden", "Access denied")
+	m.Register(ErrInvalidInput, http.StatusBadRequest, "invalid-input", "Invalid input")
+	m.Register(ErrAlreadyExists, http.StatusConflict, "conflict", "Resource already exists")
+
+	return m
+}
+
+// Register додає відповідність sentinel-помилки статусу.
+// Пізніші реєстрації мають пріоритет над ранішими.
+func (m *Mapper) Register(target error, status int, slug, title string) {
+	m.sentinels = append([]sentinel{{target, status, slug, title}}, m.sentinels...)
+}
+
+// Map будує Problem для помилки; instance - зазвичай шлях запиту
+func (m *Mapper) Map(err error, instance string) *Problem {
+	p := m.classify(err)
+	p.Instance = instance
+
+	if p.Status >= 500 {
+		// Внутрішні деталі (SQL, хости, стек) лишаються тільки в логах
+		m.Logf("ERROR %d %s: %v", p.Status, instance, err)
+		p.Detail = ""
+		p.Errors = nil
+	} else if p.redacted {
+		m.Logf("WARN %d %s: %v", p.Status, instance, err)
+	}
+
+	return p
+}
+
+func (m *Mapper) classify(err error) *Problem {
+	var (
+		multiErr    MultiError
+		validErr    ValidationError
+		notFoundErr NotFoundError
+		authErr     AuthError
+		apiErr      APIError
+	)
+
+	switch {
+	case errors.As(err, &multiErr):
+		p := m.problem(http.StatusBadRequest, "validation-error", "Validation failed")
+		p.Detail = "one or more fields are invalid"
+		for _, e := range multiErr.Errors {
+			var ve ValidationError
+			if errors.As(e, &ve) {
+				p.Errors = append(p.Errors, FieldProblem{Field: ve.Field, Detail: ve.Message})
+			}
+		}
+		return p
+
+	case errors.As(err, &validErr):
+		p := m.problem(http.StatusBadRequest, "validation-error", "Validation failed")
+		p.Detail = "one or more fields are invalid"
+		p.Errors = []FieldProblem{{Field: validErr.Field, Detail: validErr.Message}}
+		return p
+
+	case errors.As(err, &notFoundErr):
+		p := m.problem(http.StatusNotFound, "not-found", "Resource not found")
+		p.Detail = notFoundErr.Error()
+		return p
+
+	case errors.As(err, &authErr):
+		// Username не повертаємо - лише причину
+		p := m.problem(http.StatusUnauthorized, "unauthorized", "Authentication failed")
+		p.Detail = authErr.Reason
+		return p
+
+	case errors.As(err, &apiErr):
+		status := apiErr.Code
+		if status < 400 || status > 599 {
+			status = http.StatusInternalServerError
+		}
+		p := m.problem(status, slugify(http.StatusText(status)), http.StatusText(status))
+		p.Detail = apiErr.Message
+		p.Errors = fieldProblems(apiErr.Details)
+		return p
+	}
+
+	for _, s := range m.sentinels {
+		if errors.Is(err, s.target) {
+			// Обгортки навколо sentinel несуть запити, ID, таблиці -
+			// клієнту лише текст самого sentinel
+			p := m.problem(s.status, s.slug, s.title)
+			p.Detail = s.target.Error()
+			p.redacted = err != s.target
+			return p
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return m.problem(http.StatusGatewayTimeout, "timeout", "Request timeout")
+	case errors.Is(err, context.Canceled):
+		return m.problem(StatusClientClosedRequest, "client-closed-request", "Client closed request")
+	}
+
+	// DBError і невідомі помилки
+	return m.problem(http.StatusInternalServerError, "internal-error", "Internal server error")
+}
+
+func (m *Mapper) problem(status int, slug, title string) *Problem {
+	typ := "about:blank"
+	if m.BaseURI != "" {
+		typ = m.BaseURI + slug
+	}
+	return &Problem{Type: typ, Title: title, Status: status}
+}
+
+// ========================================
+// HTTP integration
+// ========================================
+
+// Write відправляє помилку як application/problem+json
+func (m *Mapper) Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := m.Map(err, r.URL.Path)
+	if p.Status == StatusClientClosedRequest {
+		w.WriteHeader(p.Status) // лише для логів: тіло ніхто не прочитає
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// HandlerFunc - обробник, який повертає помилку замість того,
+// щоб самостійно писати відповідь про неї
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handle адаптує HandlerFunc до http.Handler через Mapper
+func (m *Mapper) Handle(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			m.Write(w, r, err)
+		}
+	})
+}
+
+// ========================================
+// Helpers
+// ========================================
+
+func fieldProblems(details map[string]string) []FieldProblem {
+	if len(details) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(details))
+	for f := range details {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	problems := make([]FieldProblem, 0, len(fields))
+	for _, f := range fields {
+		problems = append(problems, FieldProblem{Field: f, Detail: details[f]})
+	}
+	return problems
+}
+
+func slugify(s string) string {
+	return strings.ReplaceAll(strings.ToLower(s), " ", "-")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// ============= Клієнт без центрального реєстру =============
+// URLStatus і CheckURLs - з interviewtasks/practice/solutions/solution_02_url_checker.go
+// (інший package main, тому скопійовано в скороченому вигляді).
+// Різниця лише в тому, звідки беруться URL: не зі статичного списку,
+// а з живих учасників gossip.
+
+type URLStatus struct {
+	URL        string
+	StatusCode int
+	Error      error
+}
+
+func CheckURLs(urls []string) []URLStatus {
+	results := make([]URLStatus, len(urls))
+	done := make(chan struct{}, len(urls))
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	for i, url := range urls {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			results[i].URL = url
+			resp, err := client.Get(url)
+			if err != nil {
+				results[i].Error = err
+				return
+			}
+			results[i].StatusCode = resp.StatusCode
+			resp.Body.Close()
+		}()
+	}
+	for range urls {
+		<-done
+	}
+	return results
+}
+
+// ServiceURLs - значення Meta[key] усіх живих учасників, відсортовані
+func ServiceURLs(m *Memberlist, key string) []string {
+	var urls []string
+	for _, node := range m.Peers() {
+		if url, ok := node.Meta[key]; ok {
+			urls = append(urls, url)
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
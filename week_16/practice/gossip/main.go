@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   UDP Gossip Membership (SWIM)         ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	// Прискорені інтервали, щоб демо не тривало хвилину
+	newNode := func(name string) (*Memberlist, *httptest.Server) {
+		service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "hello from %s\n", name)
+		}))
+
+		cfg := DefaultConfig(name)
+		cfg.ProbeInterval = 200 * time.Millisecond
+		cfg.ProbeTimeout = 80 * time.Millisecond
+		cfg.SuspicionTimeout = time.Second
+		cfg.Meta = map[string]string{"http": service.URL}
+		cfg.Logf = nil
+
+		m, err := Create(cfg)
+		if err != nil {
+			panic(err)
+		}
+		return m, service
+	}
+
+	fmt.Println("\n🚀 1. Запуск 4 екземплярів сервісу:")
+	names := []string{"api-1", "api-2", "api-3", "api-4"}
+	nodes := make([]*Memberlist, len(names))
+	for i, name := range names {
+		var service *httptest.Server
+		nodes[i], service = newNode(name)
+		defer service.Close()
+		fmt.Printf("   %s: gossip %s, http %s\n", name, nodes[i].LocalNode().Addr, service.URL)
+	}
+	defer nodes[0].Shutdown()
+	defer nodes[1].Shutdown()
+
+	go func() {
+		for ev := range nodes[0].Events() {
+			fmt.Printf("   📣 [api-1] %-7s %s\n", ev.Type, ev.Node.Name)
+		}
+	}()
+
+	fmt.Println("\n🤝 2. Join через seed (api-1):")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	seed := nodes[0].LocalNode().Addr
+	for _, m := range nodes[1:] {
+		if err := m.Join(ctx, seed); err != nil {
+			fmt.Println("   join failed:", err)
+		}
+	}
+	time.Sleep(600 * time.Millisecond)
+	for _, m := range nodes {
+		fmt.Printf("   %s бачить %d учасників\n", m.LocalNode().Name, len(m.Peers()))
+	}
+
+	fmt.Println("\n🔎 3. CheckURLs по живих учасниках (з точки зору api-2):")
+	printStatuses(CheckURLs(ServiceURLs(nodes[1], "http")))
+
+	fmt.Println("\n💥 4. api-3 падає без попередження (suspect -> failed):")
+	nodes[2].Shutdown()
+	time.Sleep(2500 * time.Millisecond)
+
+	fmt.Println("\n👋 5. api-4 виходить через Leave (одразу, без підозри):")
+	nodes[3].Leave()
+	time.Sleep(300 * time.Millisecond)
+
+	fmt.Println("\n📋 6. Таблиця членства api-1:")
+	for _, mem := range nodes[0].Members() {
+		fmt.Printf("   %-6s %-8s inc=%d\n", mem.Node.Name, mem.State, mem.Incarnation)
+	}
+
+	fmt.Println("\n🔎 7. CheckURLs після змін - тільки живі:")
+	printStatuses(CheckURLs(ServiceURLs(nodes[1], "http")))
+
+	fmt.Println("\n✅ Demo completed!")
+}
+
+func printStatuses(statuses []URLStatus) {
+	for _, s := range statuses {
+		if s.Error != nil {
+			fmt.Printf("   ❌ %s: %v\n", s.URL, s.Error)
+			continue
+		}
+		fmt.Printf("   ✅ %s: %d\n", s.URL, s.StatusCode)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testConfig(name string) Config {
+	cfg := DefaultConfig(name)
+	cfg.ProbeInterval = 50 * time.Millisecond
+	cfg.ProbeTimeout = 20 * time.Millisecond
+	cfg.SuspicionTimeout = 300 * time.Millisecond
+	cfg.EventBuffer = 256
+	cfg.Logf = nil
+	return cfg
+}
+
+func newTestNode(t *testing.T, cfg Config) *Memberlist {
+	t.Helper()
+	m, err := Create(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Shutdown() })
+	return m
+}
+
+func join(t *testing.T, m *Memberlist, addrs ...string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := m.Join(ctx, addrs...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func stateOf(m *Memberlist, name string) State {
+	for _, mem := range m.Members() {
+		if mem.Node.Name == name {
+			return mem.State
+		}
+	}
+	return ""
+}
+
+// waitEvent читає події, поки не трапиться потрібна
+func waitEvent(t *testing.T, m *Memberlist, typ EventType, name string) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev, ok := <-m.Events():
+			if !ok {
+				t.Fatal("events channel closed")
+			}
+			if ev.Type == typ && ev.Node.Name == name {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("Expected %s event for %s", typ, name)
+		}
+	}
+}
+
+func TestJoinConvergence(t *testing.T) {
+	a := newTestNode(t, testConfig("a"))
+	cfgB := testConfig("b")
+	cfgB.Meta = map[string]string{"http": "http://b"}
+	b := newTestNode(t, cfgB)
+	c := newTestNode(t, testConfig("c"))
+
+	join(t, b, a.LocalNode().Addr)
+	join(t, c, a.LocalNode().Addr)
+
+	// c знає про b лише через gossip від a
+	for _, m := range []*Memberlist{a, b, c} {
+		waitFor(t, m.LocalNode().Name+" sees 2 peers", func() bool { return len(m.Peers()) == 2 })
+	}
+	if urls := ServiceURLs(c, "http"); len(urls) != 1 || urls[0] != "http://b" {
+		t.Errorf("Expected [http://b], got %v", urls)
+	}
+	waitEvent(t, a, EventJoin, "b")
+}
+
+func TestJoinNoPeers(t *testing.T) {
+	a := newTestNode(t, testConfig("a"))
+
+	// Порт, на якому ніхто не слухає
+	probe, _ := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	addr := probe.LocalAddr().String()
+	probe.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := a.Join(ctx, addr); err == nil {
+		t.Error("Expected ErrNoPeersReached")
+	}
+}
+
+func TestDiscoveryPorts(t *testing.T) {
+	a := newTestNode(t, testConfig("a"))
+	_, portStr, _ := net.SplitHostPort(a.LocalNode().Addr)
+	port, _ := strconv.Atoi(portStr)
+
+	cfg := testConfig("b")
+	cfg.DiscoveryPorts = []int{port}
+	b := newTestNode(t, cfg)
+	join(t, b)
+
+	waitFor(t, "a sees b", func() bool { return stateOf(a, "b") == StateAlive })
+}
+
+func TestFailureDetection(t *testing.T) {
+	a := newTestNode(t, testConfig("a"))
+	b := newTestNode(t, testConfig("b"))
+	c := newTestNode(t, testConfig("c"))
+	join(t, b, a.LocalNode().Addr)
+	join(t, c, a.LocalNode().Addr)
+	waitFor(t, "convergence", func() bool { return len(a.Peers()) == 2 && len(b.Peers()) == 2 })
+
+	c.Shutdown()
+
+	waitEvent(t, a, EventSuspect, "c")
+	waitEvent(t, a, EventFailed, "c")
+	waitFor(t, "b marks c dead", func() bool { return stateOf(b, "c") == StateDead })
+
+	if peers := a.Peers(); len(peers) != 1 || peers[0].Name != "b" {
+		t.Errorf("Expected only b alive, got %v", peers)
+	}
+}
+
+func TestLeave(t *testing.T) {
+	cfg := testConfig("a")
+	cfg.SuspicionTimeout = time.Minute // якщо Leave не спрацює, тест впаде по таймауту
+	a := newTestNode(t, cfg)
+	bCfg := testConfig("b")
+	bCfg.SuspicionTimeout = time.Minute
+	b := newTestNode(t, bCfg)
+	join(t, b, a.LocalNode().Addr)
+	waitFor(t, "a sees b", func() bool { return len(a.Peers()) == 1 })
+
+	if err := b.Leave(); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, a, EventLeave, "b")
+	if got := stateOf(a, "b"); got != StateLeft {
+		t.Errorf("Expected %s, got %s", StateLeft, got)
+	}
+}
+
+func TestRefuteSuspicion(t *testing.T) {
+	a := newTestNode(t, testConfig("a"))
+	b := newTestNode(t, testConfig("b"))
+	join(t, b, a.LocalNode().Addr)
+	waitFor(t, "b sees a", func() bool { return stateOf(b, "a") == StateAlive })
+
+	// b помилково підозрює a; a дізнається через gossip і спростовує
+	b.applyUpdate(Update{Node: a.LocalNode(), State: StateSuspect, Incarnation: 0})
+
+	waitEvent(t, b, EventAlive, "a")
+	for _, mem := range b.Members() {
+		if mem.Node.Name == "a" && mem.Incarnation == 0 {
+			t.Error("Expected incarnation > 0 after refutation")
+		}
+	}
+}
+
+// a і c не бачать одне одного напряму, але b - бачить обох.
+// Завдяки ping-req через b a не повинен оголосити c мертвим.
+func TestIndirectProbe(t *testing.T) {
+	var mu sync.Mutex
+	blocked := map[[2]string]bool{}
+	hook := func(self string) func(string) bool {
+		return func(addr string) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return blocked[[2]string{self, addr}]
+		}
+	}
+
+	var nodes []*Memberlist
+	for _, name := range []string{"a", "b", "c"} {
+		cfg := testConfig(name)
+		cfg.blocked = hook(name)
+		nodes = append(nodes, newTestNode(t, cfg))
+	}
+	a, b, c := nodes[0], nodes[1], nodes[2]
+	join(t, b, a.LocalNode().Addr)
+	join(t, c, a.LocalNode().Addr)
+	waitFor(t, "convergence", func() bool {
+		return len(a.Peers()) == 2 && len(b.Peers()) == 2 && len(c.Peers()) == 2
+	})
+
+	for len(a.Events()) > 0 {
+		<-a.Events()
+	}
+
+	mu.Lock()
+	blocked[[2]string{"a", c.LocalNode().Addr}] = true
+	blocked[[2]string{"c", a.LocalNode().Addr}] = true
+	mu.Unlock()
+
+	// Без ping-req c став би suspect (і потім спростовував би через b)
+	time.Sleep(4 * 300 * time.Millisecond)
+	for len(a.Events()) > 0 {
+		if ev := <-a.Events(); ev.Node.Name == "c" {
+			t.Errorf("Expected no events for c, got %s", ev.Type)
+		}
+	}
+	if got := stateOf(a, "c"); got != StateAlive {
+		t.Errorf("Expected %s, got %s", StateAlive, got)
+	}
+}
+
+func TestApplyUpdatePrecedence(t *testing.T) {
+	m := newTestNode(t, testConfig("self"))
+	node := Node{Name: "x", Addr: "127.0.0.1:1"}
+
+	m.applyUpdate(Update{Node: node, State: StateAlive, Incarnation: 2})
+
+	tests := []struct {
+		name   string
+		update Update
+		want   State
+	}{
+		{"stale incarnation ignored", Update{Node: node, State: StateDead, Incarnation: 1}, StateAlive},
+		{"same incarnation, worse state wins", Update{Node: node, State: StateSuspect, Incarnation: 2}, StateSuspect},
+		{"same incarnation, better state loses", Update{Node: node, State: StateAlive, Incarnation: 2}, StateSuspect},
+		{"higher incarnation refutes", Update{Node: node, State: StateAlive, Incarnation: 3}, StateAlive},
+		{"dead at current incarnation", Update{Node: node, State: StateDead, Incarnation: 3}, StateDead},
+		{"no resurrection at same incarnation", Update{Node: node, State: StateAlive, Incarnation: 3}, StateDead},
+		{"rejoin with higher incarnation", Update{Node: node, State: StateAlive, Incarnation: 4}, StateAlive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.applyUpdate(tt.update)
+			if got := stateOf(m, "x"); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	// Невідомий вузол у стані dead не додається
+	m.applyUpdate(Update{Node: Node{Name: "ghost"}, State: StateDead})
+	if got := stateOf(m, "ghost"); got != "" {
+		t.Errorf("Expected ghost to be ignored, got %s", got)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	msg := Message{
+		Type: MsgPingReq, Seq: 42, From: "a", Target: "127.0.0.1:9",
+		Updates: []Update{{Node: Node{Name: "b", Meta: map[string]string{"k": "v"}}, State: StateSuspect, Incarnation: 7}},
+	}
+	data, err := encode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Seq != 42 || got.Target != msg.Target || got.Updates[0].Node.Meta["k"] != "v" || got.Updates[0].Incarnation != 7 {
+		t.Errorf("Expected %+v, got %+v", msg, got)
+	}
+}
@@ -0,0 +1,627 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============= Memberlist =============
+// SWIM (Scalable Weakly-consistent Infection-style Membership):
+//
+//  1. Кожні ProbeInterval вузол пінгує одного учасника (по колу у
+//     випадковому порядку)
+//  2. Немає ack за ProbeTimeout - просить IndirectChecks інших учасників
+//     пропінгувати ціль за нього (ping-req). Це відрізняє "ціль впала"
+//     від "між нами погана мережа"
+//  3. Немає ack і через них - ціль стає suspect. Якщо вона за
+//     SuspicionTimeout не спростує (alive з більшою інкарнацією) - dead
+//  4. Зміни стану поширюються, "причепившись" до ping/ack (gossip)
+
+var ErrNoPeersReached = errors.New("gossip: no peers reached")
+
+type Config struct {
+	Name     string
+	BindAddr string // "127.0.0.1:0" - порт обере ОС
+	Meta     map[string]string
+
+	// Seeds - адреси для Join за замовчуванням
+	Seeds []string
+	// DiscoveryPorts - "broadcast" на localhost: join надсилається на
+	// 127.0.0.1:port для кожного порту. Справжній broadcast потребує
+	// SO_BROADCAST і спільного порту, тож для локальної розробки простіше так.
+	DiscoveryPorts []int
+
+	ProbeInterval    time.Duration
+	ProbeTimeout     time.Duration
+	IndirectChecks   int
+	SuspicionTimeout time.Duration
+	RetransmitMult   int // кожне оновлення передається RetransmitMult*log(n+1) разів
+
+	EventBuffer int
+	Logf        func(format string, args ...any)
+
+	// blocked - тестовий хук: імітація мережевого розділу
+	blocked func(addr string) bool
+}
+
+func DefaultConfig(name string) Config {
+	return Config{
+		Name:             name,
+		BindAddr:         "127.0.0.1:0",
+		ProbeInterval:    time.Second,
+		ProbeTimeout:     300 * time.Millisecond,
+		IndirectChecks:   3,
+		SuspicionTimeout: 4 * time.Second,
+		RetransmitMult:   4,
+		EventBuffer:      64,
+		Logf:             log.Printf,
+	}
+}
+
+// Member - запис у таблиці членства
+type Member struct {
+	Node        Node
+	State       State
+	Incarnation uint64
+	Since       time.Time
+}
+
+type broadcast struct {
+	update    Update
+	remaining int
+}
+
+type Memberlist struct {
+	cfg  Config
+	conn *net.UDPConn
+
+	mu          sync.Mutex
+	self        Node
+	incarnation uint64
+	members     map[string]*Member // без себе
+	queue       []*broadcast
+	probeOrder  []string
+	pending     map[uint64]func() // seq -> що зробити на ack
+	joined      chan struct{}     // закривається на першому відомому учаснику
+	closed      bool
+
+	seq     atomic.Uint64
+	events  chan Event
+	dropped atomic.Int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func Create(cfg Config) (*Memberlist, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("gossip: Name is required")
+	}
+	if cfg.Logf == nil {
+		cfg.Logf = func(string, ...any) {}
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", cfg.BindAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Memberlist{
+		cfg:     cfg,
+		conn:    conn,
+		self:    Node{Name: cfg.Name, Addr: conn.LocalAddr().String(), Meta: maps.Clone(cfg.Meta)},
+		members: make(map[string]*Member),
+		pending: make(map[uint64]func()),
+		joined:  make(chan struct{}),
+		events:  make(chan Event, cfg.EventBuffer),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	m.wg.Add(2)
+	go m.readLoop()
+	go m.probeLoop()
+	return m, nil
+}
+
+func (m *Memberlist) LocalNode() Node {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.self
+}
+
+// Events - join/suspect/alive/failed/leave. Якщо ніхто не читає і буфер
+// повний, подія відкидається (Dropped), а не блокує gossip.
+func (m *Memberlist) Events() <-chan Event {
+	return m.events
+}
+
+func (m *Memberlist) Dropped() int64 {
+	return m.dropped.Load()
+}
+
+// Join надсилає join на addrs (або Seeds/DiscoveryPorts з Config) і чекає,
+// поки хтось відповість. UDP не гарантує доставку, тож join повторюється.
+func (m *Memberlist) Join(ctx context.Context, addrs ...string) error {
+	if len(addrs) == 0 {
+		addrs = append(addrs, m.cfg.Seeds...)
+		for _, port := range m.cfg.DiscoveryPorts {
+			addrs = append(addrs, net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+		}
+	}
+
+	retry := time.NewTicker(m.cfg.ProbeInterval)
+	defer retry.Stop()
+	for {
+		m.mu.Lock()
+		hello := Message{Type: MsgJoin, From: m.self.Name, Updates: []Update{m.selfUpdate(StateAlive)}}
+		m.mu.Unlock()
+
+		for _, addr := range addrs {
+			if addr != m.self.Addr {
+				m.send(addr, hello)
+			}
+		}
+
+		select {
+		case <-m.joined:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ErrNoPeersReached, ctx.Err())
+		case <-m.ctx.Done():
+			return m.ctx.Err()
+		case <-retry.C:
+		}
+	}
+}
+
+// Leave повідомляє інших про вихід, щоб вони не чекали SuspicionTimeout
+func (m *Memberlist) Leave() error {
+	m.mu.Lock()
+	m.incarnation++
+	bye := Message{Type: MsgSync, From: m.self.Name, Updates: []Update{m.selfUpdate(StateLeft)}}
+	targets := m.liveAddrsLocked()
+	m.mu.Unlock()
+
+	// Прямо кожному живому: gossip уже не встигне
+	for _, addr := range targets {
+		m.send(addr, bye)
+	}
+	return m.Shutdown()
+}
+
+// Shutdown зупиняє вузол без попередження - для інших це падіння
+func (m *Memberlist) Shutdown() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	m.cancel()
+	err := m.conn.Close()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	close(m.events)
+	m.mu.Unlock()
+	return err
+}
+
+// Members - таблиця без себе, включно з suspect і нещодавно мертвими
+func (m *Memberlist) Members() []Member {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]Member, 0, len(m.members))
+	for _, mem := range m.members {
+		copied := *mem
+		copied.Node.Meta = maps.Clone(mem.Node.Meta)
+		result = append(result, copied)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Node.Name < result[j].Node.Name })
+	return result
+}
+
+// Peers - живі учасники без себе; suspect теж, бо вони ще можуть
+// спростувати підозру, а відкидати їх одразу - зайва нестабільність
+func (m *Memberlist) Peers() []Node {
+	var nodes []Node
+	for _, mem := range m.Members() {
+		if mem.State == StateAlive || mem.State == StateSuspect {
+			nodes = append(nodes, mem.Node)
+		}
+	}
+	return nodes
+}
+
+// ============= Network =============
+
+func (m *Memberlist) readLoop() {
+	defer m.wg.Done()
+
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := m.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			m.cfg.Logf("gossip %s: read: %v", m.cfg.Name, err)
+			continue
+		}
+		if m.cfg.blocked != nil && m.cfg.blocked(from.String()) {
+			continue
+		}
+
+		msg, err := decode(buf[:n])
+		if err != nil {
+			m.cfg.Logf("gossip %s: bad packet from %s: %v", m.cfg.Name, from, err)
+			continue
+		}
+		m.handle(msg, from.String())
+	}
+}
+
+func (m *Memberlist) handle(msg Message, from string) {
+	for _, u := range msg.Updates {
+		m.applyUpdate(u)
+	}
+
+	switch msg.Type {
+	case MsgPing:
+		m.send(from, Message{Type: MsgAck, Seq: msg.Seq, From: m.cfg.Name})
+
+	case MsgAck:
+		m.mu.Lock()
+		onAck, ok := m.pending[msg.Seq]
+		delete(m.pending, msg.Seq)
+		m.mu.Unlock()
+		if ok {
+			onAck()
+		}
+
+	case MsgPingReq:
+		// Пінгуємо ціль своїм seq; ack пересилаємо з seq того, хто просив
+		seq := m.seq.Add(1)
+		m.expect(seq, m.cfg.ProbeTimeout, func() {
+			m.send(from, Message{Type: MsgAck, Seq: msg.Seq, From: m.cfg.Name})
+		})
+		m.send(msg.Target, Message{Type: MsgPing, Seq: seq, From: m.cfg.Name})
+
+	case MsgJoin:
+		m.sendState(from)
+	}
+}
+
+// sendState - повна таблиця новому учаснику, порціями під розмір пакета
+func (m *Memberlist) sendState(to string) {
+	m.mu.Lock()
+	updates := []Update{m.selfUpdate(StateAlive)}
+	for _, mem := range m.members {
+		updates = append(updates, Update{Node: mem.Node, State: mem.State, Incarnation: mem.Incarnation})
+	}
+	m.mu.Unlock()
+
+	const batch = 8
+	for start := 0; start < len(updates); start += batch {
+		end := min(start+batch, len(updates))
+		m.sendRaw(to, Message{Type: MsgSync, From: m.cfg.Name, Updates: updates[start:end]})
+	}
+}
+
+// send додає до повідомлення оновлення з черги gossip
+func (m *Memberlist) send(addr string, msg Message) {
+	m.mu.Lock()
+	msg.Updates = append(msg.Updates, m.piggybackLocked(8-len(msg.Updates))...)
+	m.mu.Unlock()
+	m.sendRaw(addr, msg)
+}
+
+func (m *Memberlist) sendRaw(addr string, msg Message) {
+	if m.cfg.blocked != nil && m.cfg.blocked(addr) {
+		return
+	}
+
+	data, err := encode(msg)
+	for err == nil && len(data) > maxPacketSize && len(msg.Updates) > 0 {
+		msg.Updates = msg.Updates[:len(msg.Updates)-1]
+		data, err = encode(msg)
+	}
+	if err != nil {
+		m.cfg.Logf("gossip %s: encode: %v", m.cfg.Name, err)
+		return
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		m.cfg.Logf("gossip %s: resolve %s: %v", m.cfg.Name, addr, err)
+		return
+	}
+	m.conn.WriteToUDP(data, udpAddr)
+}
+
+// expect реєструє обробник ack з таймаутом
+func (m *Memberlist) expect(seq uint64, timeout time.Duration, onAck func()) {
+	m.mu.Lock()
+	m.pending[seq] = onAck
+	m.mu.Unlock()
+
+	time.AfterFunc(timeout, func() {
+		m.mu.Lock()
+		delete(m.pending, seq)
+		m.mu.Unlock()
+	})
+}
+
+// ============= Probing =============
+
+func (m *Memberlist) probeLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.probe()
+			m.reap()
+		}
+	}
+}
+
+func (m *Memberlist) probe() {
+	target, ok := m.nextTarget()
+	if !ok {
+		return
+	}
+
+	acked := make(chan struct{}, 1)
+	seq := m.seq.Add(1)
+	m.expect(seq, m.cfg.ProbeInterval, func() {
+		select {
+		case acked <- struct{}{}:
+		default:
+		}
+	})
+	m.send(target.Node.Addr, Message{Type: MsgPing, Seq: seq, From: m.cfg.Name})
+
+	select {
+	case <-acked:
+		return
+	case <-m.ctx.Done():
+		return
+	case <-time.After(m.cfg.ProbeTimeout):
+	}
+
+	// Непряма перевірка через інших учасників
+	for _, helper := range m.randomPeers(m.cfg.IndirectChecks, target.Node.Name) {
+		m.send(helper.Addr, Message{Type: MsgPingReq, Seq: seq, From: m.cfg.Name, Target: target.Node.Addr})
+	}
+
+	select {
+	case <-acked:
+		return
+	case <-m.ctx.Done():
+		return
+	case <-time.After(m.cfg.ProbeInterval - m.cfg.ProbeTimeout):
+	}
+
+	m.cfg.Logf("gossip %s: %s did not respond, suspect", m.cfg.Name, target.Node.Name)
+	m.applyUpdate(Update{Node: target.Node, State: StateSuspect, Incarnation: target.Incarnation})
+}
+
+// nextTarget - обхід по колу у випадковому порядку: кожен учасник
+// перевіряється не рідше ніж раз за N інтервалів
+func (m *Memberlist) nextTarget() (Member, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for attempts := 0; attempts < 2; attempts++ {
+		for len(m.probeOrder) > 0 {
+			name := m.probeOrder[0]
+			m.probeOrder = m.probeOrder[1:]
+			if mem, ok := m.members[name]; ok && (mem.State == StateAlive || mem.State == StateSuspect) {
+				return *mem, true
+			}
+		}
+		for name, mem := range m.members {
+			if mem.State == StateAlive || mem.State == StateSuspect {
+				m.probeOrder = append(m.probeOrder, name)
+			}
+		}
+		rand.Shuffle(len(m.probeOrder), func(i, j int) {
+			m.probeOrder[i], m.probeOrder[j] = m.probeOrder[j], m.probeOrder[i]
+		})
+	}
+	return Member{}, false
+}
+
+func (m *Memberlist) randomPeers(k int, exclude string) []Node {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var candidates []Node
+	for name, mem := range m.members {
+		if name != exclude && mem.State == StateAlive {
+			candidates = append(candidates, mem.Node)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	return candidates[:min(k, len(candidates))]
+}
+
+// reap прибирає надгробки: dead/left тримаються якийсь час, щоб
+// запізнілий gossip зі старою інкарнацією не "воскресив" вузол
+func (m *Memberlist) reap() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ttl := 10 * m.cfg.SuspicionTimeout
+	for name, mem := range m.members {
+		if (mem.State == StateDead || mem.State == StateLeft) && time.Since(mem.Since) > ttl {
+			delete(m.members, name)
+		}
+	}
+}
+
+// ============= State =============
+
+func (m *Memberlist) applyUpdate(u Update) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return
+	}
+
+	if u.Node.Name == m.self.Name {
+		// Про нас кажуть, що ми suspect/dead - спростовуємо більшою інкарнацією
+		if u.State != StateAlive && u.Incarnation >= m.incarnation {
+			m.incarnation = u.Incarnation + 1
+			m.enqueueLocked(m.selfUpdate(StateAlive))
+			m.cfg.Logf("gossip %s: refuting %s with incarnation %d", m.cfg.Name, u.State, m.incarnation)
+		}
+		return
+	}
+
+	existing, known := m.members[u.Node.Name]
+	if !known {
+		if u.State == StateDead || u.State == StateLeft {
+			return
+		}
+		mem := &Member{Node: u.Node, State: u.State, Incarnation: u.Incarnation, Since: time.Now()}
+		m.members[u.Node.Name] = mem
+		m.enqueueLocked(u)
+		m.emitLocked(EventJoin, u.Node)
+		if u.State == StateSuspect {
+			m.startSuspicionLocked(mem)
+		}
+		select {
+		case <-m.joined:
+		default:
+			close(m.joined)
+		}
+		return
+	}
+
+	newer := u.Incarnation > existing.Incarnation ||
+		(u.Incarnation == existing.Incarnation && stateRank(u.State) > stateRank(existing.State))
+	if !newer {
+		return
+	}
+
+	prev := existing.State
+	metaChanged := existing.Node.Addr != u.Node.Addr || !maps.Equal(existing.Node.Meta, u.Node.Meta)
+	existing.Node = u.Node
+	existing.State = u.State
+	existing.Incarnation = u.Incarnation
+	if prev != u.State {
+		existing.Since = time.Now()
+	}
+	m.enqueueLocked(u)
+
+	switch {
+	case u.State == StateAlive && (prev == StateDead || prev == StateLeft):
+		m.emitLocked(EventJoin, u.Node)
+	case u.State == StateAlive && prev == StateSuspect:
+		m.emitLocked(EventAlive, u.Node)
+	case u.State == StateAlive && metaChanged:
+		m.emitLocked(EventUpdate, u.Node)
+	case u.State == StateSuspect && prev != StateSuspect:
+		m.emitLocked(EventSuspect, u.Node)
+		m.startSuspicionLocked(existing)
+	case u.State == StateDead && prev != StateDead:
+		m.emitLocked(EventFailed, u.Node)
+	case u.State == StateLeft && prev != StateLeft:
+		m.emitLocked(EventLeave, u.Node)
+	}
+}
+
+// startSuspicionLocked: якщо за SuspicionTimeout інкарнація не зросла - dead
+func (m *Memberlist) startSuspicionLocked(mem *Member) {
+	node, inc := mem.Node, mem.Incarnation
+	time.AfterFunc(m.cfg.SuspicionTimeout, func() {
+		m.mu.Lock()
+		current, ok := m.members[node.Name]
+		stillSuspect := ok && current.State == StateSuspect && current.Incarnation == inc
+		m.mu.Unlock()
+
+		if stillSuspect {
+			m.cfg.Logf("gossip %s: %s failed", m.cfg.Name, node.Name)
+			m.applyUpdate(Update{Node: node, State: StateDead, Incarnation: inc})
+		}
+	})
+}
+
+func (m *Memberlist) selfUpdate(state State) Update {
+	return Update{Node: m.self, State: state, Incarnation: m.incarnation}
+}
+
+func (m *Memberlist) enqueueLocked(u Update) {
+	// Нове оновлення про вузол замінює старе в черзі
+	for i, b := range m.queue {
+		if b.update.Node.Name == u.Node.Name {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			break
+		}
+	}
+	n := float64(len(m.members) + 1)
+	retransmits := m.cfg.RetransmitMult * int(math.Ceil(math.Log10(n+1)))
+	m.queue = append(m.queue, &broadcast{update: u, remaining: max(retransmits, 1)})
+}
+
+func (m *Memberlist) piggybackLocked(limit int) []Update {
+	var updates []Update
+	kept := m.queue[:0]
+	for _, b := range m.queue {
+		if len(updates) < limit {
+			updates = append(updates, b.update)
+			b.remaining--
+		}
+		if b.remaining > 0 {
+			kept = append(kept, b)
+		}
+	}
+	m.queue = kept
+	return updates
+}
+
+func (m *Memberlist) emitLocked(t EventType, node Node) {
+	select {
+	case m.events <- Event{Type: t, Node: node, Time: time.Now()}:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+func (m *Memberlist) liveAddrsLocked() []string {
+	var addrs []string
+	for _, mem := range m.members {
+		if mem.State == StateAlive || mem.State == StateSuspect {
+			addrs = append(addrs, mem.Node.Addr)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ============= Wire format =============
+// Кожне повідомлення - один UDP-датаграм з JSON. Оновлення стану
+// членів (Updates) "їдуть" на будь-якому повідомленні - ping, ack,
+// ping-req - замість окремої розсилки (piggybacking, як у SWIM).
+
+type MessageType string
+
+const (
+	MsgPing    MessageType = "ping"
+	MsgAck     MessageType = "ack"
+	MsgPingReq MessageType = "ping-req" // "пропінгуй Target за мене"
+	MsgJoin    MessageType = "join"     // новий вузол просить повний стан
+	MsgSync    MessageType = "sync"     // відповідь на join: частина членів
+)
+
+// maxPacketSize - з запасом під MTU 1500 мінус IP/UDP заголовки
+const maxPacketSize = 1400
+
+type Message struct {
+	Type    MessageType `json:"type"`
+	Seq     uint64      `json:"seq,omitempty"`
+	From    string      `json:"from"`             // ім'я відправника
+	Target  string      `json:"target,omitempty"` // адреса для ping-req
+	Updates []Update    `json:"updates,omitempty"`
+}
+
+type State string
+
+const (
+	StateAlive   State = "alive"
+	StateSuspect State = "suspect" // не відповів, але ще може спростувати
+	StateDead    State = "dead"    // не спростував за SuspicionTimeout
+	StateLeft    State = "left"    // пішов сам через Leave
+)
+
+// Node - те, що інші знають про екземпляр сервісу
+type Node struct {
+	Name string            `json:"name"`
+	Addr string            `json:"addr"`           // UDP адреса gossip
+	Meta map[string]string `json:"meta,omitempty"` // наприклад, {"http": "http://127.0.0.1:8081"}
+}
+
+// Update - твердження "вузол X у стані S з інкарнацією I".
+// Більша інкарнація завжди перемагає; при рівній - гірший стан.
+type Update struct {
+	Node        Node   `json:"node"`
+	State       State  `json:"state"`
+	Incarnation uint64 `json:"inc"`
+}
+
+func encode(msg Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func decode(data []byte) (Message, error) {
+	var msg Message
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// ============= Events =============
+
+type EventType string
+
+const (
+	EventJoin    EventType = "join"
+	EventUpdate  EventType = "update" // змінились Meta або адреса
+	EventSuspect EventType = "suspect"
+	EventAlive   EventType = "alive" // спростував підозру
+	EventFailed  EventType = "failed"
+	EventLeave   EventType = "leave"
+)
+
+type Event struct {
+	Type EventType
+	Node Node
+	Time time.Time
+}
+
+// stateRank - порядок "гіршості" для рівних інкарнацій
+func stateRank(s State) int {
+	switch s {
+	case StateAlive:
+		return 0
+	case StateSuspect:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ============= Cache =============
+// Storage з TTL і обмеженням розміру. На відміну від Cache з
+// week_23/maps/18_cache.go, який росте без меж, тут:
+//
+//   - MaxEntries / MaxBytes - при переповненні витісняє EvictionPolicy
+//   - TTL на ключ: прострочений ключ видаляється при зверненні (ліниво)
+//     і фоновим janitor'ом, щоб непотрібні ключі не займали пам'ять
+//   - статистика hit/miss/eviction
+
+var ErrTooLarge = errors.New("entry exceeds MaxBytes")
+
+type EvictReason string
+
+const (
+	ReasonEvicted EvictReason = "evicted" // витіснено політикою
+	ReasonExpired EvictReason = "expired" // минув TTL
+)
+
+type CacheOptions struct {
+	MaxEntries int   // 0 - без обмеження
+	MaxBytes   int64 // сума len(key)+len(value); 0 - без обмеження
+
+	DefaultTTL time.Duration // для Save; 0 - без терміну
+	// JanitorInterval - як часто прибирати прострочені ключі у фоні; 0 - лише ліниво
+	JanitorInterval time.Duration
+
+	Policy EvictionPolicy // nil - LRU
+
+	// OnEvict викликається поза локом, тож може звертатися до Cache
+	OnEvict func(key, value string, reason EvictReason)
+
+	now func() time.Time
+}
+
+type CacheStats struct {
+	Hits        int64
+	Misses      int64
+	Evictions   int64
+	Expirations int64
+	Entries     int
+	Bytes       int64
+}
+
+func (s CacheStats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+type cacheEntry struct {
+	value   string
+	expires time.Time // нульовий - без терміну
+	size    int64
+}
+
+type removed struct {
+	key, value string
+	reason     EvictReason
+}
+
+type Cache struct {
+	mu      sync.Mutex // не RWMutex: навіть Load змінює порядок у політиці
+	opts    CacheOptions
+	items   map[string]*cacheEntry
+	bytes   int64
+	policy  EvictionPolicy
+	expiry  expiryHeap
+	stats   CacheStats
+	rev     Version // ревізія для Watch; версій ключів Cache не має
+	hub     *watchHub
+	stop    chan struct{}
+	stopped sync.Once
+	wg      sync.WaitGroup
+}
+
+func NewCache(opts CacheOptions) *Cache {
+	if opts.Policy == nil {
+		opts.Policy = NewLRU()
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	c := &Cache{
+		opts:   opts,
+		items:  make(map[string]*cacheEntry),
+		policy: opts.Policy,
+		hub:    newWatchHub(),
+		stop:   make(chan struct{}),
+	}
+	if opts.JanitorInterval > 0 {
+		c.wg.Add(1)
+		go c.janitor()
+	}
+	return c
+}
+
+func (c *Cache) Save(key, value string) error {
+	return c.SaveTTL(key, value, c.opts.DefaultTTL)
+}
+
+// SaveTTL: ttl <= 0 - без терміну
+func (c *Cache) SaveTTL(key, value string, ttl time.Duration) error {
+	size := int64(len(key) + len(value))
+	if c.opts.MaxBytes > 0 && size > c.opts.MaxBytes {
+		return ErrTooLarge
+	}
+
+	c.mu.Lock()
+	var evicted []removed
+	defer func() { c.notify(evicted) }()
+	defer c.mu.Unlock()
+
+	entry, exists := c.items[key]
+	if exists {
+		c.bytes -= entry.size
+	} else {
+		entry = &cacheEntry{}
+		c.items[key] = entry
+	}
+	entry.value = value
+	entry.size = size
+	entry.expires = time.Time{}
+	if ttl > 0 {
+		entry.expires = c.opts.now().Add(ttl)
+		heap.Push(&c.expiry, expiryItem{key: key, at: entry.expires})
+		c.compactExpiryLocked()
+	}
+	c.bytes += size
+	c.policy.Added(key)
+
+	// Витісняємо, поки не вліземо; щойно записаний ключ не чіпаємо
+	for c.overLimit() {
+		victim, ok := c.policy.Victim(key)
+		if !ok {
+			break
+		}
+		evicted = append(evicted, c.removeLocked(victim, ReasonEvicted))
+	}
+
+	c.rev++
+	events := []Event{{Type: EventPut, Key: key, Value: value, Revision: c.rev}}
+	for _, r := range evicted {
+		events = append(events, r.event(c.rev))
+	}
+	c.hub.publish(events...)
+	return nil
+}
+
+func (c *Cache) overLimit() bool {
+	return (c.opts.MaxEntries > 0 && len(c.items) > c.opts.MaxEntries) ||
+		(c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes)
+}
+
+func (c *Cache) Load(key string) (string, error) {
+	c.mu.Lock()
+	var expired []removed
+	defer func() { c.notify(expired) }()
+	defer c.mu.Unlock()
+
+	entry, ok := c.items[key]
+	if ok && c.expiredLocked(entry) {
+		expired = append(expired, c.removeLocked(key, ReasonExpired))
+		c.publishLocked(expired)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return "", notFound(key)
+	}
+	c.stats.Hits++
+	c.policy.Accessed(key)
+	return entry.value, nil
+}
+
+func (c *Cache) Delete(key string) error {
+	c.mu.Lock()
+	var expired []removed
+	defer func() { c.notify(expired) }()
+	defer c.mu.Unlock()
+
+	entry, ok := c.items[key]
+	if !ok || c.expiredLocked(entry) {
+		if ok {
+			expired = append(expired, c.removeLocked(key, ReasonExpired))
+			c.publishLocked(expired)
+		}
+		return notFound(key)
+	}
+	c.publishLocked([]removed{c.removeLocked(key, "")})
+	return nil
+}
+
+// Exists не рахується зверненням: не впливає ні на статистику, ні на LRU
+func (c *Cache) Exists(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.items[key]
+	return ok && !c.expiredLocked(entry)
+}
+
+func (c *Cache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.items))
+	for key, entry := range c.items {
+		if !c.expiredLocked(entry) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rev++
+	events := make([]Event, 0, len(c.items))
+	for key := range c.items {
+		c.policy.Removed(key)
+		events = append(events, Event{Type: EventDelete, Key: key, Revision: c.rev})
+	}
+	c.hub.publish(events...)
+	c.items = make(map[string]*cacheEntry)
+	c.expiry = nil
+	c.bytes = 0
+	return nil
+}
+
+// TTL - скільки лишилось; false - ключа немає або він без терміну
+func (c *Cache) TTL(key string) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.items[key]
+	if !ok || entry.expires.IsZero() || c.expiredLocked(entry) {
+		return 0, false
+	}
+	return entry.expires.Sub(c.opts.now()), true
+}
+
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.items)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Close зупиняє janitor
+func (c *Cache) Close() error {
+	c.stopped.Do(func() { close(c.stop) })
+	c.wg.Wait()
+	return nil
+}
+
+func (c *Cache) expiredLocked(entry *cacheEntry) bool {
+	return !entry.expires.IsZero() && !c.opts.now().Before(entry.expires)
+}
+
+// removeLocked; reason "" - явний Delete, без колбеку і статистики
+func (c *Cache) removeLocked(key string, reason EvictReason) removed {
+	entry := c.items[key]
+	delete(c.items, key)
+	c.bytes -= entry.size
+	c.policy.Removed(key)
+
+	switch reason {
+	case ReasonEvicted:
+		c.stats.Evictions++
+	case ReasonExpired:
+		c.stats.Expirations++
+	}
+	return removed{key: key, value: entry.value, reason: reason}
+}
+
+func (r removed) event(rev Version) Event {
+	switch r.reason {
+	case ReasonExpired:
+		return Event{Type: EventExpire, Key: r.key, Revision: rev}
+	case ReasonEvicted:
+		return Event{Type: EventEvict, Key: r.key, Revision: rev}
+	default:
+		return Event{Type: EventDelete, Key: r.key, Revision: rev}
+	}
+}
+
+// publishLocked - усі видалення однієї операції під однією ревізією
+func (c *Cache) publishLocked(list []removed) {
+	if len(list) == 0 {
+		return
+	}
+	c.rev++
+	events := make([]Event, len(list))
+	for i, r := range list {
+		events[i] = r.event(c.rev)
+	}
+	c.hub.publish(events...)
+}
+
+// Watch: expire приходить, коли прострочений ключ помічено - при
+// зверненні або проході janitor'а, а не рівно в момент закінчення TTL
+func (c *Cache) Watch(ctx context.Context, keyOrPrefix string, opts WatchOptions) (<-chan Event, error) {
+	return c.hub.watch(ctx, keyOrPrefix, opts)
+}
+
+func (c *Cache) notify(list []removed) {
+	if c.opts.OnEvict == nil {
+		return
+	}
+	for _, r := range list {
+		c.opts.OnEvict(r.key, r.value, r.reason)
+	}
+}
+
+// ============= Janitor =============
+
+func (c *Cache) janitor() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.opts.JanitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.DeleteExpired()
+		}
+	}
+}
+
+// DeleteExpired знімає з купи все, чий термін минув. Запис у купі міг
+// застаріти (ключ перезаписали з іншим TTL або видалили) - тоді він
+// просто пропускається.
+func (c *Cache) DeleteExpired() int {
+	c.mu.Lock()
+	var expired []removed
+	now := c.opts.now()
+	for len(c.expiry) > 0 && !now.Before(c.expiry[0].at) {
+		item := heap.Pop(&c.expiry).(expiryItem)
+		if entry, ok := c.items[item.key]; ok && entry.expires.Equal(item.at) {
+			expired = append(expired, c.removeLocked(item.key, ReasonExpired))
+		}
+	}
+	c.publishLocked(expired)
+	c.mu.Unlock()
+
+	c.notify(expired)
+	return len(expired)
+}
+
+// compactExpiryLocked: якщо ключі часто перезаписуються з TTL, а janitor
+// вимкнено, застарілі записи накопичуються в купі - перебудовуємо її
+func (c *Cache) compactExpiryLocked() {
+	if len(c.expiry) <= 2*len(c.items)+64 {
+		return
+	}
+	fresh := make(expiryHeap, 0, len(c.items))
+	for key, entry := range c.items {
+		if !entry.expires.IsZero() {
+			fresh = append(fresh, expiryItem{key: key, at: entry.expires})
+		}
+	}
+	heap.Init(&fresh)
+	c.expiry = fresh
+}
+
+type expiryItem struct {
+	key string
+	at  time.Time
+}
+
+type expiryHeap []expiryItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x any)        { *h = append(*h, x.(expiryItem)) }
+func (h *expiryHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// ============= LRUCache =============
+// week_18/middle/01_lru_cache.md "по-справжньому": той самий API
+// get/put, але потокобезпечний і поверх Cache
+
+type LRUCache struct {
+	cache *Cache
+}
+
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{cache: NewCache(CacheOptions{MaxEntries: capacity, Policy: NewLRU()})}
+}
+
+func (l *LRUCache) Get(key string) (string, bool) {
+	value, err := l.cache.Load(key)
+	return value, err == nil
+}
+
+func (l *LRUCache) Put(key, value string) {
+	l.cache.Save(key, value)
+}
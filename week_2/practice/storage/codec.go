@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// ============= Codecs =============
+// Storage зберігає рядки, Repository - типізовані сутності. Codec
+// перетворює одне на інше; його можна замінити, не чіпаючи репозиторій.
+
+type Codec[T any] interface {
+	Encode(v T) (string, error)
+	Decode(data string) (T, error)
+}
+
+// JSONCodec - читабельний у сховищі, стійкий до доданих полів
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(v T) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func (JSONCodec[T]) Decode(data string) (T, error) {
+	var v T
+	err := json.Unmarshal([]byte(data), &v)
+	return v, err
+}
+
+// GobCodec - компактніший, але лише для Go і кожне значення несе
+// опис типу (gob-потік на одне значення)
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(v T) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (GobCodec[T]) Decode(data string) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewBufferString(data)).Decode(&v)
+	return v, err
+}
@@ -0,0 +1,160 @@
+package main
+
+import "container/list"
+
+// ============= Eviction Policies =============
+// Strategy: Cache вирішує, КОЛИ витісняти (ліміт записів або байтів),
+// політика - КОГО. Політики не потокобезпечні: їх викликає Cache під своїм локом.
+
+type EvictionPolicy interface {
+	Added(key string)
+	Accessed(key string)
+	Removed(key string)
+	// Victim - кандидат на витіснення, крім exclude (ключ, який саме
+	// записується). Сам ключ не видаляє.
+	Victim(exclude string) (string, bool)
+}
+
+// ============= LRU =============
+// HashMap + двозв'язний список (week_18/middle/01_lru_cache.md):
+// голова - щойно використаний, хвіст - найдавніший. Усе O(1).
+
+type lruPolicy struct {
+	order *list.List
+	nodes map[string]*list.Element
+}
+
+func NewLRU() EvictionPolicy {
+	return &lruPolicy{order: list.New(), nodes: make(map[string]*list.Element)}
+}
+
+func (p *lruPolicy) Added(key string) {
+	if el, ok := p.nodes[key]; ok {
+		p.order.MoveToFront(el)
+		return
+	}
+	p.nodes[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy) Accessed(key string) {
+	if el, ok := p.nodes[key]; ok {
+		p.order.MoveToFront(el)
+	}
+}
+
+func (p *lruPolicy) Removed(key string) {
+	if el, ok := p.nodes[key]; ok {
+		p.order.Remove(el)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *lruPolicy) Victim(exclude string) (string, bool) {
+	for el := p.order.Back(); el != nil; el = el.Prev() {
+		if key := el.Value.(string); key != exclude {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// ============= LFU =============
+// O(1) LFU: списки ключів для кожної частоти і мінімальна частота.
+// При рівній частоті витісняється найдавніший (LRU всередині частоти).
+// Новий ключ має частоту 1, тож одноразові ключі йдуть першими -
+// на відміну від LRU, сканування не вимиває "гарячі" ключі.
+
+type lfuPolicy struct {
+	nodes   map[string]*list.Element
+	buckets map[int]*list.List
+	minFreq int
+}
+
+type lfuNode struct {
+	key  string
+	freq int
+}
+
+func NewLFU() EvictionPolicy {
+	return &lfuPolicy{nodes: make(map[string]*list.Element), buckets: make(map[int]*list.List)}
+}
+
+func (p *lfuPolicy) bucket(freq int) *list.List {
+	b, ok := p.buckets[freq]
+	if !ok {
+		b = list.New()
+		p.buckets[freq] = b
+	}
+	return b
+}
+
+func (p *lfuPolicy) Added(key string) {
+	if _, ok := p.nodes[key]; ok {
+		p.Accessed(key)
+		return
+	}
+	p.nodes[key] = p.bucket(1).PushFront(&lfuNode{key: key, freq: 1})
+	p.minFreq = 1
+}
+
+func (p *lfuPolicy) Accessed(key string) {
+	el, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	node := el.Value.(*lfuNode)
+	old := p.buckets[node.freq]
+	old.Remove(el)
+	if old.Len() == 0 {
+		delete(p.buckets, node.freq)
+		if p.minFreq == node.freq {
+			p.minFreq++
+		}
+	}
+	node.freq++
+	p.nodes[key] = p.bucket(node.freq).PushFront(node)
+}
+
+func (p *lfuPolicy) Removed(key string) {
+	el, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	node := el.Value.(*lfuNode)
+	b := p.buckets[node.freq]
+	b.Remove(el)
+	delete(p.nodes, key)
+	if b.Len() == 0 {
+		delete(p.buckets, node.freq)
+		// minFreq оновлюється ліниво у Victim
+	}
+}
+
+func (p *lfuPolicy) Victim(exclude string) (string, bool) {
+	if len(p.nodes) == 0 {
+		return "", false
+	}
+	// Після Removed мінімальна частота могла зникнути - шукаємо наступну
+	if _, ok := p.buckets[p.minFreq]; !ok {
+		p.minFreq = p.nextFreq(0)
+	}
+	for freq := p.minFreq; freq != 0; freq = p.nextFreq(freq) {
+		for el := p.buckets[freq].Back(); el != nil; el = el.Prev() {
+			if key := el.Value.(*lfuNode).key; key != exclude {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// nextFreq - найменша частота, більша за after; 0 - немає
+func (p *lfuPolicy) nextFreq(after int) int {
+	next := 0
+	for freq := range p.buckets {
+		if freq > after && (next == 0 || freq < next) {
+			next = freq
+		}
+	}
+	return next
+}
@@ -0,0 +1,660 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============= File Storage (append-only log) =============
+// Оригінальний FileStorage на кожен Save робить os.Create і переписує
+// весь файл "key=value": збій посеред запису - порожній файл, а '=' чи
+// '\n' у ключі або значенні ламають loadFromFile. Тут:
+//
+//   - кожна зміна - запис у кінець журналу (wal-NNNNNN.log) з CRC32
+//   - при відкритті журнал програється; обірваний хвіст (torn write)
+//     відрізається, а не ламає все
+//   - компакція пише snapshot у тимчасовий файл, fsync, rename - файл
+//     або старий, або новий, ніколи не напівзаписаний
+//
+// Формат запису: crc32c(4) | op(1) | len(key)(4) | len(value)(4) | key | value.
+// CRC рахується по всьому після себе, тож пошкоджена довжина теж помітна.
+
+var (
+	ErrCorrupt = errors.New("storage file is corrupt")
+	ErrClosed  = errors.New("storage is closed")
+)
+
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // fsync перед поверненням з кожного запису
+	SyncInterval                   // fsync у фоні кожні SyncInterval: можна втратити останній інтервал
+	SyncNever                      // вирішує ОС; переживає падіння процесу, але не вимкнення живлення
+)
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncAlways:
+		return "always"
+	case SyncInterval:
+		return "interval"
+	default:
+		return "never"
+	}
+}
+
+type FileOptions struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration // для SyncInterval; 0 - 1s
+
+	// CompactThreshold - автоматична компакція, коли журнал більший за
+	// поріг і вдвічі більший за живі дані; 0 - лише ручний Compact
+	CompactThreshold int64
+
+	// MaxRecordSize захищає від "довжини" 4 GB у пошкодженому заголовку
+	MaxRecordSize int
+}
+
+const (
+	opPut byte = iota + 1
+	opDelete
+	opClear
+	opSnapshot // перший запис snapshot: value - "<перший журнал після нього> <ревізія>"
+	opBatch    // value - вкладені записи opPut/opDelete
+
+	headerSize    = 13
+	snapshotName  = "snapshot.dat"
+	defaultMaxRec = 64 << 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type FileStorage struct {
+	dir  string
+	opts FileOptions
+
+	mu        sync.RWMutex
+	kv        versioned
+	log       *os.File
+	seq       int   // номер поточного журналу
+	logBytes  int64 // розмір поточного журналу
+	truncated int64 // скільки байт відрізано при відкритті
+	broken    error // не вдалося відкотити частковий запис - далі писати не можна
+	closed    bool
+
+	compactMu sync.Mutex
+	compactCh chan struct{}
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+type FileStats struct {
+	Keys      int
+	LogBytes  int64 // поточний журнал
+	LiveBytes int64 // len(key)+len(value) живих записів
+	Truncated int64 // відрізаний обірваний хвіст при останньому відкритті
+}
+
+// OpenFileStorage відкриває (або створює) сховище в каталозі dir
+func OpenFileStorage(dir string, opts FileOptions) (*FileStorage, error) {
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = time.Second
+	}
+	if opts.MaxRecordSize <= 0 {
+		opts.MaxRecordSize = defaultMaxRec
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	fs := &FileStorage{
+		dir:       dir,
+		opts:      opts,
+		kv:        newVersioned(),
+		compactCh: make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+	if err := fs.recover(); err != nil {
+		return nil, err
+	}
+
+	fs.wg.Add(1)
+	go fs.background()
+	return fs, nil
+}
+
+// ============= Storage =============
+
+func (f *FileStorage) Save(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.appendLocked(encodeRecord(opPut, key, value)); err != nil {
+		return err
+	}
+	f.kv.put(key, value)
+	f.maybeCompactLocked()
+	return nil
+}
+
+func (f *FileStorage) Load(key string) (string, error) {
+	value, _, err := f.LoadVersion(key)
+	return value, err
+}
+
+func (f *FileStorage) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.kv.data[key]; !exists {
+		return notFound(key)
+	}
+	if err := f.appendLocked(encodeRecord(opDelete, key, "")); err != nil {
+		return err
+	}
+	f.kv.delete(key)
+	f.maybeCompactLocked()
+	return nil
+}
+
+func (f *FileStorage) Exists(key string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	_, exists := f.kv.data[key]
+	return exists
+}
+
+func (f *FileStorage) Keys() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.kv.keys()
+}
+
+func (f *FileStorage) Clear() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.appendLocked(encodeRecord(opClear, "", "")); err != nil {
+		return err
+	}
+	f.kv.clear()
+	f.maybeCompactLocked()
+	return nil
+}
+
+func (f *FileStorage) Stats() FileStats {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return FileStats{Keys: len(f.kv.data), LogBytes: f.logBytes, LiveBytes: f.kv.bytes, Truncated: f.truncated}
+}
+
+func (f *FileStorage) LoadVersion(key string) (string, Version, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.kv.load(key)
+}
+
+// Watch: події програного при відкритті журналу теж в історії, тож
+// після перезапуску можна продовжити з ревізії, якої не торкнулася компакція
+func (f *FileStorage) Watch(ctx context.Context, keyOrPrefix string, opts WatchOptions) (<-chan Event, error) {
+	return f.kv.watch(ctx, keyOrPrefix, opts)
+}
+
+// Commit пише весь пакет одним записом журналу: обірваний пакет
+// відрізається при відновленні цілком, частково він не застосується
+func (f *FileStorage) Commit(b *Batch) (Version, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.kv.check(b.Checks); err != nil {
+		return 0, err
+	}
+	if len(b.Ops) == 0 {
+		return f.kv.rev, nil
+	}
+	if err := f.appendLocked(encodeBatch(b.Ops)); err != nil {
+		return 0, err
+	}
+	rev := f.kv.apply(b.Ops)
+	f.maybeCompactLocked()
+	return rev, nil
+}
+
+// Sync - примусовий fsync (для SyncInterval/SyncNever перед важливою точкою)
+func (f *FileStorage) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	return f.log.Sync()
+}
+
+func (f *FileStorage) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	f.mu.Unlock()
+
+	close(f.stop)
+	f.wg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	syncErr := f.log.Sync()
+	if err := f.log.Close(); err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// ============= Log =============
+
+func encodeRecord(op byte, key, value string) []byte {
+	buf := make([]byte, headerSize+len(key)+len(value))
+	buf[4] = op
+	binary.BigEndian.PutUint32(buf[5:], uint32(len(key)))
+	binary.BigEndian.PutUint32(buf[9:], uint32(len(value)))
+	copy(buf[headerSize:], key)
+	copy(buf[headerSize+len(key):], value)
+	binary.BigEndian.PutUint32(buf[0:], crc32.Checksum(buf[4:], castagnoli))
+	return buf
+}
+
+type record struct {
+	op         byte
+	key, value string
+}
+
+// readRecord: io.EOF - чистий кінець; ErrCorrupt - обірваний або пошкоджений запис
+func readRecord(r *bufio.Reader, maxSize int) (record, int, error) {
+	var header [headerSize]byte
+	n, err := io.ReadFull(r, header[:])
+	if err == io.EOF {
+		return record{}, 0, io.EOF
+	}
+	if err != nil {
+		return record{}, n, ErrCorrupt
+	}
+
+	keyLen := binary.BigEndian.Uint32(header[5:])
+	valueLen := binary.BigEndian.Uint32(header[9:])
+	if uint64(keyLen)+uint64(valueLen) > uint64(maxSize) {
+		return record{}, n, ErrCorrupt
+	}
+
+	body := make([]byte, keyLen+valueLen)
+	m, err := io.ReadFull(r, body)
+	n += m
+	if err != nil {
+		return record{}, n, ErrCorrupt
+	}
+
+	crc := crc32.Update(crc32.Checksum(header[4:], castagnoli), castagnoli, body)
+	if crc != binary.BigEndian.Uint32(header[0:]) || header[4] < opPut || header[4] > opBatch {
+		return record{}, n, ErrCorrupt
+	}
+	return record{op: header[4], key: string(body[:keyLen]), value: string(body[keyLen:])}, n, nil
+}
+
+func encodeBatch(ops []BatchOp) []byte {
+	var body []byte
+	for _, op := range ops {
+		if op.Delete {
+			body = append(body, encodeRecord(opDelete, op.Key, "")...)
+		} else {
+			body = append(body, encodeRecord(opPut, op.Key, op.Value)...)
+		}
+	}
+	return encodeRecord(opBatch, "", string(body))
+}
+
+func decodeBatch(body string, maxSize int) ([]BatchOp, error) {
+	br := bufio.NewReader(strings.NewReader(body))
+	var ops []BatchOp
+	for {
+		rec, _, err := readRecord(br, maxSize)
+		if err == io.EOF {
+			return ops, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, BatchOp{Key: rec.key, Value: rec.value, Delete: rec.op == opDelete})
+	}
+}
+
+func (f *FileStorage) appendLocked(buf []byte) error {
+	if f.closed {
+		return ErrClosed
+	}
+	if f.broken != nil {
+		return f.broken
+	}
+	if len(buf)-headerSize > f.opts.MaxRecordSize {
+		return fmt.Errorf("record of %d bytes exceeds MaxRecordSize", len(buf)-headerSize)
+	}
+
+	if _, err := f.log.Write(buf); err != nil {
+		// Частковий запис посеред журналу відрізав би при відновленні все,
+		// що записано після нього - відкочуємо
+		if terr := f.log.Truncate(f.logBytes); terr != nil {
+			f.broken = fmt.Errorf("log is in unknown state after failed write: %w", err)
+		}
+		f.log.Seek(f.logBytes, io.SeekStart)
+		return err
+	}
+	f.logBytes += int64(len(buf))
+
+	if f.opts.Sync == SyncAlways {
+		return f.log.Sync()
+	}
+	return nil
+}
+
+// apply програє запис так само, як його застосував живий запис:
+// ревізії після відновлення збігаються з тими, що бачили клієнти
+func (f *FileStorage) apply(rec record) error {
+	switch rec.op {
+	case opPut:
+		f.kv.put(rec.key, rec.value)
+	case opDelete:
+		f.kv.delete(rec.key)
+	case opClear:
+		f.kv.clear()
+	case opBatch:
+		ops, err := decodeBatch(rec.value, f.opts.MaxRecordSize)
+		if err != nil {
+			return err
+		}
+		f.kv.apply(ops)
+	}
+	return nil
+}
+
+// ============= Recovery =============
+
+func logName(seq int) string {
+	return fmt.Sprintf("wal-%06d.log", seq)
+}
+
+// recover: snapshot + журнали з номером >= записаного в snapshot.
+// Обірваний хвіст допустимий лише в останньому журналі - лише туди
+// писали в момент збою. Пошкодження в snapshot чи старшому журналі - ErrCorrupt.
+func (f *FileStorage) recover() error {
+	firstLog := 1
+	if snap, err := os.Open(filepath.Join(f.dir, snapshotName)); err == nil {
+		next, err := f.loadSnapshot(snap)
+		snap.Close()
+		if err != nil {
+			return err
+		}
+		firstLog = next
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	seqs, err := f.listLogs()
+	if err != nil {
+		return err
+	}
+	var live []int
+	for _, seq := range seqs {
+		if seq >= firstLog {
+			live = append(live, seq)
+		} else {
+			// Компакція впала після rename snapshot, але до видалення старих журналів
+			os.Remove(filepath.Join(f.dir, logName(seq)))
+		}
+	}
+
+	for i, seq := range live {
+		last := i == len(live)-1
+		good, err := f.replay(seq, last)
+		if err != nil {
+			return err
+		}
+		if last {
+			return f.openLog(seq, good)
+		}
+	}
+	return f.openLog(firstLog, 0)
+}
+
+func (f *FileStorage) loadSnapshot(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	head, _, err := readRecord(br, f.opts.MaxRecordSize)
+	if err != nil || head.op != opSnapshot {
+		return 0, fmt.Errorf("%s: %w", snapshotName, ErrCorrupt)
+	}
+	var next int
+	var rev Version
+	if _, err := fmt.Sscanf(head.value, "%d %d", &next, &rev); err != nil {
+		return 0, fmt.Errorf("%s: %w", snapshotName, ErrCorrupt)
+	}
+
+	// Версії окремих ключів snapshot не зберігає: усі отримують його
+	// ревізію. Вона не менша за справжню версію, тож старий CAS
+	// отримає конфлікт, але ніколи не пройде помилково.
+	f.kv.rev = rev
+	f.kv.hub.reset(rev)
+	for {
+		rec, _, err := readRecord(br, f.opts.MaxRecordSize)
+		if err == io.EOF {
+			return next, nil
+		}
+		if err != nil || rec.op != opPut {
+			return 0, fmt.Errorf("%s: %w", snapshotName, ErrCorrupt)
+		}
+		f.kv.set(rec.key, rec.value, rev)
+	}
+}
+
+// replay повертає довжину коректної частини журналу
+func (f *FileStorage) replay(seq int, last bool) (int64, error) {
+	file, err := os.Open(filepath.Join(f.dir, logName(seq)))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	var good int64
+	for {
+		rec, n, err := readRecord(br, f.opts.MaxRecordSize)
+		if err == io.EOF {
+			return good, nil
+		}
+		if err != nil {
+			if !last {
+				return 0, fmt.Errorf("%s at offset %d: %w", logName(seq), good, err)
+			}
+			info, _ := file.Stat()
+			f.truncated = info.Size() - good
+			return good, nil
+		}
+		if err := f.apply(rec); err != nil {
+			return 0, fmt.Errorf("%s at offset %d: %w", logName(seq), good, err)
+		}
+		good += int64(n)
+	}
+}
+
+func (f *FileStorage) openLog(seq int, size int64) error {
+	path := filepath.Join(f.dir, logName(seq))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	// Відрізаємо обірваний хвіст, інакше нові записи підуть після сміття
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	if err := syncDir(f.dir); err != nil {
+		file.Close()
+		return err
+	}
+	f.log, f.seq, f.logBytes = file, seq, size
+	return nil
+}
+
+func (f *FileStorage) listLogs() ([]int, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "wal-") || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		if seq, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "wal-"), ".log")); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+	return seqs, nil
+}
+
+// syncDir - fsync каталогу: без нього створений чи перейменований файл
+// може "зникнути" після збою, навіть якщо сам файл синхронізовано
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// ============= Compaction =============
+
+// Compact замінює журнали snapshot'ом живих даних. Записи під час
+// компакції не блокуються: вони йдуть у новий журнал.
+func (f *FileStorage) Compact() error {
+	f.compactMu.Lock()
+	defer f.compactMu.Unlock()
+
+	// 1. Під локом: закрити поточний журнал, почати новий, скопіювати дані
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return ErrClosed
+	}
+	if err := f.log.Sync(); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	oldLog, next := f.log, f.seq+1
+	if err := f.openLog(next, 0); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	oldLog.Close()
+	data, rev := maps.Clone(f.kv.data), f.kv.rev
+	f.mu.Unlock()
+
+	// 2. Без локу: snapshot -> tmp, fsync, rename, fsync каталогу
+	if err := f.writeSnapshot(data, next, rev); err != nil {
+		return err
+	}
+
+	// 3. Старі журнали більше не потрібні
+	seqs, err := f.listLogs()
+	if err != nil {
+		return err
+	}
+	for _, seq := range seqs {
+		if seq < next {
+			os.Remove(filepath.Join(f.dir, logName(seq)))
+		}
+	}
+	return nil
+}
+
+func (f *FileStorage) writeSnapshot(data map[string]string, next int, rev Version) error {
+	tmp, err := os.CreateTemp(f.dir, snapshotName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // після успішного rename - no-op
+
+	w := bufio.NewWriter(tmp)
+	w.Write(encodeRecord(opSnapshot, "", fmt.Sprintf("%d %d", next, rev)))
+	for key, value := range data {
+		w.Write(encodeRecord(opPut, key, value))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(f.dir, snapshotName)); err != nil {
+		return err
+	}
+	return syncDir(f.dir)
+}
+
+func (f *FileStorage) maybeCompactLocked() {
+	if f.opts.CompactThreshold <= 0 || f.logBytes < f.opts.CompactThreshold || f.logBytes < 2*f.kv.bytes {
+		return
+	}
+	select {
+	case f.compactCh <- struct{}{}:
+	default: // компакція вже запланована
+	}
+}
+
+func (f *FileStorage) background() {
+	defer f.wg.Done()
+
+	var tick <-chan time.Time
+	if f.opts.Sync == SyncInterval {
+		ticker := time.NewTicker(f.opts.SyncInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-tick:
+			f.mu.Lock()
+			if !f.closed {
+				f.log.Sync()
+			}
+			f.mu.Unlock()
+		case <-f.compactCh:
+			f.Compact()
+		}
+	}
+}
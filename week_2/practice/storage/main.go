@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+func main() {
+	fmt.Println("╔══════════════════════════════════════════╗")
+	fmt.Println("║        Storage: production backends      ║")
+	fmt.Println("╚══════════════════════════════════════════╝")
+
+	demoCache()
+	demoFileStorage()
+	demoTransactions()
+	demoOrdered()
+	demoWatch()
+	demoRepository()
+}
+
+func demoCache() {
+	logEvict := func(key, value string, reason EvictReason) {
+		fmt.Printf("   🗑️  %s: %s\n", reason, key)
+	}
+
+	fmt.Println("\n📦 1. LRU, MaxEntries=3:")
+	lru := NewCache(CacheOptions{MaxEntries: 3, OnEvict: logEvict})
+	lru.Save("user:1", "Alice")
+	lru.Save("user:2", "Bob")
+	lru.Save("user:3", "Carol")
+	lru.Load("user:1") // user:1 щойно використаний
+	lru.Save("user:4", "Dave")
+	fmt.Println("   ключі:", sortedKeys(lru))
+
+	fmt.Println("\n📊 2. LFU: частий ключ переживає сканування:")
+	lfu := NewCache(CacheOptions{MaxEntries: 3, Policy: NewLFU(), OnEvict: logEvict})
+	lfu.Save("config", "v1")
+	for range 5 {
+		lfu.Load("config")
+	}
+	for i := range 4 {
+		lfu.Save("scan:"+strconv.Itoa(i), "x")
+	}
+	fmt.Println("   ключі:", sortedKeys(lfu))
+
+	fmt.Println("\n💾 3. Бюджет у байтах, MaxBytes=32:")
+	budget := NewCache(CacheOptions{MaxBytes: 32, OnEvict: logEvict})
+	budget.Save("a", "0123456789")
+	budget.Save("b", "0123456789")
+	budget.Save("c", "0123456789")
+	fmt.Printf("   %d записів, %d байт\n", budget.Stats().Entries, budget.Stats().Bytes)
+	if err := budget.Save("huge", string(make([]byte, 64))); err != nil {
+		fmt.Println("   huge:", err)
+	}
+
+	fmt.Println("\n⏱️  4. TTL і janitor:")
+	ttl := NewCache(CacheOptions{DefaultTTL: 50 * time.Millisecond, JanitorInterval: 20 * time.Millisecond, OnEvict: logEvict})
+	defer ttl.Close()
+	ttl.Save("session:abc", "user:1")
+	ttl.SaveTTL("token", "xyz", time.Hour)
+	left, _ := ttl.TTL("session:abc")
+	fmt.Printf("   session:abc живе ще ~%v\n", left.Round(10*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	fmt.Println("   після 100ms:", sortedKeys(ttl))
+
+	fmt.Println("\n🏃 5. 8 горутин x 1000 операцій (без гонок, go test -race):")
+	shared := NewCache(CacheOptions{MaxEntries: 100, Policy: NewLFU()})
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				key := "k" + strconv.Itoa((g*7+i)%150)
+				if _, err := shared.Load(key); err != nil {
+					shared.Save(key, "v")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	stats := shared.Stats()
+	fmt.Printf("   hits=%d misses=%d evictions=%d hit rate=%.0f%% entries=%d\n",
+		stats.Hits, stats.Misses, stats.Evictions, stats.HitRate()*100, stats.Entries)
+
+	fmt.Println("\n🎯 6. LRUCache з week_18/middle:")
+	cache := NewLRUCache(2)
+	cache.Put("1", "one")
+	cache.Put("2", "two")
+	cache.Get("1")
+	cache.Put("3", "three")
+	_, has2 := cache.Get("2")
+	one, _ := cache.Get("1")
+	fmt.Printf("   get(2) знайдено=%v, get(1)=%s\n", has2, one)
+}
+
+func demoFileStorage() {
+	dir, err := os.MkdirTemp("", "filestorage-demo")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Println("\n📝 7. FileStorage: '=' і переноси рядків більше не ламають файл:")
+	fs, err := OpenFileStorage(dir, FileOptions{})
+	if err != nil {
+		panic(err)
+	}
+	fs.Save("query", "a=1&b=2")
+	fs.Save("note", "рядок 1\nрядок 2")
+	fs.Close()
+	fs, _ = OpenFileStorage(dir, FileOptions{})
+	note, _ := fs.Load("note")
+	query, _ := fs.Load("query")
+	fmt.Printf("   query=%q note=%q\n", query, note)
+	fs.Close()
+
+	fmt.Println("\n💥 8. Обірваний запис (збій посеред write):")
+	logFile, _ := os.OpenFile(filepath.Join(dir, logName(1)), os.O_APPEND|os.O_WRONLY, 0)
+	torn := encodeRecord(opPut, "half", "written")
+	logFile.Write(torn[:len(torn)/2])
+	logFile.Close()
+	fs, err = OpenFileStorage(dir, FileOptions{})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("   відновлено ключі %v, відрізано %d байт хвоста\n", sortedKeys(fs), fs.Stats().Truncated)
+
+	fmt.Println("\n🗜️  9. Компакція:")
+	for i := range 1000 {
+		fs.Save("counter", strconv.Itoa(i))
+	}
+	before := fs.Stats().LogBytes
+	fs.Compact()
+	snap, _ := os.Stat(filepath.Join(dir, snapshotName))
+	fmt.Printf("   журнал %d байт -> snapshot %d байт + новий журнал %d байт\n", before, snap.Size(), fs.Stats().LogBytes)
+	fs.Close()
+
+	fmt.Println("\n⏱️  10. Ціна fsync (200 записів):")
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		pdir, _ := os.MkdirTemp(dir, policy.String())
+		pfs, _ := OpenFileStorage(pdir, FileOptions{Sync: policy})
+		start := time.Now()
+		for i := range 200 {
+			pfs.Save(strconv.Itoa(i), "value")
+		}
+		fmt.Printf("   %-8s %v\n", policy, time.Since(start).Round(time.Microsecond))
+		pfs.Close()
+	}
+}
+
+func demoTransactions() {
+	fmt.Println("\n🔁 11. Лічильник: Load+Save проти Update (8 горутин x 100):")
+	naive := NewMemoryStorage()
+	safe := NewMemoryStorage()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				v, _ := naive.Load("visits")
+				n, _ := strconv.Atoi(v)
+				runtime.Gosched() // вікно між читанням і записом, як у реальному сервісі
+				naive.Save("visits", strconv.Itoa(n+1))
+
+				for {
+					err := Update(safe, func(tx *Txn) error {
+						v, _ := tx.Load("visits")
+						n, _ := strconv.Atoi(v)
+						tx.Save("visits", strconv.Itoa(n+1))
+						return nil
+					})
+					if !errors.Is(err, ErrConflict) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	lost, _ := naive.Load("visits")
+	counted, _ := safe.Load("visits")
+	fmt.Printf("   Load+Save: %s з 800, Update: %s з 800\n", lost, counted)
+
+	fmt.Println("\n🔒 12. Compare-and-swap:")
+	s := NewMemoryStorage()
+	v1, _ := CompareAndSwap(s, "leader", 0, "node-a")
+	_, err := CompareAndSwap(s, "leader", 0, "node-b")
+	fmt.Printf("   node-a: версія %d; node-b: %v\n", v1, err)
+
+	fmt.Println("\n📦 13. Резервування: залишок і резерв одним пакетом:")
+	s.Save("stock:sku-1", "1")
+	reserve := func(order string) error {
+		return Update(s, func(tx *Txn) error {
+			v, _ := tx.Load("stock:sku-1")
+			stock, _ := strconv.Atoi(v)
+			if stock == 0 {
+				return errors.New("out of stock")
+			}
+			tx.Save("stock:sku-1", strconv.Itoa(stock-1))
+			tx.Save("reservation:"+order, "sku-1")
+			return nil
+		})
+	}
+	fmt.Println("   order-1:", reserve("order-1"))
+	fmt.Println("   order-2:", reserve("order-2"))
+	fmt.Println("   ключі:", sortedKeys(s))
+
+	fmt.Println("\n🧪 14. MockStorage: підкинутий конфлікт:")
+	mock := NewMockStorage()
+	mock.InjectConflicts(2)
+	attempts := 0
+	Update(mock, func(tx *Txn) error {
+		attempts++
+		tx.Load("k")
+		tx.Save("k", "v")
+		return nil
+	})
+	fmt.Printf("   спроб: %d, %s\n", attempts, mock.Stats())
+}
+
+func demoOrdered() {
+	o := NewOrderedStorage()
+	for tenant := range 3 {
+		for user := range 1000 {
+			o.Save(fmt.Sprintf("tenant:%d:user:%04d", tenant, user), fmt.Sprintf("user-%d-%d", tenant, user))
+		}
+	}
+
+	fmt.Println("\n🔎 15. Scan(\"tenant:1:\") без копіювання 3000 ключів:")
+	n := 0
+	for key, value := range o.Scan("tenant:1:") {
+		if n < 3 {
+			fmt.Printf("   %s = %s\n", key, value)
+		}
+		n++
+	}
+	fmt.Printf("   ... усього %d\n", n)
+
+	fmt.Println("\n↔️  16. Range і зворотний порядок:")
+	fmt.Print("   [tenant:2:user:0100, tenant:2:user:0103):")
+	for key := range o.Range("tenant:2:user:0100", "tenant:2:user:0103") {
+		fmt.Print(" ", key)
+	}
+	fmt.Print("\n   останні 3 tenant:0:")
+	n = 0
+	for key := range o.Iter(Query{Prefix: "tenant:0:", Reverse: true}) {
+		fmt.Print(" ", key)
+		if n++; n == 3 {
+			break
+		}
+	}
+	fmt.Println()
+
+	fmt.Println("\n📄 17. Пагінація курсорами (по 400):")
+	q := Query{Prefix: "tenant:1:", Limit: 400}
+	for {
+		page, err := o.Page(q)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("   %d записів: %s .. %s, next=%q\n", len(page.Items), page.Items[0].Key, page.Items[len(page.Items)-1].Key, page.Next)
+		if page.Next == "" {
+			break
+		}
+		q.Cursor = page.Next
+	}
+}
+
+func demoWatch() {
+	s := NewMemoryStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fmt.Println("\n👀 18. Watch(\"config:\") - push замість опитування:")
+	events, _ := s.Watch(ctx, "config:", WatchOptions{Prefix: true})
+	s.Save("config:timeout", "5s")
+	s.Save("user:1", "Alice") // не під префіксом
+	PutAll(s, map[string]string{"config:retries": "3"})
+	s.Delete("config:timeout")
+	for range 3 {
+		ev := <-events
+		fmt.Printf("   rev=%d %-6s %s %s\n", ev.Revision, ev.Type, ev.Key, ev.Value)
+	}
+
+	fmt.Println("\n🔌 19. Повільний watcher відключається, потім продовжує з ревізії:")
+	slow, _ := s.Watch(ctx, "counter", WatchOptions{Buffer: 4})
+	for i := range 20 {
+		s.Save("counter", strconv.Itoa(i))
+	}
+	var resume Version
+	got := 0
+	for ev := range slow {
+		if ev.Type == EventDisconnected {
+			resume = ev.Revision + 1
+			fmt.Printf("   отримано %d, відключено; продовжуємо з rev=%d\n", got, resume)
+			break
+		}
+		got++
+	}
+	again, _ := s.Watch(ctx, "counter", WatchOptions{StartRevision: resume})
+	var last Event
+	for got < 20 {
+		last = <-again
+		got++
+	}
+	fmt.Printf("   дочитано до counter=%s, усього %d без пропусків\n", last.Value, got)
+
+	fmt.Println("\n⏰ 20. Cache: expire та evict:")
+	clock := time.Now()
+	cache := NewCache(CacheOptions{MaxEntries: 1, now: func() time.Time { return clock }})
+	cacheEvents, _ := cache.Watch(ctx, "", WatchOptions{Prefix: true})
+	cache.SaveTTL("token", "abc", time.Second)
+	cache.Save("session", "xyz") // витісняє token
+	cache.SaveTTL("otp", "123", time.Second)
+	clock = clock.Add(2 * time.Second)
+	cache.DeleteExpired()
+	for range 6 {
+		ev := <-cacheEvents
+		fmt.Printf("   rev=%d %-6s %s\n", ev.Revision, ev.Type, ev.Key)
+	}
+}
+
+func demoRepository() {
+	dir, err := os.MkdirTemp("", "users-demo")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Println("\n👥 21. UserService поверх Repository[int, User] і FileStorage:")
+	fs, _ := OpenFileStorage(dir, FileOptions{})
+	var svc UserService = NewStorageUserService(fs, nil)
+	for _, u := range [][2]string{{"alice", "alice@example.com"}, {"bob", "bob@example.com"}, {"eve", "ALICE@example.com"}, {"", "x@example.com"}} {
+		user, err := svc.Create(u[0], u[1])
+		if err != nil {
+			var conflict *ConflictError
+			fmt.Printf("   ❌ %-6s %v (ConflictError: %v)\n", u[0], err, errors.As(err, &conflict))
+			continue
+		}
+		fmt.Println("   ✅", user)
+	}
+	svc.Deactivate(2)
+	_, err = svc.GetByID(42)
+	var notFound *NotFoundError
+	fmt.Printf("   GetByID(42): %v (NotFoundError: %v)\n", err, errors.As(err, &notFound))
+
+	fmt.Println("\n🗝️  22. Що лежить у сховищі:")
+	for _, key := range sortedKeys(fs) {
+		value, _ := fs.Load(key)
+		if len(value) > 50 {
+			value = value[:50] + "..."
+		}
+		fmt.Printf("   %-34s %s\n", key, value)
+	}
+
+	fmt.Println("\n🔄 23. Після перезапуску нумерація продовжується:")
+	fs.Close()
+	fs, _ = OpenFileStorage(dir, FileOptions{})
+	defer fs.Close()
+	svc = NewStorageUserService(fs, nil)
+	carol, _ := svc.Create("carol", "carol@example.com")
+	users, _ := svc.GetAll()
+	fmt.Println("   новий:", carol)
+	fmt.Printf("   усього %d користувачів\n", len(users))
+}
+
+func sortedKeys(s Storage) []string {
+	keys := s.Keys()
+	sort.Strings(keys)
+	return keys
+}
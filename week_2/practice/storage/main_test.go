@@ -0,0 +1,1802 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock - керований час для TTL
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func keysOf(s Storage) []string {
+	keys := s.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ============= Storage contract =============
+
+func TestStorageContract(t *testing.T) {
+	backends := map[string]func(t *testing.T) Storage{
+		"memory":  func(t *testing.T) Storage { return NewMemoryStorage() },
+		"cache":   func(t *testing.T) Storage { return NewCache(CacheOptions{}) },
+		"file":    func(t *testing.T) Storage { return openFile(t, t.TempDir(), FileOptions{}) },
+		"mock":    func(t *testing.T) Storage { return NewMockStorage() },
+		"ordered": func(t *testing.T) Storage { return NewOrderedStorage() },
+	}
+	for name, newStorage := range backends {
+		t.Run(name, func(t *testing.T) {
+			s := newStorage(t)
+			s.Save("a", "1")
+			s.Save("b", "2")
+			s.Save("a", "3")
+
+			if v, err := s.Load("a"); err != nil || v != "3" {
+				t.Errorf("Expected 3, got %q %v", v, err)
+			}
+			if _, err := s.Load("missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+			if err := s.Delete("missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound on delete, got %v", err)
+			}
+			if !s.Exists("b") || s.Exists("missing") {
+				t.Error("Expected Exists to match contents")
+			}
+			if got := keysOf(s); !equalKeys(got, []string{"a", "b"}) {
+				t.Errorf("Expected [a b], got %v", got)
+			}
+			s.Delete("a")
+			s.Clear()
+			if len(s.Keys()) != 0 {
+				t.Errorf("Expected empty after Clear, got %v", s.Keys())
+			}
+		})
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	backends := map[string]Storage{
+		"memory":  NewMemoryStorage(),
+		"lru":     NewCache(CacheOptions{MaxEntries: 50}),
+		"lfu":     NewCache(CacheOptions{MaxEntries: 50, Policy: NewLFU(), DefaultTTL: time.Millisecond, JanitorInterval: time.Millisecond}),
+		"file":    openFile(t, t.TempDir(), FileOptions{Sync: SyncNever, CompactThreshold: 4096}),
+		"mock":    NewMockStorage(),
+		"ordered": NewOrderedStorage(),
+	}
+	for name, s := range backends {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for g := range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range 500 {
+						key := strconv.Itoa((g + i) % 80)
+						s.Save(key, "v")
+						s.Load(key)
+						s.Exists(key)
+						if i%10 == 0 {
+							s.Delete(key)
+							s.Keys()
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			if c, ok := s.(*Cache); ok {
+				c.Close()
+				if n := c.Stats().Entries; n > 50 {
+					t.Errorf("Expected at most 50 entries, got %d", n)
+				}
+			}
+		})
+	}
+}
+
+// ============= Eviction =============
+
+// Тест-кейси з week_18/middle/01_lru_cache.md
+func TestLRUCacheExercise(t *testing.T) {
+	t.Run("basic usage", func(t *testing.T) {
+		cache := NewLRUCache(2)
+		cache.Put("1", "one")
+		cache.Put("2", "two")
+		if v, _ := cache.Get("1"); v != "one" {
+			t.Errorf("Expected one, got %q", v)
+		}
+		cache.Put("3", "three")
+		if _, ok := cache.Get("2"); ok {
+			t.Error("Expected 2 to be evicted")
+		}
+	})
+
+	t.Run("update existing key", func(t *testing.T) {
+		cache := NewLRUCache(2)
+		cache.Put("1", "one")
+		cache.Put("2", "two")
+		cache.Put("1", "ONE")
+		if v, _ := cache.Get("1"); v != "ONE" {
+			t.Errorf("Expected ONE, got %q", v)
+		}
+	})
+
+	t.Run("get updates recency", func(t *testing.T) {
+		cache := NewLRUCache(2)
+		cache.Put("1", "one")
+		cache.Put("2", "two")
+		cache.Get("1")
+		cache.Put("3", "three")
+		if _, ok := cache.Get("2"); ok {
+			t.Error("Expected 2 to be evicted")
+		}
+		if v, _ := cache.Get("1"); v != "one" {
+			t.Errorf("Expected one, got %q", v)
+		}
+	})
+}
+
+func TestLFUEviction(t *testing.T) {
+	c := NewCache(CacheOptions{MaxEntries: 3, Policy: NewLFU()})
+	c.Save("hot", "1")
+	c.Save("warm", "2")
+	c.Save("cold", "3")
+	for range 3 {
+		c.Load("hot")
+	}
+	c.Load("warm")
+
+	c.Save("new1", "4") // витісняє cold (частота 1, найдавніший)
+	if got := keysOf(c); !equalKeys(got, []string{"hot", "new1", "warm"}) {
+		t.Errorf("Expected [hot new1 warm], got %v", got)
+	}
+
+	// Щойно записаний ключ з мінімальною частотою не витісняє сам себе
+	c.Save("new2", "5")
+	if got := keysOf(c); !equalKeys(got, []string{"hot", "new2", "warm"}) {
+		t.Errorf("Expected [hot new2 warm], got %v", got)
+	}
+
+	// Після видалення мінімальна частота шукається заново
+	c.Delete("new2")
+	c.Delete("warm")
+	c.Save("x", "6")
+	c.Save("y", "7")
+	if got := keysOf(c); !equalKeys(got, []string{"hot", "x", "y"}) {
+		t.Errorf("Expected [hot x y], got %v", got)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	var evicted []string
+	c := NewCache(CacheOptions{MaxBytes: 10, OnEvict: func(key, value string, reason EvictReason) {
+		evicted = append(evicted, key+":"+string(reason))
+	}})
+
+	c.Save("a", "1234") // 5 байт
+	c.Save("b", "1234") // 10
+	c.Save("c", "12")   // 13 -> витісняє a
+	if got := keysOf(c); !equalKeys(got, []string{"b", "c"}) {
+		t.Errorf("Expected [b c], got %v", got)
+	}
+	if stats := c.Stats(); stats.Bytes != 8 || stats.Evictions != 1 {
+		t.Errorf("Expected 8 bytes and 1 eviction, got %+v", stats)
+	}
+
+	// Перезапис зменшує зайняте
+	c.Save("b", "")
+	if stats := c.Stats(); stats.Bytes != 4 {
+		t.Errorf("Expected 4 bytes, got %d", stats.Bytes)
+	}
+
+	if err := c.Save("big", "0123456789"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+	if len(evicted) != 1 || evicted[0] != "a:evicted" {
+		t.Errorf("Expected [a:evicted], got %v", evicted)
+	}
+}
+
+// ============= TTL =============
+
+func TestLazyExpiry(t *testing.T) {
+	clock := newFakeClock()
+	var reasons []EvictReason
+	c := NewCache(CacheOptions{DefaultTTL: time.Minute, now: clock.Now, OnEvict: func(key, value string, reason EvictReason) {
+		reasons = append(reasons, reason)
+	}})
+
+	c.Save("session", "s1")
+	c.SaveTTL("forever", "f", 0)
+	c.SaveTTL("short", "x", time.Second)
+
+	if ttl, ok := c.TTL("session"); !ok || ttl != time.Minute {
+		t.Errorf("Expected 1m, got %v %v", ttl, ok)
+	}
+	if _, ok := c.TTL("forever"); ok {
+		t.Error("Expected no TTL for forever")
+	}
+
+	clock.Advance(2 * time.Second)
+	if c.Exists("short") {
+		t.Error("Expected short to be expired")
+	}
+	if _, err := c.Load("short"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	// Перезапис без TTL знімає термін
+	c.SaveTTL("session", "s2", 0)
+	clock.Advance(time.Hour)
+	if got := keysOf(c); !equalKeys(got, []string{"forever", "session"}) {
+		t.Errorf("Expected [forever session], got %v", got)
+	}
+
+	stats := c.Stats()
+	if stats.Expirations != 1 || stats.Misses != 1 || len(reasons) != 1 || reasons[0] != ReasonExpired {
+		t.Errorf("Expected 1 expiration and 1 miss, got %+v %v", stats, reasons)
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	clock := newFakeClock()
+	c := NewCache(CacheOptions{now: clock.Now})
+
+	c.SaveTTL("a", "1", time.Second)
+	c.SaveTTL("b", "2", 3*time.Second)
+	c.SaveTTL("a", "1", 5*time.Second) // старий запис у купі застарів
+	c.SaveTTL("gone", "3", time.Second)
+	c.Delete("gone")
+
+	clock.Advance(2 * time.Second)
+	if n := c.DeleteExpired(); n != 0 {
+		t.Errorf("Expected 0 removed, got %d", n)
+	}
+	clock.Advance(2 * time.Second)
+	if n := c.DeleteExpired(); n != 1 || c.Stats().Entries != 1 {
+		t.Errorf("Expected b removed, got %d, entries %d", n, c.Stats().Entries)
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	expired := make(chan string, 10)
+	c := NewCache(CacheOptions{
+		DefaultTTL:      20 * time.Millisecond,
+		JanitorInterval: 5 * time.Millisecond,
+		OnEvict:         func(key, value string, reason EvictReason) { expired <- key },
+	})
+	defer c.Close()
+
+	c.Save("temp", "1")
+	select {
+	case key := <-expired:
+		if key != "temp" {
+			t.Errorf("Expected temp, got %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected janitor to remove expired key without access")
+	}
+	if c.Stats().Entries != 0 {
+		t.Error("Expected empty cache")
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := NewCache(CacheOptions{MaxEntries: 1})
+	c.Save("a", "1")
+	c.Load("a")
+	c.Load("a")
+	c.Load("b")
+	c.Save("b", "2")
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if rate := stats.HitRate(); rate < 0.66 || rate > 0.67 {
+		t.Errorf("Expected hit rate 2/3, got %f", rate)
+	}
+}
+
+// ============= File Storage =============
+
+func openFile(t *testing.T, dir string, opts FileOptions) *FileStorage {
+	t.Helper()
+	fs, err := OpenFileStorage(dir, opts)
+	if err != nil {
+		t.Fatalf("OpenFileStorage: %v", err)
+	}
+	t.Cleanup(func() { fs.Close() })
+	return fs
+}
+
+func reopen(t *testing.T, fs *FileStorage, opts FileOptions) *FileStorage {
+	t.Helper()
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return openFile(t, fs.dir, opts)
+}
+
+func TestFileStorageTrickyKeys(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+
+	data := map[string]string{
+		"a=b":        "c=d=e",
+		"line\nkey":  "multi\nline\nvalue",
+		"":           "empty key",
+		"empty":      "",
+		"binary\x00": "\xff\x00\r\n",
+	}
+	for k, v := range data {
+		if err := fs.Save(k, v); err != nil {
+			t.Fatalf("Save(%q): %v", k, err)
+		}
+	}
+
+	fs = reopen(t, fs, FileOptions{})
+	for k, want := range data {
+		if got, err := fs.Load(k); err != nil || got != want {
+			t.Errorf("Load(%q): expected %q, got %q %v", k, want, got, err)
+		}
+	}
+}
+
+func TestFileStorageReplay(t *testing.T) {
+	fs := openFile(t, t.TempDir(), FileOptions{})
+	fs.Save("a", "1")
+	fs.Save("b", "2")
+	fs.Save("a", "3")
+	fs.Delete("b")
+	fs.Clear()
+	fs.Save("c", "4")
+
+	fs = reopen(t, fs, FileOptions{})
+	if got := keysOf(fs); !equalKeys(got, []string{"c"}) {
+		t.Errorf("Expected [c], got %v", got)
+	}
+	if st := fs.Stats(); st.LiveBytes != 2 {
+		t.Errorf("Expected LiveBytes 2, got %d", st.LiveBytes)
+	}
+}
+
+// Збій посеред запису: обрізаємо останній запис на кожному можливому байті
+func TestFileStorageTornTail(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	fs.Save("keep", "ok")
+	fs.Close()
+
+	logPath := filepath.Join(dir, logName(1))
+	good, _ := os.ReadFile(logPath)
+	last := encodeRecord(opPut, "lost", "value")
+
+	for cut := 1; cut < len(last); cut++ {
+		os.WriteFile(logPath, append(append([]byte{}, good...), last[:cut]...), 0o644)
+
+		fs, err := OpenFileStorage(dir, FileOptions{})
+		if err != nil {
+			t.Fatalf("cut %d: expected recovery, got %v", cut, err)
+		}
+		if got := keysOf(fs); !equalKeys(got, []string{"keep"}) {
+			t.Errorf("cut %d: expected [keep], got %v", cut, got)
+		}
+		if st := fs.Stats(); st.Truncated != int64(cut) {
+			t.Errorf("cut %d: expected %d truncated bytes, got %d", cut, cut, st.Truncated)
+		}
+		// Новий запис іде після коректної частини, а не після сміття
+		fs.Save("next", "1")
+		fs.Close()
+
+		fs, err = OpenFileStorage(dir, FileOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := keysOf(fs); !equalKeys(got, []string{"keep", "next"}) {
+			t.Errorf("cut %d: expected [keep next] after reopen, got %v", cut, got)
+		}
+		fs.Delete("next")
+		fs.Close()
+		os.WriteFile(logPath, good, 0o644)
+	}
+}
+
+func TestFileStorageChecksum(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	fs.Save("a", "1")
+	fs.Save("b", "2")
+	fs.Close()
+
+	// Перевернутий біт у значенні останнього запису
+	logPath := filepath.Join(dir, logName(1))
+	raw, _ := os.ReadFile(logPath)
+	raw[len(raw)-1] ^= 0x01
+	os.WriteFile(logPath, raw, 0o644)
+
+	fs = openFile(t, dir, FileOptions{})
+	if fs.Exists("b") {
+		t.Error("Expected corrupted record to be dropped")
+	}
+	if v, _ := fs.Load("a"); v != "1" {
+		t.Errorf("Expected a=1, got %q", v)
+	}
+}
+
+func TestFileStorageCorruptOlderLog(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	fs.Save("a", "1")
+	fs.Save("b", "2")
+	fs.Close()
+
+	// Пошкодження не в останньому журналі - це не torn write, мовчки
+	// відрізати не можна
+	logPath := filepath.Join(dir, logName(1))
+	raw, _ := os.ReadFile(logPath)
+	raw[len(raw)-1] ^= 0x01
+	os.WriteFile(logPath, raw, 0o644)
+	os.WriteFile(filepath.Join(dir, logName(2)), encodeRecord(opPut, "c", "3"), 0o644)
+
+	if _, err := OpenFileStorage(dir, FileOptions{}); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestFileStorageCompaction(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	for i := range 100 {
+		fs.Save("counter", strconv.Itoa(i))
+	}
+	fs.Save("gone", "x")
+	fs.Delete("gone")
+
+	if err := fs.Compact(); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	fs.Save("after", "1")
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !equalKeys(names, []string{snapshotName, logName(2)}) {
+		t.Errorf("Expected snapshot and one log, got %v", names)
+	}
+
+	fs = reopen(t, fs, FileOptions{})
+	if got := keysOf(fs); !equalKeys(got, []string{"after", "counter"}) {
+		t.Errorf("Expected [after counter], got %v", got)
+	}
+	if v, _ := fs.Load("counter"); v != "99" {
+		t.Errorf("Expected counter=99, got %q", v)
+	}
+}
+
+// Збій після rename snapshot, але до видалення старих журналів:
+// старий журнал не повинен програтися поверх snapshot
+func TestFileStorageCrashAfterSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	fs.Save("k", "old")
+	fs.Close()
+	stale, _ := os.ReadFile(filepath.Join(dir, logName(1)))
+
+	fs = openFile(t, dir, FileOptions{})
+	fs.Compact()
+	fs.Save("k", "new")
+	fs.Close()
+	os.WriteFile(filepath.Join(dir, logName(1)), stale, 0o644)
+
+	fs = openFile(t, dir, FileOptions{})
+	if v, _ := fs.Load("k"); v != "new" {
+		t.Errorf("Expected new, got %q", v)
+	}
+	if _, err := os.Stat(filepath.Join(dir, logName(1))); !os.IsNotExist(err) {
+		t.Error("Expected stale log to be removed")
+	}
+}
+
+func TestFileStorageAutoCompaction(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{Sync: SyncNever, CompactThreshold: 1024})
+
+	for i := range 500 {
+		fs.Save("k"+strconv.Itoa(i%5), strconv.Itoa(i))
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for fs.Stats().LogBytes >= 1024 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := fs.Stats(); st.LogBytes >= 1024 {
+		t.Errorf("Expected log to be compacted below threshold, got %d bytes", st.LogBytes)
+	}
+
+	fs = reopen(t, fs, FileOptions{})
+	for i := range 5 {
+		if v, _ := fs.Load("k" + strconv.Itoa(i)); v != strconv.Itoa(495+i) {
+			t.Errorf("Expected k%d=%d, got %q", i, 495+i, v)
+		}
+	}
+}
+
+func TestFileStorageCompactDuringWrites(t *testing.T) {
+	fs := openFile(t, t.TempDir(), FileOptions{Sync: SyncNever})
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				fs.Save("g"+strconv.Itoa(g), strconv.Itoa(i))
+			}
+		}()
+	}
+	for range 5 {
+		if err := fs.Compact(); err != nil {
+			t.Errorf("Compact: %v", err)
+		}
+	}
+	wg.Wait()
+
+	fs = reopen(t, fs, FileOptions{})
+	for g := range 4 {
+		if v, _ := fs.Load("g" + strconv.Itoa(g)); v != "199" {
+			t.Errorf("Expected g%d=199, got %q", g, v)
+		}
+	}
+}
+
+func TestFileStorageSyncPolicies(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		t.Run(policy.String(), func(t *testing.T) {
+			fs := openFile(t, t.TempDir(), FileOptions{Sync: policy, SyncInterval: time.Millisecond})
+			for i := range 20 {
+				if err := fs.Save(strconv.Itoa(i), "v"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			fs = reopen(t, fs, FileOptions{})
+			if n := fs.Stats().Keys; n != 20 {
+				t.Errorf("Expected 20 keys, got %d", n)
+			}
+		})
+	}
+}
+
+func TestFileStorageClosed(t *testing.T) {
+	fs := openFile(t, t.TempDir(), FileOptions{})
+	fs.Close()
+	if err := fs.Save("a", "1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if err := fs.Compact(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed from Compact, got %v", err)
+	}
+}
+
+// ============= Transactions =============
+
+func transactionalBackends(t *testing.T) map[string]Transactional {
+	return map[string]Transactional{
+		"memory":  NewMemoryStorage(),
+		"file":    openFile(t, t.TempDir(), FileOptions{Sync: SyncNever}),
+		"mock":    NewMockStorage(),
+		"ordered": NewOrderedStorage(),
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	for name, s := range transactionalBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, v, err := s.LoadVersion("k"); v != 0 || !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected version 0 and ErrNotFound, got %d %v", v, err)
+			}
+
+			v1, err := CompareAndSwap(s, "k", 0, "a")
+			if err != nil {
+				t.Fatalf("Expected create to succeed, got %v", err)
+			}
+			if _, err := CompareAndSwap(s, "k", 0, "b"); !errors.Is(err, ErrConflict) {
+				t.Errorf("Expected ErrConflict on second create, got %v", err)
+			}
+
+			v2, err := CompareAndSwap(s, "k", v1, "b")
+			if err != nil || v2 <= v1 {
+				t.Fatalf("Expected new version > %d, got %d %v", v1, v2, err)
+			}
+			if _, err := CompareAndSwap(s, "k", v1, "c"); !errors.Is(err, ErrConflict) {
+				t.Errorf("Expected ErrConflict on stale version, got %v", err)
+			}
+
+			// Видалений і знову створений ключ не повертається до старої версії
+			s.Delete("k")
+			s.Save("k", "b")
+			if _, v, _ := s.LoadVersion("k"); v == v2 {
+				t.Errorf("Expected fresh version after recreate, got %d again", v)
+			}
+			if value, _ := s.Load("k"); value != "b" {
+				t.Errorf("Expected b, got %q", value)
+			}
+		})
+	}
+}
+
+func TestBatchAtomic(t *testing.T) {
+	for name, s := range transactionalBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			s.Save("a", "1")
+			_, va, _ := s.LoadVersion("a")
+
+			// Умова не виконана - не застосовується жодна операція
+			_, err := s.Commit(NewBatch().IfVersion("a", va+100).Put("b", "2").Delete("a"))
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("Expected ErrConflict, got %v", err)
+			}
+			if s.Exists("b") || !s.Exists("a") {
+				t.Error("Expected failed batch to change nothing")
+			}
+
+			if err := PutAll(s, map[string]string{"x": "1", "y": "2", "z": "3"}); err != nil {
+				t.Fatal(err)
+			}
+			_, vx, _ := s.LoadVersion("x")
+			_, vz, _ := s.LoadVersion("z")
+			if vx != vz {
+				t.Errorf("Expected one revision for the batch, got %d and %d", vx, vz)
+			}
+
+			if err := DeleteAll(s, "x", "y", "missing"); err != nil {
+				t.Fatalf("Expected multi-delete to ignore missing keys, got %v", err)
+			}
+			if got := keysOf(s); !equalKeys(got, []string{"a", "z"}) {
+				t.Errorf("Expected [a z], got %v", got)
+			}
+		})
+	}
+}
+
+func TestUpdateCounter(t *testing.T) {
+	for name, s := range transactionalBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			incr := func(tx *Txn) error {
+				v, _ := tx.Load("counter")
+				n, _ := strconv.Atoi(v) // відсутній ключ - 0
+				tx.Save("counter", strconv.Itoa(n+1))
+				return nil
+			}
+
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range 25 {
+						err := Update(s, incr)
+						// На 8 горутинах 10 спроб іноді мало - повторюємо ззовні
+						for errors.Is(err, ErrConflict) {
+							err = Update(s, incr)
+						}
+						if err != nil {
+							t.Error(err)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if v, _ := s.Load("counter"); v != "200" {
+				t.Errorf("Expected 200, got %s", v)
+			}
+		})
+	}
+}
+
+// Резервування товару: залишок і резерв змінюються разом або ніяк
+func TestInventoryReservation(t *testing.T) {
+	s := NewMemoryStorage()
+	s.Save("stock:sku-1", "10")
+
+	reserve := func(order string) error {
+		return Update(s, func(tx *Txn) error {
+			v, err := tx.Load("stock:sku-1")
+			if err != nil {
+				return err
+			}
+			stock, _ := strconv.Atoi(v)
+			if stock == 0 {
+				return errors.New("out of stock")
+			}
+			tx.Save("stock:sku-1", strconv.Itoa(stock-1))
+			tx.Save("reservation:"+order, "sku-1")
+			return nil
+		})
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := reserve(strconv.Itoa(i))
+			for errors.Is(err, ErrConflict) {
+				err = reserve(strconv.Itoa(i))
+			}
+			if err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 10 {
+		t.Errorf("Expected 10 reservations, got %d", reserved)
+	}
+	if v, _ := s.Load("stock:sku-1"); v != "0" {
+		t.Errorf("Expected stock 0, got %s", v)
+	}
+	if n := s.Size(); n != 11 {
+		t.Errorf("Expected 10 reservations + stock, got %d keys", n)
+	}
+}
+
+func TestTxnReadYourWrites(t *testing.T) {
+	s := NewMemoryStorage()
+	s.Save("a", "1")
+
+	err := Update(s, func(tx *Txn) error {
+		tx.Save("a", "2")
+		if v, _ := tx.Load("a"); v != "2" {
+			t.Errorf("Expected own write 2, got %s", v)
+		}
+		tx.Delete("a")
+		if tx.Exists("a") {
+			t.Error("Expected own delete to be visible")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Exists("a") {
+		t.Error("Expected a to be deleted")
+	}
+
+	// Помилка з fn - нічого не комітиться і не повторюється
+	calls := 0
+	errAbort := errors.New("abort")
+	err = Update(s, func(tx *Txn) error {
+		calls++
+		tx.Save("b", "1")
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) || calls != 1 || s.Exists("b") {
+		t.Errorf("Expected abort without commit, got %v calls=%d", err, calls)
+	}
+}
+
+func TestMockInjectConflicts(t *testing.T) {
+	m := NewMockStorage()
+	m.Save("counter", "1")
+	m.InjectConflicts(2)
+
+	attempts := 0
+	err := Update(m, func(tx *Txn) error {
+		attempts++
+		v, _ := tx.Load("counter")
+		n, _ := strconv.Atoi(v)
+		tx.Save("counter", strconv.Itoa(n+1))
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success on 3rd attempt, got %v after %d", err, attempts)
+	}
+	if v, _ := m.Load("counter"); v != "2" {
+		t.Errorf("Expected 2, got %s", v)
+	}
+
+	m.InjectConflicts(MaxTxnAttempts)
+	err = Update(m, func(tx *Txn) error {
+		tx.Load("counter")
+		tx.Save("counter", "x")
+		return nil
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict after %d attempts, got %v", MaxTxnAttempts, err)
+	}
+	if stats := m.Stats(); !strings.Contains(stats, "Conflicts: 12") {
+		t.Errorf("Expected 12 conflicts in stats, got %s", stats)
+	}
+}
+
+// BeforeCommit імітує клієнта, що встиг записати між читанням і комітом
+func TestMockBeforeCommit(t *testing.T) {
+	m := NewMockStorage()
+	m.Save("balance", "100")
+
+	raced := false
+	m.BeforeCommit(func(b *Batch) {
+		if !raced {
+			raced = true
+			m.Save("balance", "50")
+		}
+	})
+
+	var seen []string
+	err := Update(m, func(tx *Txn) error {
+		v, _ := tx.Load("balance")
+		seen = append(seen, v)
+		n, _ := strconv.Atoi(v)
+		tx.Save("balance", strconv.Itoa(n-30))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalKeys(seen, []string{"100", "50"}) {
+		t.Errorf("Expected retry to see concurrent write, got %v", seen)
+	}
+	if v, _ := m.Load("balance"); v != "20" {
+		t.Errorf("Expected 20, got %s", v)
+	}
+}
+
+func TestMockShouldFail(t *testing.T) {
+	m := NewMockStorage()
+	m.SetShouldFail(true)
+	if err := m.Save("a", "1"); err == nil {
+		t.Error("Expected save error")
+	}
+	if _, err := m.Commit(NewBatch().Put("a", "1")); err == nil || errors.Is(err, ErrConflict) {
+		t.Errorf("Expected non-conflict error, got %v", err)
+	}
+}
+
+func TestFileStorageBatchDurable(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	fs.Save("a", "1")
+	PutAll(fs, map[string]string{"b": "2", "c": "3"})
+	_, vb, _ := fs.LoadVersion("b")
+
+	fs = reopen(t, fs, FileOptions{})
+	// Ревізії після програвання журналу ті самі, що бачили клієнти
+	if _, v, _ := fs.LoadVersion("b"); v != vb {
+		t.Errorf("Expected version %d after reopen, got %d", vb, v)
+	}
+	if _, err := CompareAndSwap(fs, "b", vb, "22"); err != nil {
+		t.Errorf("Expected CAS with pre-restart version to succeed, got %v", err)
+	}
+
+	// Обірваний пакет відкидається цілком
+	fs.Close()
+	logPath := filepath.Join(dir, logName(1))
+	good, _ := os.ReadFile(logPath)
+	torn := encodeBatch([]BatchOp{{Key: "x", Value: "1"}, {Key: "y", Value: "2"}})
+	os.WriteFile(logPath, append(good, torn[:len(torn)-1]...), 0o644)
+
+	fs = openFile(t, dir, FileOptions{})
+	if fs.Exists("x") || fs.Exists("y") {
+		t.Error("Expected torn batch to be dropped entirely")
+	}
+}
+
+func TestFileStorageVersionsAfterCompaction(t *testing.T) {
+	fs := openFile(t, t.TempDir(), FileOptions{})
+	fs.Save("a", "1")
+	_, stale, _ := fs.LoadVersion("a")
+	fs.Save("b", "2")
+	fs.Save("c", "3")
+	_, latest, _ := fs.LoadVersion("c")
+
+	fs.Compact()
+	fs = reopen(t, fs, FileOptions{})
+
+	// Зі snapshot версії грубіші: стара версія - конфлікт, а не хибний успіх
+	if _, err := CompareAndSwap(fs, "a", stale, "x"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected conflict for stale version, got %v", err)
+	}
+	if _, err := CompareAndSwap(fs, "c", latest, "x"); err != nil {
+		t.Errorf("Expected CAS on latest version to succeed, got %v", err)
+	}
+	fs.Save("d", "4")
+	if _, v, _ := fs.LoadVersion("d"); v <= latest {
+		t.Errorf("Expected revisions to keep growing, got %d <= %d", v, latest)
+	}
+}
+
+// ============= Ordered Storage =============
+
+func collect(seq iter.Seq2[string, string]) []string {
+	var keys []string
+	for key := range seq {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestSkipListMatchesSort(t *testing.T) {
+	sl := newSkipList()
+	want := map[string]bool{}
+	for i := range 2000 {
+		key := strconv.Itoa(rand.IntN(500))
+		if i%3 == 0 {
+			if sl.remove(key) != want[key] {
+				t.Fatalf("remove(%s) disagrees with model", key)
+			}
+			delete(want, key)
+		} else {
+			if sl.insert(key) == want[key] {
+				t.Fatalf("insert(%s) disagrees with model", key)
+			}
+			want[key] = true
+		}
+	}
+
+	var sorted []string
+	for key := range want {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var forward, backward []string
+	for n := sl.seekGE(""); n != nil; n = n.next[0] {
+		forward = append(forward, n.key)
+	}
+	for n := sl.last(); n != nil; n = n.prev {
+		backward = append([]string{n.key}, backward...)
+	}
+	if !equalKeys(forward, sorted) || !equalKeys(backward, sorted) || sl.length != len(sorted) {
+		t.Errorf("Expected %d sorted keys both ways, got %d/%d (length %d)", len(sorted), len(forward), len(backward), sl.length)
+	}
+}
+
+func newTenantStorage() *OrderedStorage {
+	o := NewOrderedStorage()
+	for _, key := range []string{"tenant:1:user:a", "tenant:1:user:b", "tenant:2:user:a", "tenant:10:user:a", "tenant:1:user:c", "config"} {
+		o.Save(key, strings.ToUpper(key))
+	}
+	return o
+}
+
+func TestOrderedQueries(t *testing.T) {
+	o := newTenantStorage()
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"prefix", Query{Prefix: "tenant:1:"}, []string{"tenant:1:user:a", "tenant:1:user:b", "tenant:1:user:c"}},
+		{"prefix reverse", Query{Prefix: "tenant:1:", Reverse: true}, []string{"tenant:1:user:c", "tenant:1:user:b", "tenant:1:user:a"}},
+		// Порядок побайтовий: ':' > '0', тож tenant:10 іде перед tenant:1:
+		{"range", Query{Start: "tenant:1", End: "tenant:1:user:c"}, []string{"tenant:10:user:a", "tenant:1:user:a", "tenant:1:user:b"}},
+		{"range reverse", Query{Start: "tenant:1", End: "tenant:1:user:c", Reverse: true}, []string{"tenant:1:user:b", "tenant:1:user:a", "tenant:10:user:a"}},
+		{"open end", Query{Start: "tenant:2"}, []string{"tenant:2:user:a"}},
+		{"prefix and range", Query{Prefix: "tenant:1", Start: "tenant:1:user:b", End: "tenant:1:user:c"}, []string{"tenant:1:user:b"}},
+		{"no match", Query{Prefix: "tenant:3:"}, nil},
+		{"all reverse", Query{Reverse: true}, []string{"tenant:2:user:a", "tenant:1:user:c", "tenant:1:user:b", "tenant:1:user:a", "tenant:10:user:a", "config"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collect(o.Iter(tt.q)); !equalKeys(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	for key, value := range o.Scan("config") {
+		if key != "config" || value != "CONFIG" {
+			t.Errorf("Expected config=CONFIG, got %s=%s", key, value)
+		}
+	}
+	if got := o.Keys(); !sort.StringsAreSorted(got) {
+		t.Errorf("Expected sorted Keys, got %v", got)
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	tests := map[string]string{
+		"user:":    "user;",
+		"a\xff":    "b",
+		"\xff\xff": "",
+	}
+	for prefix, want := range tests {
+		if got := prefixEnd(prefix); got != want {
+			t.Errorf("prefixEnd(%q): expected %q, got %q", prefix, want, got)
+		}
+	}
+}
+
+// Більше за iterChunk - перевіряє перехід між порціями
+func TestOrderedIterChunks(t *testing.T) {
+	o := NewOrderedStorage()
+	for i := range 1000 {
+		o.Save(fmt.Sprintf("k%04d", i), "v")
+	}
+
+	forward := collect(o.Scan("k"))
+	backward := collect(o.Iter(Query{Prefix: "k", Reverse: true}))
+	if len(forward) != 1000 || len(backward) != 1000 {
+		t.Fatalf("Expected 1000 keys both ways, got %d/%d", len(forward), len(backward))
+	}
+	for i := range forward {
+		if forward[i] != fmt.Sprintf("k%04d", i) || backward[999-i] != forward[i] {
+			t.Fatalf("Order broken at %d: %s / %s", i, forward[i], backward[999-i])
+		}
+	}
+
+	// break зупиняє ітератор
+	n := 0
+	for range o.Scan("k") {
+		n++
+		if n == 70 {
+			break
+		}
+	}
+	if n != 70 {
+		t.Errorf("Expected 70, got %d", n)
+	}
+}
+
+// Запис у сховище з тіла циклу не блокується і не дає повторів
+func TestOrderedWriteDuringIteration(t *testing.T) {
+	o := NewOrderedStorage()
+	for i := range 200 {
+		o.Save(fmt.Sprintf("job:%03d", i), "pending")
+	}
+
+	seen := map[string]bool{}
+	for key := range o.Scan("job:") {
+		if seen[key] {
+			t.Fatalf("Key %s yielded twice", key)
+		}
+		seen[key] = true
+		o.Save(key, "done")
+		o.Delete(key)
+	}
+	if len(seen) != 200 || o.Size() != 0 {
+		t.Errorf("Expected 200 processed and empty storage, got %d and %d", len(seen), o.Size())
+	}
+}
+
+func TestOrderedPagination(t *testing.T) {
+	o := NewOrderedStorage()
+	for i := range 25 {
+		o.Save(fmt.Sprintf("tenant:7:user:%02d", i), strconv.Itoa(i))
+	}
+	o.Save("tenant:8:user:00", "other")
+
+	for _, reverse := range []bool{false, true} {
+		var all []string
+		pages := 0
+		q := Query{Prefix: "tenant:7:", Limit: 10, Reverse: reverse}
+		for {
+			page, err := o.Page(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages++
+			for _, item := range page.Items {
+				all = append(all, item.Key)
+			}
+			if page.Next == "" {
+				break
+			}
+			q.Cursor = page.Next
+		}
+
+		if pages != 3 || len(all) != 25 {
+			t.Errorf("reverse=%v: expected 25 keys in 3 pages, got %d in %d", reverse, len(all), pages)
+		}
+		if sorted := sort.StringsAreSorted(all); sorted == reverse {
+			t.Errorf("reverse=%v: wrong order %v", reverse, all)
+		}
+	}
+
+	// Нові ключі між запитами не зсувають сторінки
+	first, _ := o.Page(Query{Prefix: "tenant:7:", Limit: 5})
+	o.Save("tenant:7:user:00a", "inserted")
+	o.Delete("tenant:7:user:01")
+	second, _ := o.Page(Query{Prefix: "tenant:7:", Limit: 5, Cursor: first.Next})
+	if second.Items[0].Key != "tenant:7:user:05" {
+		t.Errorf("Expected page 2 to start at user:05, got %s", second.Items[0].Key)
+	}
+
+	// Сторінка рівно до кінця - без курсора
+	exact, _ := o.Page(Query{Prefix: "tenant:8:", Limit: 1})
+	if len(exact.Items) != 1 || exact.Next != "" {
+		t.Errorf("Expected one item and no cursor, got %+v", exact)
+	}
+
+	if _, err := o.Page(Query{Cursor: "!!!"}); !errors.Is(err, ErrBadCursor) {
+		t.Errorf("Expected ErrBadCursor, got %v", err)
+	}
+	if _, err := o.Page(Query{Cursor: first.Next, Reverse: true}); !errors.Is(err, ErrBadCursor) {
+		t.Errorf("Expected ErrBadCursor for forward cursor in reverse query, got %v", err)
+	}
+}
+
+func TestOrderedCommitUpdatesIndex(t *testing.T) {
+	o := NewOrderedStorage()
+	o.Save("b", "1")
+	o.Commit(NewBatch().Put("a", "1").Put("c", "1").Delete("b"))
+	if got := collect(o.Range("", "")); !equalKeys(got, []string{"a", "c"}) {
+		t.Errorf("Expected [a c], got %v", got)
+	}
+	o.Clear()
+	if got := collect(o.Scan("")); len(got) != 0 {
+		t.Errorf("Expected empty after Clear, got %v", got)
+	}
+}
+
+// ============= Watch =============
+
+func recv(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("Expected event, channel closed")
+		}
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for event")
+	}
+	return Event{}
+}
+
+func expectClosed(t *testing.T, ch <-chan Event) {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if ok {
+			t.Fatalf("Expected closed channel, got %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for close")
+	}
+}
+
+func TestWatchBackends(t *testing.T) {
+	backends := map[string]interface {
+		Storage
+		Watchable
+	}{
+		"memory":  NewMemoryStorage(),
+		"file":    openFile(t, t.TempDir(), FileOptions{Sync: SyncNever}),
+		"mock":    NewMockStorage(),
+		"ordered": NewOrderedStorage(),
+		"cache":   NewCache(CacheOptions{}),
+	}
+	for name, s := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			exact, _ := s.Watch(ctx, "config", WatchOptions{})
+			prefix, _ := s.Watch(ctx, "user:", WatchOptions{Prefix: true})
+
+			s.Save("config", "v1")
+			s.Save("user:1", "Alice")
+			s.Save("other", "x")
+			s.Delete("user:1")
+			s.Save("config-backup", "v1") // не "config" - точний збіг
+
+			ev := recv(t, exact)
+			if ev.Type != EventPut || ev.Key != "config" || ev.Value != "v1" {
+				t.Errorf("Expected put config=v1, got %+v", ev)
+			}
+			put, del := recv(t, prefix), recv(t, prefix)
+			if put.Type != EventPut || put.Key != "user:1" || del.Type != EventDelete || del.Key != "user:1" {
+				t.Errorf("Expected put and delete of user:1, got %+v %+v", put, del)
+			}
+			if !(ev.Revision < put.Revision && put.Revision < del.Revision) {
+				t.Errorf("Expected increasing revisions, got %d %d %d", ev.Revision, put.Revision, del.Revision)
+			}
+
+			select {
+			case ev := <-exact:
+				t.Errorf("Expected no more events for config, got %+v", ev)
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			cancel()
+			expectClosed(t, exact)
+			expectClosed(t, prefix)
+		})
+	}
+}
+
+func TestWatchBatchOneRevision(t *testing.T) {
+	s := NewMemoryStorage()
+	s.Save("b", "old")
+	ch, _ := s.Watch(context.Background(), "", WatchOptions{Prefix: true})
+
+	rev, _ := s.Commit(NewBatch().Put("a", "1").Delete("b").Delete("missing"))
+	s.Clear()
+
+	a, b := recv(t, ch), recv(t, ch)
+	if a.Revision != rev || b.Revision != rev || b.Type != EventDelete {
+		t.Errorf("Expected put+delete at revision %d, got %+v %+v", rev, a, b)
+	}
+	if cleared := recv(t, ch); cleared.Type != EventDelete || cleared.Key != "a" || cleared.Revision != rev+1 {
+		t.Errorf("Expected Clear to delete a at %d, got %+v", rev+1, cleared)
+	}
+}
+
+func TestWatchResume(t *testing.T) {
+	s := NewOrderedStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, _ := s.Watch(ctx, "cfg:", WatchOptions{Prefix: true})
+
+	s.Save("cfg:a", "1")
+	last := recv(t, ch).Revision
+	cancel()
+	expectClosed(t, ch)
+
+	// Поки watcher'а немає
+	s.Save("cfg:b", "2")
+	s.Save("cfg:a", "3")
+
+	ch, err := s.Watch(context.Background(), "cfg:", WatchOptions{Prefix: true, StartRevision: last + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Save("cfg:c", "4")
+	var got []string
+	for range 3 {
+		ev := recv(t, ch)
+		got = append(got, ev.Key+"="+ev.Value)
+	}
+	if !equalKeys(got, []string{"cfg:b=2", "cfg:a=3", "cfg:c=4"}) {
+		t.Errorf("Expected missed events then live ones, got %v", got)
+	}
+}
+
+func TestWatchCompacted(t *testing.T) {
+	s := NewMemoryStorage()
+	for i := range watchHistory + 10 {
+		s.Save("k", strconv.Itoa(i))
+	}
+	if _, err := s.Watch(context.Background(), "k", WatchOptions{StartRevision: 1}); !errors.Is(err, ErrCompacted) {
+		t.Errorf("Expected ErrCompacted, got %v", err)
+	}
+	ch, err := s.Watch(context.Background(), "k", WatchOptions{StartRevision: watchHistory + 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := recv(t, ch); ev.Value != strconv.Itoa(watchHistory+9) {
+		t.Errorf("Expected last value, got %+v", ev)
+	}
+}
+
+// Повільний watcher відключається, записи не чекають на нього, а
+// перепідключення з Revision+1 нічого не губить
+func TestWatchSlowDisconnect(t *testing.T) {
+	s := NewMemoryStorage()
+	ch, _ := s.Watch(context.Background(), "n", WatchOptions{Buffer: 2})
+
+	for i := range 50 {
+		s.Save("n", strconv.Itoa(i))
+	}
+
+	var values []string
+	var resume Version
+	for ev := range ch {
+		if ev.Type == EventDisconnected {
+			resume = ev.Revision + 1
+			break
+		}
+		values = append(values, ev.Value)
+	}
+	expectClosed(t, ch)
+	if len(values) >= 50 || resume == 0 {
+		t.Fatalf("Expected disconnect before all 50 events, got %d events", len(values))
+	}
+
+	ch, err := s.Watch(context.Background(), "n", WatchOptions{StartRevision: resume, MaxPending: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(values) < 50 {
+		values = append(values, recv(t, ch).Value)
+	}
+	for i, v := range values {
+		if v != strconv.Itoa(i) {
+			t.Fatalf("Expected gapless sequence, got %v at %d", v, i)
+		}
+	}
+}
+
+func TestWatchSlowBuffered(t *testing.T) {
+	s := NewMemoryStorage()
+	ch, _ := s.Watch(context.Background(), "n", WatchOptions{Buffer: 2, MaxPending: 1000})
+	for i := range 500 {
+		s.Save("n", strconv.Itoa(i))
+	}
+	for i := range 500 {
+		if ev := recv(t, ch); ev.Type != EventPut || ev.Value != strconv.Itoa(i) {
+			t.Fatalf("Expected put %d, got %+v", i, ev)
+		}
+	}
+}
+
+// Історія не рахується в MaxPending, але й не "позичає" місце живим подіям
+func TestWatchResumeKeepsLiveLimit(t *testing.T) {
+	s := NewMemoryStorage()
+	for i := range 200 {
+		s.Save("n", strconv.Itoa(i))
+	}
+	ch, err := s.Watch(context.Background(), "n", WatchOptions{StartRevision: 1, MaxPending: 5, Buffer: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 200 {
+		if ev := recv(t, ch); ev.Value != strconv.Itoa(i) {
+			t.Fatalf("Expected replayed %d, got %+v", i, ev)
+		}
+	}
+
+	for i := range 100 {
+		s.Save("n", strconv.Itoa(200+i))
+	}
+	live := 0
+	var last Event
+	for last.Type != EventDisconnected && live < 100 {
+		if last = recv(t, ch); last.Type == EventPut {
+			live++
+		}
+	}
+	if last.Type != EventDisconnected {
+		t.Errorf("Expected disconnect after at most Buffer+MaxPending live events, got %d, last %+v", live, last)
+	}
+}
+
+// Пакет при відключенні доставляється цілком або не доставляється
+func TestWatchDisconnectKeepsBatchWhole(t *testing.T) {
+	s := NewMemoryStorage()
+	ch, _ := s.Watch(context.Background(), "", WatchOptions{Prefix: true, Buffer: 1})
+
+	for i := range 20 {
+		s.Commit(NewBatch().Put("a", strconv.Itoa(i)).Put("b", strconv.Itoa(i)).Put("c", strconv.Itoa(i)))
+	}
+
+	perRevision := map[Version]int{}
+	var last Event
+	for ev := range ch {
+		last = ev
+		if ev.Type != EventDisconnected {
+			perRevision[ev.Revision]++
+		}
+	}
+	if last.Type != EventDisconnected {
+		t.Fatalf("Expected disconnect, got %+v", last)
+	}
+	for rev, n := range perRevision {
+		if n != 3 {
+			t.Errorf("Revision %d delivered %d of 3 events", rev, n)
+		}
+		if rev > last.Revision {
+			t.Errorf("Delivered revision %d after reported %d", rev, last.Revision)
+		}
+	}
+}
+
+func TestWatchCacheExpireAndEvict(t *testing.T) {
+	clock := newFakeClock()
+	c := NewCache(CacheOptions{MaxEntries: 2, now: clock.Now})
+	ch, _ := c.Watch(context.Background(), "", WatchOptions{Prefix: true})
+
+	c.SaveTTL("session", "abc", time.Minute)
+	c.Save("a", "1")
+	c.Save("b", "2") // витісняє session (LRU)
+	clock.Advance(2 * time.Minute)
+	c.SaveTTL("t", "x", time.Second) // витісняє a
+	clock.Advance(time.Hour)
+	c.DeleteExpired()
+
+	want := []EventType{EventPut, EventPut, EventPut, EventEvict, EventPut, EventEvict, EventExpire}
+	var got []EventType
+	for range want {
+		got = append(got, recv(t, ch).Type)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestWatchFileResumeAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	fs.Save("a", "1")
+	_, v, _ := fs.LoadVersion("a")
+	fs.Save("a", "2")
+
+	fs = reopen(t, fs, FileOptions{})
+	ch, err := fs.Watch(context.Background(), "a", WatchOptions{StartRevision: v + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := recv(t, ch); ev.Value != "2" {
+		t.Errorf("Expected replayed put a=2, got %+v", ev)
+	}
+
+	// Після компакції ревізії до snapshot недоступні
+	fs.Compact()
+	fs = reopen(t, fs, FileOptions{})
+	if _, err := fs.Watch(context.Background(), "a", WatchOptions{StartRevision: v + 1}); !errors.Is(err, ErrCompacted) {
+		t.Errorf("Expected ErrCompacted after snapshot, got %v", err)
+	}
+}
+
+// ============= Repository =============
+
+type product struct {
+	ID    string
+	SKU   string
+	Name  string
+	Stock int
+}
+
+func newProductRepo(s Storage, codec Codec[product]) *Repository[string, product] {
+	return NewRepository(s, RepositoryOptions[string, product]{
+		Name:   "product",
+		Codec:  codec,
+		ID:     func(p *product) string { return p.ID },
+		SetID:  func(p *product, id string) { p.ID = id },
+		NextID: RandomIDs,
+		Unique: []UniqueIndex[product]{{Name: "sku", Key: func(p *product) string { return p.SKU }}},
+	})
+}
+
+func TestRepositoryCRUD(t *testing.T) {
+	backends := map[string]Storage{
+		"memory":  NewMemoryStorage(),
+		"file":    openFile(t, t.TempDir(), FileOptions{Sync: SyncNever}),
+		"ordered": NewOrderedStorage(),
+		"cache":   NewCache(CacheOptions{}), // без транзакцій
+	}
+	for name, s := range backends {
+		t.Run(name, func(t *testing.T) {
+			repo := newProductRepo(s, nil)
+
+			p := product{SKU: "A-1", Name: "Widget", Stock: 3}
+			if err := repo.Create(&p); err != nil {
+				t.Fatal(err)
+			}
+			if len(p.ID) != 32 {
+				t.Errorf("Expected generated hex ID, got %q", p.ID)
+			}
+
+			got, err := repo.Get(p.ID)
+			if err != nil || got != p {
+				t.Errorf("Expected %+v, got %+v %v", p, got, err)
+			}
+			if got, _ := repo.FindBy("sku", "A-1"); got.ID != p.ID {
+				t.Errorf("Expected FindBy to return %s, got %+v", p.ID, got)
+			}
+
+			dup := product{SKU: "A-1"}
+			var conflict *ConflictError
+			if err := repo.Create(&dup); !errors.As(err, &conflict) || conflict.Field != "sku" || !errors.Is(err, ErrConflict) {
+				t.Errorf("Expected sku ConflictError, got %v", err)
+			}
+			if dup.ID != "" {
+				t.Errorf("Expected generated ID to be reset after failed Create, got %q", dup.ID)
+			}
+			if err := repo.Create(&product{ID: p.ID, SKU: "B-2"}); !errors.As(err, &conflict) || conflict.Field != "id" {
+				t.Errorf("Expected id ConflictError, got %v", err)
+			}
+
+			// Зміна SKU звільняє старе значення індексу
+			p.SKU = "A-2"
+			if err := repo.Update(&p); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.FindBy("sku", "A-1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected old sku to be free, got %v", err)
+			}
+			other := product{SKU: "A-1"}
+			if err := repo.Create(&other); err != nil {
+				t.Errorf("Expected freed sku to be reusable, got %v", err)
+			}
+
+			if err := repo.Modify(p.ID, func(p *product) error { p.Stock--; return nil }); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := repo.Get(p.ID); got.Stock != 2 {
+				t.Errorf("Expected stock 2, got %d", got.Stock)
+			}
+
+			items, _ := repo.List()
+			if len(items) != 2 || repo.Count() != 2 {
+				t.Errorf("Expected 2 items, got %d (count %d)", len(items), repo.Count())
+			}
+
+			if err := repo.Delete(p.ID); err != nil {
+				t.Fatal(err)
+			}
+			var notFound *NotFoundError
+			if _, err := repo.Get(p.ID); !errors.As(err, &notFound) || !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected NotFoundError, got %v", err)
+			}
+			if err := repo.Delete(p.ID); !errors.As(err, &notFound) {
+				t.Errorf("Expected NotFoundError on second delete, got %v", err)
+			}
+			if _, err := repo.FindBy("sku", "A-2"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected index entry removed with entity, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	u := User{ID: 7, Username: "alice", Email: "a@x.io", CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), IsActive: true}
+	codecs := map[string]Codec[User]{
+		"json": JSONCodec[User]{},
+		"gob":  GobCodec[User]{},
+	}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Encode(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := codec.Decode(data)
+			if err != nil || got != u {
+				t.Errorf("Expected %+v, got %+v %v", u, got, err)
+			}
+			if _, err := codec.Decode("garbage"); err == nil {
+				t.Error("Expected decode error")
+			}
+		})
+	}
+}
+
+// Два екземпляри Repository над одним Transactional: унікальність
+// тримає Commit, а не лок репозиторію
+func TestRepositoryUniqueAcrossInstances(t *testing.T) {
+	s := NewMemoryStorage()
+	repos := []*Repository[string, product]{newProductRepo(s, nil), newProductRepo(s, GobCodec[product]{})}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, conflicts := 0, 0
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repos[i%2].Create(&product{SKU: "ONLY-ONE"})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, ErrConflict):
+				conflicts++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 || conflicts != 19 {
+		t.Errorf("Expected 1 created and 19 conflicts, got %d and %d", created, conflicts)
+	}
+}
+
+func TestSequenceIDs(t *testing.T) {
+	for name, s := range map[string]Storage{"memory": NewMemoryStorage(), "cache": NewCache(CacheOptions{})} {
+		t.Run(name, func(t *testing.T) {
+			// Два генератори над одним сховищем - як два процеси (для Transactional)
+			gens := []func() (int, error){SequenceIDs(s, "order"), SequenceIDs(s, "order")}
+			if _, ok := s.(Transactional); !ok {
+				gens = gens[:1] // без CAS унікальність лише в межах одного генератора
+			}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			seen := map[int]bool{}
+			for g := range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range 50 {
+						id, err := gens[g%len(gens)]()
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						mu.Lock()
+						if seen[id] {
+							t.Errorf("Duplicate id %d", id)
+						}
+						seen[id] = true
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if len(seen) != 400 || !seen[1] || !seen[400] {
+				t.Errorf("Expected ids 1..400, got %d ids", len(seen))
+			}
+		})
+	}
+}
+
+// ============= User Service =============
+
+func TestUserServiceBehaviour(t *testing.T) {
+	var svc UserService = NewStorageUserService(NewMemoryStorage(), nil)
+
+	tests := []struct {
+		name, username, email, wantErr string
+	}{
+		{"valid", "alice", "alice@example.com", ""},
+		{"empty username", "", "x@example.com", "username cannot be empty"},
+		{"invalid email", "bob", "bob.example.com", "invalid email format"},
+		{"duplicate username", "alice", "other@example.com", "already exists"},
+		{"duplicate email ignores case", "alice2", "ALICE@example.com", "already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Create(tt.username, tt.email)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected success, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	bob, _ := svc.Create("bob", "bob@example.com")
+	if bob.ID != 2 {
+		t.Errorf("Expected sequential ID 2, got %d", bob.ID)
+	}
+
+	// Як в оригіналі: email без '@' ігнорується
+	svc.Update(bob.ID, "robert", "not-an-email")
+	got, _ := svc.GetByID(bob.ID)
+	if got.Username != "robert" || got.Email != "bob@example.com" {
+		t.Errorf("Expected robert <bob@example.com>, got %s", got)
+	}
+	if err := svc.Update(bob.ID, "alice", ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected conflict renaming to alice, got %v", err)
+	}
+
+	svc.Deactivate(bob.ID)
+	if got, _ := svc.GetByID(bob.ID); got.IsActive {
+		t.Error("Expected user to be inactive")
+	}
+
+	// Копія: зміна повернутого *User не змінює сховище
+	got.Username = "hacked"
+	if again, _ := svc.GetByID(bob.ID); again.Username != "robert" {
+		t.Errorf("Expected stored username robert, got %s", again.Username)
+	}
+
+	var notFound *NotFoundError
+	if _, err := svc.GetByID(99); !errors.As(err, &notFound) || err.Error() != "user with id 99 not found" {
+		t.Errorf("Expected NotFoundError for 99, got %v", err)
+	}
+	if err := svc.Activate(99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestUserServiceGetAllOrderedByID(t *testing.T) {
+	svc := NewStorageUserService(NewOrderedStorage(), nil)
+	for i := range 12 {
+		svc.Create(fmt.Sprintf("user%d", i), fmt.Sprintf("u%d@example.com", i))
+	}
+	svc.Delete(5)
+
+	users, _ := svc.GetAll()
+	if len(users) != 11 {
+		t.Fatalf("Expected 11 users, got %d", len(users))
+	}
+	for i := 1; i < len(users); i++ {
+		if users[i-1].ID >= users[i].ID {
+			t.Fatalf("Expected ascending IDs, got %d before %d", users[i-1].ID, users[i].ID)
+		}
+	}
+}
+
+func TestUserServicePersistsInFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	fs := openFile(t, dir, FileOptions{})
+	svc := NewStorageUserService(fs, GobCodec[User]{})
+	svc.Create("alice", "alice@example.com")
+	svc.Create("bob", "bob@example.com")
+
+	fs = reopen(t, fs, FileOptions{})
+	svc = NewStorageUserService(fs, GobCodec[User]{})
+	carol, err := svc.Create("carol", "carol@example.com")
+	if err != nil || carol.ID != 3 {
+		t.Errorf("Expected sequence to continue with 3, got %v %v", carol, err)
+	}
+	if u, err := svc.GetByEmail("Bob@Example.com"); err != nil || u.Username != "bob" {
+		t.Errorf("Expected bob by email, got %v %v", u, err)
+	}
+}
+
+func TestRepositoryModifyConcurrent(t *testing.T) {
+	repo := newProductRepo(NewMemoryStorage(), nil)
+	p := product{SKU: "C-1"}
+	repo.Create(&p)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				repo.Modify(p.ID, func(p *product) error { p.Stock++; return nil })
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, _ := repo.Get(p.ID); got.Stock != 400 {
+		t.Errorf("Expected 400, got %d", got.Stock)
+	}
+	if err := repo.Modify(p.ID, func(p *product) error { p.ID = "other"; return nil }); err == nil {
+		t.Error("Expected error when Modify changes ID")
+	}
+}
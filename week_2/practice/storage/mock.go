@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ============= Mock Storage =============
+// MockStorage з week_2/solutions/solution_3.go: лічильники викликів і
+// SetShouldFail. Тепер він потокобезпечний і має версії, а для тестів
+// транзакцій уміє підкидати конфлікти.
+
+var errMockFail = errors.New("mock: operation failed")
+
+type MockStorage struct {
+	mu sync.Mutex
+	kv versioned
+
+	saveCalled   int
+	loadCalled   int
+	deleteCalled int
+	commitCalled int
+	conflicts    int
+	shouldFail   bool
+
+	injectConflicts int
+	beforeCommit    func(b *Batch)
+}
+
+func NewMockStorage() *MockStorage {
+	return &MockStorage{
+		kv: newVersioned(),
+	}
+}
+
+func (m *MockStorage) SetShouldFail(fail bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shouldFail = fail
+}
+
+// InjectConflicts - наступні n Commit з умовами повернуть ErrConflict,
+// нічого не записавши
+func (m *MockStorage) InjectConflicts(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.injectConflicts = n
+}
+
+// BeforeCommit викликається на початку кожного Commit поза локом:
+// тут можна змінити ключ, імітуючи конкурентного клієнта
+func (m *MockStorage) BeforeCommit(fn func(b *Batch)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.beforeCommit = fn
+}
+
+func (m *MockStorage) Save(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saveCalled++
+	if m.shouldFail {
+		return fmt.Errorf("save: %w", errMockFail)
+	}
+	m.kv.put(key, value)
+	return nil
+}
+
+func (m *MockStorage) Load(key string) (string, error) {
+	value, _, err := m.LoadVersion(key)
+	return value, err
+}
+
+func (m *MockStorage) LoadVersion(key string) (string, Version, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loadCalled++
+	if m.shouldFail {
+		return "", 0, fmt.Errorf("load: %w", errMockFail)
+	}
+	return m.kv.load(key)
+}
+
+func (m *MockStorage) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteCalled++
+	if m.shouldFail {
+		return fmt.Errorf("delete: %w", errMockFail)
+	}
+	if !m.kv.delete(key) {
+		return notFound(key)
+	}
+	return nil
+}
+
+func (m *MockStorage) Exists(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, exists := m.kv.data[key]
+	return exists
+}
+
+func (m *MockStorage) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.kv.keys()
+}
+
+func (m *MockStorage) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shouldFail {
+		return fmt.Errorf("clear: %w", errMockFail)
+	}
+	m.kv.clear()
+	return nil
+}
+
+func (m *MockStorage) Commit(b *Batch) (Version, error) {
+	m.mu.Lock()
+	hook := m.beforeCommit
+	m.mu.Unlock()
+	if hook != nil {
+		hook(b)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commitCalled++
+	if m.shouldFail {
+		return 0, fmt.Errorf("commit: %w", errMockFail)
+	}
+	if m.injectConflicts > 0 && len(b.Checks) > 0 {
+		m.injectConflicts--
+		m.conflicts++
+		c := b.Checks[0]
+		return 0, conflict(c.Key, c.Version, m.kv.versions[c.Key])
+	}
+	if err := m.kv.check(b.Checks); err != nil {
+		m.conflicts++
+		return 0, err
+	}
+	if len(b.Ops) == 0 {
+		return m.kv.rev, nil
+	}
+	return m.kv.apply(b.Ops), nil
+}
+
+func (m *MockStorage) Watch(ctx context.Context, keyOrPrefix string, opts WatchOptions) (<-chan Event, error) {
+	return m.kv.watch(ctx, keyOrPrefix, opts)
+}
+
+func (m *MockStorage) Stats() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return fmt.Sprintf("Save: %d, Load: %d, Delete: %d, Commit: %d, Conflicts: %d",
+		m.saveCalled, m.loadCalled, m.deleteCalled, m.commitCalled, m.conflicts)
+}
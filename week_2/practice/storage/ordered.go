@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"iter"
+	"sync"
+)
+
+// ============= Ordered Storage =============
+// Keys() решти бекендів повертає всі ключі у випадковому порядку: щоб
+// знайти користувачів одного тенанта, треба скопіювати і відфільтрувати
+// весь keyspace. OrderedStorage тримає поруч з мапою skip list ключів,
+// тож Scan("tenant:42:") одразу стає на перший потрібний ключ.
+//
+// Ітератори не тримають лок, поки працює тіло циклу: вони читають
+// порціями по iterChunk і між порціями шукають далі від останнього
+// ключа. Тому в циклі можна писати в те саме сховище. Зміни, зроблені
+// під час обходу, можуть потрапити в обхід або ні, але кожен ключ
+// трапиться не більше одного разу і в правильному порядку.
+
+const iterChunk = 64
+
+var ErrBadCursor = errors.New("invalid cursor")
+
+type OrderedStorage struct {
+	mu    sync.RWMutex
+	kv    versioned
+	index *skipList
+}
+
+func NewOrderedStorage() *OrderedStorage {
+	return &OrderedStorage{kv: newVersioned(), index: newSkipList()}
+}
+
+// ============= Storage =============
+
+func (o *OrderedStorage) Save(key, value string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.kv.put(key, value)
+	o.index.insert(key)
+	return nil
+}
+
+func (o *OrderedStorage) Load(key string) (string, error) {
+	value, _, err := o.LoadVersion(key)
+	return value, err
+}
+
+func (o *OrderedStorage) Delete(key string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.kv.delete(key) {
+		return notFound(key)
+	}
+	o.index.remove(key)
+	return nil
+}
+
+func (o *OrderedStorage) Exists(key string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	_, exists := o.kv.data[key]
+	return exists
+}
+
+// Keys - у порядку зростання
+func (o *OrderedStorage) Keys() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	keys := make([]string, 0, o.index.length)
+	for n := o.index.seekGE(""); n != nil; n = n.next[0] {
+		keys = append(keys, n.key)
+	}
+	return keys
+}
+
+func (o *OrderedStorage) Clear() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.kv.clear()
+	o.index = newSkipList()
+	return nil
+}
+
+func (o *OrderedStorage) Size() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.index.length
+}
+
+// ============= Transactional =============
+
+func (o *OrderedStorage) LoadVersion(key string) (string, Version, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.kv.load(key)
+}
+
+func (o *OrderedStorage) Commit(b *Batch) (Version, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.kv.check(b.Checks); err != nil {
+		return 0, err
+	}
+	if len(b.Ops) == 0 {
+		return o.kv.rev, nil
+	}
+	rev := o.kv.apply(b.Ops)
+	for _, op := range b.Ops {
+		if op.Delete {
+			o.index.remove(op.Key)
+		} else {
+			o.index.insert(op.Key)
+		}
+	}
+	return rev, nil
+}
+
+func (o *OrderedStorage) Watch(ctx context.Context, keyOrPrefix string, opts WatchOptions) (<-chan Event, error) {
+	return o.kv.watch(ctx, keyOrPrefix, opts)
+}
+
+// ============= Iteration =============
+
+// Query - діапазон [Start, End) ∩ Prefix. Порожній End - до кінця.
+type Query struct {
+	Prefix  string
+	Start   string
+	End     string
+	Reverse bool
+	Limit   int    // для Page; 0 - DefaultPageSize
+	Cursor  string // Page.Next попередньої сторінки
+}
+
+const DefaultPageSize = 100
+
+// Scan - ключі з префіксом, за зростанням
+func (o *OrderedStorage) Scan(prefix string) iter.Seq2[string, string] {
+	return o.Iter(Query{Prefix: prefix})
+}
+
+// Range - ключі з [start, end), за зростанням
+func (o *OrderedStorage) Range(start, end string) iter.Seq2[string, string] {
+	return o.Iter(Query{Start: start, End: end})
+}
+
+// Iter ігнорує Limit і Cursor - обрив циклу і є ліміт
+func (o *OrderedStorage) Iter(q Query) iter.Seq2[string, string] {
+	start, end := q.bounds()
+	if q.Reverse {
+		return o.iterBackward(start, end)
+	}
+	return o.iterForward(start, end)
+}
+
+// bounds перетинає [Start, End) з діапазоном префікса
+func (q Query) bounds() (start, end string) {
+	start, end = q.Start, q.End
+	if q.Prefix != "" {
+		start = max(start, q.Prefix)
+		if pe := prefixEnd(q.Prefix); pe != "" && (end == "" || pe < end) {
+			end = pe
+		}
+	}
+	return start, end
+}
+
+// prefixEnd - найменший рядок, більший за всі рядки з префіксом
+// ("user:" -> "user;"); "" - такого немає (префікс з одних 0xff)
+func prefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
+type entry struct {
+	key, value string
+}
+
+func (o *OrderedStorage) iterForward(start, end string) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		from := start
+		for {
+			chunk := o.chunkForward(from, end)
+			for _, e := range chunk {
+				if !yield(e.key, e.value) {
+					return
+				}
+			}
+			if len(chunk) < iterChunk {
+				return
+			}
+			// key+"\x00" - найменший рядок, більший за key
+			from = chunk[len(chunk)-1].key + "\x00"
+		}
+	}
+}
+
+func (o *OrderedStorage) chunkForward(from, end string) []entry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	chunk := make([]entry, 0, iterChunk)
+	for n := o.index.seekGE(from); n != nil && len(chunk) < iterChunk; n = n.next[0] {
+		if end != "" && n.key >= end {
+			break
+		}
+		chunk = append(chunk, entry{n.key, o.kv.data[n.key]})
+	}
+	return chunk
+}
+
+func (o *OrderedStorage) iterBackward(start, end string) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		before, open := end, end == ""
+		for {
+			chunk := o.chunkBackward(start, before, open)
+			for _, e := range chunk {
+				if !yield(e.key, e.value) {
+					return
+				}
+			}
+			if len(chunk) < iterChunk {
+				return
+			}
+			before, open = chunk[len(chunk)-1].key, false
+		}
+	}
+}
+
+// chunkBackward - ключі < before (або з самого кінця, якщо open) і >= start
+func (o *OrderedStorage) chunkBackward(start, before string, open bool) []entry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	n := o.index.last()
+	if !open {
+		n = o.index.seekLT(before)
+	}
+	chunk := make([]entry, 0, iterChunk)
+	for ; n != nil && len(chunk) < iterChunk; n = n.prev {
+		if n.key < start {
+			break
+		}
+		chunk = append(chunk, entry{n.key, o.kv.data[n.key]})
+	}
+	return chunk
+}
+
+// ============= Pagination =============
+
+type Page struct {
+	Items []KV
+	Next  string // курсор наступної сторінки; "" - сторінок більше немає
+}
+
+type KV struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Page повертає одну сторінку. Курсор - останній виданий ключ, тож
+// сторінки не зсуваються, коли між запитами додаються чи видаляються
+// ключі (на відміну від offset).
+func (o *OrderedStorage) Page(q Query) (Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+
+	if q.Cursor != "" {
+		last, err := decodeCursor(q.Cursor, q.Reverse)
+		if err != nil {
+			return Page{}, err
+		}
+		if q.Reverse {
+			q.End = last
+		} else {
+			q.Start = last + "\x00"
+		}
+	}
+
+	var page Page
+	for key, value := range o.Iter(q) {
+		if len(page.Items) == limit {
+			// Є ще хоча б один ключ - видаємо курсор
+			page.Next = encodeCursor(page.Items[limit-1].Key, q.Reverse)
+			break
+		}
+		page.Items = append(page.Items, KV{key, value})
+	}
+	return page, nil
+}
+
+// Курсор непрозорий: напрямок + ключ у base64, щоб клієнт не
+// конструював його сам і не переплутав напрямок
+func encodeCursor(key string, reverse bool) string {
+	dir := "f"
+	if reverse {
+		dir = "r"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(dir + key))
+}
+
+func decodeCursor(cursor string, reverse bool) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) == 0 {
+		return "", ErrBadCursor
+	}
+	if (raw[0] == 'r') != reverse || (raw[0] != 'r' && raw[0] != 'f') {
+		return "", ErrBadCursor
+	}
+	return string(raw[1:]), nil
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============= Repository =============
+// InMemoryUserService (week_2/practice/user_service), UserStore (week_6),
+// MockDatabase/UserRepository і APIDatabase (week_4/solutions) - кожен
+// пише свій CRUD на мапі, свою перевірку дублікатів і свої тексти
+// помилок. Repository[ID, T] робить це один раз поверх будь-якого Storage:
+//
+//	user:<id>                 -> закодована сутність
+//	idx:user:<index>:<value>  -> <id> (унікальний індекс)
+//	seq:user                  -> лічильник для SequenceIDs
+//
+// Якщо Storage - Transactional, сутність і її індекси пишуться одним
+// Commit з перевірками версій. Тоді унікальність тримається навіть між
+// різними екземплярами Repository над одним сховищем. На простому
+// Storage записи йдуть по черзі під локом репозиторію.
+
+// NotFoundError - errors.Is(err, ErrNotFound) теж працює
+type NotFoundError struct {
+	Entity string
+	Field  string // "id" або назва індексу
+	Value  any
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s with %s %v not found", e.Entity, e.Field, e.Value)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ConflictError - дублікат id чи значення унікального індексу;
+// errors.Is(err, ErrConflict) теж працює
+type ConflictError struct {
+	Entity string
+	Field  string
+	Value  any
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s with %s %v already exists", e.Entity, e.Field, e.Value)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// UniqueIndex - порожнє значення не індексується (необов'язкове поле)
+type UniqueIndex[T any] struct {
+	Name string
+	Key  func(*T) string
+}
+
+type RepositoryOptions[ID comparable, T any] struct {
+	Name  string   // префікс ключів і назва в помилках; "" - "entity"
+	Codec Codec[T] // nil - JSONCodec
+
+	ID    func(*T) ID // обов'язково
+	SetID func(*T, ID)
+	// NextID викликається в Create, якщо ID нульовий; потребує SetID
+	NextID func() (ID, error)
+
+	Unique []UniqueIndex[T]
+}
+
+type Repository[ID comparable, T any] struct {
+	s    Storage
+	tx   Transactional // nil - Storage без транзакцій
+	opts RepositoryOptions[ID, T]
+	mu   sync.Mutex
+}
+
+func NewRepository[ID comparable, T any](s Storage, opts RepositoryOptions[ID, T]) *Repository[ID, T] {
+	if opts.ID == nil {
+		panic("NewRepository: RepositoryOptions.ID is required")
+	}
+	if opts.NextID != nil && opts.SetID == nil {
+		panic("NewRepository: NextID requires SetID")
+	}
+	if opts.Name == "" {
+		opts.Name = "entity"
+	}
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec[T]{}
+	}
+	r := &Repository[ID, T]{s: s, opts: opts}
+	r.tx, _ = s.(Transactional)
+	return r
+}
+
+func (r *Repository[ID, T]) key(id ID) string {
+	return r.opts.Name + ":" + fmt.Sprint(id)
+}
+
+func (r *Repository[ID, T]) indexKey(index, value string) string {
+	return "idx:" + r.opts.Name + ":" + index + ":" + value
+}
+
+// ============= CRUD =============
+
+// Create зберігає нову сутність; нульовий ID генерується через NextID
+// і записується в v
+func (r *Repository[ID, T]) Create(v *T) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.opts.ID(v)
+	var zero ID
+	if id == zero && r.opts.NextID != nil {
+		// Дублікат email не повинен з'їдати номер із послідовності
+		if err := r.reindex(NewBatch(), id, nil, v); err != nil {
+			return err
+		}
+		if id, err = r.opts.NextID(); err != nil {
+			return fmt.Errorf("generate %s id: %w", r.opts.Name, err)
+		}
+		r.opts.SetID(v, id)
+		defer func() {
+			if err != nil {
+				r.opts.SetID(v, zero) // невдалий Create не лишає згенерований ID
+			}
+		}()
+	}
+
+	key := r.key(id)
+	if r.s.Exists(key) {
+		return &ConflictError{Entity: r.opts.Name, Field: "id", Value: id}
+	}
+	data, err := r.opts.Codec.Encode(*v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", r.opts.Name, err)
+	}
+
+	b := NewBatch().IfVersion(key, 0).Put(key, data)
+	if err := r.reindex(b, id, nil, v); err != nil {
+		return err
+	}
+	return r.commit(b, id, nil, v)
+}
+
+func (r *Repository[ID, T]) Get(id ID) (T, error) {
+	v, _, err := r.load(id)
+	return v, err
+}
+
+// Update перезаписує існуючу сутність і переносить змінені значення індексів
+func (r *Repository[ID, T]) Update(v *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.opts.ID(v)
+	old, version, err := r.load(id)
+	if err != nil {
+		return err
+	}
+	return r.update(id, old, version, v)
+}
+
+// Modify - прочитати, змінити, записати без вікна між Get і Update,
+// у якому чужа зміна загубилася б. Змінювати ID у change не можна.
+func (r *Repository[ID, T]) Modify(id ID, change func(*T) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, version, err := r.load(id)
+	if err != nil {
+		return err
+	}
+	updated := old
+	if err := change(&updated); err != nil {
+		return err
+	}
+	if r.opts.ID(&updated) != id {
+		return fmt.Errorf("%s: Modify must not change id", r.opts.Name)
+	}
+	return r.update(id, old, version, &updated)
+}
+
+func (r *Repository[ID, T]) update(id ID, old T, version Version, v *T) error {
+	data, err := r.opts.Codec.Encode(*v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", r.opts.Name, err)
+	}
+
+	key := r.key(id)
+	b := NewBatch().IfVersion(key, version).Put(key, data)
+	if err := r.reindex(b, id, &old, v); err != nil {
+		return err
+	}
+	return r.commit(b, id, &old, v)
+}
+
+func (r *Repository[ID, T]) Delete(id ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, version, err := r.load(id)
+	if err != nil {
+		return err
+	}
+	key := r.key(id)
+	b := NewBatch().IfVersion(key, version).Delete(key)
+	if err := r.reindex(b, id, &old, nil); err != nil {
+		return err
+	}
+	return r.commit(b, id, &old, nil)
+}
+
+// FindBy - пошук за унікальним індексом
+func (r *Repository[ID, T]) FindBy(index, value string) (T, error) {
+	var zero T
+	owner, err := r.s.Load(r.indexKey(index, value))
+	if errors.Is(err, ErrNotFound) {
+		return zero, &NotFoundError{Entity: r.opts.Name, Field: index, Value: value}
+	}
+	if err != nil {
+		return zero, err
+	}
+
+	data, err := r.s.Load(r.opts.Name + ":" + owner)
+	if errors.Is(err, ErrNotFound) {
+		// Індекс пережив сутність (простий Storage, збій між записами)
+		return zero, &NotFoundError{Entity: r.opts.Name, Field: index, Value: value}
+	}
+	if err != nil {
+		return zero, err
+	}
+	return r.decode(data)
+}
+
+// List - усі сутності в порядку ключів сховища
+func (r *Repository[ID, T]) List() ([]T, error) {
+	keys := r.entityKeys()
+	items := make([]T, 0, len(keys))
+	for _, key := range keys {
+		data, err := r.s.Load(key)
+		if errors.Is(err, ErrNotFound) {
+			continue // видалено між Keys і Load
+		}
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.decode(data)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+func (r *Repository[ID, T]) Count() int {
+	return len(r.entityKeys())
+}
+
+// ============= Internals =============
+
+func (r *Repository[ID, T]) entityKeys() []string {
+	prefix := r.opts.Name + ":"
+	var keys []string
+	if o, ok := r.s.(*OrderedStorage); ok {
+		for key := range o.Scan(prefix) {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+	for _, key := range r.s.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *Repository[ID, T]) load(id ID) (T, Version, error) {
+	var zero T
+	key := r.key(id)
+
+	var data string
+	var version Version
+	var err error
+	if r.tx != nil {
+		data, version, err = r.tx.LoadVersion(key)
+	} else {
+		data, err = r.s.Load(key)
+	}
+	if errors.Is(err, ErrNotFound) {
+		return zero, 0, &NotFoundError{Entity: r.opts.Name, Field: "id", Value: id}
+	}
+	if err != nil {
+		return zero, 0, err
+	}
+
+	v, err := r.decode(data)
+	return v, version, err
+}
+
+func (r *Repository[ID, T]) decode(data string) (T, error) {
+	v, err := r.opts.Codec.Decode(data)
+	if err != nil {
+		return v, fmt.Errorf("decode %s: %w", r.opts.Name, err)
+	}
+	return v, nil
+}
+
+// reindex додає в пакет зміни індексів між old і updated (nil - немає)
+func (r *Repository[ID, T]) reindex(b *Batch, id ID, old, updated *T) error {
+	owner := fmt.Sprint(id)
+	for _, idx := range r.opts.Unique {
+		var before, after string
+		if old != nil {
+			before = idx.Key(old)
+		}
+		if updated != nil {
+			after = idx.Key(updated)
+		}
+		if before == after {
+			continue
+		}
+
+		if after != "" {
+			ik := r.indexKey(idx.Name, after)
+			if current, err := r.s.Load(ik); err == nil && current != owner {
+				return &ConflictError{Entity: r.opts.Name, Field: idx.Name, Value: after}
+			}
+			// Інший екземпляр Repository може зайняти значення між
+			// перевіркою і Commit - версія 0 це ловить
+			b.IfVersion(ik, 0).Put(ik, owner)
+		}
+		if before != "" {
+			b.Delete(r.indexKey(idx.Name, before))
+		}
+	}
+	return nil
+}
+
+// commit; old/updated - щоб пояснити конфлікт версій типізованою помилкою
+func (r *Repository[ID, T]) commit(b *Batch, id ID, old, updated *T) error {
+	if r.tx != nil {
+		_, err := r.tx.Commit(b)
+		if errors.Is(err, ErrConflict) {
+			// Інший екземпляр встиг між перевіркою і Commit: якщо він
+			// зайняв значення індексу чи id - кажемо, яке саме
+			if cerr := r.reindex(NewBatch(), id, old, updated); cerr != nil {
+				return cerr
+			}
+			if old == nil && r.s.Exists(r.key(id)) {
+				return &ConflictError{Entity: r.opts.Name, Field: "id", Value: id}
+			}
+		}
+		return err
+	}
+	// Без транзакцій: умови вже перевірені під r.mu, записуємо по черзі
+	for _, op := range b.Ops {
+		var err error
+		if op.Delete {
+			err = r.s.Delete(op.Key)
+			if errors.Is(err, ErrNotFound) {
+				err = nil
+			}
+		} else {
+			err = r.s.Save(op.Key, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ============= ID generators =============
+
+// SequenceIDs - 1, 2, 3... з лічильником у самому сховищі, тож
+// нумерація продовжується після перезапуску FileStorage. На Transactional
+// інкремент - CAS, на простому Storage - лок (лише в межах процесу).
+func SequenceIDs(s Storage, name string) func() (int, error) {
+	key := "seq:" + name
+	var mu sync.Mutex
+	return func() (int, error) {
+		if tx, ok := s.(Transactional); ok {
+			for {
+				value, version, err := tx.LoadVersion(key)
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					return 0, err
+				}
+				n, _ := strconv.Atoi(value)
+				_, err = CompareAndSwap(tx, key, version, strconv.Itoa(n+1))
+				if errors.Is(err, ErrConflict) {
+					continue
+				}
+				return n + 1, err
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		value, err := s.Load(key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+		n, _ := strconv.Atoi(value)
+		return n + 1, s.Save(key, strconv.Itoa(n+1))
+	}
+}
+
+// RandomIDs - 128 біт з crypto/rand у hex: не вгадуються і не
+// потребують координації між процесами
+func RandomIDs() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
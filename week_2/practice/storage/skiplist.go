@@ -0,0 +1,130 @@
+package main
+
+import "math/rand/v2"
+
+// ============= Skip List =============
+// Впорядкований індекс ключів. Простіше за B-дерево і без ребалансування:
+// вузол потрапляє на рівень i з імовірністю 1/4^i, тож пошук у
+// середньому O(log n). Нижній рівень двозв'язний - для зворотного обходу.
+
+const (
+	skipMaxLevel = 24 // вистачає на 4^24 ключів
+	skipP        = 4
+)
+
+type skipNode struct {
+	key  string
+	next []*skipNode
+	prev *skipNode // лише рівень 0; nil у першого вузла
+}
+
+type skipList struct {
+	head   *skipNode
+	level  int
+	length int
+}
+
+func newSkipList() *skipList {
+	return &skipList{head: &skipNode{next: make([]*skipNode, skipMaxLevel)}, level: 1}
+}
+
+func randomLevel() int {
+	level := 1
+	for level < skipMaxLevel && rand.IntN(skipP) == 0 {
+		level++
+	}
+	return level
+}
+
+// findPrev заповнює update[i] - останній вузол рівня i з ключем < key
+func (s *skipList) findPrev(key string, update []*skipNode) *skipNode {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].key < key {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x
+}
+
+// insert повертає false, якщо ключ уже є
+func (s *skipList) insert(key string) bool {
+	var update [skipMaxLevel]*skipNode
+	x := s.findPrev(key, update[:])
+	if n := x.next[0]; n != nil && n.key == key {
+		return false
+	}
+
+	level := randomLevel()
+	if level > s.level {
+		for i := s.level; i < level; i++ {
+			update[i] = s.head
+		}
+		s.level = level
+	}
+
+	node := &skipNode{key: key, next: make([]*skipNode, level)}
+	for i := range level {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	if x != s.head {
+		node.prev = x
+	}
+	if node.next[0] != nil {
+		node.next[0].prev = node
+	}
+	s.length++
+	return true
+}
+
+func (s *skipList) remove(key string) bool {
+	var update [skipMaxLevel]*skipNode
+	s.findPrev(key, update[:])
+	node := update[0].next[0]
+	if node == nil || node.key != key {
+		return false
+	}
+
+	for i := range len(node.next) {
+		update[i].next[i] = node.next[i]
+	}
+	if node.next[0] != nil {
+		node.next[0].prev = node.prev
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	s.length--
+	return true
+}
+
+// seekGE - перший вузол з ключем >= key
+func (s *skipList) seekGE(key string) *skipNode {
+	return s.findPrev(key, nil).next[0]
+}
+
+// seekLT - останній вузол з ключем < key
+func (s *skipList) seekLT(key string) *skipNode {
+	x := s.findPrev(key, nil)
+	if x == s.head {
+		return nil
+	}
+	return x
+}
+
+func (s *skipList) last() *skipNode {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			x = x.next[i]
+		}
+	}
+	if x == s.head {
+		return nil
+	}
+	return x
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ============= Storage Interface =============
+// Той самий інтерфейс, що й у week_2/solutions/solution_3.go. Реалізації
+// тут - потокобезпечні версії тамтешніх MemoryStorage/FileStorage/MockStorage
+// і нові бекенди, що розширюють його.
+
+type Storage interface {
+	Save(key, value string) error
+	Load(key string) (string, error)
+	Delete(key string) error
+	Exists(key string) bool
+	Keys() []string
+	Clear() error
+}
+
+// ErrNotFound - перевіряти через errors.Is; текст "key not found: <key>"
+// той самий, що й в оригіналі
+var ErrNotFound = errors.New("key not found")
+
+func notFound(key string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, key)
+}
+
+// ============= Memory Storage =============
+
+type MemoryStorage struct {
+	mu sync.RWMutex
+	kv versioned
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		kv: newVersioned(),
+	}
+}
+
+func (m *MemoryStorage) Save(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kv.put(key, value)
+	return nil
+}
+
+func (m *MemoryStorage) Load(key string) (string, error) {
+	value, _, err := m.LoadVersion(key)
+	return value, err
+}
+
+// Delete перевіряє і видаляє під одним локом - в оригіналі між
+// Exists і delete інша горутина могла встигнути
+func (m *MemoryStorage) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.kv.delete(key) {
+		return notFound(key)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) Exists(key string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists := m.kv.data[key]
+	return exists
+}
+
+func (m *MemoryStorage) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.kv.keys()
+}
+
+func (m *MemoryStorage) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kv.clear()
+	return nil
+}
+
+func (m *MemoryStorage) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.kv.data)
+}
+
+func (m *MemoryStorage) Watch(ctx context.Context, keyOrPrefix string, opts WatchOptions) (<-chan Event, error) {
+	return m.kv.watch(ctx, keyOrPrefix, opts)
+}
+
+func (m *MemoryStorage) LoadVersion(key string) (string, Version, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.kv.load(key)
+}
+
+func (m *MemoryStorage) Commit(b *Batch) (Version, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.kv.check(b.Checks); err != nil {
+		return 0, err
+	}
+	if len(b.Ops) == 0 {
+		return m.kv.rev, nil
+	}
+	return m.kv.apply(b.Ops), nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ============= Transactions =============
+// Storage не має атомарних операцій над кількома ключами: "прочитати
+// лічильник, додати 1, зберегти" з двох горутин губить інкремент.
+// Тут - оптимістична конкурентність, як у etcd: кожен ключ має версію
+// (ревізію сховища на момент останнього запису), а коміт перевіряє, що
+// прочитані версії не змінились. Локів на час транзакції немає.
+
+// Version 0 - ключа немає. Ревізія зростає з кожним записом і не
+// повторюється, тож видалений і знову створений ключ має нову версію (без ABA).
+type Version uint64
+
+var ErrConflict = errors.New("version conflict")
+
+func conflict(key string, expected, actual Version) error {
+	return fmt.Errorf("%w: %s (expected version %d, actual %d)", ErrConflict, key, expected, actual)
+}
+
+// Transactional - Storage з версіями і атомарними пакетами
+type Transactional interface {
+	Storage
+	LoadVersion(key string) (string, Version, error)
+	// Commit перевіряє всі Check і застосовує всі операції - або нічого.
+	// Повертає ревізію, з якою записані ключі пакета.
+	Commit(b *Batch) (Version, error)
+}
+
+// ============= Batch =============
+
+type Check struct {
+	Key     string
+	Version Version // 0 - ключа не повинно існувати
+}
+
+type BatchOp struct {
+	Key    string
+	Value  string
+	Delete bool
+}
+
+// Batch - набір умов і операцій; операції виконуються по порядку,
+// Delete відсутнього ключа - не помилка
+type Batch struct {
+	Checks []Check
+	Ops    []BatchOp
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+func (b *Batch) Put(key, value string) *Batch {
+	b.Ops = append(b.Ops, BatchOp{Key: key, Value: value})
+	return b
+}
+
+func (b *Batch) Delete(key string) *Batch {
+	b.Ops = append(b.Ops, BatchOp{Key: key, Delete: true})
+	return b
+}
+
+// IfVersion - коміт лише якщо key досі має версію v
+func (b *Batch) IfVersion(key string, v Version) *Batch {
+	b.Checks = append(b.Checks, Check{Key: key, Version: v})
+	return b
+}
+
+// CompareAndSwap записує value, лише якщо key має версію expected
+// (0 - лише якщо ключа ще немає)
+func CompareAndSwap(s Transactional, key string, expected Version, value string) (Version, error) {
+	return s.Commit(NewBatch().IfVersion(key, expected).Put(key, value))
+}
+
+// PutAll / DeleteAll - атомарні multi-put і multi-delete
+func PutAll(s Transactional, values map[string]string) error {
+	b := NewBatch()
+	for key, value := range values {
+		b.Put(key, value)
+	}
+	_, err := s.Commit(b)
+	return err
+}
+
+func DeleteAll(s Transactional, keys ...string) error {
+	b := NewBatch()
+	for _, key := range keys {
+		b.Delete(key)
+	}
+	_, err := s.Commit(b)
+	return err
+}
+
+// ============= Txn =============
+
+// MaxTxnAttempts - скільки разів Update перезапускає fn при конфлікті
+const MaxTxnAttempts = 10
+
+// Txn читає зі сховища, запам'ятовуючи версії, і буферизує записи
+// до коміту. Свої записи видно в наступних Load (read-your-writes).
+type Txn struct {
+	s      Transactional
+	reads  map[string]Version
+	writes map[string]BatchOp
+	order  []string
+}
+
+func (t *Txn) Load(key string) (string, error) {
+	if op, ok := t.writes[key]; ok {
+		if op.Delete {
+			return "", notFound(key)
+		}
+		return op.Value, nil
+	}
+
+	value, version, err := t.s.LoadVersion(key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	// Відсутність теж фіксується: конкурентне створення ключа - конфлікт
+	if _, seen := t.reads[key]; !seen {
+		t.reads[key] = version
+	}
+	return value, err
+}
+
+func (t *Txn) Exists(key string) bool {
+	_, err := t.Load(key)
+	return err == nil
+}
+
+func (t *Txn) Save(key, value string) {
+	t.write(BatchOp{Key: key, Value: value})
+}
+
+func (t *Txn) Delete(key string) {
+	t.write(BatchOp{Key: key, Delete: true})
+}
+
+func (t *Txn) write(op BatchOp) {
+	if _, ok := t.writes[op.Key]; !ok {
+		t.order = append(t.order, op.Key)
+	}
+	t.writes[op.Key] = op
+}
+
+func (t *Txn) batch() *Batch {
+	b := NewBatch()
+	keys := make([]string, 0, len(t.reads))
+	for key := range t.reads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.IfVersion(key, t.reads[key])
+	}
+	for _, key := range t.order {
+		b.Ops = append(b.Ops, t.writes[key])
+	}
+	return b
+}
+
+// Update виконує fn у транзакції і комітить. При конфлікті fn
+// запускається знову з чистою Txn, тож fn не повинна мати побічних
+// ефектів поза tx. Помилка з fn скасовує транзакцію без повтору.
+func Update(s Transactional, fn func(tx *Txn) error) error {
+	var err error
+	for range MaxTxnAttempts {
+		tx := &Txn{s: s, reads: make(map[string]Version), writes: make(map[string]BatchOp)}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if _, err = s.Commit(tx.batch()); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", MaxTxnAttempts, err)
+}
+
+// ============= versioned =============
+
+// versioned - мапа з версіями, спільна для Memory/File/Mock/Ordered.
+// Локом керує власник; кожна зміна публікується в hub для Watch.
+type versioned struct {
+	data     map[string]string
+	versions map[string]Version
+	rev      Version
+	bytes    int64 // len(key)+len(value) живих записів
+	hub      *watchHub
+}
+
+func newVersioned() versioned {
+	return versioned{data: make(map[string]string), versions: make(map[string]Version), hub: newWatchHub()}
+}
+
+func (v *versioned) load(key string) (string, Version, error) {
+	value, exists := v.data[key]
+	if !exists {
+		return "", 0, notFound(key)
+	}
+	return value, v.versions[key], nil
+}
+
+func (v *versioned) check(checks []Check) error {
+	for _, c := range checks {
+		if actual := v.versions[c.Key]; actual != c.Version {
+			return conflict(c.Key, c.Version, actual)
+		}
+	}
+	return nil
+}
+
+// set без зміни ревізії - для відновлення зі snapshot
+func (v *versioned) set(key, value string, version Version) {
+	if old, ok := v.data[key]; ok {
+		v.bytes -= int64(len(key) + len(old))
+	}
+	v.data[key] = value
+	v.versions[key] = version
+	v.bytes += int64(len(key) + len(value))
+}
+
+func (v *versioned) remove(key string) bool {
+	old, ok := v.data[key]
+	if !ok {
+		return false
+	}
+	delete(v.data, key)
+	delete(v.versions, key)
+	v.bytes -= int64(len(key) + len(old))
+	return true
+}
+
+func (v *versioned) put(key, value string) Version {
+	v.rev++
+	v.set(key, value, v.rev)
+	v.hub.publish(Event{Type: EventPut, Key: key, Value: value, Revision: v.rev})
+	return v.rev
+}
+
+func (v *versioned) delete(key string) bool {
+	if !v.remove(key) {
+		return false
+	}
+	v.rev++
+	v.hub.publish(Event{Type: EventDelete, Key: key, Revision: v.rev})
+	return true
+}
+
+func (v *versioned) clear() {
+	v.rev++
+	events := make([]Event, 0, len(v.data))
+	for key := range v.data {
+		events = append(events, Event{Type: EventDelete, Key: key, Revision: v.rev})
+	}
+	v.data = make(map[string]string)
+	v.versions = make(map[string]Version)
+	v.bytes = 0
+	v.hub.publish(events...)
+}
+
+// apply - усі операції пакета під однією ревізією
+func (v *versioned) apply(ops []BatchOp) Version {
+	v.rev++
+	events := make([]Event, 0, len(ops))
+	for _, op := range ops {
+		if op.Delete {
+			if v.remove(op.Key) {
+				events = append(events, Event{Type: EventDelete, Key: op.Key, Revision: v.rev})
+			}
+		} else {
+			v.set(op.Key, op.Value, v.rev)
+			events = append(events, Event{Type: EventPut, Key: op.Key, Value: op.Value, Revision: v.rev})
+		}
+	}
+	v.hub.publish(events...)
+	return v.rev
+}
+
+func (v *versioned) watch(ctx context.Context, key string, opts WatchOptions) (<-chan Event, error) {
+	return v.hub.watch(ctx, key, opts)
+}
+
+func (v *versioned) keys() []string {
+	keys := make([]string, 0, len(v.data))
+	for key := range v.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
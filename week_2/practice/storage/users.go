@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============= User Service =============
+// User і UserService - з week_2/practice/user_service. Та сама
+// поведінка, що й у InMemoryUserService, але:
+//
+//   - дані в будь-якому Storage (FileStorage - переживає перезапуск)
+//   - унікальність username/email - індекси, а не перебір усіх користувачів
+//   - помилки типізовані: NotFoundError / ConflictError
+//   - GetByID повертає копію: зміна *User не змінює сховище в обхід Update
+
+type User struct {
+	ID        int
+	Username  string
+	Email     string
+	CreatedAt time.Time
+	IsActive  bool
+}
+
+func (u User) String() string {
+	status := "активний"
+	if !u.IsActive {
+		status = "неактивний"
+	}
+	return fmt.Sprintf("[%d] %s <%s> - %s", u.ID, u.Username, u.Email, status)
+}
+
+type UserService interface {
+	Create(username, email string) (*User, error)
+	GetByID(id int) (*User, error)
+	GetAll() ([]*User, error)
+	Update(id int, username, email string) error
+	Delete(id int) error
+	Activate(id int) error
+	Deactivate(id int) error
+}
+
+type StorageUserService struct {
+	users *Repository[int, User]
+	now   func() time.Time
+}
+
+func NewStorageUserService(s Storage, codec Codec[User]) *StorageUserService {
+	return &StorageUserService{
+		users: NewRepository(s, RepositoryOptions[int, User]{
+			Name:   "user",
+			Codec:  codec,
+			ID:     func(u *User) int { return u.ID },
+			SetID:  func(u *User, id int) { u.ID = id },
+			NextID: SequenceIDs(s, "user"),
+			Unique: []UniqueIndex[User]{
+				{Name: "username", Key: func(u *User) string { return u.Username }},
+				{Name: "email", Key: func(u *User) string { return strings.ToLower(u.Email) }},
+			},
+		}),
+		now: time.Now,
+	}
+}
+
+func (s *StorageUserService) Create(username, email string) (*User, error) {
+	if username == "" {
+		return nil, errors.New("username cannot be empty")
+	}
+	if !strings.Contains(email, "@") {
+		return nil, errors.New("invalid email format")
+	}
+
+	user := &User{
+		Username:  username,
+		Email:     email,
+		CreatedAt: s.now(),
+		IsActive:  true,
+	}
+	if err := s.users.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *StorageUserService) GetByID(id int) (*User, error) {
+	user, err := s.users.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *StorageUserService) GetByEmail(email string) (*User, error) {
+	user, err := s.users.FindBy("email", strings.ToLower(email))
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetAll - за ID (ключі сховища впорядковані як рядки: user:10 < user:2)
+func (s *StorageUserService) GetAll() ([]*User, error) {
+	list, err := s.users.List()
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*User, len(list))
+	for i := range list {
+		users[i] = &list[i]
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Update: порожні поля і email без '@' не змінюються - як в оригіналі
+func (s *StorageUserService) Update(id int, username, email string) error {
+	return s.modify(id, func(u *User) {
+		if username != "" {
+			u.Username = username
+		}
+		if email != "" && strings.Contains(email, "@") {
+			u.Email = email
+		}
+	})
+}
+
+func (s *StorageUserService) Delete(id int) error {
+	return s.users.Delete(id)
+}
+
+func (s *StorageUserService) Activate(id int) error {
+	return s.modify(id, func(u *User) { u.IsActive = true })
+}
+
+func (s *StorageUserService) Deactivate(id int) error {
+	return s.modify(id, func(u *User) { u.IsActive = false })
+}
+
+func (s *StorageUserService) modify(id int, change func(*User)) error {
+	return s.users.Modify(id, func(u *User) error {
+		change(u)
+		return nil
+	})
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ============= Watch =============
+// Observer з design_patterns/behavioral/observer, але для сховища:
+// замість Update(message string) - канал подій з ревізіями, а Subject -
+// сам бекенд. Відмінності від класичного Observer:
+//
+//   - Notify не викликає підписників синхронно: повільний споживач не
+//     повинен гальмувати запис. Кожен watcher має свою чергу і горутину.
+//   - хаб пам'ятає останні події, тож після перепідключення можна
+//     продовжити з ревізії і нічого не пропустити
+//   - за політикою повільний watcher або буферизується, або відключається
+
+type EventType string
+
+const (
+	EventPut    EventType = "put"
+	EventDelete EventType = "delete"
+	EventExpire EventType = "expire" // Cache: минув TTL
+	EventEvict  EventType = "evict"  // Cache: витіснено політикою
+
+	// EventDisconnected - останнє повідомлення повільному watcher'у перед
+	// закриттям каналу. Revision - остання доставлена подія: продовжити
+	// можна з WatchOptions{StartRevision: ev.Revision + 1}.
+	EventDisconnected EventType = "disconnected"
+)
+
+type Event struct {
+	Type     EventType
+	Key      string
+	Value    string // для put
+	Revision Version
+}
+
+var ErrCompacted = errors.New("requested revision is no longer in watch history")
+
+// watchHistory - скільки останніх подій пам'ятає хаб для відновлення
+const watchHistory = 1024
+
+type WatchOptions struct {
+	Prefix bool // key - префікс, а не точний ключ
+
+	// StartRevision > 0 - спершу віддати збережені події з ревізією >=
+	// StartRevision. Якщо їх уже витіснено з історії - ErrCompacted.
+	StartRevision Version
+
+	Buffer int // ємність каналу; 0 - 64
+
+	// MaxPending - скільки подій понад Buffer накопичити, поки споживач
+	// не встигає. 0 - відключати, щойно канал заповнено (політика
+	// "disconnect"); велике значення - політика "buffer".
+	MaxPending int
+}
+
+// Watchable - бекенд, на зміни якого можна підписатися
+type Watchable interface {
+	Watch(ctx context.Context, keyOrPrefix string, opts WatchOptions) (<-chan Event, error)
+}
+
+// ============= Hub =============
+
+type watchHub struct {
+	mu        sync.Mutex
+	history   []Event
+	compacted Version // події з ревізією <= compacted уже не в історії
+	rev       Version // ревізія останньої події
+	watchers  map[*watcher]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watchers: make(map[*watcher]struct{})}
+}
+
+// publish викликається бекендом під його локом запису, тож ревізії
+// зростають. Усі події одного виклику - одна ревізія (один Commit):
+// watcher отримує їх цілком або, якщо його відключено, не отримує жодної.
+func (h *watchHub) publish(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ev := range events {
+		h.history = append(h.history, ev)
+		if len(h.history) > watchHistory {
+			h.compacted = h.history[0].Revision
+			h.history = h.history[1:]
+		}
+	}
+	h.rev = events[0].Revision
+
+	for w := range h.watchers {
+		var matched []Event
+		for _, ev := range events {
+			if w.matches(ev.Key) {
+				matched = append(matched, ev)
+			}
+		}
+		if len(matched) > 0 {
+			w.enqueue(matched)
+		}
+	}
+}
+
+// reset - ревізії до rev недоступні (бекенд відновлено зі snapshot)
+func (h *watchHub) reset(rev Version) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = nil
+	h.compacted, h.rev = rev, rev
+}
+
+func (h *watchHub) watch(ctx context.Context, key string, opts WatchOptions) (<-chan Event, error) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 64
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w := &watcher{
+		key:        key,
+		prefix:     opts.Prefix,
+		out:        make(chan Event, opts.Buffer),
+		notify:     make(chan struct{}, 1),
+		maxPending: opts.MaxPending,
+		delivered:  h.rev,
+	}
+
+	if opts.StartRevision > 0 {
+		if opts.StartRevision <= h.compacted {
+			return nil, ErrCompacted
+		}
+		w.delivered = opts.StartRevision - 1
+		// Історія - це вже сталося, тож вона не рахується в MaxPending
+		for _, ev := range h.history {
+			if ev.Revision < opts.StartRevision || !w.matches(ev.Key) {
+				continue
+			}
+			if n := len(w.pending); n > 0 && w.pending[n-1][0].Revision == ev.Revision {
+				w.pending[n-1] = append(w.pending[n-1], ev)
+			} else {
+				w.pending = append(w.pending, []Event{ev})
+			}
+		}
+		w.replay = len(w.pending)
+		w.signal()
+	}
+
+	h.watchers[w] = struct{}{}
+	go w.pump(ctx, h)
+	return w.out, nil
+}
+
+func (h *watchHub) remove(w *watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.watchers, w)
+}
+
+// ============= Watcher =============
+
+type watcher struct {
+	key    string
+	prefix bool
+	out    chan Event
+	notify chan struct{}
+
+	mu         sync.Mutex
+	pending    [][]Event // по ревізіях
+	queued     int       // живих подій у pending
+	replay     int       // ревізій історії на початку pending; не в queued
+	maxPending int
+	dropped    bool
+
+	delivered Version // остання повністю доставлена ревізія; лише для pump
+}
+
+func (w *watcher) matches(key string) bool {
+	if w.prefix {
+		return strings.HasPrefix(key, w.key)
+	}
+	return key == w.key
+}
+
+func (w *watcher) signal() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue не блокує: інакше один повільний watcher зупинив би всі записи
+func (w *watcher) enqueue(batch []Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dropped {
+		return
+	}
+	// Місце в каналі pump ще заповнить із черги; відключаємо, лише коли
+	// черга не влізе в канал навіть із запасом MaxPending
+	// Уже прийняте в чергу все одно доставляється, потім EventDisconnected
+	if w.queued+len(batch) > w.maxPending+cap(w.out)-len(w.out) {
+		w.dropped = true
+	} else {
+		w.pending = append(w.pending, batch)
+		w.queued += len(batch)
+	}
+	w.signal()
+}
+
+// pump переносить чергу в канал по одній ревізії. Ревізії в черзі
+// доставляються до кінця навіть після відключення, тож Revision у
+// EventDisconnected завжди повна.
+func (w *watcher) pump(ctx context.Context, h *watchHub) {
+	defer close(w.out)
+	defer h.remove(w)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.notify:
+		}
+
+		for {
+			w.mu.Lock()
+			if len(w.pending) == 0 {
+				dropped := w.dropped
+				w.mu.Unlock()
+				if !dropped {
+					break
+				}
+				h.remove(w) // більше не ставити в чергу
+				select {
+				case w.out <- Event{Type: EventDisconnected, Revision: w.delivered}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			batch := w.pending[0]
+			w.pending = w.pending[1:]
+			if w.replay > 0 {
+				w.replay--
+			} else {
+				w.queued -= len(batch)
+			}
+			w.mu.Unlock()
+
+			for _, ev := range batch {
+				select {
+				case w.out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			w.delivered = batch[0].Revision
+		}
+	}
+}
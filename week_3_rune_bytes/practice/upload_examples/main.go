@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func printSeparator(title string) {
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", 60))
+}
+
+func printResponse(resp *http.Response) {
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	fmt.Printf("  %d %s", resp.StatusCode, body)
+}
+
+func main() {
+	fmt.Println("╔══════════════════════════════════════════════════════════╗")
+	fmt.Println("║      Streaming Upload: magic bytes, SHA-256, resume      ║")
+	fmt.Println("╚══════════════════════════════════════════════════════════╝")
+
+	uploader := NewUploader(NewMemoryBlobStore(), 1<<20,
+		"image/png", "image/jpeg", "image/gif", "application/pdf", "text/plain")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go uploader.Janitor(ctx, time.Minute)
+	server := httptest.NewServer(uploader.Routes())
+	defer server.Close()
+
+	png := append([]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}, bytes.Repeat([]byte{0}, 100)...)
+
+	// Demo 1: сире тіло
+	printSeparator("1. Raw body upload")
+	req, _ := http.NewRequest("POST", server.URL+"/upload", bytes.NewReader(png))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-File-Name", "logo.png")
+	resp, _ := http.DefaultClient.Do(req)
+	printResponse(resp)
+
+	// Demo 2: multipart
+	printSeparator("2. Multipart upload")
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("description", "release notes")
+	part, _ := mw.CreateFormFile("file", "notes.txt")
+	part.Write([]byte("Привіт! Version 2.0 released 🎉\n"))
+	mw.Close()
+	resp, _ = http.Post(server.URL+"/upload", mw.FormDataContentType(), &body)
+	printResponse(resp)
+
+	// Demo 3: заборонений тип
+	printSeparator("3. Disallowed type (ELF executable)")
+	elf := []byte{0x7F, 'E', 'L', 'F', 2, 1, 1, 0}
+	resp, _ = http.Post(server.URL+"/upload", "application/octet-stream", bytes.NewReader(elf))
+	printResponse(resp)
+
+	// Demo 4: resumable upload шматками з "обривом"
+	printSeparator("4. Resumable upload with Content-Range")
+	pdf := append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("x"), 2000)...)
+	total := len(pdf)
+
+	req, _ = http.NewRequest("POST", server.URL+"/uploads", nil)
+	req.Header.Set("Upload-Length", strconv.Itoa(total))
+	req.Header.Set("X-File-Name", "report.pdf")
+	resp, _ = http.DefaultClient.Do(req)
+	location := resp.Header.Get("Location")
+	printResponse(resp)
+
+	const chunk = 600
+	lost := false
+	for offset := 0; offset < total; {
+		end := min(offset+chunk, total) - 1
+
+		// Один раз клієнт "губить" позицію: сервер бачить неправильний start
+		start := offset
+		if offset == chunk && !lost {
+			start += 10
+			lost = true
+		}
+
+		req, _ = http.NewRequest("PUT", server.URL+location, bytes.NewReader(pdf[start:end+1]))
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, total))
+		resp, _ = http.DefaultClient.Do(req)
+		fmt.Printf("  PUT bytes %d-%d ->", start, end)
+		printResponse(resp)
+
+		if resp.StatusCode == http.StatusConflict {
+			// Питаємо сервер, звідки продовжити
+			head, _ := http.Head(server.URL + location)
+			offset, _ = strconv.Atoi(head.Header.Get("Upload-Offset"))
+			fmt.Printf("  HEAD -> Upload-Offset: %d, resuming\n", offset)
+			continue
+		}
+		offset, _ = strconv.Atoi(resp.Header.Get("Upload-Offset"))
+	}
+
+	// Demo 5: таблиця сигнатур
+	printSeparator("5. DetectFileType")
+	samples := map[string][]byte{
+		"gif":  []byte("GIF89a..."),
+		"webp": []byte("RIFF\x24\x00\x00\x00WEBPVP8 "),
+		"wav":  []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+		"gzip": {0x1F, 0x8B, 0x08, 0x00},
+		"mp4":  []byte("\x00\x00\x00\x18ftypmp42"),
+		"text": []byte("Київ, Україна"),
+	}
+	for _, name := range []string{"gif", "webp", "wav", "gzip", "mp4", "text"} {
+		t := DetectFileType(samples[name])
+		fmt.Printf("  %-5s -> %-20s %s\n", name, t.Name, t.MIME)
+	}
+}
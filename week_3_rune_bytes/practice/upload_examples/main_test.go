@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pngHeader = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+
+func pngFile(size int) []byte {
+	data := make([]byte, size)
+	copy(data, pngHeader)
+	for i := len(pngHeader); i < size; i++ {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func send(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeResult(t *testing.T, rec *httptest.ResponseRecorder) UploadResult {
+	t.Helper()
+	var result UploadResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	return result
+}
+
+func TestDetectFileType(t *testing.T) {
+	tar := make([]byte, 300)
+	copy(tar[257:], "ustar")
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", pngHeader, "image/png"},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, "image/jpeg"},
+		{"gif", []byte("GIF89a"), "image/gif"},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"wav", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), "audio/wav"},
+		{"riff other", []byte("RIFF\x00\x00\x00\x00XXXX"), TypeUnknown.MIME},
+		{"pdf", []byte("%PDF-1.4"), "application/pdf"},
+		{"zip", []byte{'P', 'K', 3, 4}, "application/zip"},
+		{"gzip", []byte{0x1F, 0x8B, 8}, "application/gzip"},
+		{"tar", tar, "application/x-tar"},
+		{"mp4", []byte("\x00\x00\x00\x18ftypisom"), "video/mp4"},
+		{"avif", []byte("\x00\x00\x00\x1cftypavif"), "image/avif"},
+		{"elf", []byte{0x7F, 'E', 'L', 'F'}, "application/x-elf"},
+		{"utf-8 text", []byte("Привіт, світ!\n"), TypeText.MIME},
+		{"binary", []byte{0x01, 0x02, 0x03}, TypeUnknown.MIME},
+		{"empty", nil, TypeUnknown.MIME},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFileType(tt.data).MIME; got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+
+	// Багатобайтовий символ, обрізаний на межі sniffLen, - все ще текст
+	text := bytes.Repeat([]byte("ї"), sniffLen)
+	if got := DetectFileType(text[:sniffLen+1]); got != TypeText {
+		t.Errorf("Expected text for cut rune, got %s", got.MIME)
+	}
+}
+
+func TestUpload_RawBody(t *testing.T) {
+	store := NewMemoryBlobStore()
+	h := NewUploader(store, 1<<20, "image/png").Routes()
+	data := pngFile(100_000)
+
+	req := httptest.NewRequest("POST", "/upload", bytes.NewReader(data))
+	req.Header.Set("X-File-Name", "logo.png")
+	rec := send(h, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	result := decodeResult(t, rec)
+	if result.SHA256 != sha(data) || result.Size != int64(len(data)) || result.ContentType != "image/png" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// Скачаний файл збігається з відправленим
+	down := send(h, httptest.NewRequest("GET", "/files/"+result.ID, nil))
+	if !bytes.Equal(down.Body.Bytes(), data) {
+		t.Errorf("Downloaded content differs")
+	}
+	if down.Header().Get("X-Content-Type-Options") != "nosniff" || down.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Unexpected download headers: %v", down.Header())
+	}
+}
+
+func TestUpload_Multipart(t *testing.T) {
+	h := NewUploader(NewMemoryBlobStore(), 1<<20).Routes()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("description", "ignored")
+	part, _ := mw.CreateFormFile("file", "notes.txt")
+	part.Write([]byte("hello"))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := send(h, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	result := decodeResult(t, rec)
+	if result.Name != "notes.txt" || result.SHA256 != sha([]byte("hello")) || result.Type != "text" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// multipart без файлу
+	var empty bytes.Buffer
+	mw = multipart.NewWriter(&empty)
+	mw.WriteField("description", "no file")
+	mw.Close()
+	req = httptest.NewRequest("POST", "/upload", &empty)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if rec := send(h, req); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without file part, got %d", rec.Code)
+	}
+}
+
+func TestUpload_Rejections(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"disallowed type", []byte{0x7F, 'E', 'L', 'F', 2, 1, 1}, http.StatusUnsupportedMediaType},
+		{"too large", pngFile(2048), http.StatusRequestEntityTooLarge},
+		{"exactly max", pngFile(1024), http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		store := NewMemoryBlobStore()
+		h := NewUploader(store, 1024, "image/png").Routes()
+
+		rec := send(h, httptest.NewRequest("POST", "/upload", bytes.NewReader(tt.data)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, rec.Code, rec.Body.String())
+		}
+		if tt.want != http.StatusCreated && len(store.blobs) != 0 {
+			t.Errorf("%s: expected nothing stored, got %d blobs", tt.name, len(store.blobs))
+		}
+	}
+}
+
+// resumable створює сесію і повертає її шлях
+func resumable(t *testing.T, h http.Handler, total int) string {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/uploads", nil)
+	req.Header.Set("Upload-Length", strconv.Itoa(total))
+	rec := send(h, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return rec.Header().Get("Location")
+}
+
+func putChunk(h http.Handler, location string, body io.Reader, start, end, total int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PUT", location, body)
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, total))
+	return send(h, req)
+}
+
+// failingReader віддає n байт, а потім імітує обрив з'єднання
+type failingReader struct {
+	data []byte
+	n    int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, errors.New("connection reset by peer")
+	}
+	n := copy(p, r.data[:min(r.n, len(r.data))])
+	r.data, r.n = r.data[n:], r.n-n
+	return n, nil
+}
+
+func TestResumable_SurvivesDroppedChunk(t *testing.T) {
+	store := NewMemoryBlobStore()
+	h := NewUploader(store, 1<<20, "image/png").Routes()
+	data := pngFile(3000)
+	location := resumable(t, h, len(data))
+
+	// Перший шматок обривається на 700 байтах з 1000
+	req := httptest.NewRequest("PUT", location, &failingReader{data: data[:1000], n: 700})
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes 0-999/%d", len(data)))
+	req.ContentLength = -1
+	send(h, req)
+
+	head := send(h, httptest.NewRequest("HEAD", location, nil))
+	offset, _ := strconv.Atoi(head.Header().Get("Upload-Offset"))
+	if offset != 700 {
+		t.Fatalf("Expected offset 700 after drop, got %d", offset)
+	}
+
+	// Шматок не з того місця
+	if rec := putChunk(h, location, bytes.NewReader(data[1000:2000]), 1000, 1999, len(data)); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for wrong offset, got %d", rec.Code)
+	}
+
+	// Продовжуємо з offset
+	if rec := putChunk(h, location, bytes.NewReader(data[offset:2000]), offset, 1999, len(data)); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for middle chunk, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := putChunk(h, location, bytes.NewReader(data[2000:]), 2000, len(data)-1, len(data))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 on last chunk, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	result := decodeResult(t, rec)
+	if result.SHA256 != sha(data) || result.ContentType != "image/png" {
+		t.Errorf("Expected hash of whole file, got %+v", result)
+	}
+	if head := send(h, httptest.NewRequest("HEAD", location, nil)); head.Code != http.StatusNotFound {
+		t.Errorf("Expected session removed after completion, got %d", head.Code)
+	}
+}
+
+func TestResumable_Validation(t *testing.T) {
+	h := NewUploader(NewMemoryBlobStore(), 4096, "image/png").Routes()
+
+	req := httptest.NewRequest("POST", "/uploads", nil)
+	req.Header.Set("Upload-Length", "5000")
+	if rec := send(h, req); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for Upload-Length over max, got %d", rec.Code)
+	}
+
+	location := resumable(t, h, 1000)
+	tests := []struct {
+		name string
+		rng  string
+		body []byte
+		want int
+	}{
+		{"bad header", "bytes 0-10", pngFile(11), http.StatusBadRequest},
+		{"wrong total", "bytes 0-599/999", pngFile(600), http.StatusBadRequest},
+		{"first chunk too small", "bytes 0-99/1000", pngFile(100), http.StatusBadRequest},
+		{"length mismatch", "bytes 0-599/1000", pngFile(500), http.StatusBadRequest},
+		{"disallowed type", "bytes 0-599/1000", bytes.Repeat([]byte("MZ"), 300), http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", location, bytes.NewReader(tt.body))
+		req.Header.Set("Content-Range", tt.rng)
+		if rec := send(h, req); rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Після забороненого типу сесію скасовано
+	if head := send(h, httptest.NewRequest("HEAD", location, nil)); head.Code != http.StatusNotFound {
+		t.Errorf("Expected session aborted, got %d", head.Code)
+	}
+}
+
+func TestResumable_ExpiryAndLimit(t *testing.T) {
+	store := NewMemoryBlobStore()
+	u := NewUploader(store, 1<<20, "image/png")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	u.now = func() time.Time { return now }
+	u.SessionTTL = time.Minute
+	u.MaxSessions = 2
+	h := u.Routes()
+	data := pngFile(2000)
+
+	abandoned := resumable(t, h, len(data))
+	active := resumable(t, h, len(data))
+
+	req := httptest.NewRequest("POST", "/uploads", nil)
+	req.Header.Set("Upload-Length", "100")
+	if rec := send(h, req); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 over MaxSessions, got %d", rec.Code)
+	}
+
+	now = now.Add(50 * time.Second)
+	putChunk(h, active, bytes.NewReader(data[:1000]), 0, 999, len(data))
+	now = now.Add(20 * time.Second)
+
+	// Ліміт вичерпано, але покинута сесія прострочена - місце звільняється
+	third := resumable(t, h, len(data))
+	if head := send(h, httptest.NewRequest("HEAD", abandoned, nil)); head.Code != http.StatusNotFound {
+		t.Errorf("Expected abandoned session expired, got %d", head.Code)
+	}
+	if _, err := store.Size(strings.TrimPrefix(abandoned, "/uploads/")); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Expected abandoned blob deleted, got %v", err)
+	}
+	if head := send(h, httptest.NewRequest("HEAD", active, nil)); head.Code != http.StatusOK {
+		t.Errorf("Expected active session kept, got %d", head.Code)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if n := u.ExpireSessions(); n != 2 {
+		t.Errorf("Expected 2 expired sessions, got %d", n)
+	}
+	if rec := putChunk(h, third, bytes.NewReader(data[:1000]), 0, 999, len(data)); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for expired session, got %d", rec.Code)
+	}
+}
+
+// blockingReader віддає дані лише після release
+type blockingReader struct {
+	data    []byte
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	if r.started != nil {
+		close(r.started)
+		r.started = nil
+		<-r.release
+	}
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestResumable_AbortWaitsForChunk(t *testing.T) {
+	store := NewMemoryBlobStore()
+	h := NewUploader(store, 1<<20, "image/png").Routes()
+	data := pngFile(2000)
+	location := resumable(t, h, len(data))
+
+	body := &blockingReader{data: data[:1000], started: make(chan struct{}), release: make(chan struct{})}
+	started := body.started
+	chunkDone := make(chan int)
+	go func() {
+		chunkDone <- putChunk(h, location, body, 0, 999, len(data)).Code
+	}()
+	<-started
+
+	abortDone := make(chan int)
+	go func() {
+		abortDone <- send(h, httptest.NewRequest("DELETE", location, nil)).Code
+	}()
+	select {
+	case code := <-abortDone:
+		t.Fatalf("Expected abort to wait for the chunk, got %d", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(body.release)
+	if code := <-chunkDone; code != http.StatusOK {
+		t.Errorf("Expected chunk to finish with 200, got %d", code)
+	}
+	if code := <-abortDone; code != http.StatusNoContent {
+		t.Errorf("Expected 204 from abort, got %d", code)
+	}
+	if _, err := store.Size(strings.TrimPrefix(location, "/uploads/")); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Expected blob deleted after abort, got %v", err)
+	}
+	if rec := send(h, httptest.NewRequest("DELETE", location, nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 on second abort, got %d", rec.Code)
+	}
+}
+
+func TestDirBlobStore(t *testing.T) {
+	store, err := NewDirBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewUploader(store, 1<<20).Routes()
+	data := pngFile(5000)
+
+	location := resumable(t, h, len(data))
+	putChunk(h, location, bytes.NewReader(data[:2500]), 0, 2499, len(data))
+	rec := putChunk(h, location, bytes.NewReader(data[2500:]), 2500, len(data)-1, len(data))
+	result := decodeResult(t, rec)
+
+	blob, err := store.Open(result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Close()
+	stored, _ := io.ReadAll(blob)
+	if !bytes.Equal(stored, data) || result.SHA256 != sha(data) {
+		t.Errorf("Expected stored file to match upload")
+	}
+
+	if _, err := store.Writer(result.ID, 10); !errors.Is(err, ErrOffsetChanged) {
+		t.Errorf("Expected ErrOffsetChanged, got %v", err)
+	}
+	if _, err := store.Size("missing"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Expected ErrBlobNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// ============= Magic bytes =============
+
+// sniffLen - скільки байт потрібно з початку файлу.
+// Найдальша сигнатура - "ustar" у TAR на зсуві 257.
+const sniffLen = 512
+
+// FileType - результат розпізнавання
+type FileType struct {
+	MIME string
+	Name string
+}
+
+var (
+	TypeUnknown = FileType{"application/octet-stream", "unknown"}
+	TypeText    = FileType{"text/plain; charset=utf-8", "text"}
+)
+
+// signature - magic bytes на певному зсуві.
+// also - додаткова перевірка для контейнерів на кшталт RIFF, де
+// спільний префікс не визначає тип (WAV і WEBP обидва починаються з RIFF).
+type signature struct {
+	offset int
+	magic  []byte
+	also   *signature
+	typ    FileType
+}
+
+// Порядок важливий: довші та специфічніші сигнатури йдуть першими
+var signatures = []signature{
+	// Зображення
+	{offset: 0, magic: []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}, typ: FileType{"image/png", "PNG image"}},
+	{offset: 0, magic: []byte{0xFF, 0xD8, 0xFF}, typ: FileType{"image/jpeg", "JPEG image"}},
+	{offset: 0, magic: []byte("GIF87a"), typ: FileType{"image/gif", "GIF image"}},
+	{offset: 0, magic: []byte("GIF89a"), typ: FileType{"image/gif", "GIF image"}},
+	{offset: 0, magic: []byte("RIFF"), also: &signature{offset: 8, magic: []byte("WEBP")}, typ: FileType{"image/webp", "WebP image"}},
+	{offset: 0, magic: []byte("BM"), typ: FileType{"image/bmp", "BMP image"}},
+	{offset: 0, magic: []byte{'I', 'I', 0x2A, 0x00}, typ: FileType{"image/tiff", "TIFF image"}},
+	{offset: 0, magic: []byte{'M', 'M', 0x00, 0x2A}, typ: FileType{"image/tiff", "TIFF image"}},
+	{offset: 0, magic: []byte{0x00, 0x00, 0x01, 0x00}, typ: FileType{"image/x-icon", "ICO image"}},
+	{offset: 4, magic: []byte("ftypavif"), typ: FileType{"image/avif", "AVIF image"}},
+	{offset: 4, magic: []byte("ftypheic"), typ: FileType{"image/heic", "HEIC image"}},
+
+	// Документи
+	{offset: 0, magic: []byte("%PDF-"), typ: FileType{"application/pdf", "PDF document"}},
+	{offset: 0, magic: []byte("%!PS"), typ: FileType{"application/postscript", "PostScript document"}},
+	{offset: 0, magic: []byte("{\\rtf"), typ: FileType{"application/rtf", "RTF document"}},
+	{offset: 0, magic: []byte("SQLite format 3\x00"), typ: FileType{"application/vnd.sqlite3", "SQLite database"}},
+
+	// Архіви
+	{offset: 0, magic: []byte{'P', 'K', 0x03, 0x04}, typ: FileType{"application/zip", "ZIP archive"}},
+	{offset: 0, magic: []byte{'P', 'K', 0x05, 0x06}, typ: FileType{"application/zip", "ZIP archive (empty)"}},
+	{offset: 0, magic: []byte{0x1F, 0x8B}, typ: FileType{"application/gzip", "GZIP archive"}},
+	{offset: 0, magic: []byte("BZh"), typ: FileType{"application/x-bzip2", "BZIP2 archive"}},
+	{offset: 0, magic: []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}, typ: FileType{"application/x-xz", "XZ archive"}},
+	{offset: 0, magic: []byte{0x28, 0xB5, 0x2F, 0xFD}, typ: FileType{"application/zstd", "Zstandard archive"}},
+	{offset: 0, magic: []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}, typ: FileType{"application/x-7z-compressed", "7-Zip archive"}},
+	{offset: 0, magic: []byte("Rar!\x1A\x07"), typ: FileType{"application/vnd.rar", "RAR archive"}},
+	{offset: 257, magic: []byte("ustar"), typ: FileType{"application/x-tar", "TAR archive"}},
+
+	// Аудіо та відео
+	{offset: 0, magic: []byte("ID3"), typ: FileType{"audio/mpeg", "MP3 audio"}},
+	{offset: 0, magic: []byte{0xFF, 0xFB}, typ: FileType{"audio/mpeg", "MP3 audio"}},
+	{offset: 0, magic: []byte("OggS"), typ: FileType{"audio/ogg", "OGG audio"}},
+	{offset: 0, magic: []byte("fLaC"), typ: FileType{"audio/flac", "FLAC audio"}},
+	{offset: 0, magic: []byte("RIFF"), also: &signature{offset: 8, magic: []byte("WAVE")}, typ: FileType{"audio/wav", "WAV audio"}},
+	{offset: 0, magic: []byte("RIFF"), also: &signature{offset: 8, magic: []byte("AVI ")}, typ: FileType{"video/x-msvideo", "AVI video"}},
+	{offset: 4, magic: []byte("ftyp"), typ: FileType{"video/mp4", "MP4 video"}},
+	{offset: 0, magic: []byte{0x1A, 0x45, 0xDF, 0xA3}, typ: FileType{"video/webm", "WebM/Matroska video"}},
+
+	// Виконувані файли - зазвичай заборонені для завантаження
+	{offset: 0, magic: []byte{0x7F, 'E', 'L', 'F'}, typ: FileType{"application/x-elf", "ELF executable"}},
+	{offset: 0, magic: []byte("MZ"), typ: FileType{"application/x-msdownload", "Windows executable"}},
+	{offset: 0, magic: []byte{0xCF, 0xFA, 0xED, 0xFE}, typ: FileType{"application/x-mach-binary", "Mach-O executable"}},
+	{offset: 0, magic: []byte{0x00, 'a', 's', 'm'}, typ: FileType{"application/wasm", "WebAssembly module"}},
+	{offset: 0, magic: []byte("#!"), typ: FileType{"text/x-shellscript", "shell script"}},
+}
+
+func (s signature) match(data []byte) bool {
+	end := s.offset + len(s.magic)
+	if len(data) < end || !bytes.Equal(data[s.offset:end], s.magic) {
+		return false
+	}
+	return s.also == nil || s.also.match(data)
+}
+
+// DetectFileType - розширена версія detectFileType з http_examples:
+// таблиця сигнатур замість ланцюжка if, сигнатури на довільному зсуві.
+// Файл без сигнатури, що є коректним UTF-8 без керуючих символів, - текст.
+func DetectFileType(data []byte) FileType {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+
+	for _, sig := range signatures {
+		if sig.match(data) {
+			return sig.typ
+		}
+	}
+
+	if len(data) > 0 && isText(data) {
+		return TypeText
+	}
+	return TypeUnknown
+}
+
+func isText(data []byte) bool {
+	// Останній символ міг обрізатись на межі sniffLen
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			return len(data) < utf8.UTFMax && !utf8.FullRune(data)
+		}
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+		data = data[size:]
+	}
+	return true
+}
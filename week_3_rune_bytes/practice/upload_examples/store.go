@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ============= Blob Store =============
+
+var (
+	ErrBlobNotFound  = errors.New("blob not found")
+	ErrOffsetChanged = errors.New("blob offset mismatch")
+)
+
+// BlobStore зберігає вміст файлів.
+// Writer(id, offset) дописує з позиції offset, яка має дорівнювати
+// поточному розміру blob - так шматки resumable upload не перекриваються.
+// offset 0 створює blob заново.
+type BlobStore interface {
+	Writer(id string, offset int64) (io.WriteCloser, error)
+	Size(id string) (int64, error)
+	Open(id string) (io.ReadCloser, error)
+	Delete(id string) error
+}
+
+// ============= Memory =============
+
+type MemoryBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string]*bytes.Buffer
+}
+
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: make(map[string]*bytes.Buffer)}
+}
+
+func (s *MemoryBlobStore) Writer(id string, offset int64) (io.WriteCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if offset == 0 {
+		s.blobs[id] = &bytes.Buffer{}
+	}
+	buf, ok := s.blobs[id]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	if int64(buf.Len()) != offset {
+		return nil, fmt.Errorf("%w: have %d, got %d", ErrOffsetChanged, buf.Len(), offset)
+	}
+	return &memoryWriter{store: s, buf: buf}, nil
+}
+
+func (s *MemoryBlobStore) Size(id string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, ok := s.blobs[id]
+	if !ok {
+		return 0, ErrBlobNotFound
+	}
+	return int64(buf.Len()), nil
+}
+
+func (s *MemoryBlobStore) Open(id string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, ok := s.blobs[id]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(bytes.Clone(buf.Bytes()))), nil
+}
+
+func (s *MemoryBlobStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, id)
+	return nil
+}
+
+type memoryWriter struct {
+	store *MemoryBlobStore
+	buf   *bytes.Buffer
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *memoryWriter) Close() error {
+	return nil
+}
+
+// ============= Directory =============
+
+// DirBlobStore - один файл на blob у директорії
+type DirBlobStore struct {
+	dir string
+}
+
+func NewDirBlobStore(dir string) (*DirBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirBlobStore{dir: dir}, nil
+}
+
+func (s *DirBlobStore) path(id string) string {
+	// id генерує сервер, але Base захищає від "../"
+	return filepath.Join(s.dir, filepath.Base(id))
+}
+
+func (s *DirBlobStore) Writer(id string, offset int64) (io.WriteCloser, error) {
+	if offset == 0 {
+		return os.Create(s.path(id))
+	}
+
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY|os.O_APPEND, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() != offset {
+		f.Close()
+		return nil, fmt.Errorf("%w: have %d, got %d", ErrOffsetChanged, info.Size(), offset)
+	}
+	return f, nil
+}
+
+func (s *DirBlobStore) Size(id string) (int64, error) {
+	info, err := os.Stat(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrBlobNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *DirBlobStore) Open(id string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return f, err
+}
+
+func (s *DirBlobStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,609 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// multipartOverhead - запас на межі та заголовки частин multipart
+const multipartOverhead = 64 << 10
+
+// UploadResult - метадані збереженого файлу
+type UploadResult struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	ContentType string `json:"content_type"`
+	Type        string `json:"type"`
+}
+
+// UploadError несе HTTP-статус, з яким треба відповісти
+type UploadError struct {
+	Status  int
+	Message string
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+func uploadErrorf(status int, format string, args ...interface{}) error {
+	return &UploadError{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// ============= Uploader =============
+
+// Uploader приймає файли потоком: тіло ніколи не читається в пам'ять
+// цілком, як у handleFileUpload з http_examples. Перші sniffLen байт
+// переглядаються через bufio.Reader.Peek, щоб визначити тип до запису.
+type Uploader struct {
+	store   BlobStore
+	maxSize int64
+	allowed map[string]bool // nil - дозволено все
+
+	// SessionTTL - сесія без жодного шматка довше цього видаляється разом
+	// з тимчасовим blob (див. Janitor). MaxSessions - скільки незавершених
+	// сесій може бути одночасно; понад це POST /uploads отримує 503.
+	SessionTTL  time.Duration
+	MaxSessions int
+
+	now func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	files    map[string]UploadResult
+}
+
+// NewUploader: allowed - MIME-типи без параметрів ("image/png", "text/plain")
+func NewUploader(store BlobStore, maxSize int64, allowed ...string) *Uploader {
+	u := &Uploader{
+		store:       store,
+		maxSize:     maxSize,
+		SessionTTL:  time.Hour,
+		MaxSessions: 1000,
+		now:         time.Now,
+		sessions:    make(map[string]*session),
+		files:       make(map[string]UploadResult),
+	}
+	if len(allowed) > 0 {
+		u.allowed = make(map[string]bool, len(allowed))
+		for _, t := range allowed {
+			u.allowed[t] = true
+		}
+	}
+	return u
+}
+
+func (u *Uploader) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /upload", u.handleUpload)
+	mux.HandleFunc("POST /uploads", u.handleCreateSession)
+	mux.HandleFunc("PUT /uploads/{id}", u.handleChunk)
+	mux.HandleFunc("HEAD /uploads/{id}", u.handleSessionStatus)
+	mux.HandleFunc("DELETE /uploads/{id}", u.handleAbort)
+	mux.HandleFunc("GET /files/{id}", u.handleDownload)
+	return mux
+}
+
+func (u *Uploader) checkType(head []byte) (FileType, error) {
+	typ := DetectFileType(head)
+	mediaType, _, _ := strings.Cut(typ.MIME, ";")
+	if u.allowed != nil && !u.allowed[mediaType] {
+		return typ, uploadErrorf(http.StatusUnsupportedMediaType, "file type %s (%s) is not allowed", typ.Name, mediaType)
+	}
+	return typ, nil
+}
+
+// ============= Single request upload =============
+
+// handleUpload приймає multipart/form-data (перша частина з filename)
+// або сире тіло з будь-яким іншим Content-Type
+func (u *Uploader) handleUpload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, u.maxSize+multipartOverhead)
+
+	src, name, err := uploadSource(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result, err := u.save(newID(), name, src)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/files/"+result.ID)
+	writeJSON(w, http.StatusCreated, result)
+}
+
+func uploadSource(r *http.Request) (io.Reader, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		name := r.Header.Get("X-File-Name")
+		if name == "" {
+			name = "upload"
+		}
+		return r.Body, name, nil
+	}
+
+	// MultipartReader читає частини по черзі, на відміну від ParseMultipartForm
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", uploadErrorf(http.StatusBadRequest, "invalid multipart body: %v", err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, "", uploadErrorf(http.StatusBadRequest, "multipart body has no file part")
+		}
+		if err != nil {
+			return nil, "", uploadErrorf(http.StatusBadRequest, "invalid multipart body: %v", err)
+		}
+		if part.FileName() != "" {
+			return part, part.FileName(), nil
+		}
+		part.Close()
+	}
+}
+
+// save перевіряє тип, пише у сховище і рахує SHA-256 одним проходом
+func (u *Uploader) save(id, name string, src io.Reader) (UploadResult, error) {
+	br := bufio.NewReaderSize(src, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return UploadResult{}, readError(err)
+	}
+
+	typ, err := u.checkType(head)
+	if err != nil {
+		return UploadResult{}, err
+	}
+
+	bw, err := u.store.Writer(id, 0)
+	if err != nil {
+		return UploadResult{}, err
+	}
+
+	h := sha256.New()
+	size, err := copyHashed(bw, h, io.LimitReader(br, u.maxSize+1))
+	if closeErr := bw.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > u.maxSize {
+		err = uploadErrorf(http.StatusRequestEntityTooLarge, "file exceeds %d bytes", u.maxSize)
+	}
+	if err != nil {
+		u.store.Delete(id)
+		return UploadResult{}, readError(err)
+	}
+
+	result := UploadResult{
+		ID:          id,
+		Name:        name,
+		Size:        size,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+		ContentType: typ.MIME,
+		Type:        typ.Name,
+	}
+
+	u.mu.Lock()
+	u.files[id] = result
+	u.mu.Unlock()
+	return result, nil
+}
+
+// ============= Resumable upload =============
+
+// session - незавершене завантаження. Стан SHA-256 живе між запитами,
+// тому хеш рахується без повторного читання вже записаних шматків.
+type session struct {
+	mu sync.Mutex // один шматок, скасування чи прибирання одночасно
+
+	id         string
+	name       string
+	total      int64
+	offset     int64
+	typ        FileType
+	hash       hash.Hash
+	lastActive time.Time
+	closed     bool // завершена, скасована або прострочена
+}
+
+// handleCreateSession: POST /uploads з Upload-Length: <повний розмір>
+func (u *Uploader) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	total, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || total <= 0 {
+		writeError(w, uploadErrorf(http.StatusBadRequest, "Upload-Length header is required"))
+		return
+	}
+	if total > u.maxSize {
+		writeError(w, uploadErrorf(http.StatusRequestEntityTooLarge, "file exceeds %d bytes", u.maxSize))
+		return
+	}
+
+	s := &session{
+		id:         newID(),
+		name:       r.Header.Get("X-File-Name"),
+		total:      total,
+		hash:       sha256.New(),
+		lastActive: u.now(),
+	}
+	if s.name == "" {
+		s.name = "upload"
+	}
+
+	if !u.addSession(s) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(u.SessionTTL.Seconds())))
+		writeError(w, uploadErrorf(http.StatusServiceUnavailable, "too many unfinished uploads"))
+		return
+	}
+	bw, err := u.store.Writer(s.id, 0)
+	if err != nil {
+		u.mu.Lock()
+		delete(u.sessions, s.id)
+		u.mu.Unlock()
+		writeError(w, err)
+		return
+	}
+	bw.Close()
+
+	w.Header().Set("Location", "/uploads/"+s.id)
+	w.Header().Set("Upload-Offset", "0")
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"id": s.id, "offset": 0, "size": total})
+}
+
+// handleChunk: PUT /uploads/{id} з Content-Range: bytes start-end/total.
+// start має дорівнювати поточному Upload-Offset, інакше 409 -
+// клієнт питає HEAD і продовжує з правильного місця.
+func (u *Uploader) handleChunk(w http.ResponseWriter, r *http.Request) {
+	s, ok := u.session(r.PathValue("id"))
+	if !ok {
+		writeError(w, uploadErrorf(http.StatusNotFound, "upload session not found"))
+		return
+	}
+	if !s.mu.TryLock() {
+		writeError(w, uploadErrorf(http.StatusConflict, "another chunk is in progress"))
+		return
+	}
+	defer s.mu.Unlock()
+	if s.closed {
+		writeError(w, uploadErrorf(http.StatusNotFound, "upload session not found"))
+		return
+	}
+	s.lastActive = u.now()
+	defer func() { s.lastActive = u.now() }()
+
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	length := end - start + 1
+
+	switch {
+	case total != s.total:
+		err = uploadErrorf(http.StatusBadRequest, "total size %d does not match Upload-Length %d", total, s.total)
+	case start != s.offset:
+		w.Header().Set("Upload-Offset", strconv.FormatInt(s.offset, 10))
+		err = uploadErrorf(http.StatusConflict, "chunk starts at %d, expected %d", start, s.offset)
+	case r.ContentLength >= 0 && r.ContentLength != length:
+		err = uploadErrorf(http.StatusBadRequest, "Content-Length %d does not match Content-Range", r.ContentLength)
+	case start == 0 && length < min(sniffLen, total):
+		err = uploadErrorf(http.StatusBadRequest, "first chunk must contain at least %d bytes", min(sniffLen, total))
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	src := bufio.NewReaderSize(io.LimitReader(r.Body, length), sniffLen)
+	if start == 0 {
+		head, err := src.Peek(sniffLen)
+		if err != nil && err != io.EOF {
+			writeError(w, readError(err))
+			return
+		}
+		if s.typ, err = u.checkType(head); err != nil {
+			u.closeSession(s)
+			writeError(w, err)
+			return
+		}
+	}
+
+	bw, err := u.store.Writer(s.id, s.offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	n, err := copyHashed(bw, s.hash, src)
+	if closeErr := bw.Close(); err == nil {
+		err = closeErr
+	}
+
+	// Навіть при обриві зберігаємо те, що встигло записатись
+	s.offset += n
+	w.Header().Set("Upload-Offset", strconv.FormatInt(s.offset, 10))
+	if err == nil && n < length {
+		err = uploadErrorf(http.StatusBadRequest, "chunk truncated at %d bytes", n)
+	}
+	if err != nil {
+		writeError(w, readError(err))
+		return
+	}
+
+	if s.offset < s.total {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": s.id, "offset": s.offset, "size": s.total})
+		return
+	}
+
+	result := UploadResult{
+		ID:          s.id,
+		Name:        s.name,
+		Size:        s.total,
+		SHA256:      hex.EncodeToString(s.hash.Sum(nil)),
+		ContentType: s.typ.MIME,
+		Type:        s.typ.Name,
+	}
+
+	s.closed = true
+	u.mu.Lock()
+	delete(u.sessions, s.id)
+	u.files[s.id] = result
+	u.mu.Unlock()
+
+	w.Header().Set("Location", "/files/"+s.id)
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// handleSessionStatus: HEAD /uploads/{id} повертає, скільки байт уже прийнято
+func (u *Uploader) handleSessionStatus(w http.ResponseWriter, r *http.Request) {
+	s, ok := u.session(r.PathValue("id"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	offset, closed := s.offset, s.closed
+	s.mu.Unlock()
+	if closed {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(s.total, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleAbort чекає, поки допишеться поточний шматок: інакше blob
+// видалився б посеред запису, а шматок відновив би його
+func (u *Uploader) handleAbort(w http.ResponseWriter, r *http.Request) {
+	s, ok := u.session(r.PathValue("id"))
+	if ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	if !ok || s.closed {
+		writeError(w, uploadErrorf(http.StatusNotFound, "upload session not found"))
+		return
+	}
+	u.closeSession(s)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (u *Uploader) session(id string) (*session, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	s, ok := u.sessions[id]
+	return s, ok
+}
+
+// addSession реєструє сесію, якщо не перевищено MaxSessions.
+// Коли місця немає, спершу прибирає прострочені.
+func (u *Uploader) addSession(s *session) bool {
+	u.mu.Lock()
+	full := u.MaxSessions > 0 && len(u.sessions) >= u.MaxSessions
+	u.mu.Unlock()
+	if full {
+		u.ExpireSessions()
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.MaxSessions > 0 && len(u.sessions) >= u.MaxSessions {
+		return false
+	}
+	u.sessions[s.id] = s
+	return true
+}
+
+// closeSession видаляє сесію і її blob; викликається під s.mu
+func (u *Uploader) closeSession(s *session) {
+	s.closed = true
+	u.mu.Lock()
+	delete(u.sessions, s.id)
+	u.mu.Unlock()
+	u.store.Delete(s.id)
+}
+
+// ExpireSessions видаляє сесії, неактивні довше SessionTTL.
+// Сесію, у яку саме пишеться шматок, не чіпає. Повертає кількість видалених.
+func (u *Uploader) ExpireSessions() int {
+	cutoff := u.now().Add(-u.SessionTTL)
+
+	u.mu.Lock()
+	candidates := make([]*session, 0, len(u.sessions))
+	for _, s := range u.sessions {
+		candidates = append(candidates, s)
+	}
+	u.mu.Unlock()
+
+	// s.mu береться без u.mu - той самий порядок, що й у handleChunk
+	expired := 0
+	for _, s := range candidates {
+		if !s.mu.TryLock() {
+			continue
+		}
+		if !s.closed && s.lastActive.Before(cutoff) {
+			u.closeSession(s)
+			expired++
+		}
+		s.mu.Unlock()
+	}
+	return expired
+}
+
+// Janitor періодично прибирає покинуті завантаження - інакше вони
+// займали б пам'ять і тимчасові файли вічно
+func (u *Uploader) Janitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			u.ExpireSessions()
+		}
+	}
+}
+
+// parseContentRange розбирає "bytes 0-1023/4096"
+func parseContentRange(header string) (start, end, total int64, err error) {
+	invalid := uploadErrorf(http.StatusBadRequest, "invalid Content-Range %q", header)
+
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+	rng, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+	first, last, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+
+	start, err1 := strconv.ParseInt(first, 10, 64)
+	end, err2 := strconv.ParseInt(last, 10, 64)
+	total, err3 := strconv.ParseInt(size, 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || start < 0 || end < start || end >= total {
+		return 0, 0, 0, invalid
+	}
+	return start, end, total, nil
+}
+
+// ============= Download =============
+
+func (u *Uploader) handleDownload(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	result, ok := u.files[r.PathValue("id")]
+	u.mu.Unlock()
+	if !ok {
+		writeError(w, uploadErrorf(http.StatusNotFound, "file not found"))
+		return
+	}
+
+	blob, err := u.store.Open(result.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer blob.Close()
+
+	// Тип визначено сервером - браузер не повинен вгадувати свій
+	w.Header().Set("Content-Type", result.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Length", strconv.FormatInt(result.Size, 10))
+	w.Header().Set("ETag", `"`+result.SHA256+`"`)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": result.Name}))
+	io.Copy(w, blob)
+}
+
+// ============= Helpers =============
+
+// copyHashed пише у dst і хешує лише ті байти, що реально записані,
+// тому хеш і вміст сховища не розходяться навіть після обриву
+func copyHashed(dst io.Writer, h hash.Hash, src io.Reader) (int64, error) {
+	buf := make([]byte, 32<<10)
+	var written int64
+	for {
+		nr, readErr := src.Read(buf)
+		if nr > 0 {
+			nw, err := dst.Write(buf[:nr])
+			h.Write(buf[:nw])
+			written += int64(nw)
+			if err != nil {
+				return written, err
+			}
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+// readError перетворює помилки читання тіла на UploadError
+func readError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return uploadErrorf(http.StatusRequestEntityTooLarge, "request body exceeds %d bytes", maxErr.Limit)
+	}
+	return err
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var uploadErr *UploadError
+	switch {
+	case errors.As(err, &uploadErr):
+		status = uploadErr.Status
+	case errors.Is(err, ErrBlobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrOffsetChanged):
+		status = http.StatusConflict
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		message = "internal error"
+	}
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -17,14 +17,28 @@ func main() {
 	fmt.Println("Connected to server at localhost:9000")
 	fmt.Println("Type messages (Ctrl+C to exit):")
 
+	// Reader-и створюються один раз: bufio.Reader читає з запасом,
+	// і новий reader на кожній ітерації губив би вже прочитані дані
+	input := bufio.NewReader(os.Stdin)
+	server := bufio.NewReader(conn)
+
 	for {
-		reader := bufio.NewReader(os.Stdin)
 		fmt.Print("> ")
-		message, _ := reader.ReadString('\n')
+		message, err := input.ReadString('\n')
+		if err != nil {
+			return
+		}
 
-		conn.Write([]byte(message))
+		if _, err := conn.Write([]byte(message)); err != nil {
+			fmt.Println("Write error:", err)
+			return
+		}
 
-		response, _ := bufio.NewReader(conn).ReadString('\n')
+		response, err := server.ReadString('\n')
+		if err != nil {
+			fmt.Println("Server closed connection:", err)
+			return
+		}
 		fmt.Printf("Server: %s", response)
 	}
 }
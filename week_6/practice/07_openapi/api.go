@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============= Typed handlers =============
+
+// HandlerFunc - типізований обробник: отримує розпарсений запит і
+// повертає відповідь, яку API серіалізує в JSON
+type HandlerFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// Route описує одну операцію API
+type Route struct {
+	Method  string
+	Path    string // шаблон net/http: /api/users/{id}
+	Summary string
+	Status  int // код успішної відповіді, за замовчуванням 200
+}
+
+// HTTPError дозволяє обробнику повернути конкретний статус
+type HTTPError struct {
+	Status  int    `json:"-"`
+	Message string `json:"error"`
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// NewHTTPError створює помилку з HTTP-статусом
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+// Operation - зареєстрована операція разом з метаданими для специфікації
+type Operation struct {
+	Route
+	Params       []Param
+	RequestBody  *Schema // nil, якщо операція не має тіла
+	ResponseBody *Schema
+}
+
+// Param - path або query параметр, взятий з тегів структури запиту
+type Param struct {
+	Name     string
+	In       string // "path" або "query"
+	Required bool
+	Schema   *Schema
+	field    []int
+}
+
+// API збирає типізовані операції, роутить їх через http.ServeMux
+// і генерує з них OpenAPI документ
+type API struct {
+	Title   string
+	Version string
+
+	mux        *http.ServeMux
+	schemas    *schemaGenerator
+	operations []*Operation
+}
+
+// NewAPI створює API і одразу реєструє GET /openapi.json
+func NewAPI(title, version string) *API {
+	api := &API{
+		Title:   title,
+		Version: version,
+		mux:     http.NewServeMux(),
+		schemas: newSchemaGenerator(),
+	}
+
+	api.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.Spec())
+	})
+
+	return api
+}
+
+func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mux.ServeHTTP(w, r)
+}
+
+// HandleFunc реєструє звичайний обробник, який не потрапляє в специфікацію
+func (api *API) HandleFunc(pattern string, handler http.HandlerFunc) {
+	api.mux.HandleFunc(pattern, handler)
+}
+
+// Operations повертає зареєстровані операції у порядку шляхів
+func (api *API) Operations() []*Operation {
+	ops := make([]*Operation, len(api.operations))
+	copy(ops, api.operations)
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].Path < ops[j].Path
+	})
+	return ops
+}
+
+// Handle реєструє типізований обробник. Схеми запиту і відповіді
+// виводяться з Req та Resp, а тіло перевіряється validation middleware
+// ще до виклику fn.
+//
+// Поля Req з тегом `path:"id"` або `query:"name"` беруться з URL,
+// решта полів з тегом json - з тіла запиту.
+func Handle[Req, Resp any](api *API, route Route, fn HandlerFunc[Req, Resp]) {
+	if route.Status == 0 {
+		route.Status = http.StatusOK
+	}
+
+	reqType := reflect.TypeFor[Req]()
+	if reqType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("openapi: request type for %s %s must be a struct", route.Method, route.Path))
+	}
+
+	op := &Operation{
+		Route:        route,
+		Params:       api.paramsFor(reqType),
+		ResponseBody: api.schemas.schemaFor(reflect.TypeFor[Resp]()),
+	}
+	if hasBody(route.Method) {
+		body := api.schemas.structSchema(reqType, isParamField)
+		if len(body.Properties) > 0 {
+			op.RequestBody = body
+		}
+	}
+	api.operations = append(api.operations, op)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if op.RequestBody != nil {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, NewHTTPError(http.StatusBadRequest, "invalid JSON body"))
+				return
+			}
+		}
+		if err := bindParams(r, op.Params, reflect.ValueOf(&req).Elem()); err != nil {
+			writeJSON(w, http.StatusBadRequest, NewHTTPError(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				httpErr = NewHTTPError(http.StatusInternalServerError, "internal server error")
+			}
+			writeJSON(w, httpErr.Status, httpErr)
+			return
+		}
+
+		writeJSON(w, route.Status, resp)
+	})
+
+	api.mux.Handle(route.Method+" "+route.Path, api.validateRequest(op, handler))
+}
+
+// paramsFor збирає path/query параметри з тегів структури запиту
+func (api *API) paramsFor(t reflect.Type) []Param {
+	var params []Param
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+
+		p := Param{field: field.Index}
+		switch {
+		case field.Tag.Get("path") != "":
+			p.Name, p.In, p.Required = field.Tag.Get("path"), "path", true
+		case field.Tag.Get("query") != "":
+			p.Name, p.In = field.Tag.Get("query"), "query"
+			p.Required = hasRule(field.Tag.Get("validate"), "required")
+		default:
+			continue
+		}
+
+		p.Schema = api.schemas.schemaFor(field.Type)
+		applyConstraints(p.Schema, field)
+		params = append(params, p)
+	}
+
+	return params
+}
+
+func isParamField(field reflect.StructField) bool {
+	return field.Tag.Get("path") != "" || field.Tag.Get("query") != ""
+}
+
+func hasBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// bindParams заповнює поля запиту значеннями з URL
+func bindParams(r *http.Request, params []Param, v reflect.Value) error {
+	for _, p := range params {
+		var raw string
+		if p.In == "path" {
+			raw = r.PathValue(p.Name)
+		} else {
+			raw = r.URL.Query().Get(p.Name)
+		}
+		if raw == "" {
+			continue
+		}
+
+		if err := setFromString(v.FieldByIndex(p.field), raw); err != nil {
+			return fmt.Errorf("invalid %s parameter %q: %v", p.In, p.Name, err)
+		}
+	}
+	return nil
+}
+
+func setFromString(f reflect.Value, raw string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported kind %s", f.Kind())
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// operationID будує стабільний ідентифікатор: "GET /api/users/{id}" -> getApiUsersId
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
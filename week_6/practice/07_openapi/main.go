@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============= Domain =============
+
+type User struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserStore struct {
+	mu     sync.RWMutex
+	users  map[int]User
+	nextID int
+}
+
+func NewUserStore() *UserStore {
+	return &UserStore{
+		users:  make(map[int]User),
+		nextID: 1,
+	}
+}
+
+func (s *UserStore) Create(name, email, role string) User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := User{
+		ID:        s.nextID,
+		Name:      name,
+		Email:     email,
+		Role:      role,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.users[s.nextID] = user
+	s.nextID++
+	return user
+}
+
+func (s *UserStore) GetAll() []User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+func (s *UserStore) GetByID(id int) (User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	return user, ok
+}
+
+// ============= Request / Response DTOs =============
+
+type ListUsersRequest struct {
+	Role string `query:"role" validate:"oneof=admin user"`
+}
+
+type CreateUserRequest struct {
+	Name  string `json:"name" validate:"required,min=2,max=50"`
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"oneof=admin user"`
+}
+
+type GetUserRequest struct {
+	ID int `path:"id" validate:"min=1"`
+}
+
+// ============= Routes =============
+
+func registerRoutes(api *API, store *UserStore) {
+	Handle(api, Route{Method: "GET", Path: "/api/users", Summary: "Get all users"},
+		func(ctx context.Context, req ListUsersRequest) ([]User, error) {
+			users := store.GetAll()
+			if req.Role == "" {
+				return users, nil
+			}
+			filtered := make([]User, 0, len(users))
+			for _, u := range users {
+				if u.Role == req.Role {
+					filtered = append(filtered, u)
+				}
+			}
+			return filtered, nil
+		})
+
+	Handle(api, Route{Method: "POST", Path: "/api/users", Summary: "Create user", Status: http.StatusCreated},
+		func(ctx context.Context, req CreateUserRequest) (User, error) {
+			if req.Role == "" {
+				req.Role = "user"
+			}
+			return store.Create(req.Name, req.Email, req.Role), nil
+		})
+
+	Handle(api, Route{Method: "GET", Path: "/api/users/{id}", Summary: "Get user by ID"},
+		func(ctx context.Context, req GetUserRequest) (User, error) {
+			user, ok := store.GetByID(req.ID)
+			if !ok {
+				return User{}, NewHTTPError(http.StatusNotFound, "user not found")
+			}
+			return user, nil
+		})
+
+	// Список ендпоінтів тепер будується з тих самих операцій, що й специфікація
+	api.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Welcome to %s!\n", api.Title)
+		fmt.Fprintf(w, "\nEndpoints:\n")
+		for _, op := range api.Operations() {
+			fmt.Fprintf(w, "%-6s %-20s - %s\n", op.Method, op.Path, op.Summary)
+		}
+		fmt.Fprintf(w, "%-6s %-20s - %s\n", "GET", "/openapi.json", "OpenAPI 3 specification")
+	})
+}
+
+// Middleware для логування
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func main() {
+	store := NewUserStore()
+
+	// Seed data
+	store.Create("John Doe", "john@example.com", "admin")
+	store.Create("Jane Smith", "jane@example.com", "user")
+
+	api := NewAPI("User API", "1.0.0")
+	registerRoutes(api, store)
+
+	fmt.Println("🚀 Server started at http://localhost:8080")
+	fmt.Println("Try: curl http://localhost:8080/openapi.json")
+	log.Fatal(http.ListenAndServe(":8080", loggingMiddleware(api)))
+}
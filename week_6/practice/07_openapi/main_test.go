@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "admin")
+
+	api := NewAPI("User API", "1.0.0")
+	registerRoutes(api, store)
+
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestSpec_GeneratedFromHandlers checks that /openapi.json reflects registered routes
+func TestSpec_GeneratedFromHandlers(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("GET /openapi.json: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var doc Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected openapi 3.0.3, got %s", doc.OpenAPI)
+	}
+
+	post := doc.Paths["/api/users"]["post"]
+	if post == nil {
+		t.Fatal("Expected POST /api/users in spec")
+	}
+	if _, ok := post.Responses["201"]; !ok {
+		t.Errorf("Expected 201 response, got %v", post.Responses)
+	}
+
+	body := post.RequestBody.Content["application/json"].Schema
+	if got := strings.Join(body.Required, ","); got != "name,email" {
+		t.Errorf("Expected required [name email], got %s", got)
+	}
+	if body.Properties["email"].Format != "email" {
+		t.Errorf("Expected email format, got %q", body.Properties["email"].Format)
+	}
+	if name := body.Properties["name"]; *name.MinLength != 2 || *name.MaxLength != 50 {
+		t.Errorf("Expected name length 2..50, got %d..%d", *name.MinLength, *name.MaxLength)
+	}
+	if len(body.Properties["role"].Enum) != 2 {
+		t.Errorf("Expected role enum, got %v", body.Properties["role"].Enum)
+	}
+
+	get := doc.Paths["/api/users/{id}"]["get"]
+	if get == nil || len(get.Parameters) != 1 || get.Parameters[0].In != "path" {
+		t.Fatalf("Expected path parameter id, got %+v", get)
+	}
+	if get.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("Expected integer id, got %s", get.Parameters[0].Schema.Type)
+	}
+
+	user := doc.Components["schemas"]["User"]
+	if user == nil || user.Properties["created_at"].Format != "date-time" {
+		t.Errorf("Expected User component with date-time created_at, got %+v", user)
+	}
+}
+
+// TestValidation_RejectsInvalidBodies checks the request validation middleware
+func TestValidation_RejectsInvalidBodies(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{"valid", `{"name":"Alice","email":"alice@example.com"}`, http.StatusCreated, ""},
+		{"missing name", `{"email":"alice@example.com"}`, http.StatusBadRequest, "name"},
+		{"short name", `{"name":"A","email":"alice@example.com"}`, http.StatusBadRequest, "name"},
+		{"bad email", `{"name":"Alice","email":"not-an-email"}`, http.StatusBadRequest, "email"},
+		{"wrong type", `{"name":42,"email":"alice@example.com"}`, http.StatusBadRequest, "name"},
+		{"bad role", `{"name":"Alice","email":"alice@example.com","role":"root"}`, http.StatusBadRequest, "role"},
+		{"not json", `{`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/api/users", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantField == "" {
+				return
+			}
+
+			var verr ValidationErrors
+			json.NewDecoder(resp.Body).Decode(&verr)
+			if len(verr.Details) == 0 || verr.Details[0].Field != tt.wantField {
+				t.Errorf("Expected error on %q, got %+v", tt.wantField, verr.Details)
+			}
+		})
+	}
+}
+
+type Address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type CreateOrderRequest struct {
+	Item    string   `json:"item" validate:"required"`
+	Address *Address `json:"address"`
+}
+
+// TestValidation_NullableRef checks that an optional *Struct field accepts null
+func TestValidation_NullableRef(t *testing.T) {
+	api := NewAPI("Orders", "1.0.0")
+	Handle(api, Route{Method: "POST", Path: "/orders"},
+		func(ctx context.Context, req CreateOrderRequest) (CreateOrderRequest, error) {
+			return req, nil
+		})
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	address := api.Spec().Paths["/orders"]["post"].RequestBody.Content["application/json"].Schema.Properties["address"]
+	if !address.Nullable || len(address.AllOf) != 1 || address.AllOf[0].Ref != "#/components/schemas/Address" {
+		t.Errorf("Expected nullable allOf [$ref Address], got %+v", address)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{"null nested object", `{"item":"book","address":null}`, http.StatusOK, ""},
+		{"missing nested object", `{"item":"book"}`, http.StatusOK, ""},
+		{"valid nested object", `{"item":"book","address":{"city":"Kyiv"}}`, http.StatusOK, ""},
+		{"invalid nested object", `{"item":"book","address":{}}`, http.StatusBadRequest, "address.city"},
+		{"wrong type", `{"item":"book","address":"Kyiv"}`, http.StatusBadRequest, "address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/orders", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantField == "" {
+				return
+			}
+			var verr ValidationErrors
+			json.NewDecoder(resp.Body).Decode(&verr)
+			if len(verr.Details) == 0 || verr.Details[0].Field != tt.wantField {
+				t.Errorf("Expected error on %q, got %+v", tt.wantField, verr.Details)
+			}
+		})
+	}
+}
+
+// TestHandle_PathAndQueryParams checks parameter binding and typed errors
+func TestHandle_PathAndQueryParams(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/api/users/1", http.StatusOK},
+		{"/api/users/99", http.StatusNotFound},
+		{"/api/users/abc", http.StatusBadRequest},
+		{"/api/users/0", http.StatusBadRequest},
+		{"/api/users?role=admin", http.StatusOK},
+		{"/api/users?role=guest", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tt.path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("GET %s: expected %d, got %d", tt.path, tt.wantStatus, resp.StatusCode)
+		}
+	}
+}
+
+// TestIndex_ListsRegisteredOperations checks that "/" no longer drifts from the routes
+func TestIndex_ListsRegisteredOperations(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"POST   /api/users", "GET    /api/users/{id}", "/openapi.json"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected index to contain %q, got:\n%s", want, body)
+		}
+	}
+}
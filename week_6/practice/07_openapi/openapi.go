@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ============= OpenAPI 3 document =============
+
+type Document struct {
+	OpenAPI    string                        `json:"openapi"`
+	Info       Info                          `json:"info"`
+	Paths      map[string]map[string]*OpSpec `json:"paths"`
+	Components map[string]map[string]*Schema `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpSpec struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []ParamSpec          `json:"parameters,omitempty"`
+	RequestBody *RequestBodySpec     `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type ParamSpec struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBodySpec struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// errorSchema описує тіло HTTPError та помилок валідації
+var errorSchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"error": {Type: "string"},
+		"details": {Type: "array", Items: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"field":   {Type: "string"},
+				"message": {Type: "string"},
+			},
+		}},
+	},
+	Required: []string{"error"},
+}
+
+// Spec будує OpenAPI документ із зареєстрованих операцій.
+// Документ генерується щоразу заново, тому він не може розійтися з кодом.
+func (api *API) Spec() *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: api.Title, Version: api.Version},
+		Paths:   make(map[string]map[string]*OpSpec),
+	}
+
+	for _, op := range api.operations {
+		item, ok := doc.Paths[op.Path]
+		if !ok {
+			item = make(map[string]*OpSpec)
+			doc.Paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = op.spec()
+	}
+
+	schemas := make(map[string]*Schema, len(api.schemas.components)+1)
+	for name, s := range api.schemas.components {
+		schemas[name] = s
+	}
+	schemas["Error"] = errorSchema
+	doc.Components = map[string]map[string]*Schema{"schemas": schemas}
+
+	return doc
+}
+
+func (op *Operation) spec() *OpSpec {
+	spec := &OpSpec{
+		OperationID: operationID(op.Method, op.Path),
+		Summary:     op.Summary,
+		Responses: map[string]*Response{
+			strconv.Itoa(op.Status): {
+				Description: http.StatusText(op.Status),
+				Content:     jsonContent(op.ResponseBody),
+			},
+			"default": {
+				Description: "Error",
+				Content:     jsonContent(&Schema{Ref: "#/components/schemas/Error"}),
+			},
+		},
+	}
+
+	for _, p := range op.Params {
+		spec.Parameters = append(spec.Parameters, ParamSpec{
+			Name:     p.Name,
+			In:       p.In,
+			Required: p.Required,
+			Schema:   p.Schema,
+		})
+	}
+
+	if op.RequestBody != nil {
+		spec.RequestBody = &RequestBodySpec{Required: true, Content: jsonContent(op.RequestBody)}
+		spec.Responses["400"] = &Response{
+			Description: "Validation failed",
+			Content:     jsonContent(&Schema{Ref: "#/components/schemas/Error"}),
+		}
+	}
+
+	return spec
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
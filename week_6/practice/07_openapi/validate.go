@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============= Request validation middleware =============
+
+const maxBodyBytes = 1 << 20
+
+// FieldError - одне порушення схеми з шляхом до поля
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors - тіло відповіді 400
+type ValidationErrors struct {
+	Error   string       `json:"error"`
+	Details []FieldError `json:"details"`
+}
+
+// validateRequest перевіряє параметри і тіло запиту проти схеми операції
+// до того, як запит дійде до обробника
+func (api *API) validateRequest(op *Operation, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var errs []FieldError
+
+		for _, p := range op.Params {
+			raw := r.URL.Query().Get(p.Name)
+			if p.In == "path" {
+				raw = r.PathValue(p.Name)
+			}
+			if raw == "" {
+				if p.Required {
+					errs = append(errs, FieldError{Field: p.Name, Message: "is required"})
+				}
+				continue
+			}
+			errs = append(errs, api.validateValue(p.Name, paramValue(raw, p.Schema), p.Schema)...)
+		}
+
+		if op.RequestBody != nil {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				writeJSON(w, http.StatusRequestEntityTooLarge, NewHTTPError(http.StatusRequestEntityTooLarge, "request body too large"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			var value any
+			if err := dec.Decode(&value); err != nil {
+				writeJSON(w, http.StatusBadRequest, NewHTTPError(http.StatusBadRequest, "invalid JSON body"))
+				return
+			}
+			errs = append(errs, api.validateValue("", value, op.RequestBody)...)
+		}
+
+		if len(errs) > 0 {
+			writeJSON(w, http.StatusBadRequest, ValidationErrors{Error: "validation failed", Details: errs})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// paramValue приводить рядок з URL до JSON-значення за типом схеми,
+// щоб параметри і тіло перевірялися одним кодом
+func paramValue(raw string, s *Schema) any {
+	switch s.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// resolve розгортає $ref на components/schemas
+func (api *API) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		s = api.schemas.components[name]
+	}
+	return s
+}
+
+// validateValue рекурсивно звіряє розпарсене JSON-значення зі схемою
+func (api *API) validateValue(path string, value any, s *Schema) []FieldError {
+	s = api.resolve(s)
+	fail := func(format string, args ...any) []FieldError {
+		field := path
+		if field == "" {
+			field = "(body)"
+		}
+		return []FieldError{{Field: field, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fail("must not be null")
+	}
+
+	if len(s.AllOf) > 0 {
+		var errs []FieldError
+		for _, sub := range s.AllOf {
+			errs = append(errs, api.validateValue(path, value, sub)...)
+		}
+		return errs
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fail("must be an object")
+		}
+		var errs []FieldError
+		for _, name := range s.Required {
+			if v, ok := obj[name]; !ok || isZero(v) {
+				errs = append(errs, FieldError{Field: joinPath(path, name), Message: "is required"})
+			}
+		}
+		for name, v := range obj {
+			if ps, ok := s.Properties[name]; ok {
+				errs = append(errs, api.validateValue(joinPath(path, name), v, ps)...)
+			} else if s.AdditionalProperties != nil {
+				errs = append(errs, api.validateValue(joinPath(path, name), v, s.AdditionalProperties)...)
+			}
+		}
+		return errs
+
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fail("must be an array")
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			return fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			return fail("must contain at most %d items", *s.MaxItems)
+		}
+		var errs []FieldError
+		for i, v := range arr {
+			errs = append(errs, api.validateValue(fmt.Sprintf("%s[%d]", path, i), v, s.Items)...)
+		}
+		return errs
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		n := utf8.RuneCountInString(str)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Format == "email" {
+			if addr, err := mail.ParseAddress(str); err != nil || addr.Address != str {
+				return fail("must be a valid email address")
+			}
+		}
+		if len(s.Enum) > 0 && !inEnum(str, s.Enum) {
+			return fail("must be one of %v", s.Enum)
+		}
+
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			return fail("must be a number")
+		}
+		f, err := num.Float64()
+		if err != nil {
+			return fail("must be a number")
+		}
+		if s.Type == "integer" && f != math.Trunc(f) {
+			return fail("must be an integer")
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fail("must be <= %v", *s.Maximum)
+		}
+		if len(s.Enum) > 0 && !inEnum(f, s.Enum) {
+			return fail("must be one of %v", s.Enum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	}
+
+	return nil
+}
+
+// isZero повторює семантику validate:"required": порожній рядок теж не підходить
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	}
+	return false
+}
+
+func inEnum(v any, enum []any) bool {
+	for _, e := range enum {
+		switch e := e.(type) {
+		case int64:
+			if f, ok := v.(float64); ok && f == float64(e) {
+				return true
+			}
+		default:
+			if e == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============= Cache storage =============
+
+// Entry - збережена відповідь разом з правилами свіжості
+type Entry struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	ETag     string
+	StoredAt time.Time
+
+	MaxAge               time.Duration // скільки відповідь свіжа
+	StaleWhileRevalidate time.Duration // скільки ще можна віддавати застарілу
+}
+
+// Age - вік запису на момент now
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Fresh - запис ще в межах max-age
+func (e *Entry) Fresh(now time.Time) bool {
+	return e.Age(now) <= e.MaxAge
+}
+
+// Usable - свіжий або в межах stale-while-revalidate
+func (e *Entry) Usable(now time.Time) bool {
+	return e.Age(now) <= e.MaxAge+e.StaleWhileRevalidate
+}
+
+// Cache - сховище відповідей; middleware не залежить від реалізації
+// (пам'ять, Redis, файли...)
+type Cache interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	Delete(key string)
+}
+
+// ============= Memory Cache =============
+
+// MemoryCache - потокобезпечний кеш у пам'яті з обмеженням кількості записів.
+// При переповненні видаляється найстаріший запис.
+type MemoryCache struct {
+	mu         sync.RWMutex
+	entries    map[string]*Entry
+	maxEntries int
+}
+
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]*Entry),
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+func (c *MemoryCache) Set(key string, entry *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evictOldest()
+	}
+	c.entries[key] = entry
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.entries)
+}
+
+func (c *MemoryCache) evictOldest() {
+	var (
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.StoredAt.Before(oldest) {
+			oldestKey, oldest = key, entry.StoredAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type User struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// UserStore рахує звернення, щоб було видно, скільки запитів зняв кеш
+type UserStore struct {
+	mu     sync.RWMutex
+	users  map[int]User
+	nextID int
+	reads  atomic.Int64
+}
+
+func NewUserStore() *UserStore {
+	return &UserStore{
+		users:  make(map[int]User),
+		nextID: 1,
+	}
+}
+
+func (s *UserStore) Create(name, email string) User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := User{ID: s.nextID, Name: name, Email: email}
+	s.users[s.nextID] = user
+	s.nextID++
+	return user
+}
+
+func (s *UserStore) GetAll() []User {
+	s.reads.Add(1)
+	time.Sleep(50 * time.Millisecond) // повільне сховище
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+func (s *UserStore) Reads() int64 {
+	return s.reads.Load()
+}
+
+type Server struct {
+	store *UserStore
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		// Обробник сам вирішує, скільки відповідь може жити в кеші
+		w.Header().Set("Cache-Control", "public, max-age=5, stale-while-revalidate=30")
+		json.NewEncoder(w).Encode(s.store.GetAll())
+
+	case http.MethodPost:
+		var req struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		user := s.store.Create(req.Name, req.Email)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	// Персональні дані - тільки в кеші браузера
+	w.Header().Set("Cache-Control", "private, max-age=60")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"user": r.Header.Get("X-User")})
+}
+
+func (s *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users", s.handleUsers)
+	mux.HandleFunc("/api/me", s.handleMe)
+	return mux
+}
+
+func main() {
+	server := &Server{store: NewUserStore()}
+	server.store.Create("John Doe", "john@example.com")
+	server.store.Create("Jane Smith", "jane@example.com")
+
+	cache := NewHTTPCache(NewMemoryCache(1000))
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			log.Printf("store reads so far: %d", server.store.Reads())
+		}
+	}()
+
+	fmt.Println("🚀 Server started at http://localhost:8080")
+	fmt.Println("Try: curl -i http://localhost:8080/api/users   (see X-Cache, ETag, Age)")
+	fmt.Println(`     curl -i -H 'If-None-Match: "<etag>"' http://localhost:8080/api/users`)
+	log.Fatal(http.ListenAndServe(":8080", cache.Middleware(server.Routes())))
+}
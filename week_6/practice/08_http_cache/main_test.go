@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock дозволяє "перемотувати" час без sleep
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// countingHandler відповідає "v<N>", де N - номер виклику
+func countingHandler(cacheControl string, calls *atomic.Int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if r.Method == http.MethodGet {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		fmt.Fprintf(w, "v%d", n)
+	})
+}
+
+func newTestCache(cacheControl string) (http.Handler, *HTTPCache, *fakeClock, *atomic.Int64) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	calls := &atomic.Int64{}
+
+	c := NewHTTPCache(NewMemoryCache(100))
+	c.now = clock.Now
+	return c.Middleware(countingHandler(cacheControl, calls)), c, clock, calls
+}
+
+func do(h http.Handler, method, target string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCache_MissThenHit(t *testing.T) {
+	h, _, clock, calls := newTestCache("public, max-age=60")
+
+	first := do(h, "GET", "/api/users")
+	clock.Advance(10 * time.Second)
+	second := do(h, "GET", "/api/users")
+
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("Expected MISS then HIT, got %s then %s",
+			first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if second.Body.String() != "v1" || calls.Load() != 1 {
+		t.Errorf("Expected cached v1 and 1 call, got %s and %d calls", second.Body.String(), calls.Load())
+	}
+	if second.Header().Get("Age") != "10" {
+		t.Errorf("Expected Age 10, got %s", second.Header().Get("Age"))
+	}
+
+	// Різні query - різні записи
+	do(h, "GET", "/api/users?page=2")
+	if calls.Load() != 2 {
+		t.Errorf("Expected separate entry for query, got %d calls", calls.Load())
+	}
+}
+
+func TestCache_ETagAndNotModified(t *testing.T) {
+	h, _, _, _ := newTestCache("max-age=60")
+
+	first := do(h, "GET", "/api/users")
+	etag := first.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("Expected strong ETag, got %q", etag)
+	}
+
+	for _, inm := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		rec := do(h, "GET", "/api/users", "If-None-Match", inm)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected empty 304, got %d %q", inm, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("Expected ETag on 304, got %q", rec.Header().Get("ETag"))
+		}
+	}
+
+	if rec := do(h, "GET", "/api/users", "If-None-Match", `"stale"`); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 for non-matching ETag, got %d", rec.Code)
+	}
+}
+
+func TestCache_HandlerDirectives(t *testing.T) {
+	for _, cc := range []string{"no-store", "private, max-age=60", "no-cache, max-age=60", ""} {
+		h, _, _, calls := newTestCache(cc)
+
+		do(h, "GET", "/api/me")
+		rec := do(h, "GET", "/api/me")
+
+		if calls.Load() != 2 || rec.Header().Get("X-Cache") != "MISS" {
+			t.Errorf("Cache-Control %q: expected no caching, got %d calls, %s", cc, calls.Load(), rec.Header().Get("X-Cache"))
+		}
+		if rec.Header().Get("ETag") == "" {
+			t.Errorf("Cache-Control %q: expected ETag even when not cached", cc)
+		}
+	}
+}
+
+func TestCache_ClientDirectives(t *testing.T) {
+	h, _, clock, calls := newTestCache("max-age=60")
+	do(h, "GET", "/api/users")
+	clock.Advance(20 * time.Second)
+
+	tests := []struct {
+		name      string
+		headers   []string
+		wantCache string
+		wantCalls int64
+	}{
+		{"max-age satisfied", []string{"Cache-Control", "max-age=30"}, "HIT", 1},
+		{"max-age too old", []string{"Cache-Control", "max-age=10"}, "MISS", 2},
+		{"no-cache", []string{"Cache-Control", "no-cache"}, "MISS", 3},
+		{"no-store", []string{"Cache-Control", "no-store"}, "BYPASS", 4},
+		{"authorization", []string{"Authorization", "Bearer token"}, "BYPASS", 5},
+	}
+
+	for _, tt := range tests {
+		rec := do(h, "GET", "/api/users", tt.headers...)
+		if got := rec.Header().Get("X-Cache"); got != tt.wantCache {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.wantCache, got)
+		}
+		if calls.Load() != tt.wantCalls {
+			t.Errorf("%s: expected %d calls, got %d", tt.name, tt.wantCalls, calls.Load())
+		}
+	}
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	var calls atomic.Int64
+	release := make(chan struct{})
+
+	// Фонове оновлення блокується, поки всі запити не отримають застарілу відповідь
+	origin := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n > 1 {
+			<-release
+		}
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		fmt.Fprintf(w, "v%d", n)
+	})
+
+	c := NewHTTPCache(NewMemoryCache(100))
+	c.now = clock.Now
+	h := c.Middleware(origin)
+
+	do(h, "GET", "/api/users")
+	clock.Advance(30 * time.Second)
+
+	// Багато одночасних запитів до застарілого запису -> одне оновлення
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := do(h, "GET", "/api/users")
+			if rec.Body.String() != "v1" || rec.Header().Get("X-Cache") != "STALE" {
+				t.Errorf("Expected stale v1, got %s %s", rec.Header().Get("X-Cache"), rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	c.Wait()
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected exactly one background refresh, got %d handler calls", calls.Load())
+	}
+
+	rec := do(h, "GET", "/api/users")
+	if rec.Body.String() != "v2" || rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("Expected refreshed v2 HIT, got %s %s", rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+
+	// За межами stale-while-revalidate - синхронний запит
+	clock.Advance(2 * time.Minute)
+	if rec := do(h, "GET", "/api/users"); rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected MISS after stale window, got %s", rec.Header().Get("X-Cache"))
+	}
+}
+
+func TestCache_UnsafeMethodsInvalidate(t *testing.T) {
+	h, _, _, calls := newTestCache("max-age=60")
+
+	do(h, "GET", "/api/users")
+	do(h, "GET", "/api/users/1")
+	do(h, "PUT", "/api/users/1")
+
+	if rec := do(h, "GET", "/api/users/1"); rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected /api/users/1 invalidated, got %s", rec.Header().Get("X-Cache"))
+	}
+	if rec := do(h, "GET", "/api/users"); rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected parent collection invalidated, got %s", rec.Header().Get("X-Cache"))
+	}
+	if calls.Load() != 5 {
+		t.Errorf("Expected 5 handler calls, got %d", calls.Load())
+	}
+}
+
+func TestCache_InvalidateQueryVariants(t *testing.T) {
+	h, _, _, _ := newTestCache("max-age=60")
+
+	variants := []string{"/api/users", "/api/users?role=admin", "/api/users?page=2&role=user"}
+	for _, target := range variants {
+		do(h, "GET", target)
+		if rec := do(h, "GET", target); rec.Header().Get("X-Cache") != "HIT" {
+			t.Fatalf("Expected %s cached, got %s", target, rec.Header().Get("X-Cache"))
+		}
+	}
+
+	do(h, "POST", "/api/users")
+	for _, target := range variants {
+		if rec := do(h, "GET", target); rec.Header().Get("X-Cache") != "MISS" {
+			t.Errorf("Expected %s invalidated by POST, got %s", target, rec.Header().Get("X-Cache"))
+		}
+	}
+
+	// Зміна елемента скидає й варіанти батьківської колекції
+	do(h, "PUT", "/api/users/1")
+	if rec := do(h, "GET", "/api/users?role=admin"); rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected parent query variant invalidated by PUT, got %s", rec.Header().Get("X-Cache"))
+	}
+}
+
+// Індекс варіантів не росте разом із ключами, які Cache уже витіснив
+func TestCache_VariantIndexPruned(t *testing.T) {
+	c := NewHTTPCache(NewMemoryCache(4))
+	h := c.Middleware(countingHandler("max-age=60", &atomic.Int64{}))
+	for i := range 200 {
+		do(h, "GET", fmt.Sprintf("/api/users?page=%d", i))
+	}
+	if n := len(c.variants["example.com/api/users"].keys); n > 2*minPruneAt {
+		t.Errorf("Expected pruned variant index, got %d keys", n)
+	}
+}
+
+func TestCache_ResponseHeadersDoNotAliasEntry(t *testing.T) {
+	h, _, _, _ := newTestCache("max-age=60")
+
+	rec := do(h, "GET", "/api/users")
+	rec.Header()["Cache-Control"][0] = "mutated"
+
+	if rec := do(h, "GET", "/api/users"); rec.Header().Get("Cache-Control") != "max-age=60" {
+		t.Errorf("Expected cached header untouched, got %q", rec.Header().Get("Cache-Control"))
+	}
+}
+
+func TestMemoryCache_EvictsOldest(t *testing.T) {
+	c := NewMemoryCache(2)
+	base := time.Now()
+
+	c.Set("a", &Entry{StoredAt: base})
+	c.Set("b", &Entry{StoredAt: base.Add(time.Second)})
+	c.Set("c", &Entry{StoredAt: base.Add(2 * time.Second)})
+
+	if _, ok := c.Get("a"); ok || c.Len() != 2 {
+		t.Errorf("Expected oldest entry evicted, len=%d", c.Len())
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============= HTTP caching middleware =============
+
+// HTTPCache кешує GET-відповіді за правилами Cache-Control:
+//
+//   - відповідь зберігається, лише якщо обробник дозволив це через
+//     max-age / s-maxage і не вказав no-store, private чи no-cache
+//   - клієнт може обійти кеш (no-store), вимагати перевірку (no-cache)
+//     або обмежити вік відповіді (max-age=N)
+//   - кожна 200-відповідь отримує сильний ETag, If-None-Match дає 304
+//   - в межах stale-while-revalidate клієнт одразу отримує застарілу
+//     відповідь, а оновлення йде у фоні - одне на ключ завдяки SingleFlight
+//   - успішні POST/PUT/PATCH/DELETE видаляють шлях і батьківську колекцію
+//     разом з усіма їхніми варіантами query (?role=admin, ?page=2...)
+type HTTPCache struct {
+	cache  Cache
+	flight *SingleFlight
+	now    func() time.Time
+
+	refreshing sync.WaitGroup
+
+	mu       sync.Mutex
+	variants map[string]*pathVariants // Host+path -> ключі з будь-яким query
+}
+
+// pathVariants - ключі кешу одного шляху. Cache сам витісняє записи і не
+// повідомляє про це, тож мертві ключі прибираються, коли набір подвоївся.
+type pathVariants struct {
+	keys    map[string]struct{}
+	pruneAt int
+}
+
+const minPruneAt = 16
+
+func NewHTTPCache(cache Cache) *HTTPCache {
+	return &HTTPCache{
+		cache:    cache,
+		flight:   NewSingleFlight(),
+		now:      time.Now,
+		variants: make(map[string]*pathVariants),
+	}
+}
+
+func (c *HTTPCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+			if sw.status < 400 {
+				c.invalidate(r)
+			}
+			return
+		}
+
+		reqCC := parseCacheControl(r.Header.Get("Cache-Control"))
+		key := cacheKey(r)
+
+		// Запити з Authorization персональні - спільний кеш їх не чіпає
+		if reqCC.has("no-store") || r.Header.Get("Authorization") != "" {
+			entry := c.fetch(next, r)
+			writeEntry(w, r, entry, "BYPASS", c.now())
+			return
+		}
+
+		now := c.now()
+		if entry, ok := c.cache.Get(key); ok && !reqCC.has("no-cache") {
+			switch {
+			case entry.Fresh(now) && withinClientMaxAge(entry, reqCC, now):
+				writeEntry(w, r, entry, "HIT", now)
+				return
+			case !reqCC.has("max-age") && entry.Usable(now):
+				c.revalidate(next, r, key)
+				writeEntry(w, r, entry, "STALE", now)
+				return
+			}
+		}
+
+		entry := c.fetch(next, r)
+		if r.Method == http.MethodGet {
+			c.store(key, entry)
+		}
+		writeEntry(w, r, entry, "MISS", c.now())
+	})
+}
+
+// Wait чекає завершення фонових оновлень (для тестів і graceful shutdown)
+func (c *HTTPCache) Wait() {
+	c.refreshing.Wait()
+}
+
+// fetch викликає обробник і записує відповідь у Entry
+func (c *HTTPCache) fetch(next http.Handler, r *http.Request) *Entry {
+	rec := newRecorder()
+	next.ServeHTTP(rec, r)
+
+	entry := &Entry{
+		Status:   rec.status,
+		Header:   rec.header,
+		Body:     rec.body.Bytes(),
+		StoredAt: c.now(),
+	}
+
+	if entry.Status == http.StatusOK {
+		entry.ETag = entry.Header.Get("ETag")
+		if entry.ETag == "" {
+			entry.ETag = strongETag(entry.Body)
+			entry.Header.Set("ETag", entry.ETag)
+		}
+	}
+
+	return entry
+}
+
+// store зберігає відповідь, якщо обробник це дозволив
+func (c *HTTPCache) store(key string, entry *Entry) bool {
+	if entry.Status != http.StatusOK {
+		return false
+	}
+	if entry.Header.Get("Vary") != "" || entry.Header.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	cc := parseCacheControl(entry.Header.Get("Cache-Control"))
+	if cc.has("no-store") || cc.has("private") || cc.has("no-cache") {
+		return false
+	}
+
+	maxAge, ok := cc.seconds("s-maxage")
+	if !ok {
+		maxAge, ok = cc.seconds("max-age")
+	}
+	if !ok || maxAge <= 0 {
+		return false
+	}
+
+	entry.MaxAge = maxAge
+	entry.StaleWhileRevalidate, _ = cc.seconds("stale-while-revalidate")
+	c.cache.Set(key, entry)
+	c.trackVariant(key)
+	return true
+}
+
+func (c *HTTPCache) trackVariant(key string) {
+	pathKey, _, _ := strings.Cut(key, "?")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v := c.variants[pathKey]
+	if v == nil {
+		v = &pathVariants{keys: make(map[string]struct{}), pruneAt: minPruneAt}
+		c.variants[pathKey] = v
+	}
+	v.keys[key] = struct{}{}
+
+	if len(v.keys) >= v.pruneAt {
+		for k := range v.keys {
+			if _, ok := c.cache.Get(k); !ok {
+				delete(v.keys, k)
+			}
+		}
+		v.pruneAt = max(2*len(v.keys), minPruneAt)
+	}
+}
+
+// revalidate оновлює запис у фоні; паралельні запити на той самий ключ
+// не запускають обробник повторно
+func (c *HTTPCache) revalidate(next http.Handler, r *http.Request, key string) {
+	bg := r.Clone(context.WithoutCancel(r.Context()))
+	bg.Method = http.MethodGet
+	bg.Header.Del("If-None-Match")
+	bg.Header.Del("Cache-Control")
+
+	c.refreshing.Add(1)
+	go func() {
+		defer c.refreshing.Done()
+
+		c.flight.Do(key, func() (interface{}, error) {
+			// Якщо нова відповідь більше не кешується - прибираємо стару
+			if !c.store(key, c.fetch(next, bg)) {
+				c.cache.Delete(key)
+			}
+			return nil, nil
+		})
+	}()
+}
+
+func (c *HTTPCache) invalidate(r *http.Request) {
+	c.invalidatePath(r.Host + r.URL.EscapedPath())
+	if parent := path.Dir(strings.TrimSuffix(r.URL.EscapedPath(), "/")); parent != "/" && parent != "." {
+		c.invalidatePath(r.Host + parent)
+	}
+}
+
+// invalidatePath видаляє шлях з усіма варіантами query
+func (c *HTTPCache) invalidatePath(pathKey string) {
+	c.mu.Lock()
+	v := c.variants[pathKey]
+	delete(c.variants, pathKey)
+	c.mu.Unlock()
+
+	c.cache.Delete(pathKey)
+	if v != nil {
+		for key := range v.keys {
+			c.cache.Delete(key)
+		}
+	}
+}
+
+// ============= Writing responses =============
+
+func writeEntry(w http.ResponseWriter, r *http.Request, entry *Entry, status string, now time.Time) {
+	h := w.Header()
+	// Копія: інакше зміна заголовків відповіді змінила б запис у кеші
+	for k, v := range entry.Header {
+		h[k] = slices.Clone(v)
+	}
+	h.Set("X-Cache", status)
+	if status == "HIT" || status == "STALE" {
+		h.Set("Age", strconv.Itoa(int(entry.Age(now).Seconds())))
+	}
+
+	if entry.ETag != "" && etagMatches(r.Header.Get("If-None-Match"), entry.ETag) {
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(entry.Status)
+	if r.Method != http.MethodHead {
+		w.Write(entry.Body)
+	}
+}
+
+func cacheKey(r *http.Request) string {
+	return r.Host + r.URL.RequestURI()
+}
+
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches - слабке порівняння для If-None-Match (RFC 7232 §3.2)
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func withinClientMaxAge(entry *Entry, reqCC cacheControl, now time.Time) bool {
+	maxAge, ok := reqCC.seconds("max-age")
+	return !ok || entry.Age(now) <= maxAge
+}
+
+// ============= Cache-Control parsing =============
+
+type cacheControl map[string]string
+
+func parseCacheControl(header string) cacheControl {
+	cc := cacheControl{}
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	value, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// ============= Response recorders =============
+
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func newRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
+
+// statusWriter пропускає відповідь наскрізь, запам'ятовуючи статус
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
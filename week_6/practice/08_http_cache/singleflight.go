@@ -0,0 +1,52 @@
+package main
+
+import "sync"
+
+// ============= SingleFlight =============
+// Та сама ідея, що й week_26/concurrency_patterns/single_flight_implementation:
+// одночасні виклики з однаковим ключем виконують action лише один раз.
+
+type call struct {
+	err error
+	val interface{}
+
+	done chan struct{}
+}
+
+type SingleFlight struct {
+	mutex sync.Mutex
+	calls map[string]*call
+}
+
+func NewSingleFlight() *SingleFlight {
+	return &SingleFlight{
+		calls: make(map[string]*call),
+	}
+}
+
+// Do виконує action або чекає на вже запущений виклик з тим самим ключем.
+// shared = true, якщо результат отримано від чужого виклику.
+func (s *SingleFlight) Do(key string, action func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	s.mutex.Lock()
+	if c, found := s.calls[key]; found {
+		s.mutex.Unlock()
+		<-c.done
+		return c.val, c.err, true
+	}
+
+	c := &call{
+		done: make(chan struct{}),
+	}
+	s.calls[key] = c
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.calls, key)
+		s.mutex.Unlock()
+		close(c.done)
+	}()
+
+	c.val, c.err = action()
+	return c.val, c.err, false
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============= Circuit Breaker =============
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	StateClosed   BreakerState = iota // запити проходять, рахуємо помилки
+	StateOpen                         // запити одразу відхиляються
+	StateHalfOpen                     // пропускаємо одну пробну спробу
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// CircuitBreaker відкривається після threshold помилок поспіль,
+// через cooldown пропускає одну пробу: успіх закриває його, помилка - знову відкриває
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow повертає ErrCircuitOpen, якщо запит не можна виконувати
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		// Поки пробний запит не завершився, інші не проходять
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon знімає пробу без зміни стану, якщо запит скасував сам клієнт
+func (b *CircuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// ============= Breakers per host =============
+
+// BreakerSet тримає окремий CircuitBreaker для кожного хоста,
+// щоб один недоступний сервіс не блокував запити до інших
+type BreakerSet struct {
+	mu        sync.Mutex
+	breakers  map[string]*CircuitBreaker
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+func NewBreakerSet(threshold int, cooldown time.Duration) *BreakerSet {
+	return &BreakerSet{
+		breakers:  make(map[string]*CircuitBreaker),
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+func (s *BreakerSet) For(host string) *CircuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[host]
+	if !ok {
+		b = NewCircuitBreaker(s.threshold, s.cooldown)
+		b.now = s.now
+		s.breakers[host] = b
+	}
+	return b
+}
+
+// Middleware рахує помилкою транспорту збій або відповідь 5xx.
+// Скасування контексту клієнтом - не провина сервера, тому не рахується.
+func (s *BreakerSet) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			breaker := s.For(req.URL.Host)
+			if err := breaker.Allow(); err != nil {
+				return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+			}
+
+			resp, err := next.RoundTrip(req)
+			switch {
+			case err != nil && req.Context().Err() != nil:
+				breaker.abandon()
+			case err != nil || resp.StatusCode >= 500:
+				breaker.Failure()
+			default:
+				breaker.Success()
+			}
+			return resp, err
+		})
+	}
+}
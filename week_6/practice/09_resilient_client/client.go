@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ============= Resilient Client =============
+
+type Config struct {
+	Timeout     time.Duration // на весь виклик разом з повторами
+	Retry       RetryPolicy
+	Hedging     Hedging // MaxHedges = 0 вимикає hedging
+	MaxPerHost  int
+	BreakerFail int // помилок поспіль до відкриття breaker
+	BreakerWait time.Duration
+	Logf        func(format string, args ...interface{}) // nil - без логів
+	Transport   http.RoundTripper                        // nil - http.DefaultTransport
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:     10 * time.Second,
+		Retry:       DefaultRetryPolicy(),
+		MaxPerHost:  10,
+		BreakerFail: 5,
+		BreakerWait: 30 * time.Second,
+	}
+}
+
+// Client - звичайний *http.Client, у якого Transport зібраний з ланцюжка:
+//
+//	retry -> hedging -> circuit breaker -> per-host limit -> logging -> metrics -> transport
+//
+// Тобто кожна повторна чи hedged спроба окремо проходить через breaker,
+// займає слот хоста і потрапляє в лог та метрики.
+type Client struct {
+	*http.Client
+
+	metrics  *Metrics
+	breakers *BreakerSet
+}
+
+func NewClient(cfg Config) *Client {
+	metrics := NewMetrics()
+	breakers := NewBreakerSet(cfg.BreakerFail, cfg.BreakerWait)
+
+	middlewares := []Middleware{
+		cfg.Retry.Middleware(),
+		cfg.Hedging.Middleware(),
+		breakers.Middleware(),
+		NewHostLimiter(cfg.MaxPerHost).Middleware(),
+	}
+	if cfg.Logf != nil {
+		middlewares = append(middlewares, Logging(cfg.Logf))
+	}
+	middlewares = append(middlewares, metrics.Middleware())
+
+	return &Client{
+		Client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: Chain(cfg.Transport, middlewares...),
+		},
+		metrics:  metrics,
+		breakers: breakers,
+	}
+}
+
+func (c *Client) Metrics() *Metrics {
+	return c.metrics
+}
+
+func (c *Client) BreakerState(host string) BreakerState {
+	return c.breakers.For(host).State()
+}
+
+// GetJSON виконує GET з контекстом і декодує JSON-відповідь у v
+func (c *Client) GetJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{URL: url, Code: resp.StatusCode, Body: string(body)}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// StatusError - сервер відповів, але не 200
+type StatusError struct {
+	URL  string
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: unexpected status %d", e.URL, e.Code)
+}
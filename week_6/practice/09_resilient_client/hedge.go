@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ============= Hedged requests =============
+
+// Hedging: якщо відповідь на GET/HEAD не прийшла за Delay, паралельно
+// відправляється ще одна копія запиту (до MaxHedges додаткових).
+// Перемагає перша успішна відповідь, решта спроб скасовується.
+// Це зрізає "хвіст" затримок, коли одна репліка чи з'єднання гальмує.
+type Hedging struct {
+	Delay     time.Duration
+	MaxHedges int
+}
+
+type hedgeResult struct {
+	index  int
+	resp   *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+func (h Hedging) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if h.MaxHedges < 1 || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+				return next.RoundTrip(req)
+			}
+			return h.roundTrip(next, req)
+		})
+	}
+}
+
+func (h Hedging) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	total := h.MaxHedges + 1
+	results := make(chan hedgeResult, total)
+
+	var cancels []context.CancelFunc
+	launch := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := next.RoundTrip(req.Clone(ctx))
+			results <- hedgeResult{index: index, resp: resp, err: err, cancel: cancel}
+		}()
+	}
+
+	launch()
+	launched, received := 1, 0
+
+	timer := time.NewTimer(h.Delay)
+	defer timer.Stop()
+
+	var fallback *hedgeResult
+	for received < launched {
+		select {
+		case <-timer.C:
+			if launched < total {
+				launch()
+				launched++
+				timer.Reset(h.Delay)
+			}
+
+		case res := <-results:
+			received++
+			if res.err == nil && res.resp.StatusCode < 500 {
+				// Решта спроб більше не потрібна
+				for i, cancel := range cancels {
+					if i != res.index {
+						cancel()
+					}
+				}
+				go drainHedges(results, launched-received)
+				if fallback != nil {
+					discard(*fallback)
+				}
+				res.resp.Body = &onCloseBody{ReadCloser: res.resp.Body, onClose: res.cancel}
+				return res.resp, nil
+			}
+
+			// Невдалу відповідь тримаємо на випадок, якщо всі спроби провалляться
+			if fallback != nil {
+				discard(*fallback)
+			}
+			fallback = &res
+
+			// Не чекаємо таймера: одразу пробуємо ще раз
+			if launched < total && req.Context().Err() == nil {
+				launch()
+				launched++
+				timer.Reset(h.Delay)
+			}
+		}
+	}
+
+	if fallback.resp != nil {
+		fallback.resp.Body = &onCloseBody{ReadCloser: fallback.resp.Body, onClose: fallback.cancel}
+		return fallback.resp, nil
+	}
+	fallback.cancel()
+	return nil, fallback.err
+}
+
+// drainHedges скасовує і прибирає спроби, що програли
+func drainHedges(results <-chan hedgeResult, pending int) {
+	for i := 0; i < pending; i++ {
+		discard(<-results)
+	}
+}
+
+func discard(res hedgeResult) {
+	res.cancel()
+	if res.resp != nil {
+		io.Copy(io.Discard, io.LimitReader(res.resp.Body, 64<<10))
+		res.resp.Body.Close()
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ============= Per-host concurrency limit =============
+
+// HostLimiter - семафор (буферизований канал) на кожен хост.
+// Слот звільняється лише після закриття тіла відповіді,
+// бо саме до того моменту з'єднання зайняте.
+type HostLimiter struct {
+	mu    sync.Mutex
+	max   int
+	slots map[string]chan struct{}
+}
+
+func NewHostLimiter(maxPerHost int) *HostLimiter {
+	if maxPerHost < 1 {
+		maxPerHost = 1
+	}
+	return &HostLimiter{
+		max:   maxPerHost,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+func (l *HostLimiter) sem(host string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sem, ok := l.slots[host]
+	if !ok {
+		sem = make(chan struct{}, l.max)
+		l.slots[host] = sem
+	}
+	return sem
+}
+
+// Acquire чекає на вільний слот або на скасування контексту
+func (l *HostLimiter) Acquire(ctx context.Context, host string) error {
+	select {
+	case l.sem(host) <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *HostLimiter) Release(host string) {
+	<-l.sem(host)
+}
+
+func (l *HostLimiter) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			host := req.URL.Host
+			if err := l.Acquire(req.Context(), host); err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				l.Release(host)
+				return nil, err
+			}
+
+			resp.Body = &onCloseBody{ReadCloser: resp.Body, onClose: func() { l.Release(host) }}
+			return resp, nil
+		})
+	}
+}
+
+// onCloseBody викликає onClose рівно один раз при закритті тіла
+type onCloseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	onClose func()
+}
+
+func (b *onCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.onClose)
+	return err
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============= Callers from the course =============
+
+type URLStatus struct {
+	URL        string
+	StatusCode int
+	Error      error
+}
+
+// CheckURLs - версія з interviewtasks solution_02, але через Client:
+// контекст замість жорсткого таймауту, повтори і breaker на хост
+func CheckURLs(ctx context.Context, client *Client, urls []string) []URLStatus {
+	results := make([]URLStatus, len(urls))
+
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(index int, u string) {
+			defer wg.Done()
+
+			status := URLStatus{URL: u}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+			if err == nil {
+				var resp *http.Response
+				if resp, err = client.Do(req); err == nil {
+					status.StatusCode = resp.StatusCode
+					resp.Body.Close()
+				}
+			}
+			status.Error = err
+
+			// Кожна goroutine пише у свій індекс - порядок зберігається
+			results[index] = status
+		}(i, url)
+	}
+	wg.Wait()
+
+	return results
+}
+
+type Post struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Title  string `json:"title"`
+}
+
+// ExternalAPI з week_4/solutions/solution_3.go, тепер зі справжнім HTTP
+type ExternalAPI struct {
+	baseURL string
+	client  *Client
+}
+
+func NewExternalAPI(baseURL string, client *Client) *ExternalAPI {
+	return &ExternalAPI{baseURL: baseURL, client: client}
+}
+
+func (api *ExternalAPI) FetchUserPosts(ctx context.Context, userID int) ([]Post, error) {
+	var posts []Post
+	url := api.baseURL + "/users/" + strconv.Itoa(userID) + "/posts"
+	if err := api.client.GetJSON(ctx, url, &posts); err != nil {
+		return nil, fmt.Errorf("fetch posts for user %d: %w", userID, err)
+	}
+	return posts, nil
+}
+
+// ============= Demo =============
+
+// flakyServer: кожен третій запит - 503, кожен п'ятий - повільний
+func flakyServer() *httptest.Server {
+	var calls atomic.Int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n%3 == 0 {
+			http.Error(w, "temporarily unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if n%5 == 0 {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Post{
+			{ID: 1, UserID: 1, Title: "Post 1"},
+			{ID: 2, UserID: 1, Title: "Post 2"},
+		})
+	}))
+}
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║        Resilient HTTP Client           ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	flaky := flakyServer()
+	defer flaky.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	cfg := DefaultConfig()
+	cfg.Hedging = Hedging{Delay: 100 * time.Millisecond, MaxHedges: 1}
+	cfg.BreakerFail = 3
+	cfg.Logf = log.Printf
+	client := NewClient(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fmt.Println("\n📝 FetchUserPosts через нестабільний сервер:")
+	api := NewExternalAPI(flaky.URL, client)
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		posts, err := api.FetchUserPosts(ctx, 1)
+		if err != nil {
+			fmt.Printf("  ❌ %v\n", err)
+			continue
+		}
+		fmt.Printf("  ✅ %d posts (%v)\n", len(posts), time.Since(start).Round(time.Millisecond))
+	}
+
+	fmt.Println("\n📝 CheckURLs, один хост лежить:")
+	for round := 1; round <= 3; round++ {
+		for _, r := range CheckURLs(ctx, client, []string{flaky.URL, down.URL}) {
+			if r.Error != nil {
+				fmt.Printf("  [%d] ❌ %s: %v\n", round, r.URL, r.Error)
+			} else {
+				fmt.Printf("  [%d] ✅ %s: %d\n", round, r.URL, r.StatusCode)
+			}
+		}
+	}
+
+	fmt.Println("\n📊 Metrics:")
+	for _, host := range client.Metrics().Hosts() {
+		s := client.Metrics().Host(host)
+		fmt.Printf("  %s: requests=%d errors=%d 5xx=%d avg=%v breaker=%s\n",
+			host, s.Requests, s.Errors, s.ServerErrors,
+			s.AvgLatency().Round(time.Millisecond), client.BreakerState(host))
+	}
+}
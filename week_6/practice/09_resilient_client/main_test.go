@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// faultServer відповідає за сценарієм: fault(n) отримує номер запиту
+// і повертає true, якщо запит уже оброблено (помилкою чи затримкою)
+func faultServer(t *testing.T, fault func(n int64, w http.ResponseWriter) bool) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	calls := &atomic.Int64{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if fault != nil && fault(n, w) {
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, calls
+}
+
+// resetConnection рве TCP-з'єднання без відповіді
+func resetConnection(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func testConfig() Config {
+	return Config{
+		Timeout:     5 * time.Second,
+		Retry:       RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		MaxPerHost:  10,
+		BreakerFail: 100,
+		BreakerWait: time.Minute,
+	}
+}
+
+func get(t *testing.T, c *Client, url string) (*http.Response, error) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := c.Do(req)
+	if err == nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func TestRetry_RecoversFromFaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault func(n int64, w http.ResponseWriter) bool
+	}{
+		{"503", func(n int64, w http.ResponseWriter) bool {
+			if n < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return true
+			}
+			return false
+		}},
+		{"connection reset", func(n int64, w http.ResponseWriter) bool {
+			if n < 3 {
+				resetConnection(w)
+				return true
+			}
+			return false
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := faultServer(t, tt.fault)
+			client := NewClient(testConfig())
+
+			resp, err := get(t, client, srv.URL)
+			if err != nil || resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected 200 after retries, got %v, %v", resp, err)
+			}
+			if calls.Load() != 3 {
+				t.Errorf("Expected 3 attempts, got %d", calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetry_GivesUpAndReturnsLastResponse(t *testing.T) {
+	srv, calls := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusBadGateway)
+		return true
+	})
+	client := NewClient(testConfig())
+
+	resp, err := get(t, client, srv.URL)
+	if err != nil || resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Expected final 502, got %v, %v", resp, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected MaxAttempts=3 calls, got %d", calls.Load())
+	}
+}
+
+func TestRetry_OnlyIdempotentRequests(t *testing.T) {
+	srv, calls := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	client := NewClient(testConfig())
+
+	// POST без Idempotency-Key - одна спроба
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("Expected POST not retried, got %d calls", calls.Load())
+	}
+
+	// З Idempotency-Key - повторюється, тіло відправляється щоразу
+	var bodies []string
+	var mu sync.Mutex
+	keyed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer keyed.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, keyed.URL, strings.NewReader("data"))
+	req.Header.Set("Idempotency-Key", "abc")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 3 || bodies[2] != "data" {
+		t.Errorf("Expected 3 attempts with full body, got %q", bodies)
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	srv, _ := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return true
+		}
+		return false
+	})
+
+	cfg := testConfig()
+	cfg.Retry.MaxDelay = 50 * time.Millisecond // стеля і для Retry-After
+	client := NewClient(cfg)
+
+	start := time.Now()
+	resp, err := get(t, client, srv.URL)
+	elapsed := time.Since(start)
+
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %v, %v", resp, err)
+	}
+	if elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected Retry-After capped at 50ms, waited %v", elapsed)
+	}
+}
+
+func TestRetry_StopsOnContextCancel(t *testing.T) {
+	srv, calls := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+
+	cfg := testConfig()
+	cfg.Retry = RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: time.Second}
+	client := NewClient(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+	start := time.Now()
+	_, err := client.Do(req)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond || calls.Load() > 2 {
+		t.Errorf("Expected retries to stop with context, took %v and %d calls", time.Since(start), calls.Load())
+	}
+}
+
+func TestBackoff_JitterWithinBounds(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{0, 10 * time.Millisecond},
+		{2, 40 * time.Millisecond},
+		{5, 100 * time.Millisecond},
+		{60, 100 * time.Millisecond}, // без переповнення зсуву
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := p.Backoff(tt.attempt); d < 0 || d >= tt.ceiling {
+				t.Fatalf("attempt %d: expected delay in [0, %v), got %v", tt.attempt, tt.ceiling, d)
+			}
+		}
+	}
+}
+
+func TestCircuitBreaker_StateTransitions(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(2, 10*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	if b.State() != StateClosed {
+		t.Fatalf("Expected closed after 1 failure, got %s", b.State())
+	}
+	b.Failure()
+	if b.State() != StateOpen || b.Allow() != ErrCircuitOpen {
+		t.Fatalf("Expected open and rejecting, got %s", b.State())
+	}
+
+	// Після cooldown - рівно одна проба
+	now = now.Add(11 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Expected probe allowed, got %v", err)
+	}
+	if b.State() != StateHalfOpen || b.Allow() != ErrCircuitOpen {
+		t.Fatalf("Expected half-open with single probe, got %s", b.State())
+	}
+
+	// Невдала проба - знову open
+	b.Failure()
+	if b.State() != StateOpen {
+		t.Fatalf("Expected open after failed probe, got %s", b.State())
+	}
+
+	now = now.Add(11 * time.Second)
+	b.Allow()
+	b.Success()
+	if b.State() != StateClosed || b.Allow() != nil {
+		t.Errorf("Expected closed after successful probe, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_PerHost(t *testing.T) {
+	bad, badCalls := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusInternalServerError)
+		return true
+	})
+	good, _ := faultServer(t, nil)
+
+	cfg := testConfig()
+	cfg.BreakerFail = 3
+	client := NewClient(cfg)
+
+	for i := 0; i < 5; i++ {
+		get(t, client, bad.URL)
+	}
+
+	if badCalls.Load() != 3 {
+		t.Errorf("Expected breaker to stop calls after 3 failures, got %d", badCalls.Load())
+	}
+	if _, err := get(t, client, bad.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	// Інший хост не зачеплено
+	if resp, err := get(t, client, good.URL); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected healthy host to work, got %v, %v", resp, err)
+	}
+}
+
+func TestHedging_SlowFirstAttempt(t *testing.T) {
+	srv, calls := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		if n == 1 {
+			time.Sleep(time.Second)
+		}
+		return false
+	})
+
+	cfg := testConfig()
+	cfg.Hedging = Hedging{Delay: 20 * time.Millisecond, MaxHedges: 1}
+	client := NewClient(cfg)
+
+	start := time.Now()
+	resp, err := get(t, client, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	if string(body) != "ok" || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected fast hedged response, got %q after %v", body, time.Since(start))
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestHedging_NotForUnsafeMethods(t *testing.T) {
+	srv, calls := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		time.Sleep(50 * time.Millisecond)
+		return false
+	})
+
+	cfg := testConfig()
+	cfg.Hedging = Hedging{Delay: 5 * time.Millisecond, MaxHedges: 2}
+	client := NewClient(cfg)
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("x"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected PUT not hedged, got %d calls", calls.Load())
+	}
+}
+
+func TestHostLimiter_CapsConcurrency(t *testing.T) {
+	var active, peak atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.MaxPerHost = 2
+	client := NewClient(cfg)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if resp, err := client.Do(req); err == nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() != 2 {
+		t.Errorf("Expected peak concurrency 2, got %d", peak.Load())
+	}
+}
+
+func TestChain_LoggingAndMetrics(t *testing.T) {
+	srv, _ := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return true
+		}
+		return false
+	})
+
+	var mu sync.Mutex
+	var lines []string
+	cfg := testConfig()
+	cfg.Logf = func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, format)
+	}
+	client := NewClient(cfg)
+
+	get(t, client, srv.URL)
+
+	if len(lines) != 2 {
+		t.Errorf("Expected one log line per attempt, got %d", len(lines))
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	stats := client.Metrics().Host(host)
+	if stats.Requests != 2 || stats.ServerErrors != 1 {
+		t.Errorf("Expected 2 requests and 1 server error, got %+v", stats)
+	}
+}
+
+func TestCheckURLs_KeepsOrder(t *testing.T) {
+	slow, _ := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		time.Sleep(30 * time.Millisecond)
+		return false
+	})
+	missing, _ := faultServer(t, func(n int64, w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusNotFound)
+		return true
+	})
+
+	urls := []string{slow.URL, missing.URL, "http://127.0.0.1:1"}
+	results := CheckURLs(context.Background(), NewClient(testConfig()), urls)
+
+	if results[0].StatusCode != 200 || results[1].StatusCode != 404 || results[2].Error == nil {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	for i, r := range results {
+		if r.URL != urls[i] {
+			t.Errorf("Expected %s at %d, got %s", urls[i], i, r.URL)
+		}
+	}
+}
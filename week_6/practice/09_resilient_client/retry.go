@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============= Retries =============
+
+// RetryPolicy - повтори з експоненційною затримкою та jitter
+type RetryPolicy struct {
+	MaxAttempts int           // разом з першою спробою
+	BaseDelay   time.Duration // затримка перед другою спробою
+	MaxDelay    time.Duration // стеля для затримки і для Retry-After
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+	}
+}
+
+// Backoff повертає затримку перед спробою attempt+1 ("full jitter"):
+// випадкове значення в [0, min(MaxDelay, BaseDelay*2^attempt)).
+// Випадковість не дає клієнтам, що впали разом, повторювати теж разом.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	ceiling := p.MaxDelay
+	if attempt < 30 {
+		if d := p.BaseDelay << attempt; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// retryableStatus - коди, після яких повтор має сенс
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isIdempotent - чи можна безпечно виконати запит двічі.
+// POST/PATCH повторюються лише з явним Idempotency-Key.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func (p RetryPolicy) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// Тіло можна відправити повторно лише через GetBody
+			canRetry := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+			for attempt := 0; ; attempt++ {
+				attemptReq, err := rewind(req, attempt)
+				if err != nil {
+					return nil, err
+				}
+
+				resp, err := next.RoundTrip(attemptReq)
+				last := !canRetry || attempt+1 >= p.MaxAttempts
+				if last || !p.shouldRetry(req, resp, err) {
+					return resp, err
+				}
+
+				delay := p.Backoff(attempt)
+				if resp != nil {
+					if after, ok := retryAfter(resp, p.MaxDelay); ok {
+						delay = after
+					}
+					// Тіло треба дочитати і закрити, щоб з'єднання повернулось у пул
+					io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+					resp.Body.Close()
+				}
+
+				if err := sleep(req.Context(), delay); err != nil {
+					return nil, err
+				}
+			}
+		})
+	}
+}
+
+func (p RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		// Відкритий breaker не лікується повторами - одразу віддаємо помилку
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	return retryableStatus(resp.StatusCode)
+}
+
+// rewind готує запит для спроби: друга і далі отримують свіже тіло
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// retryAfter розбирає Retry-After у секундах або як HTTP-дату
+func retryAfter(resp *http.Response, maxDelay time.Duration) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		delay = time.Until(at)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay, true
+}
+
+// sleep чекає d або скасування контексту
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============= RoundTripper chain =============
+
+// Middleware обгортає RoundTripper, як func(http.Handler) http.Handler на сервері
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc дозволяє використовувати звичайну функцію як RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain збирає ланцюжок: перший middleware - зовнішній.
+// Chain(base, A, B) виконується як A -> B -> base.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+// Logging пише одну строку на кожну спробу
+func Logging(logf func(format string, args ...interface{})) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			if err != nil {
+				logf("%s %s -> error: %v (%v)", req.Method, req.URL, err, elapsed)
+				return nil, err
+			}
+			logf("%s %s -> %d (%v)", req.Method, req.URL, resp.StatusCode, elapsed)
+			return resp, nil
+		})
+	}
+}
+
+// ============= Metrics =============
+
+// HostStats - лічильники для одного хоста
+type HostStats struct {
+	Requests     int
+	Errors       int // помилки транспорту
+	ServerErrors int // відповіді 5xx
+	TotalLatency time.Duration
+}
+
+func (s HostStats) AvgLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Requests)
+}
+
+// Metrics збирає статистику по хостах
+type Metrics struct {
+	mu    sync.Mutex
+	hosts map[string]*HostStats
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{hosts: make(map[string]*HostStats)}
+}
+
+func (m *Metrics) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			m.record(req.URL.Host, time.Since(start), resp, err)
+			return resp, err
+		})
+	}
+}
+
+func (m *Metrics) record(host string, latency time.Duration, resp *http.Response, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.hosts[host]
+	if !ok {
+		stats = &HostStats{}
+		m.hosts[host] = stats
+	}
+
+	stats.Requests++
+	stats.TotalLatency += latency
+	switch {
+	case err != nil:
+		stats.Errors++
+	case resp.StatusCode >= 500:
+		stats.ServerErrors++
+	}
+}
+
+// Host повертає копію статистики хоста
+func (m *Metrics) Host(host string) HostStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stats, ok := m.hosts[host]; ok {
+		return *stats
+	}
+	return HostStats{}
+}
+
+// Hosts повертає відсортований список хостів, до яких були запити
+func (m *Metrics) Hosts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hosts := make([]string, 0, len(m.hosts))
+	for host := range m.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
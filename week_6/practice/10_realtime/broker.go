@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ============= Broker =============
+// PubSub з week_23/channels/19_pub_sub.go, доповнений для мережі:
+//
+//   - кожне повідомлення отримує зростаючий у межах топіка ID (для Last-Event-ID)
+//   - останні historySize повідомлень топіка зберігаються для replay
+//   - Publish не блокується і не плодить goroutine на кожну доставку:
+//     якщо буфер підписника повний, підписка закривається (slow consumer),
+//     а клієнт перепідключається і добирає пропущене з історії
+
+type Message struct {
+	ID    uint64    `json:"id"`
+	Topic string    `json:"topic"`
+	Data  string    `json:"data"`
+	Time  time.Time `json:"time"`
+}
+
+type Subscription struct {
+	C <-chan Message
+
+	ch         chan Message
+	topic      string
+	overflowed bool // читати лише після закриття C
+}
+
+// Overflowed - чи була підписка закрита через переповнений буфер
+func (s *Subscription) Overflowed() bool {
+	return s.overflowed
+}
+
+type Broker struct {
+	mu          sync.Mutex
+	nextID      map[string]uint64
+	historySize int
+	history     map[string][]Message
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		nextID:      make(map[string]uint64),
+		historySize: historySize,
+		history:     make(map[string][]Message),
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe підписує на топік і повертає повідомлення з історії після lastID.
+// Replay і підписка робляться під одним локом, тому між ними нічого не губиться.
+// complete = false, якщо частина пропущених повідомлень уже витіснена з історії.
+func (b *Broker) Subscribe(topic string, lastID uint64, buffer int) (sub *Subscription, replay []Message, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Message, buffer)
+	sub = &Subscription{C: ch, ch: ch, topic: topic}
+
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[topic][sub] = struct{}{}
+
+	complete = true
+	if lastID > 0 {
+		history := b.history[topic]
+		for i, msg := range history {
+			if msg.ID > lastID {
+				replay = append(replay, history[i:]...)
+				break
+			}
+		}
+		// Найстаріше збережене повідомлення не одразу після lastID - є діра
+		if len(history) > 0 && history[0].ID > lastID+1 {
+			complete = false
+		}
+	}
+	return sub, replay, complete
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// remove викликається під локом
+func (b *Broker) remove(sub *Subscription) {
+	subs := b.subscribers[sub.topic]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.topic)
+	}
+	close(sub.ch)
+}
+
+func (b *Broker) Publish(topic, data string) Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID[topic]++
+	msg := Message{ID: b.nextID[topic], Topic: topic, Data: data, Time: time.Now()}
+
+	history := append(b.history[topic], msg)
+	if len(history) > b.historySize {
+		history = history[len(history)-b.historySize:]
+	}
+	b.history[topic] = history
+
+	for sub := range b.subscribers[topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			sub.overflowed = true
+			b.remove(sub)
+		}
+	}
+	return msg
+}
+
+// Subscribers - кількість підписників топіка
+func (b *Broker) Subscribers(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[topic])
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Realtime demo</title></head>
+<body>
+<h3>SSE: /events/news</h3>
+<pre id="sse"></pre>
+<h3>WebSocket: /ws (topic chat)</h3>
+<input id="text" placeholder="message"><button onclick="send()">Send</button>
+<pre id="ws"></pre>
+<script>
+const sse = new EventSource("/events/news");
+sse.addEventListener("news", e => { document.getElementById("sse").textContent += e.lastEventId + ": " + e.data + "\n"; });
+
+const ws = new WebSocket("ws://" + location.host + "/ws");
+ws.onopen = () => ws.send(JSON.stringify({action: "subscribe", topic: "chat"}));
+ws.onmessage = e => { document.getElementById("ws").textContent += e.data + "\n"; };
+function send() {
+  const input = document.getElementById("text");
+  ws.send(JSON.stringify({action: "publish", topic: "chat", data: input.value}));
+  input.value = "";
+}
+</script>
+</body>
+</html>`
+
+func Routes(broker *Broker) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, indexHTML)
+	})
+	mux.Handle("GET /events/{topic}", NewSSEHandler(broker))
+	mux.Handle("GET /ws", NewWSHandler(broker))
+
+	// Публікація звичайним HTTP - зручно з curl і з інших сервісів
+	mux.HandleFunc("POST /publish/{topic}", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		msg := broker.Publish(r.PathValue("topic"), string(body))
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "%d\n", msg.ID)
+	})
+
+	return mux
+}
+
+func main() {
+	broker := NewBroker(100)
+
+	// Фоновий "видавець новин"
+	go func() {
+		for i := 1; ; i++ {
+			broker.Publish("news", fmt.Sprintf("Breaking news #%d at %s", i, time.Now().Format(time.TimeOnly)))
+			time.Sleep(3 * time.Second)
+		}
+	}()
+
+	fmt.Println("🚀 Server started at http://localhost:8080")
+	fmt.Println("Try: curl -N http://localhost:8080/events/news")
+	fmt.Println(`     curl -N -H "Last-Event-ID: 2" http://localhost:8080/events/news`)
+	fmt.Println(`     curl -d "hello" http://localhost:8080/publish/chat`)
+	log.Fatal(http.ListenAndServe(":8080", Routes(broker)))
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBroker_ReplayAndGaps(t *testing.T) {
+	b := NewBroker(3)
+	for _, data := range []string{"a", "b", "c", "d", "e"} {
+		b.Publish("news", data)
+	}
+	b.Publish("sports", "x") // інший топік - своя нумерація
+
+	tests := []struct {
+		lastID   uint64
+		want     string
+		complete bool
+	}{
+		{0, "", true},     // новий клієнт - без replay
+		{3, "de", true},   // 4 і 5 ще в історії
+		{2, "cde", true},  // 3 - найстаріше збережене
+		{1, "cde", false}, // 2 вже витіснено
+		{5, "", true},
+	}
+
+	for _, tt := range tests {
+		sub, replay, complete := b.Subscribe("news", tt.lastID, 10)
+		var got string
+		for _, msg := range replay {
+			got += msg.Data
+		}
+		if got != tt.want || complete != tt.complete {
+			t.Errorf("lastID %d: expected %q complete=%v, got %q complete=%v", tt.lastID, tt.want, tt.complete, got, complete)
+		}
+		b.Unsubscribe(sub)
+	}
+}
+
+func TestBroker_SlowSubscriberClosed(t *testing.T) {
+	b := NewBroker(10)
+	slow, _, _ := b.Subscribe("chat", 0, 2)
+	fast, _, _ := b.Subscribe("chat", 0, 10)
+
+	for i := 0; i < 5; i++ {
+		b.Publish("chat", "msg")
+	}
+
+	count := 0
+	for range slow.C {
+		count++
+	}
+	if count != 2 || !slow.Overflowed() {
+		t.Errorf("Expected slow subscription closed after 2 messages, got %d, overflowed=%v", count, slow.Overflowed())
+	}
+	if len(fast.C) != 5 || b.Subscribers("chat") != 1 {
+		t.Errorf("Expected fast subscriber unaffected, got %d queued, %d subscribers", len(fast.C), b.Subscribers("chat"))
+	}
+}
+
+// ============= SSE =============
+
+type sseEvent struct {
+	id, event, data string
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if ev.id != "" || ev.event != "" {
+				ev.data = strings.Join(data, "\n")
+				return ev
+			}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func TestSSE_ReplayAndLive(t *testing.T) {
+	broker := NewBroker(100)
+	srv := httptest.NewServer(Routes(broker))
+	defer srv.Close()
+
+	broker.Publish("news", "first")
+	broker.Publish("news", "second\nline two")
+
+	req, _ := http.NewRequest("GET", srv.URL+"/events/news", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %s", resp.Header.Get("Content-Type"))
+	}
+	r := bufio.NewReader(resp.Body)
+
+	ev := readEvent(t, r)
+	if ev.id != "2" || ev.event != "news" || ev.data != "second\nline two" {
+		t.Errorf("Expected replayed event 2 with multiline data, got %+v", ev)
+	}
+
+	broker.Publish("news", "third")
+	if ev := readEvent(t, r); ev.id != "3" || ev.data != "third" {
+		t.Errorf("Expected live event 3, got %+v", ev)
+	}
+}
+
+// ============= WebSocket =============
+
+func TestAcceptKey_RFCExample(t *testing.T) {
+	// Приклад з RFC 6455 §1.3
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Unexpected accept key %s", got)
+	}
+}
+
+func startWS(t *testing.T, configure func(*WSHandler)) (*Broker, string) {
+	t.Helper()
+	broker := NewBroker(100)
+	h := NewWSHandler(broker)
+	if configure != nil {
+		configure(h)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return broker, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url string) *WSConn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := DialWebSocket(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(CloseNormal, "") })
+	return conn
+}
+
+func sendCommand(t *testing.T, conn *WSConn, cmd clientCommand) {
+	t.Helper()
+	data, _ := json.Marshal(cmd)
+	if err := conn.WriteMessage(OpText, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readServerEvent(t *testing.T, conn *WSConn) serverEvent {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var ev serverEvent
+	json.Unmarshal(data, &ev)
+	return ev
+}
+
+func TestWebSocket_SubscribeAndPublish(t *testing.T) {
+	broker, url := startWS(t, nil)
+	broker.Publish("chat", "history")
+
+	alice := dial(t, url)
+	bob := dial(t, url)
+
+	sendCommand(t, alice, clientCommand{Action: "subscribe", Topic: "chat", LastID: 0})
+	if ev := readServerEvent(t, alice); ev.Type != "subscribed" {
+		t.Fatalf("Expected subscribed, got %+v", ev)
+	}
+
+	sendCommand(t, bob, clientCommand{Action: "publish", Topic: "chat", Data: "Привіт 👋"})
+	ev := readServerEvent(t, alice)
+	if ev.Type != "message" || ev.Message.Data != "Привіт 👋" || ev.Message.ID != 2 {
+		t.Errorf("Expected published message, got %+v", ev)
+	}
+
+	// Перепідключення з last_id отримує пропущене
+	sendCommand(t, bob, clientCommand{Action: "subscribe", Topic: "chat", LastID: 1})
+	readServerEvent(t, bob) // subscribed
+	if ev := readServerEvent(t, bob); ev.Message == nil || ev.Message.ID != 2 {
+		t.Errorf("Expected replay of message 2, got %+v", ev)
+	}
+
+	sendCommand(t, bob, clientCommand{Action: "dance", Topic: "chat"})
+	if ev := readServerEvent(t, bob); ev.Type != "error" {
+		t.Errorf("Expected error for unknown action, got %+v", ev)
+	}
+}
+
+// writeRawFrame пише фрейм вручну: потрібен для фрагментації і помилок протоколу
+func writeRawFrame(conn *WSConn, fin bool, opcode byte, masked bool, payload []byte) {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	buf := []byte{b0}
+	mask := [4]byte{1, 2, 3, 4}
+	if masked {
+		buf = append(buf, 0x80|byte(len(payload)))
+		buf = append(buf, mask[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		maskBytes(mask, buf[start:])
+	} else {
+		buf = append(buf, byte(len(payload)))
+		buf = append(buf, payload...)
+	}
+	conn.conn.Write(buf)
+}
+
+func TestWebSocket_FragmentedMessageWithPing(t *testing.T) {
+	broker, url := startWS(t, nil)
+	conn := dial(t, url)
+
+	cmd := `{"action":"subscribe","topic":"frag"}`
+	writeRawFrame(conn, false, OpText, true, []byte(cmd[:10]))
+	writeRawFrame(conn, true, OpPing, true, []byte("in-between")) // control між фрагментами дозволений
+	writeRawFrame(conn, false, OpContinuation, true, []byte(cmd[10:20]))
+	writeRawFrame(conn, true, OpContinuation, true, []byte(cmd[20:]))
+
+	var pong []byte
+	conn.OnPong = func(p []byte) { pong = p }
+	if ev := readServerEvent(t, conn); ev.Type != "subscribed" || ev.Topic != "frag" {
+		t.Fatalf("Expected subscribed after fragmented command, got %+v", ev)
+	}
+	if string(pong) != "in-between" {
+		t.Errorf("Expected pong echoing ping payload, got %q", pong)
+	}
+	if broker.Subscribers("frag") != 1 {
+		t.Errorf("Expected 1 subscriber")
+	}
+}
+
+func TestWebSocket_UnmaskedClientFrameRejected(t *testing.T) {
+	_, url := startWS(t, nil)
+	conn := dial(t, url)
+
+	writeRawFrame(conn, true, OpText, false, []byte(`{}`))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseProtocolError {
+		t.Errorf("Expected close 1002, got %v", err)
+	}
+}
+
+// Порожній Close від клієнта: локально 1005, але на дріт 1005 не йде
+func TestWebSocket_EmptyCloseNotEchoedAs1005(t *testing.T) {
+	_, url := startWS(t, nil)
+	conn := dial(t, url)
+
+	writeRawFrame(conn, true, OpClose, true, nil)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	f, err := conn.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.opcode != OpClose || len(f.payload) != 0 {
+		t.Errorf("Expected empty Close frame, got opcode %d payload %v", f.opcode, f.payload)
+	}
+
+	// Клієнтський бік: 1005 лише в CloseError
+	server, client := net.Pipe()
+	defer client.Close()
+	ws := newWSConn(server, bufio.NewReader(server), false)
+	go func() {
+		client.Write([]byte{0x80 | OpClose, 0x80, 1, 2, 3, 4}) // маскований, без payload
+		io.Copy(io.Discard, client)
+	}()
+	_, _, err = ws.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseNoStatus {
+		t.Errorf("Expected CloseError 1005, got %v", err)
+	}
+}
+
+// Close від клієнта: допустимий код повертається луною, решта - 1002/1007
+func TestWebSocket_ClosePayloadValidated(t *testing.T) {
+	_, url := startWS(t, nil)
+	closePayload := func(code int, reason string) []byte {
+		return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    int
+	}{
+		{"normal", closePayload(CloseNormal, "bye"), CloseNormal},
+		{"application code", closePayload(4000, ""), 4000},
+		{"one byte", []byte{0x03}, CloseProtocolError},
+		{"below 1000", closePayload(999, ""), CloseProtocolError},
+		{"reserved 1004", closePayload(1004, ""), CloseProtocolError},
+		{"no status 1005", closePayload(CloseNoStatus, ""), CloseProtocolError},
+		{"abnormal 1006", closePayload(1006, ""), CloseProtocolError},
+		{"tls 1015", closePayload(1015, ""), CloseProtocolError},
+		{"unassigned 2000", closePayload(2000, ""), CloseProtocolError},
+		{"above 4999", closePayload(5000, ""), CloseProtocolError},
+		{"invalid utf-8 reason", closePayload(CloseNormal, "\xff"), CloseInvalidData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dial(t, url)
+			writeRawFrame(conn, true, OpClose, true, tt.payload)
+
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			f, err := conn.readFrame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.opcode != OpClose || len(f.payload) < 2 {
+				t.Fatalf("Expected Close with code, got opcode %d payload %v", f.opcode, f.payload)
+			}
+			if got := int(binary.BigEndian.Uint16(f.payload)); got != tt.want {
+				t.Errorf("Expected close %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWebSocket_CheckOrigin(t *testing.T) {
+	upgrade := func(url, origin string) int {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	_, wsURL := startWS(t, nil)
+	httpURL := "http" + strings.TrimPrefix(wsURL, "ws")
+	tests := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{"no origin", "", http.StatusSwitchingProtocols},
+		{"same origin", httpURL, http.StatusSwitchingProtocols},
+		{"foreign origin", "https://evil.example", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := upgrade(httpURL, tt.origin); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+
+	_, wsURL = startWS(t, func(h *WSHandler) {
+		h.CheckOrigin = func(r *http.Request) bool { return r.Header.Get("Origin") == "https://app.example" }
+	})
+	httpURL = "http" + strings.TrimPrefix(wsURL, "ws")
+	if got := upgrade(httpURL, "https://app.example"); got != http.StatusSwitchingProtocols {
+		t.Errorf("Expected custom CheckOrigin to allow app.example, got %d", got)
+	}
+}
+
+func TestWebSocket_KeepAlive(t *testing.T) {
+	broker, url := startWS(t, func(h *WSHandler) {
+		h.pingInterval = 20 * time.Millisecond
+		h.pongWait = 100 * time.Millisecond
+	})
+
+	// Живий клієнт читає, отже автоматично відповідає pong
+	alive := dial(t, url)
+	sendCommand(t, alive, clientCommand{Action: "subscribe", Topic: "alive"})
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Мертвий клієнт підписується і більше нічого не читає
+	dead := dial(t, url)
+	sendCommand(t, dead, clientCommand{Action: "subscribe", Topic: "dead"})
+
+	time.Sleep(400 * time.Millisecond)
+
+	if broker.Subscribers("alive") != 1 {
+		t.Errorf("Expected client answering pings to stay connected")
+	}
+	if broker.Subscribers("dead") != 0 {
+		t.Errorf("Expected client without pongs to be disconnected")
+	}
+}
+
+func TestWebSocket_SlowClientDisconnected(t *testing.T) {
+	broker, url := startWS(t, func(h *WSHandler) {
+		h.sendBuffer = 4
+		h.writeWait = 50 * time.Millisecond
+	})
+
+	conn := dial(t, url)
+	sendCommand(t, conn, clientCommand{Action: "subscribe", Topic: "firehose"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for broker.Subscribers("firehose") == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Клієнт не читає: черга і TCP-буфери переповнюються
+	big := strings.Repeat("x", 64<<10)
+	for i := 0; i < 300 && broker.Subscribers("firehose") > 0; i++ {
+		broker.Publish("firehose", big)
+	}
+
+	for broker.Subscribers("firehose") > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if broker.Subscribers("firehose") != 0 {
+		t.Errorf("Expected slow client to be disconnected")
+	}
+}
+
+func TestWebSocket_RejectsPlainHTTP(t *testing.T) {
+	h := NewWSHandler(NewBroker(10))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/ws", nil))
+	if rec.Code != http.StatusUpgradeRequired {
+		t.Errorf("Expected 426, got %d", rec.Code)
+	}
+}
+
+func TestWebSocket_MessageTooBig(t *testing.T) {
+	_, url := startWS(t, nil)
+	conn := dial(t, url)
+
+	// Заголовок оголошує 2 MB - сервер закриває, не читаючи payload
+	header := []byte{0x80 | OpText, 0x80 | 127}
+	header = binary.BigEndian.AppendUint64(header, 2<<20)
+	header = append(header, 1, 2, 3, 4)
+	conn.conn.Write(header)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseTooBig {
+		t.Errorf("Expected close 1009, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============= Server-Sent Events =============
+
+// SSEHandler транслює топік у браузер: GET /events/{topic}.
+// Браузерний EventSource сам перепідключається і надсилає Last-Event-ID,
+// тому після обриву клієнт отримує пропущене з історії брокера.
+type SSEHandler struct {
+	broker    *Broker
+	buffer    int           // буфер підписки: більше - і клієнт вважається повільним
+	keepAlive time.Duration // коментар ": ping", щоб проксі не закривали тихе з'єднання
+}
+
+func NewSSEHandler(broker *Broker) *SSEHandler {
+	return &SSEHandler{broker: broker, buffer: 64, keepAlive: 15 * time.Second}
+}
+
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	topic := r.PathValue("topic")
+	rc := http.NewResponseController(w)
+
+	// EventSource надсилає заголовок, ручний клієнт може передати query
+	lastID, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	if v := r.URL.Query().Get("lastEventId"); v != "" && lastID == 0 {
+		lastID, _ = strconv.ParseUint(v, 10, 64)
+	}
+
+	sub, replay, complete := h.broker.Subscribe(topic, lastID, h.buffer)
+	defer h.broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 2000\n\n")
+	if !complete {
+		// Історії не вистачило - клієнт має сам перечитати стан
+		fmt.Fprint(w, "event: reset\ndata: history truncated\n\n")
+	}
+	for _, msg := range replay {
+		writeEvent(w, msg)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case msg, ok := <-sub.C:
+			if !ok {
+				// Переповнений буфер: закриваємо потік, EventSource
+				// перепідключиться з Last-Event-ID і добере решту
+				return
+			}
+			writeEvent(w, msg)
+
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+
+		// Не чекаємо повільного клієнта вічно
+		rc.SetWriteDeadline(time.Now().Add(h.keepAlive))
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent: багаторядкові дані - кілька рядків "data:"
+func writeEvent(w io.Writer, msg Message) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "id: %d\n", msg.ID)
+	fmt.Fprintf(&b, "event: %s\n", msg.Topic)
+	for _, line := range strings.Split(msg.Data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ============= WebSocket (RFC 6455) =============
+// Мінімальна реалізація лише на stdlib: handshake, фрейми, маскування,
+// фрагментація, ping/pong і закриття. Без розширень (permessage-deflate).
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// Close codes
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseInvalidData   = 1007
+	ClosePolicy        = 1008
+	CloseTooBig        = 1009
+	CloseTryAgainLater = 1013
+
+	// CloseNoStatus - Close без коду. Лише для CloseError на нашому боці:
+	// RFC 6455 §7.4.1 забороняє надсилати 1005 у фреймі
+	CloseNoStatus = 1005
+)
+
+var ErrMessageTooBig = errors.New("websocket: message too big")
+
+// CloseError - з'єднання закрито фреймом Close
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with code %d (%s)", e.Code, e.Reason)
+}
+
+// WSConn - одне WebSocket-з'єднання.
+// Читати може лише одна goroutine, писати - будь-які (запис під mutex).
+type WSConn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool // клієнт маскує свої фрейми, сервер - ні
+
+	MaxMessageSize int64
+	OnPong         func([]byte)
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closeSent bool
+}
+
+func newWSConn(conn net.Conn, br *bufio.Reader, client bool) *WSConn {
+	return &WSConn{conn: conn, br: br, client: client, MaxMessageSize: 1 << 20}
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrader - серверний handshake з налаштуваннями
+type Upgrader struct {
+	// CheckOrigin вирішує, чи приймати з'єднання з цього Origin.
+	// nil - лише той самий хост (або запит без Origin, тобто не з браузера):
+	// інакше будь-який сайт відкрив би WebSocket з cookie користувача.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Upgrade - handshake з перевіркою Origin за замовчуванням
+func Upgrade(w http.ResponseWriter, r *http.Request) (*WSConn, error) {
+	return (&Upgrader{}).Upgrade(w, r)
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Upgrade виконує серверний handshake і забирає з'єднання у net/http
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (*WSConn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: not an upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusBadRequest)
+		return nil, errors.New("websocket: unsupported version")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, errors.New("websocket: origin not allowed")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: invalid key")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return newWSConn(conn, rw.Reader, false), nil
+}
+
+// DialWebSocket - клієнтський handshake (для тестів і демо)
+func DialWebSocket(ctx context.Context, rawURL string) (*WSConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    u,
+		Host:   u.Host,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {key},
+			"Sec-WebSocket-Version": {"13"},
+		},
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed with status %d", resp.StatusCode)
+	}
+
+	return newWSConn(conn, br, true), nil
+}
+
+// ============= Frames =============
+
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// readFrame читає один фрейм.
+//
+//	0                   1                   2                   3
+//	|F|R|R|R| opcode|M| len (7) | ext len (16/64) | mask key (32) | payload
+func (c *WSConn) readFrame() (frame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{fin: header[0]&0x80 != 0, opcode: header[0] & 0x0F}
+	if header[0]&0x70 != 0 {
+		return f, c.protocolError("reserved bits set")
+	}
+	masked := header[1]&0x80 != 0
+	if masked == c.client {
+		// Клієнт зобов'язаний маскувати, сервер - ні
+		return f, c.protocolError("invalid masking")
+	}
+
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return f, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return f, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	isControl := f.opcode >= OpClose
+	if isControl && (length > 125 || !f.fin) {
+		return f, c.protocolError("invalid control frame")
+	}
+	if length < 0 || length > c.MaxMessageSize {
+		c.closeWith(CloseTooBig, "message too big")
+		return f, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return f, err
+		}
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return f, err
+	}
+	if masked {
+		maskBytes(mask, f.payload)
+	}
+	return f, nil
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
+
+func (c *WSConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	if opcode == OpClose {
+		c.closeSent = true
+	}
+
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|opcode)
+
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		buf = append(buf, mask[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		maskBytes(mask, buf[start:])
+	} else {
+		buf = append(buf, payload...)
+	}
+
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// ============= Messages =============
+
+// ReadMessage повертає наступне текстове чи бінарне повідомлення, збираючи
+// фрагменти. Ping отримує відповідь Pong автоматично, Pong передається в OnPong.
+func (c *WSConn) ReadMessage() (opcode byte, data []byte, err error) {
+	var message []byte
+	messageOp := byte(0)
+
+	for {
+		f, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case OpPing:
+			c.writeFrame(OpPong, f.payload)
+			continue
+		case OpPong:
+			if c.OnPong != nil {
+				c.OnPong(f.payload)
+			}
+			continue
+		case OpClose:
+			return 0, nil, c.handleClose(f.payload)
+
+		case OpText, OpBinary:
+			if messageOp != 0 {
+				return 0, nil, c.protocolError("new message inside fragmented message")
+			}
+			messageOp = f.opcode
+		case OpContinuation:
+			if messageOp == 0 {
+				return 0, nil, c.protocolError("unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.protocolError("unknown opcode")
+		}
+
+		if int64(len(message)+len(f.payload)) > c.MaxMessageSize {
+			c.closeWith(CloseTooBig, "message too big")
+			return 0, nil, ErrMessageTooBig
+		}
+		message = append(message, f.payload...)
+
+		if f.fin {
+			if messageOp == OpText && !utf8.Valid(message) {
+				c.closeWith(CloseInvalidData, "invalid utf-8")
+				return 0, nil, errors.New("websocket: invalid utf-8 in text message")
+			}
+			return messageOp, message, nil
+		}
+	}
+}
+
+func (c *WSConn) WriteMessage(opcode byte, data []byte) error {
+	return c.writeFrame(opcode, data)
+}
+
+func (c *WSConn) WriteText(text string) error {
+	return c.writeFrame(OpText, []byte(text))
+}
+
+func (c *WSConn) Ping(data []byte) error {
+	return c.writeFrame(OpPing, data)
+}
+
+func (c *WSConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *WSConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// handleClose відповідає на Close і повертає CloseError
+func (c *WSConn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(payload) == 1:
+		return c.protocolError("close payload of 1 byte")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+		if !validCloseCode(closeErr.Code) {
+			return c.protocolError(fmt.Sprintf("invalid close code %d", closeErr.Code))
+		}
+		if !utf8.ValidString(closeErr.Reason) {
+			c.closeWith(CloseInvalidData, "invalid utf-8")
+			return errors.New("websocket: invalid utf-8 in close reason")
+		}
+	}
+	c.closeWith(closeErr.Code, "")
+	return closeErr
+}
+
+// validCloseCode - коди, які можна отримати у фреймі (RFC 6455 §7.4).
+// 1004-1006 і 1015 зарезервовані: їх не надсилають, тож луна у відповідь
+// була б порушенням протоколу з нашого боку.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+func (c *WSConn) protocolError(reason string) error {
+	c.closeWith(CloseProtocolError, reason)
+	return errors.New("websocket: protocol error: " + reason)
+}
+
+// closeWith надсилає Close (якщо ще не надсилали) і закриває TCP.
+// CloseNoStatus - порожній Close, без коду і причини.
+func (c *WSConn) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		var payload []byte
+		if code != CloseNoStatus {
+			payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+			payload = append(payload, reason...)
+		}
+
+		c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.writeFrame(OpClose, payload)
+		c.conn.Close()
+	})
+}
+
+// Close закриває з'єднання з кодом і причиною
+func (c *WSConn) Close(code int, reason string) error {
+	c.closeWith(code, reason)
+	return nil
+}
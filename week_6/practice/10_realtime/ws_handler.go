@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============= WebSocket endpoint =============
+
+// Протокол - JSON в обидва боки:
+//
+//	клієнт: {"action":"subscribe","topic":"chat","last_id":5}
+//	        {"action":"unsubscribe","topic":"chat"}
+//	        {"action":"publish","topic":"chat","data":"hi"}
+//	сервер: {"type":"message","message":{...}}
+//	        {"type":"subscribed","topic":"chat"}, {"type":"error","error":"..."}
+type clientCommand struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+	Data   string `json:"data,omitempty"`
+	LastID uint64 `json:"last_id,omitempty"`
+}
+
+type serverEvent struct {
+	Type    string   `json:"type"`
+	Topic   string   `json:"topic,omitempty"`
+	Message *Message `json:"message,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type WSHandler struct {
+	// CheckOrigin - див. Upgrader; nil - лише той самий хост
+	CheckOrigin func(r *http.Request) bool
+
+	broker       *Broker
+	pingInterval time.Duration
+	pongWait     time.Duration // без жодного фрейму довше - клієнт мертвий
+	writeWait    time.Duration
+	sendBuffer   int // вихідна черга на з'єднання
+}
+
+func NewWSHandler(broker *Broker) *WSHandler {
+	return &WSHandler{
+		broker:       broker,
+		pingInterval: 20 * time.Second,
+		pongWait:     30 * time.Second,
+		writeWait:    5 * time.Second,
+		sendBuffer:   64,
+	}
+}
+
+// wsClient - стан одного з'єднання
+type wsClient struct {
+	h    *WSHandler
+	conn *WSConn
+	send chan []byte
+	done chan struct{}
+
+	mu   sync.Mutex
+	subs map[string]*Subscription
+
+	slowOnce sync.Once
+}
+
+func (h *WSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := Upgrader{CheckOrigin: h.CheckOrigin}
+	conn, err := upgrader.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	c := &wsClient{
+		h:    h,
+		conn: conn,
+		send: make(chan []byte, h.sendBuffer),
+		done: make(chan struct{}),
+		subs: make(map[string]*Subscription),
+	}
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+// readLoop - єдиний читач з'єднання; його завершення завершує все інше
+func (c *wsClient) readLoop() {
+	defer func() {
+		close(c.done)
+		c.unsubscribeAll()
+		c.conn.Close(CloseNormal, "")
+	}()
+
+	c.conn.SetReadDeadline(time.Now().Add(c.h.pongWait))
+	c.conn.OnPong = func([]byte) {
+		c.conn.SetReadDeadline(time.Now().Add(c.h.pongWait))
+	}
+
+	for {
+		op, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(c.h.pongWait))
+
+		if op != OpText {
+			c.enqueue(serverEvent{Type: "error", Error: "only text messages are supported"})
+			continue
+		}
+
+		var cmd clientCommand
+		if err := json.Unmarshal(data, &cmd); err != nil || cmd.Topic == "" {
+			c.enqueue(serverEvent{Type: "error", Error: "invalid command"})
+			continue
+		}
+
+		switch cmd.Action {
+		case "subscribe":
+			c.subscribe(cmd.Topic, cmd.LastID)
+		case "unsubscribe":
+			c.unsubscribe(cmd.Topic)
+		case "publish":
+			c.h.broker.Publish(cmd.Topic, cmd.Data)
+		default:
+			c.enqueue(serverEvent{Type: "error", Error: "unknown action " + cmd.Action})
+		}
+	}
+}
+
+// writeLoop - єдиний письменник: повідомлення з черги і ping за таймером
+func (c *wsClient) writeLoop() {
+	ticker := time.NewTicker(c.h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+
+		case data := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.h.writeWait))
+			if err := c.conn.WriteMessage(OpText, data); err != nil {
+				c.conn.Close(CloseGoingAway, "write failed")
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(c.h.writeWait))
+			if err := c.conn.Ping(nil); err != nil {
+				c.conn.Close(CloseGoingAway, "ping failed")
+				return
+			}
+		}
+	}
+}
+
+func (c *wsClient) subscribe(topic string, lastID uint64) {
+	c.mu.Lock()
+	if _, ok := c.subs[topic]; ok {
+		c.mu.Unlock()
+		return
+	}
+	sub, replay, complete := c.h.broker.Subscribe(topic, lastID, c.h.sendBuffer)
+	c.subs[topic] = sub
+	c.mu.Unlock()
+
+	// Історія може бути більшою за чергу, тому replay чекає на writeLoop
+	c.enqueueWait(serverEvent{Type: "subscribed", Topic: topic})
+	if !complete {
+		c.enqueueWait(serverEvent{Type: "reset", Topic: topic})
+	}
+	for i := range replay {
+		c.enqueueWait(serverEvent{Type: "message", Message: &replay[i]})
+	}
+
+	go c.forward(sub)
+}
+
+// forward переносить повідомлення з підписки у вихідну чергу
+func (c *wsClient) forward(sub *Subscription) {
+	for msg := range sub.C {
+		c.enqueue(serverEvent{Type: "message", Message: &msg})
+	}
+	if sub.Overflowed() {
+		c.slow("subscription buffer overflow")
+	}
+}
+
+// enqueue ніколи не блокується: повна черга означає, що клієнт не встигає
+// читати, і краще його відключити, ніж тримати пам'ять і гальмувати брокер
+func (c *wsClient) enqueue(event serverEvent) {
+	data, _ := json.Marshal(event)
+	select {
+	case c.send <- data:
+	case <-c.done:
+	default:
+		c.slow("send queue full")
+	}
+}
+
+// enqueueWait чекає місця в черзі не довше writeWait
+func (c *wsClient) enqueueWait(event serverEvent) {
+	data, _ := json.Marshal(event)
+	timer := time.NewTimer(c.h.writeWait)
+	defer timer.Stop()
+
+	select {
+	case c.send <- data:
+	case <-c.done:
+	case <-timer.C:
+		c.slow("send queue full")
+	}
+}
+
+func (c *wsClient) slow(reason string) {
+	c.slowOnce.Do(func() {
+		log.Printf("websocket: disconnecting slow client: %s", reason)
+		c.conn.Close(CloseTryAgainLater, "slow consumer")
+	})
+}
+
+func (c *wsClient) unsubscribe(topic string) {
+	c.mu.Lock()
+	sub, ok := c.subs[topic]
+	delete(c.subs, topic)
+	c.mu.Unlock()
+
+	if ok {
+		c.h.broker.Unsubscribe(sub)
+	}
+}
+
+func (c *wsClient) unsubscribeAll() {
+	c.mu.Lock()
+	subs := c.subs
+	c.subs = make(map[string]*Subscription)
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		c.h.broker.Unsubscribe(sub)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+)
+
+// ============= Client =============
+
+// Client - одне TCP-з'єднання. Вихідні рядки йдуть через чергу out,
+// яку розбирає окрема goroutine writeLoop, тому Receive не блокує
+// кімнату навіть якщо клієнт перестав читати.
+type Client struct {
+	conn net.Conn
+	out  chan string
+	done chan struct{}
+
+	mu    sync.Mutex
+	nick  string
+	rooms map[string]*ChatRoom
+
+	limiter   *RateLimiter
+	closeOnce sync.Once
+	slow      bool
+}
+
+func newClient(conn net.Conn, sendBuffer int, limiter *RateLimiter) *Client {
+	return &Client{
+		conn:    conn,
+		out:     make(chan string, sendBuffer),
+		done:    make(chan struct{}),
+		rooms:   make(map[string]*ChatRoom),
+		limiter: limiter,
+	}
+}
+
+func (c *Client) Nick() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nick
+}
+
+// Receive ніколи не блокується: повна черга означає slow consumer
+func (c *Client) Receive(line string) {
+	select {
+	case c.out <- line:
+	case <-c.done:
+	default:
+		c.mu.Lock()
+		c.slow = true
+		c.mu.Unlock()
+		c.Close()
+	}
+}
+
+// Close закриває з'єднання; readLoop отримає помилку і прибере клієнта з кімнат
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *Client) room(name string) *ChatRoom {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rooms[name]
+}
+
+func (c *Client) wasSlow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.slow
+}
+
+// writeLoop - єдиний писач у сокет
+func (c *Client) writeLoop(writeTimeout time.Duration) {
+	w := bufio.NewWriter(c.conn)
+	for {
+		select {
+		case <-c.done:
+			return
+		case line := <-c.out:
+			c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			w.WriteString(line)
+			w.WriteByte('\n')
+
+			// Дописуємо все, що вже накопичилось, одним Flush
+			for n := len(c.out); n > 0; n-- {
+				w.WriteString(<-c.out)
+				w.WriteByte('\n')
+			}
+			if err := w.Flush(); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// ============= Rate limiting =============
+
+// RateLimiter - token bucket: burst повідомлень одразу,
+// далі rate повідомлень на секунду
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+func (l *RateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+)
+
+func main() {
+	listener, err := net.Listen("tcp", ":9000")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer listener.Close()
+
+	fmt.Println("💬 Chat server listening on :9000")
+	fmt.Println("Try in two terminals:")
+	fmt.Println("  nc localhost 9000")
+	fmt.Println("  NICK alice")
+	fmt.Println("  JOIN general")
+	fmt.Println("  MSG general Hello everyone!")
+	fmt.Println("  DM general bob hi Bob")
+	fmt.Println("  HISTORY general 10")
+
+	server := NewServer(DefaultConfig())
+	log.Fatal(server.Serve(listener))
+}
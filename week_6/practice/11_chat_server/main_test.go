@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, cfg Config) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go NewServer(cfg).Serve(listener)
+	return listener.Addr().String()
+}
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func connect(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	c.expect("HELLO")
+	return c
+}
+
+// login = handshake + JOIN
+func login(t *testing.T, addr, nick string, rooms ...string) *testClient {
+	t.Helper()
+	c := connect(t, addr)
+	c.send("NICK " + nick)
+	c.expect("OK NICK " + nick)
+	for _, room := range rooms {
+		c.send("JOIN " + room)
+		c.expect("OK JOIN " + room)
+	}
+	return c
+}
+
+func (c *testClient) send(line string) {
+	fmt.Fprintf(c.conn, "%s\n", line)
+}
+
+// expect читає рядки, доки не трапиться рядок з prefix
+// (асинхронні EVENT між ними пропускаються)
+func (c *testClient) expect(prefix string) string {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("waiting for %q: %v", prefix, err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if strings.HasPrefix(line, prefix) {
+			return line
+		}
+		if !strings.HasPrefix(line, "EVENT ") {
+			c.t.Fatalf("Expected %q, got %q", prefix, line)
+		}
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	addr := startServer(t, DefaultConfig())
+	c := connect(t, addr)
+
+	c.send("JOIN general")
+	c.expect("ERR NO_NICK")
+
+	c.send("NICK has space")
+	c.expect("ERR INVALID_NAME")
+
+	c.send("NICK alice")
+	c.expect("OK NICK alice")
+
+	c.send("JOIN general")
+	c.expect("OK JOIN general alice")
+
+	// У кімнаті нік змінювати не можна - інакше обійдемо перевірку унікальності
+	c.send("NICK bob")
+	c.expect("ERR IN_ROOMS")
+}
+
+func TestNicknameUniquePerRoom(t *testing.T) {
+	addr := startServer(t, DefaultConfig())
+	login(t, addr, "alice", "general")
+
+	other := login(t, addr, "alice")
+	other.send("JOIN general")
+	other.expect("ERR NICK_TAKEN")
+
+	// В іншій кімнаті той самий нік вільний
+	other.send("JOIN random")
+	other.expect("OK JOIN random")
+}
+
+func TestRoomMessagesAndEvents(t *testing.T) {
+	addr := startServer(t, DefaultConfig())
+	alice := login(t, addr, "alice", "general")
+	bob := login(t, addr, "bob", "general")
+	alice.expect("EVENT JOIN general bob")
+
+	alice.send("MSG general Hello everyone!")
+	alice.expect("OK MSG general") // власне повідомлення не повертається
+	if line := bob.expect("MSG general"); line != "MSG general alice Hello everyone!" {
+		t.Errorf("Unexpected message line %q", line)
+	}
+
+	bob.send("MSG random hi")
+	bob.expect("ERR NOT_IN_ROOM random")
+
+	bob.send("LEAVE general")
+	bob.expect("OK LEAVE general")
+	alice.expect("EVENT LEAVE general bob")
+
+	// Обрив з'єднання - теж LEAVE
+	carol := login(t, addr, "carol", "general")
+	alice.expect("EVENT JOIN general carol")
+	carol.conn.Close()
+	alice.expect("EVENT LEAVE general carol")
+}
+
+func TestDirectMessages(t *testing.T) {
+	addr := startServer(t, DefaultConfig())
+	alice := login(t, addr, "alice", "general")
+	bob := login(t, addr, "bob", "general")
+
+	alice.send("DM general bob secret plan")
+	alice.expect("OK DM bob")
+	if line := bob.expect("DM "); line != "DM general alice secret plan" {
+		t.Errorf("Unexpected DM line %q", line)
+	}
+
+	alice.send("DM general nobody hello")
+	alice.expect("ERR NO_SUCH_USER nobody")
+
+	alice.send("DM general bob")
+	alice.expect("ERR USAGE")
+}
+
+func TestHistory(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HistorySize = 3
+	addr := startServer(t, cfg)
+
+	alice := login(t, addr, "alice", "general")
+	for i := 1; i <= 5; i++ {
+		alice.send(fmt.Sprintf("MSG general message %d", i))
+		alice.expect("OK MSG")
+	}
+
+	bob := login(t, addr, "bob", "general")
+	bob.send("HISTORY general")
+	for i := 3; i <= 5; i++ {
+		line := bob.expect("HISTORY general")
+		if !strings.HasSuffix(line, fmt.Sprintf("alice message %d", i)) {
+			t.Errorf("Expected message %d, got %q", i, line)
+		}
+	}
+	bob.expect("OK HISTORY general 3")
+
+	bob.send("HISTORY general 1")
+	bob.expect("HISTORY general")
+	bob.expect("OK HISTORY general 1")
+}
+
+func TestRoomLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxRooms = 2
+	addr := startServer(t, cfg)
+
+	alice := login(t, addr, "alice", "a", "b")
+	alice.send("JOIN c")
+	alice.expect("ERR TOO_MANY_ROOMS")
+
+	// Порожня кімната звільняє місце, коли ліміт вичерпано
+	alice.send("LEAVE a")
+	alice.expect("OK LEAVE a")
+	alice.send("JOIN c")
+	alice.expect("OK JOIN c")
+	alice.send("ROOMS")
+	if line := alice.expect("OK ROOMS"); line != "OK ROOMS b(1),c(1)" {
+		t.Errorf("Expected room a dropped, got %q", line)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Rate = 0.001
+	cfg.Burst = 3
+	addr := startServer(t, cfg)
+
+	alice := login(t, addr, "alice", "general")
+	for i := 0; i < 3; i++ {
+		alice.send("MSG general spam")
+		alice.expect("OK MSG")
+	}
+	alice.send("MSG general spam")
+	alice.expect("ERR RATE_LIMITED")
+}
+
+func TestSlowConsumerDisconnected(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SendBuffer = 8
+	cfg.Rate = 1e6
+	cfg.Burst = 1e6
+	addr := startServer(t, cfg)
+
+	alice := login(t, addr, "alice", "general")
+
+	// slow проходить handshake і більше нічого не читає
+	login(t, addr, "slow", "general")
+	alice.expect("EVENT JOIN general slow")
+
+	text := strings.Repeat("x", 3000)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		alice.send("MSG general " + text)
+		alice.expect("OK MSG")
+
+		alice.send("WHO general")
+		if who := alice.expect("OK WHO"); who == "OK WHO general alice" {
+			return
+		}
+	}
+	t.Fatal("Expected slow consumer to be removed from the room")
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	if !l.Allow() || !l.Allow() || l.Allow() {
+		t.Fatal("Expected burst of 2")
+	}
+
+	now = now.Add(500 * time.Millisecond) // +1 токен
+	if !l.Allow() || l.Allow() {
+		t.Error("Expected exactly one token after 500ms")
+	}
+
+	now = now.Add(time.Hour) // не більше burst
+	if !l.Allow() || !l.Allow() || l.Allow() {
+		t.Error("Expected refill capped at burst")
+	}
+}
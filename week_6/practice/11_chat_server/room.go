@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============= Mediator =============
+// Як і в design_patterns/behavioral/mediator, клієнти не знають один
+// про одного: все йде через ChatRoom. Різниця - кімнат багато, клієнти
+// живуть у різних goroutine, а Receive кладе рядок у чергу з'єднання.
+
+var (
+	ErrNickTaken    = errors.New("nickname is already taken in this room")
+	ErrNotInRoom    = errors.New("not a member of this room")
+	ErrNoSuchUser   = errors.New("no such user in this room")
+	ErrInvalidName  = errors.New("invalid name")
+	ErrTooManyRooms = errors.New("too many rooms")
+)
+
+type Mediator interface {
+	Register(member Member) error
+	Unregister(nick string)
+	Notify(sender string, message string)
+}
+
+var _ Mediator = (*ChatRoom)(nil)
+
+// Member - той, хто може отримувати повідомлення кімнати
+type Member interface {
+	Nick() string
+	Receive(line string)
+}
+
+// Entry - запис історії кімнати
+type Entry struct {
+	Time time.Time
+	From string
+	Text string
+}
+
+type ChatRoom struct {
+	name string
+
+	mu          sync.RWMutex
+	members     map[string]Member
+	history     []Entry
+	historySize int
+}
+
+func NewChatRoom(name string, historySize int) *ChatRoom {
+	return &ChatRoom{
+		name:        name,
+		members:     make(map[string]Member),
+		historySize: historySize,
+	}
+}
+
+func (cr *ChatRoom) Name() string {
+	return cr.name
+}
+
+// Register - частина handshake: нікнейм має бути унікальним у кімнаті
+func (cr *ChatRoom) Register(member Member) error {
+	cr.mu.Lock()
+	if _, taken := cr.members[member.Nick()]; taken {
+		cr.mu.Unlock()
+		return ErrNickTaken
+	}
+	cr.members[member.Nick()] = member
+	cr.mu.Unlock()
+
+	cr.broadcast("EVENT JOIN "+cr.name+" "+member.Nick(), member.Nick())
+	return nil
+}
+
+func (cr *ChatRoom) Unregister(nick string) {
+	cr.mu.Lock()
+	_, ok := cr.members[nick]
+	delete(cr.members, nick)
+	cr.mu.Unlock()
+
+	if ok {
+		cr.broadcast("EVENT LEAVE "+cr.name+" "+nick, "")
+	}
+}
+
+// Notify розсилає повідомлення всім, крім відправника, і пише в історію
+func (cr *ChatRoom) Notify(sender string, message string) {
+	cr.mu.Lock()
+	cr.history = append(cr.history, Entry{Time: time.Now(), From: sender, Text: message})
+	if len(cr.history) > cr.historySize {
+		cr.history = cr.history[len(cr.history)-cr.historySize:]
+	}
+	cr.mu.Unlock()
+
+	cr.broadcast("MSG "+cr.name+" "+sender+" "+message, sender)
+}
+
+// Direct - приватне повідомлення учаснику цієї ж кімнати
+func (cr *ChatRoom) Direct(sender, to, message string) error {
+	cr.mu.RLock()
+	_, senderIn := cr.members[sender]
+	target, ok := cr.members[to]
+	cr.mu.RUnlock()
+
+	switch {
+	case !senderIn:
+		return ErrNotInRoom
+	case !ok:
+		return ErrNoSuchUser
+	}
+	target.Receive("DM " + cr.name + " " + sender + " " + message)
+	return nil
+}
+
+// History повертає останні n записів (n <= 0 - всі)
+func (cr *ChatRoom) History(n int) []Entry {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	history := cr.history
+	if n > 0 && n < len(history) {
+		history = history[len(history)-n:]
+	}
+	return append([]Entry(nil), history...)
+}
+
+func (cr *ChatRoom) Has(nick string) bool {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	_, ok := cr.members[nick]
+	return ok
+}
+
+func (cr *ChatRoom) Members() []string {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	nicks := make([]string, 0, len(cr.members))
+	for nick := range cr.members {
+		nicks = append(nicks, nick)
+	}
+	sort.Strings(nicks)
+	return nicks
+}
+
+func (cr *ChatRoom) Len() int {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	return len(cr.members)
+}
+
+// broadcast не тримає лок під час Receive: Receive ніколи не блокується,
+// але може відключити повільного клієнта, а той викличе Unregister
+func (cr *ChatRoom) broadcast(line, except string) {
+	cr.mu.RLock()
+	targets := make([]Member, 0, len(cr.members))
+	for nick, member := range cr.members {
+		if nick != except {
+			targets = append(targets, member)
+		}
+	}
+	cr.mu.RUnlock()
+
+	for _, member := range targets {
+		member.Receive(line)
+	}
+}
+
+// ============= Rooms =============
+
+// RoomManager створює кімнату на першому JOIN. Кімнат не більше
+// maxRooms (0 - без ліміту): інакше JOIN з випадковими назвами
+// необмежено їв би пам'ять сервера.
+type RoomManager struct {
+	mu          sync.Mutex
+	rooms       map[string]*ChatRoom
+	historySize int
+	maxRooms    int
+}
+
+func NewRoomManager(historySize, maxRooms int) *RoomManager {
+	return &RoomManager{
+		rooms:       make(map[string]*ChatRoom),
+		historySize: historySize,
+		maxRooms:    maxRooms,
+	}
+}
+
+// Join реєструє учасника, створюючи кімнату за потреби.
+// Коли ліміт вичерпано, спершу видаляються порожні кімнати,
+// а якщо всі зайняті - ErrTooManyRooms.
+func (m *RoomManager) Join(name string, member Member) (*ChatRoom, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, ok := m.rooms[name]
+	if !ok {
+		if m.maxRooms > 0 && len(m.rooms) >= m.maxRooms {
+			m.dropEmpty()
+		}
+		if m.maxRooms > 0 && len(m.rooms) >= m.maxRooms {
+			return nil, ErrTooManyRooms
+		}
+		room = NewChatRoom(name, m.historySize)
+		m.rooms[name] = room
+	}
+	if err := room.Register(member); err != nil {
+		if !ok {
+			delete(m.rooms, name)
+		}
+		return nil, err
+	}
+	return room, nil
+}
+
+// dropEmpty видаляє кімнати без учасників разом з їхньою історією.
+// Викликається під m.mu, тож ніхто не зайде в кімнату, що видаляється.
+func (m *RoomManager) dropEmpty() {
+	for name, room := range m.rooms {
+		if room.Len() == 0 {
+			delete(m.rooms, name)
+		}
+	}
+}
+
+// Leave знімає учасника; порожня кімната з історією лишається,
+// щоб наступні учасники бачили, про що говорили, - доки кімнат
+// не стане maxRooms
+func (m *RoomManager) Leave(name, nick string) {
+	m.mu.Lock()
+	room, ok := m.rooms[name]
+	m.mu.Unlock()
+
+	if ok {
+		room.Unregister(nick)
+	}
+}
+
+func (m *RoomManager) Get(name string) (*ChatRoom, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	room, ok := m.rooms[name]
+	return room, ok
+}
+
+// Rooms - назви кімнат з кількістю учасників
+func (m *RoomManager) Rooms() map[string]int {
+	m.mu.Lock()
+	rooms := make([]*ChatRoom, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.Unlock()
+
+	result := make(map[string]int, len(rooms))
+	for _, room := range rooms {
+		result[room.Name()] = room.Len()
+	}
+	return result
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============= Protocol =============
+// Рядковий протокол, як у 05_networking/tcp_server.go: одна команда - один рядок.
+//
+//	NICK <name>                 обов'язковий перший крок (handshake)
+//	JOIN <room>                 нік має бути вільним у кімнаті
+//	LEAVE <room>
+//	MSG <room> <text>
+//	DM <room> <nick> <text>     приватне повідомлення учаснику кімнати
+//	HISTORY <room> [n]
+//	WHO <room> / ROOMS / QUIT
+//
+// Відповіді: "OK ...", "ERR <CODE> <details>", а також асинхронні
+// "MSG room nick text", "DM room nick text", "EVENT JOIN|LEAVE room nick".
+
+const maxLineLength = 4096
+
+type Config struct {
+	HistorySize  int
+	MaxRooms     int           // 0 - без ліміту
+	SendBuffer   int           // черга вихідних рядків на клієнта
+	WriteTimeout time.Duration // запис довше - клієнт вважається повільним
+	Rate         float64       // повідомлень на секунду
+	Burst        int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		HistorySize:  50,
+		MaxRooms:     1000,
+		SendBuffer:   256,
+		WriteTimeout: 5 * time.Second,
+		Rate:         5,
+		Burst:        10,
+	}
+}
+
+type Server struct {
+	cfg   Config
+	rooms *RoomManager
+}
+
+func NewServer(cfg Config) *Server {
+	return &Server{
+		cfg:   cfg,
+		rooms: NewRoomManager(cfg.HistorySize, cfg.MaxRooms),
+	}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+func (s *Server) handleConnection(conn net.Conn) {
+	c := newClient(conn, s.cfg.SendBuffer, NewRateLimiter(s.cfg.Rate, s.cfg.Burst))
+	go c.writeLoop(s.cfg.WriteTimeout)
+
+	defer func() {
+		s.leaveAll(c)
+		c.Close()
+
+		if c.wasSlow() {
+			log.Printf("disconnected slow consumer %s (%s)", c.Nick(), conn.RemoteAddr())
+		}
+	}()
+
+	c.Receive("HELLO send NICK <name> to start")
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 1024), maxLineLength)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if quit := s.handleCommand(c, line); quit {
+			return
+		}
+	}
+}
+
+// handleCommand повертає true, якщо з'єднання треба закрити
+func (s *Server) handleCommand(c *Client, line string) bool {
+	cmd, args, _ := strings.Cut(line, " ")
+	cmd = strings.ToUpper(cmd)
+
+	if cmd == "QUIT" {
+		return true
+	}
+
+	// До handshake дозволено лише NICK
+	if c.Nick() == "" && cmd != "NICK" {
+		c.Receive("ERR NO_NICK send NICK <name> first")
+		return false
+	}
+
+	switch cmd {
+	case "NICK":
+		s.cmdNick(c, args)
+	case "JOIN":
+		s.cmdJoin(c, args)
+	case "LEAVE":
+		s.cmdLeave(c, args)
+	case "MSG":
+		s.cmdMsg(c, args)
+	case "DM":
+		s.cmdDM(c, args)
+	case "HISTORY":
+		s.cmdHistory(c, args)
+	case "WHO":
+		s.cmdWho(c, args)
+	case "ROOMS":
+		s.cmdRooms(c)
+	default:
+		c.Receive("ERR UNKNOWN_COMMAND " + cmd)
+	}
+	return false
+}
+
+// validName: без пробілів і керуючих символів, щоб не ламати протокол
+func validName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r == 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) cmdNick(c *Client, name string) {
+	if !validName(name) {
+		c.Receive("ERR INVALID_NAME nickname must be 1-32 chars without spaces")
+		return
+	}
+
+	// Нік перевіряється кімнатами при JOIN, тому міняти його в кімнаті не можна
+	c.mu.Lock()
+	inRooms := len(c.rooms) > 0
+	if !inRooms {
+		c.nick = name
+	}
+	c.mu.Unlock()
+
+	if inRooms {
+		c.Receive("ERR IN_ROOMS leave all rooms before changing nickname")
+		return
+	}
+	c.Receive("OK NICK " + name)
+}
+
+func (s *Server) cmdJoin(c *Client, name string) {
+	if !validName(name) {
+		c.Receive("ERR INVALID_NAME room name must be 1-32 chars without spaces")
+		return
+	}
+	if c.room(name) != nil {
+		c.Receive("ERR ALREADY_JOINED " + name)
+		return
+	}
+
+	room, err := s.rooms.Join(name, c)
+	if err == ErrNickTaken {
+		c.Receive("ERR NICK_TAKEN " + c.Nick() + " is already used in " + name)
+		return
+	}
+	if err == ErrTooManyRooms {
+		c.Receive("ERR TOO_MANY_ROOMS try an existing room")
+		return
+	}
+	if err != nil {
+		c.Receive("ERR JOIN_FAILED " + err.Error())
+		return
+	}
+
+	c.mu.Lock()
+	c.rooms[name] = room
+	c.mu.Unlock()
+
+	c.Receive("OK JOIN " + name + " " + strings.Join(room.Members(), ","))
+}
+
+func (s *Server) cmdLeave(c *Client, name string) {
+	if c.room(name) == nil {
+		c.Receive("ERR NOT_IN_ROOM " + name)
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.rooms, name)
+	c.mu.Unlock()
+
+	s.rooms.Leave(name, c.Nick())
+	c.Receive("OK LEAVE " + name)
+}
+
+func (s *Server) cmdMsg(c *Client, args string) {
+	name, text, _ := strings.Cut(args, " ")
+	room := c.room(name)
+	switch {
+	case room == nil:
+		c.Receive("ERR NOT_IN_ROOM " + name)
+	case text == "":
+		c.Receive("ERR EMPTY_MESSAGE")
+	case !c.limiter.Allow():
+		c.Receive("ERR RATE_LIMITED slow down")
+	default:
+		room.Notify(c.Nick(), text)
+		c.Receive("OK MSG " + name)
+	}
+}
+
+func (s *Server) cmdDM(c *Client, args string) {
+	fields := strings.SplitN(args, " ", 3)
+	if len(fields) < 3 || fields[2] == "" {
+		c.Receive("ERR USAGE DM <room> <nick> <text>")
+		return
+	}
+	name, to, text := fields[0], fields[1], fields[2]
+
+	room := c.room(name)
+	if room == nil {
+		c.Receive("ERR NOT_IN_ROOM " + name)
+		return
+	}
+	if !c.limiter.Allow() {
+		c.Receive("ERR RATE_LIMITED slow down")
+		return
+	}
+	if err := room.Direct(c.Nick(), to, text); err != nil {
+		c.Receive("ERR NO_SUCH_USER " + to)
+		return
+	}
+	c.Receive("OK DM " + to)
+}
+
+func (s *Server) cmdHistory(c *Client, args string) {
+	name, count, _ := strings.Cut(args, " ")
+	room := c.room(name)
+	if room == nil {
+		c.Receive("ERR NOT_IN_ROOM " + name)
+		return
+	}
+
+	n, _ := strconv.Atoi(count)
+	entries := room.History(n)
+	for _, e := range entries {
+		c.Receive(fmt.Sprintf("HISTORY %s %s %s %s", name, e.Time.Format(time.RFC3339), e.From, e.Text))
+	}
+	c.Receive(fmt.Sprintf("OK HISTORY %s %d", name, len(entries)))
+}
+
+func (s *Server) cmdWho(c *Client, name string) {
+	room, ok := s.rooms.Get(name)
+	if !ok {
+		c.Receive("ERR NO_SUCH_ROOM " + name)
+		return
+	}
+	c.Receive("OK WHO " + name + " " + strings.Join(room.Members(), ","))
+}
+
+func (s *Server) cmdRooms(c *Client) {
+	rooms := s.rooms.Rooms()
+	names := make([]string, 0, len(rooms))
+	for name, count := range rooms {
+		names = append(names, fmt.Sprintf("%s(%d)", name, count))
+	}
+	sort.Strings(names)
+	c.Receive("OK ROOMS " + strings.Join(names, ","))
+}
+
+// leaveAll - клієнт відключився: прибрати його з усіх кімнат
+func (s *Server) leaveAll(c *Client) {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.rooms))
+	for name := range c.rooms {
+		names = append(names, name)
+	}
+	c.rooms = make(map[string]*ChatRoom)
+	nick := c.nick
+	c.mu.Unlock()
+
+	for _, name := range names {
+		s.rooms.Leave(name, nick)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// ============= Client =============
+
+var (
+	ErrClientClosed     = errors.New("client closed")
+	ErrHeartbeatTimeout = errors.New("heartbeat timeout")
+)
+
+// RemoteError - сервер обробив запит і повернув FrameError
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "remote: " + e.Message
+}
+
+type ClientConfig struct {
+	MaxFrameSize      int
+	WriteTimeout      time.Duration
+	HeartbeatInterval time.Duration // як часто слати PING, якщо з'єднання мовчить; 0 - вимкнено
+	HeartbeatTimeout  time.Duration // скільки чекати будь-якого фрейму після PING
+}
+
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		MaxFrameSize:      DefaultMaxFrameSize,
+		WriteTimeout:      5 * time.Second,
+		HeartbeatInterval: 10 * time.Second,
+		HeartbeatTimeout:  5 * time.Second,
+	}
+}
+
+// Client мультиплексує запити через одне з'єднання: кожен Call отримує
+// свій ID, а readLoop розкладає відповіді по ID у будь-якому порядку.
+type Client struct {
+	fc  *FrameConn
+	cfg ClientConfig
+
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]chan Frame
+	err     error // причина закриття
+
+	lastRead time.Time
+	done     chan struct{}
+}
+
+func Dial(ctx context.Context, addr string, cfg ClientConfig) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, cfg), nil
+}
+
+func NewClient(conn net.Conn, cfg ClientConfig) *Client {
+	fc := NewFrameConn(conn)
+	fc.MaxFrameSize = cfg.MaxFrameSize
+	fc.WriteTimeout = cfg.WriteTimeout
+	if cfg.HeartbeatInterval > 0 {
+		// Сервер відповідає на кожен PING, тож тиша довша за
+		// інтервал + таймаут означає мертве з'єднання
+		fc.ReadTimeout = cfg.HeartbeatInterval + cfg.HeartbeatTimeout
+	}
+
+	c := &Client{
+		fc:       fc,
+		cfg:      cfg,
+		pending:  make(map[uint32]chan Frame),
+		lastRead: time.Now(),
+		done:     make(chan struct{}),
+	}
+	go c.readLoop()
+	if cfg.HeartbeatInterval > 0 {
+		go c.heartbeatLoop()
+	}
+	return c
+}
+
+// Call надсилає запит і чекає відповідь з тим самим ID.
+// Скасований ctx повідомляє сервер через FrameCancel.
+func (c *Client) Call(ctx context.Context, payload []byte) ([]byte, error) {
+	id, ch, err := c.register()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.fc.WriteFrame(Frame{Type: FrameRequest, ID: id, Payload: payload}); err != nil {
+		c.unregister(id)
+		if errors.Is(err, ErrFrameTooLarge) {
+			return nil, err
+		}
+		// Частково записаний фрейм зламав потік - з'єднанням більше не користуємось
+		c.closeWithError(err)
+		return nil, err
+	}
+
+	select {
+	case f := <-ch:
+		if f.Type == FrameError {
+			return nil, &RemoteError{Message: string(f.Payload)}
+		}
+		return f.Payload, nil
+
+	case <-ctx.Done():
+		if c.unregister(id) {
+			c.fc.WriteFrame(Frame{Type: FrameCancel, ID: id})
+		}
+		return nil, ctx.Err()
+
+	case <-c.done:
+		return nil, c.Err()
+	}
+}
+
+func (c *Client) register() (uint32, chan Frame, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return 0, nil, c.err
+	}
+
+	// ID 0 зарезервований, переповнення uint32 просто починає спочатку
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID++
+	}
+	ch := make(chan Frame, 1)
+	c.pending[c.nextID] = ch
+	return c.nextID, ch, nil
+}
+
+// unregister повертає false, якщо відповідь уже прийшла
+func (c *Client) unregister(id uint32) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.pending[id]
+	delete(c.pending, id)
+	return ok
+}
+
+func (c *Client) readLoop() {
+	for {
+		f, err := c.fc.ReadFrame()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = ErrHeartbeatTimeout
+			}
+			c.closeWithError(err)
+			return
+		}
+
+		c.mu.Lock()
+		c.lastRead = time.Now()
+		ch, ok := c.pending[f.ID]
+		if ok && (f.Type == FrameResponse || f.Type == FrameError) {
+			delete(c.pending, f.ID)
+		} else {
+			ok = false
+		}
+		c.mu.Unlock()
+
+		// Буфер 1 - readLoop ніколи не блокується на повільному Call.
+		// Відповіді на скасовані запити і PONG просто відкидаються.
+		if ok {
+			ch <- f
+		}
+	}
+}
+
+// heartbeatLoop шле PING, лише коли з'єднання мовчить довше за інтервал
+func (c *Client) heartbeatLoop() {
+	ticker := time.NewTicker(c.cfg.HeartbeatInterval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			idle := time.Since(c.lastRead)
+			c.mu.Unlock()
+
+			if idle >= c.cfg.HeartbeatInterval {
+				if err := c.fc.WriteFrame(Frame{Type: FramePing}); err != nil {
+					c.closeWithError(err)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *Client) closeWithError(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = err
+	c.pending = make(map[uint32]chan Frame)
+	c.mu.Unlock()
+
+	close(c.done)
+	c.fc.Close()
+}
+
+// Err повертає причину, з якої клієнт перестав працювати
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close закриває з'єднання; незавершені Call отримують ErrClientClosed
+func (c *Client) Close() error {
+	c.closeWithError(ErrClientClosed)
+	return nil
+}
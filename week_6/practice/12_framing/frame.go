@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// ============= Frame format =============
+// Рядковий протокол з 05_networking не дозволяє ні бінарних даних,
+// ні кількох одночасних запитів. Тут кожне повідомлення - фрейм:
+//
+//	+---------+------+------------+-----------------+
+//	| length  | type | request ID | payload         |
+//	| 4 bytes | 1 b  | 4 bytes    | length bytes    |
+//	+---------+------+------------+-----------------+
+//
+// Усі числа big-endian. length - розмір лише payload.
+
+const (
+	headerSize          = 9
+	DefaultMaxFrameSize = 1 << 20 // 1 MB
+)
+
+type FrameType byte
+
+const (
+	FrameRequest  FrameType = iota + 1
+	FrameResponse           // успішна відповідь на запит з тим самим ID
+	FrameError              // payload - текст помилки
+	FrameCancel             // клієнт більше не чекає відповіді на ID
+	FramePing               // heartbeat; ID повертається у FramePong
+	FramePong
+)
+
+func (t FrameType) String() string {
+	switch t {
+	case FrameRequest:
+		return "REQUEST"
+	case FrameResponse:
+		return "RESPONSE"
+	case FrameError:
+		return "ERROR"
+	case FrameCancel:
+		return "CANCEL"
+	case FramePing:
+		return "PING"
+	case FramePong:
+		return "PONG"
+	default:
+		return fmt.Sprintf("FrameType(%d)", byte(t))
+	}
+}
+
+var (
+	ErrFrameTooLarge    = errors.New("frame exceeds max size")
+	ErrUnknownFrameType = errors.New("unknown frame type")
+)
+
+type Frame struct {
+	Type    FrameType
+	ID      uint32
+	Payload []byte
+}
+
+// ReadFrame читає рівно один фрейм. Розмір перевіряється до
+// виділення пам'яті - інакше клієнт міг би попросити 4 GB заголовком.
+func ReadFrame(r io.Reader, maxSize int) (Frame, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Frame{}, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	f := Frame{
+		Type: FrameType(header[4]),
+		ID:   binary.BigEndian.Uint32(header[5:9]),
+	}
+	if f.Type < FrameRequest || f.Type > FramePong {
+		return Frame{}, fmt.Errorf("%w: %d", ErrUnknownFrameType, header[4])
+	}
+	if int64(length) > int64(maxSize) {
+		return Frame{}, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, length, maxSize)
+	}
+
+	f.Payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.Payload); err != nil {
+		// Обрив посеред фрейму - не чистий EOF
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Frame{}, err
+	}
+	return f, nil
+}
+
+// WriteFrame пише заголовок і payload одним Write,
+// щоб фрейми з різних goroutine не перемішувались
+func WriteFrame(w io.Writer, f Frame, maxSize int) error {
+	if len(f.Payload) > maxSize {
+		return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(f.Payload), maxSize)
+	}
+
+	buf := make([]byte, headerSize+len(f.Payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(f.Payload)))
+	buf[4] = byte(f.Type)
+	binary.BigEndian.PutUint32(buf[5:9], f.ID)
+	copy(buf[headerSize:], f.Payload)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// ============= FrameConn =============
+
+// FrameConn - net.Conn з фреймами і дедлайнами. Читає одна goroutine,
+// писати можуть багато - запис серіалізується через mutex.
+type FrameConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	MaxFrameSize int
+	ReadTimeout  time.Duration // 0 - без дедлайну
+	WriteTimeout time.Duration
+
+	wmu sync.Mutex
+}
+
+func NewFrameConn(conn net.Conn) *FrameConn {
+	return &FrameConn{
+		conn:         conn,
+		r:            bufio.NewReader(conn),
+		MaxFrameSize: DefaultMaxFrameSize,
+	}
+}
+
+func (fc *FrameConn) ReadFrame() (Frame, error) {
+	if fc.ReadTimeout > 0 {
+		fc.conn.SetReadDeadline(time.Now().Add(fc.ReadTimeout))
+	}
+	return ReadFrame(fc.r, fc.MaxFrameSize)
+}
+
+func (fc *FrameConn) WriteFrame(f Frame) error {
+	fc.wmu.Lock()
+	defer fc.wmu.Unlock()
+
+	if fc.WriteTimeout > 0 {
+		fc.conn.SetWriteDeadline(time.Now().Add(fc.WriteTimeout))
+	}
+	return WriteFrame(fc.conn, f, fc.MaxFrameSize)
+}
+
+func (fc *FrameConn) Close() error {
+	return fc.conn.Close()
+}
+
+func (fc *FrameConn) RemoteAddr() net.Addr {
+	return fc.conn.RemoteAddr()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UpperHandler - той самий echo у верхньому регістрі, що й 05_networking/tcp_server.go.
+// "sleep <ms> <text>" затримує відповідь, щоб показати відповіді не по черзі.
+func UpperHandler() Handler {
+	return HandlerFunc(func(ctx context.Context, payload []byte) ([]byte, error) {
+		text := string(payload)
+
+		if rest, ok := strings.CutPrefix(text, "sleep "); ok {
+			var ms int
+			if _, err := fmt.Sscanf(rest, "%d", &ms); err != nil {
+				return nil, errors.New("usage: sleep <ms> <text>")
+			}
+			select {
+			case <-time.After(time.Duration(ms) * time.Millisecond):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			_, text, _ = strings.Cut(rest, " ")
+		}
+		if text == "" {
+			return nil, errors.New("empty message")
+		}
+		return bytes.ToUpper([]byte(text)), nil
+	})
+}
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   Framed Protocol & Multiplexing       ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer listener.Close()
+
+	go NewServer(UpperHandler()).Serve(listener)
+
+	ctx := context.Background()
+	client, err := Dial(ctx, listener.Addr().String(), DefaultClientConfig())
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	// 1. Кілька запитів одночасно через одне з'єднання
+	fmt.Println("\n🔀 1. Одночасні запити (одне з'єднання):")
+	requests := []string{"sleep 300 slow", "sleep 100 medium", "fast"}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	order := 1
+	start := time.Now()
+	for _, req := range requests {
+		wg.Add(1)
+		go func(req string) {
+			defer wg.Done()
+			resp, err := client.Call(ctx, []byte(req))
+
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Printf("   %d. %-18q -> %s %v (%v)\n", order, req, resp, errOrEmpty(err),
+				time.Since(start).Round(10*time.Millisecond))
+			order++
+		}(req)
+	}
+	wg.Wait()
+	fmt.Println("   Відповіді прийшли у порядку готовності, а не відправлення")
+
+	// 2. Скасування
+	fmt.Println("\n⏱️  2. Скасування через context:")
+	callCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	_, err = client.Call(callCtx, []byte("sleep 5000 never"))
+	cancel()
+	fmt.Printf("   Call: %v (сервер отримав CANCEL і зупинив обробку)\n", err)
+
+	// 3. Помилка обробника
+	fmt.Println("\n❌ 3. Помилка обробника:")
+	_, err = client.Call(ctx, []byte(""))
+	fmt.Printf("   Call: %v\n", err)
+
+	// 4. Обмеження розміру
+	fmt.Println("\n📏 4. Max frame size:")
+	_, err = client.Call(ctx, make([]byte, DefaultMaxFrameSize+1))
+	fmt.Printf("   Call: %v\n", err)
+
+	resp, err := client.Call(ctx, []byte("connection still works"))
+	fmt.Printf("   Після помилки: %s %v\n", resp, errOrEmpty(err))
+
+	fmt.Println("\n✅ Demo completed!")
+}
+
+func errOrEmpty(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, handler Handler) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go NewServer(handler).Serve(listener)
+	return listener.Addr().String()
+}
+
+func dial(t *testing.T, addr string, cfg ClientConfig) *Client {
+	t.Helper()
+	client, err := Dial(context.Background(), addr, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestFrame_RoundTrip(t *testing.T) {
+	tests := []Frame{
+		{Type: FrameRequest, ID: 1, Payload: []byte("hello")},
+		{Type: FrameResponse, ID: 1<<32 - 1, Payload: []byte{0, 1, 2, '\n', 255}},
+		{Type: FramePing, ID: 0},
+		{Type: FrameError, ID: 7, Payload: []byte("boom")},
+	}
+
+	var buf bytes.Buffer
+	for _, f := range tests {
+		if err := WriteFrame(&buf, f, 1024); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range tests {
+		got, err := ReadFrame(&buf, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Type != want.Type || got.ID != want.ID || !bytes.Equal(got.Payload, want.Payload) {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+	if _, err := ReadFrame(&buf, 1024); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestFrame_Invalid(t *testing.T) {
+	var large bytes.Buffer
+	WriteFrame(&large, Frame{Type: FrameRequest, Payload: make([]byte, 100)}, 1024)
+
+	var truncated bytes.Buffer
+	WriteFrame(&truncated, Frame{Type: FrameRequest, Payload: []byte("hello")}, 1024)
+	truncated.Truncate(truncated.Len() - 2)
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"too large", large.Bytes(), ErrFrameTooLarge},
+		{"unknown type", []byte{0, 0, 0, 0, 99, 0, 0, 0, 1}, ErrUnknownFrameType},
+		{"truncated payload", truncated.Bytes(), io.ErrUnexpectedEOF},
+		{"truncated header", []byte{0, 0, 0}, io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadFrame(bytes.NewReader(tt.data), 50)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if err := WriteFrame(io.Discard, Frame{Type: FrameRequest, Payload: make([]byte, 51)}, 50); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("Expected ErrFrameTooLarge on write, got %v", err)
+	}
+}
+
+func TestClient_OutOfOrderResponses(t *testing.T) {
+	client := dial(t, startServer(t, UpperHandler()), DefaultClientConfig())
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := client.Call(context.Background(), []byte("sleep 300 slow"))
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		slow <- string(resp)
+	}()
+
+	// Швидкий запит не чекає повільного, хоч і йде тим самим з'єднанням
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	resp, err := client.Call(context.Background(), []byte("fast"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "FAST" {
+		t.Errorf("Expected FAST, got %q", resp)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Fast call was blocked for %v", elapsed)
+	}
+
+	if got := <-slow; got != "SLOW" {
+		t.Errorf("Expected SLOW, got %q", got)
+	}
+}
+
+func TestClient_ManyConcurrentCalls(t *testing.T) {
+	client := dial(t, startServer(t, HandlerFunc(func(ctx context.Context, p []byte) ([]byte, error) {
+		return p, nil
+	})), DefaultClientConfig())
+
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		go func(i int) {
+			want := []byte{byte(i), byte(i >> 8)}
+			got, err := client.Call(context.Background(), want)
+			if err == nil && !bytes.Equal(got, want) {
+				err = errors.New("response for another request")
+			}
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < 100; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestClient_CancelPropagatesToServer(t *testing.T) {
+	cancelled := make(chan struct{})
+	client := dial(t, startServer(t, HandlerFunc(func(ctx context.Context, p []byte) ([]byte, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})), DefaultClientConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Call(ctx, []byte("wait")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected server handler to be cancelled")
+	}
+}
+
+// Усі слоти і черга зайняті завислими запитами: новий отримує busy,
+// а CANCEL усе одно доходить і до обробника, і до запиту в черзі
+func TestServer_CancelWhenInFlightFull(t *testing.T) {
+	const maxInFlight = 2
+	started := make(chan struct{}, maxInFlight)
+	cancelled := make(chan struct{}, maxInFlight)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	server := NewServer(HandlerFunc(func(ctx context.Context, p []byte) ([]byte, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		cancelled <- struct{}{}
+		return nil, ctx.Err()
+	}))
+	server.MaxInFlight = maxInFlight
+	server.MaxQueued = 1
+	go server.Serve(listener)
+	client := dial(t, listener.Addr().String(), DefaultClientConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for range maxInFlight {
+		go client.Call(ctx, []byte("wait"))
+		<-started
+	}
+	queued := make(chan error, 1)
+	go func() {
+		_, err := client.Call(ctx, []byte("queued"))
+		queued <- err
+	}()
+	time.Sleep(50 * time.Millisecond) // запит дійшов до черги сервера
+
+	_, err = client.Call(context.Background(), []byte("one more"))
+	var remote *RemoteError
+	if !errors.As(err, &remote) || remote.Message != ErrBusy {
+		t.Errorf("Expected RemoteError(%s), got %v", ErrBusy, err)
+	}
+
+	cancel()
+	for range maxInFlight {
+		select {
+		case <-cancelled:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected blocked handlers to be cancelled")
+		}
+	}
+	if err := <-queued; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected queued call cancelled, got %v", err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	client := dial(t, startServer(t, UpperHandler()), DefaultClientConfig())
+
+	_, err := client.Call(context.Background(), []byte(""))
+	var remote *RemoteError
+	if !errors.As(err, &remote) || remote.Message != "empty message" {
+		t.Errorf("Expected RemoteError(empty message), got %v", err)
+	}
+
+	_, err = client.Call(context.Background(), make([]byte, DefaultMaxFrameSize+1))
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("Expected ErrFrameTooLarge, got %v", err)
+	}
+
+	// Жодна з помилок не ламає з'єднання
+	if resp, err := client.Call(context.Background(), []byte("ok")); err != nil || string(resp) != "OK" {
+		t.Errorf("Expected OK, got %q %v", resp, err)
+	}
+}
+
+func TestServer_RejectsOversizedFrame(t *testing.T) {
+	addr := startServer(t, UpperHandler())
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Заголовок обіцяє 1 GB - сервер закриває з'єднання, не виділяючи пам'ять
+	conn.Write([]byte{0x40, 0, 0, 0, byte(FrameRequest), 0, 0, 0, 1})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected connection closed, got %v", err)
+	}
+}
+
+func TestClient_Heartbeat(t *testing.T) {
+	cfg := DefaultClientConfig()
+	cfg.HeartbeatInterval = 50 * time.Millisecond
+	cfg.HeartbeatTimeout = 50 * time.Millisecond
+
+	t.Run("alive server answers pings", func(t *testing.T) {
+		client := dial(t, startServer(t, UpperHandler()), cfg)
+		time.Sleep(300 * time.Millisecond)
+		if err := client.Err(); err != nil {
+			t.Fatalf("Expected idle connection to stay open, got %v", err)
+		}
+	})
+
+	t.Run("silent server is detected", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+
+		// Приймає з'єднання і нічого не відповідає (завислий процес)
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			<-stop
+			conn.Close()
+		}()
+
+		client := dial(t, listener.Addr().String(), cfg)
+		_, err = client.Call(context.Background(), []byte("hello"))
+		if !errors.Is(err, ErrHeartbeatTimeout) {
+			t.Errorf("Expected ErrHeartbeatTimeout, got %v", err)
+		}
+	})
+}
+
+func TestClient_ClosedFailsPendingCalls(t *testing.T) {
+	client := dial(t, startServer(t, HandlerFunc(func(ctx context.Context, p []byte) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})), DefaultClientConfig())
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.Call(context.Background(), []byte("wait"))
+		result <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	client.Close()
+
+	if err := <-result; !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed, got %v", err)
+	}
+	if _, err := client.Call(context.Background(), []byte("after")); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed after Close, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// ============= Server =============
+
+// Handler обробляє payload одного запиту. ctx скасовується, якщо клієнт
+// надіслав FrameCancel або з'єднання закрилось.
+type Handler interface {
+	Serve(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+type HandlerFunc func(ctx context.Context, payload []byte) ([]byte, error)
+
+func (f HandlerFunc) Serve(ctx context.Context, payload []byte) ([]byte, error) {
+	return f(ctx, payload)
+}
+
+// ErrBusy - текст FrameError, коли зайняті всі слоти і черга
+const ErrBusy = "busy"
+
+type Server struct {
+	Handler      Handler
+	MaxFrameSize int
+	IdleTimeout  time.Duration // без жодного фрейму (навіть PING) - з'єднання закривається
+	WriteTimeout time.Duration
+	MaxInFlight  int // одночасних запитів на з'єднання
+	MaxQueued    int // запитів, що чекають слот; понад це - ErrBusy
+}
+
+func NewServer(handler Handler) *Server {
+	return &Server{
+		Handler:      handler,
+		MaxFrameSize: DefaultMaxFrameSize,
+		IdleTimeout:  30 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		MaxInFlight:  64,
+		MaxQueued:    1024,
+	}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+// serverConn - стан одного з'єднання: запити в обробці за ID
+type serverConn struct {
+	fc       *FrameConn
+	mu       sync.Mutex
+	inFlight map[uint32]context.CancelFunc
+}
+
+func (s *Server) handleConnection(conn net.Conn) {
+	fc := NewFrameConn(conn)
+	fc.MaxFrameSize = s.MaxFrameSize
+	fc.ReadTimeout = s.IdleTimeout
+	fc.WriteTimeout = s.WriteTimeout
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sc := &serverConn{fc: fc, inFlight: make(map[uint32]context.CancelFunc)}
+	sem := make(chan struct{}, s.MaxInFlight)
+	queue := make(chan struct{}, s.MaxInFlight+s.MaxQueued)
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+		fc.Close()
+	}()
+
+	for {
+		f, err := fc.ReadFrame()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("%s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		switch f.Type {
+		case FramePing:
+			fc.WriteFrame(Frame{Type: FramePong, ID: f.ID})
+
+		case FrameCancel:
+			sc.cancel(f.ID)
+
+		case FrameRequest:
+			// Читання не блокується на семафорі: інакше CANCEL для завислих
+			// запитів і PING не дійшли б, поки всі слоти зайняті. Запит чекає
+			// слот у своїй goroutine; понад MaxInFlight+MaxQueued - ErrBusy
+			select {
+			case queue <- struct{}{}:
+			default:
+				fc.WriteFrame(Frame{Type: FrameError, ID: f.ID, Payload: []byte(ErrBusy)})
+				continue
+			}
+
+			reqCtx, ok := sc.start(ctx, f.ID)
+			if !ok {
+				<-queue
+				fc.WriteFrame(Frame{Type: FrameError, ID: f.ID, Payload: []byte("duplicate request id")})
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-queue }()
+				defer sc.cancel(f.ID)
+				select {
+				case sem <- struct{}{}:
+				case <-reqCtx.Done():
+					// Скасовано ще в черзі - відповідь нікому не потрібна
+					return
+				}
+				defer func() { <-sem }()
+				s.serveRequest(reqCtx, sc.fc, f)
+			}()
+
+		default:
+			// RESPONSE/ERROR/PONG від клієнта - порушення протоколу
+			log.Printf("%s: unexpected %s frame", conn.RemoteAddr(), f.Type)
+			return
+		}
+	}
+}
+
+func (s *Server) serveRequest(ctx context.Context, fc *FrameConn, f Frame) {
+	resp, err := s.Handler.Serve(ctx, f.Payload)
+	if ctx.Err() != nil {
+		// Клієнт уже не чекає - відповідь нікому не потрібна
+		return
+	}
+
+	out := Frame{Type: FrameResponse, ID: f.ID, Payload: resp}
+	if err != nil {
+		out = Frame{Type: FrameError, ID: f.ID, Payload: []byte(err.Error())}
+	}
+
+	if err := fc.WriteFrame(out); errors.Is(err, ErrFrameTooLarge) {
+		fc.WriteFrame(Frame{Type: FrameError, ID: f.ID, Payload: []byte("response too large")})
+	}
+}
+
+func (sc *serverConn) start(parent context.Context, id uint32) (context.Context, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if _, exists := sc.inFlight[id]; exists {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(parent)
+	sc.inFlight[id] = cancel
+	return ctx, true
+}
+
+func (sc *serverConn) cancel(id uint32) {
+	sc.mu.Lock()
+	cancel, ok := sc.inFlight[id]
+	delete(sc.inFlight, id)
+	sc.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============= Conn =============
+
+var (
+	ErrLineTooLong   = errors.New("line too long")
+	ErrServerClosing = errors.New("server is shutting down")
+)
+
+// ConnStats - знімок лічильників одного з'єднання
+type ConnStats struct {
+	RemoteAddr string
+	Start      time.Time
+	Duration   time.Duration
+	BytesIn    int64
+	BytesOut   int64
+	Requests   int64
+}
+
+// Conn обгортає net.Conn: рахує байти і ставить дедлайни так,
+// щоб повільний клієнт не тримав з'єднання вічно.
+//
+//   - IdleTimeout - скільки чекати першого байта наступного запиту
+//   - ReadTimeout - скільки всього можна читати один запит; дедлайн
+//     не подовжується з кожним байтом, тож slowloris не допоможе
+type Conn struct {
+	conn  net.Conn
+	r     *bufio.Reader
+	cfg   Config
+	start time.Time
+
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+	requests atomic.Int64
+
+	mu      sync.Mutex
+	idle    bool
+	closing bool
+}
+
+func newConn(conn net.Conn, cfg Config) *Conn {
+	c := &Conn{conn: conn, cfg: cfg, start: time.Now()}
+	c.r = bufio.NewReaderSize(countingReader{c}, 4096)
+	return c
+}
+
+// ReadLine читає один рядок-запит без '\n'. Під час Shutdown
+// з'єднання, що чекає нового запиту, отримує ErrServerClosing.
+func (c *Conn) ReadLine() (string, error) {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		return "", ErrServerClosing
+	}
+	c.idle = true
+	c.conn.SetReadDeadline(deadline(c.cfg.IdleTimeout))
+	c.mu.Unlock()
+
+	_, err := c.r.Peek(1)
+
+	c.mu.Lock()
+	c.idle = false
+	closing := c.closing
+	c.mu.Unlock()
+	if err != nil {
+		if closing {
+			return "", ErrServerClosing
+		}
+		return "", err
+	}
+
+	// Запит почався - далі діє один дедлайн на весь рядок
+	c.conn.SetReadDeadline(deadline(c.cfg.ReadTimeout))
+
+	var line []byte
+	for {
+		chunk, err := c.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		// +2 - місце для "\r\n": термінатор у ліміт не входить
+		if len(line) > c.cfg.MaxLineLength+2 {
+			return "", ErrLineTooLong
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		break
+	}
+
+	line = line[:len(line)-1]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	if len(line) > c.cfg.MaxLineLength {
+		return "", ErrLineTooLong
+	}
+	c.requests.Add(1)
+	return string(line), nil
+}
+
+func (c *Conn) WriteLine(line string) error {
+	_, err := c.Write([]byte(line + "\n"))
+	return err
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	c.conn.SetWriteDeadline(deadline(c.cfg.WriteTimeout))
+	n, err := c.conn.Write(p)
+	c.bytesOut.Add(int64(n))
+	return n, err
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) Stats() ConnStats {
+	return ConnStats{
+		RemoteAddr: c.conn.RemoteAddr().String(),
+		Start:      c.start,
+		Duration:   time.Since(c.start),
+		BytesIn:    c.bytesIn.Load(),
+		BytesOut:   c.bytesOut.Load(),
+		Requests:   c.requests.Load(),
+	}
+}
+
+// beginShutdown будить з'єднання, що чекає нового запиту.
+// Запит, який уже читається або обробляється, завершиться звичайно.
+func (c *Conn) beginShutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closing = true
+	if c.idle {
+		c.conn.SetReadDeadline(time.Now())
+	}
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// countingReader рахує байти до буферизації bufio
+type countingReader struct {
+	c *Conn
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.c.conn.Read(p)
+	r.c.bytesIn.Add(int64(n))
+	return n, err
+}
+
+func deadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// EchoHandler - протокол 05_networking/tcp_server.go (рядок у верхньому
+// регістрі), але поверх Conn з дедлайнами і підтримкою Shutdown
+func EchoHandler() Handler {
+	return HandlerFunc(func(ctx context.Context, conn *Conn) {
+		for {
+			line, err := conn.ReadLine()
+			switch {
+			case errors.Is(err, ErrLineTooLong):
+				conn.WriteLine("ERR line too long")
+				return
+			case err != nil:
+				return
+			}
+
+			if err := conn.WriteLine(strings.ToUpper(line)); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve()
+		return
+	}
+	demo()
+}
+
+// serve - справжній сервер на :9000 (клієнт: 05_networking/tcp_client.go або nc)
+func serve() {
+	cfg := DefaultConfig()
+	cfg.OnConnClose = func(s ConnStats) {
+		log.Printf("closed %s: %d requests, in=%dB out=%dB, %v",
+			s.RemoteAddr, s.Requests, s.BytesIn, s.BytesOut, s.Duration.Round(time.Millisecond))
+	}
+	server := NewServer(cfg, EchoHandler())
+
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		log.Println("shutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("forced shutdown: %v", err)
+		}
+	}()
+
+	log.Println("TCP Server listening on :9000")
+	if err := server.ListenAndServe(":9000"); !errors.Is(err, ErrServerClosed) {
+		log.Fatal(err)
+	}
+	log.Println("server stopped")
+}
+
+func demo() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   TCP Server: limits & graceful stop   ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	cfg := DefaultConfig()
+	cfg.MaxConns = 2
+	cfg.IdleTimeout = 2 * time.Second
+	cfg.ReadTimeout = 300 * time.Millisecond
+	server := NewServer(cfg, EchoHandler())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	addr := listener.Addr().String()
+	go server.Serve(listener)
+
+	// 1. Звичайний клієнт
+	fmt.Println("\n💬 1. Echo:")
+	alice := mustDial(addr)
+	fmt.Fprintln(alice, "hello")
+	fmt.Printf("   hello -> %s", readLine(alice))
+
+	// 2. Slowloris: байт раз на 100ms, рядок так і не закінчується
+	fmt.Println("\n🐌 2. Slowloris (ReadTimeout 300ms на весь рядок):")
+	slow := mustDial(addr)
+	start := time.Now()
+	for _, b := range []byte("very slow request") {
+		if _, err := slow.Write([]byte{b}); err != nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	_, err = slow.Read(make([]byte, 1))
+	fmt.Printf("   з'єднання закрито через %v: %v\n", time.Since(start).Round(100*time.Millisecond), err)
+	slow.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	// 3. Ліміт з'єднань
+	fmt.Println("\n🚦 3. MaxConns = 2:")
+	bob := mustDial(addr)
+	extra := mustDial(addr)
+	fmt.Printf("   третій клієнт: %s", readLine(extra))
+	extra.Close()
+
+	// 4. Статистика
+	fmt.Println("\n📊 4. Статистика:")
+	for _, c := range server.Conns() {
+		fmt.Printf("   %s: requests=%d in=%dB out=%dB\n", c.RemoteAddr, c.Requests, c.BytesIn, c.BytesOut)
+	}
+	st := server.Stats()
+	fmt.Printf("   active=%d accepted=%d rejected=%d\n", st.Active, st.Accepted, st.Rejected)
+
+	// 5. Graceful shutdown
+	fmt.Println("\n🛑 5. Shutdown:")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = server.Shutdown(ctx)
+	fmt.Printf("   Shutdown: %v\n", err)
+	fmt.Printf("   alice отримала: %s", readLine(alice))
+	fmt.Printf("   bob отримав:   %s", readLine(bob))
+
+	_, err = net.Dial("tcp", addr)
+	fmt.Printf("   нове з'єднання відхилено: %v\n", err != nil)
+
+	fmt.Println("\n✅ Demo completed!")
+}
+
+// demoClient тримає один bufio.Reader на з'єднання (див. виправлення в 05_networking)
+type demoClient struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func mustDial(addr string) *demoClient {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		panic(err)
+	}
+	return &demoClient{Conn: conn, r: bufio.NewReader(conn)}
+}
+
+func readLine(c *demoClient) string {
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	return line
+}
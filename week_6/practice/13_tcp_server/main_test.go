@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, cfg Config, handler Handler) (*Server, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(cfg, handler)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+	return server, listener.Addr().String()
+}
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *testClient) send(line string) {
+	fmt.Fprintf(c.conn, "%s\n", line)
+}
+
+func (c *testClient) readLine() (string, error) {
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := c.r.ReadString('\n')
+	return strings.TrimSuffix(line, "\n"), err
+}
+
+func (c *testClient) expect(want string) {
+	c.t.Helper()
+	got, err := c.readLine()
+	if err != nil {
+		c.t.Fatalf("Expected %q, got error %v", want, err)
+	}
+	if got != want {
+		c.t.Fatalf("Expected %q, got %q", want, got)
+	}
+}
+
+func (c *testClient) expectClosed() {
+	c.t.Helper()
+	if line, err := c.readLine(); err != io.EOF {
+		c.t.Fatalf("Expected connection closed, got %q %v", line, err)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	_, addr := startServer(t, DefaultConfig(), EchoHandler())
+	c := dial(t, addr)
+
+	c.send("hello")
+	c.expect("HELLO")
+	c.send("world\r") // CRLF від telnet
+	c.expect("WORLD")
+}
+
+func TestMaxConns(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConns = 2
+	server, addr := startServer(t, cfg, EchoHandler())
+
+	first := dial(t, addr)
+	second := dial(t, addr)
+	first.send("a")
+	first.expect("A")
+	second.send("b")
+	second.expect("B")
+
+	third := dial(t, addr)
+	third.expect(cfg.BusyMessage)
+	third.expectClosed()
+
+	// Слот звільняється, коли клієнт іде
+	first.conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for server.Stats().Active != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	fourth := dial(t, addr)
+	fourth.send("d")
+	fourth.expect("D")
+
+	if st := server.Stats(); st.Rejected != 1 || st.Accepted != 3 {
+		t.Errorf("Expected accepted=3 rejected=1, got %+v", st)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.IdleTimeout = 200 * time.Millisecond
+	cfg.ReadTimeout = 200 * time.Millisecond
+
+	t.Run("idle connection closed", func(t *testing.T) {
+		_, addr := startServer(t, cfg, EchoHandler())
+		c := dial(t, addr)
+		start := time.Now()
+		c.expectClosed()
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected close after ~200ms, got %v", elapsed)
+		}
+	})
+
+	t.Run("slowloris closed despite steady bytes", func(t *testing.T) {
+		_, addr := startServer(t, cfg, EchoHandler())
+		c := dial(t, addr)
+
+		// Байт кожні 50ms - менше IdleTimeout, але рядок не закінчується
+		start := time.Now()
+		for time.Since(start) < time.Second {
+			if _, err := c.conn.Write([]byte("x")); err != nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		c.expectClosed()
+	})
+
+	t.Run("line too long", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxLineLength = 10
+		_, addr := startServer(t, cfg, EchoHandler())
+		c := dial(t, addr)
+		c.send(strings.Repeat("x", 100))
+		c.expect("ERR line too long")
+	})
+
+	t.Run("line of exactly MaxLineLength", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxLineLength = 10
+		_, addr := startServer(t, cfg, EchoHandler())
+		c := dial(t, addr)
+		c.send(strings.Repeat("x", 10))
+		c.expect(strings.Repeat("X", 10))
+		c.send(strings.Repeat("y", 10) + "\r")
+		c.expect(strings.Repeat("Y", 10))
+		c.send(strings.Repeat("z", 11))
+		c.expect("ERR line too long")
+	})
+}
+
+func TestShutdown_Graceful(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := HandlerFunc(func(ctx context.Context, conn *Conn) {
+		for {
+			line, err := conn.ReadLine()
+			if err != nil {
+				return
+			}
+			if line == "slow" {
+				close(started)
+				<-release
+			}
+			conn.WriteLine("done " + line)
+		}
+	})
+
+	server, addr := startServer(t, DefaultConfig(), handler)
+	idle := dial(t, addr)
+	busy := dial(t, addr)
+	idle.send("ping")
+	idle.expect("done ping")
+
+	busy.send("slow")
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		shutdownErr <- server.Shutdown(ctx)
+	}()
+
+	// Клієнт між запитами отримує попередження одразу
+	idle.expect(DefaultConfig().GoAwayMessage)
+	idle.expectClosed()
+
+	// Запит в обробці завершується, і лише потім з'єднання закривається
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before handler finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	busy.expect("done slow")
+	busy.expect(DefaultConfig().GoAwayMessage)
+	busy.expectClosed()
+
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("Expected listener to be closed")
+	}
+}
+
+func TestShutdown_ForcedOnDeadline(t *testing.T) {
+	handler := HandlerFunc(func(ctx context.Context, conn *Conn) {
+		conn.ReadLine()
+		// Обробник ігнорує ctx, але пише в сокет - примусове закриття його зупинить
+		for {
+			if err := conn.WriteLine("still working"); err != nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	server, addr := startServer(t, DefaultConfig(), handler)
+	c := dial(t, addr)
+	c.send("go")
+	c.readLine()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestServe_AfterShutdown(t *testing.T) {
+	server := NewServer(DefaultConfig(), EchoHandler())
+	server.Shutdown(context.Background())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	if err := server.Serve(listener); err != ErrServerClosed {
+		t.Errorf("Expected ErrServerClosed, got %v", err)
+	}
+}
+
+// Shutdown під час потоку нових з'єднань: wg.Add не повинен
+// перетнутися з wg.Wait (ловить -race)
+func TestShutdown_WhileAccepting(t *testing.T) {
+	for range 20 {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := NewServer(DefaultConfig(), EchoHandler())
+		served := make(chan error, 1)
+		go func() { served <- server.Serve(listener) }()
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if conn, err := net.Dial("tcp", listener.Addr().String()); err == nil {
+						conn.Close()
+					}
+				}
+			}()
+		}
+
+		time.Sleep(time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if err := server.Shutdown(ctx); err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+		cancel()
+		close(stop)
+		wg.Wait()
+		if err := <-served; !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Expected ErrServerClosed, got %v", err)
+		}
+	}
+}
+
+// flakyListener повертає помилку на перших Accept, далі - справжні з'єднання
+type flakyListener struct {
+	net.Listener
+	errs []error
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if len(l.errs) > 0 {
+		err := l.errs[0]
+		l.errs = l.errs[1:]
+		return nil, err
+	}
+	return l.Listener.Accept()
+}
+
+func TestServe_RetriesTemporaryAcceptErrors(t *testing.T) {
+	for _, errno := range []syscall.Errno{syscall.EMFILE, syscall.ENFILE, syscall.ECONNABORTED} {
+		t.Run(errno.Error(), func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := NewServer(DefaultConfig(), EchoHandler())
+			acceptErr := &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", errno)}
+			served := make(chan error, 1)
+			go func() { served <- server.Serve(&flakyListener{Listener: listener, errs: []error{acceptErr}}) }()
+
+			c := dial(t, listener.Addr().String())
+			c.send("still here")
+			c.expect("STILL HERE")
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			server.Shutdown(ctx)
+			if err := <-served; !errors.Is(err, ErrServerClosed) {
+				t.Errorf("Expected ErrServerClosed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConnStats(t *testing.T) {
+	var mu sync.Mutex
+	var closed []ConnStats
+	cfg := DefaultConfig()
+	cfg.OnConnClose = func(s ConnStats) {
+		mu.Lock()
+		closed = append(closed, s)
+		mu.Unlock()
+	}
+	server, addr := startServer(t, cfg, EchoHandler())
+
+	c := dial(t, addr)
+	c.send("hello")
+	c.expect("HELLO")
+	c.send("go")
+	c.expect("GO")
+
+	conns := server.Conns()
+	if len(conns) != 1 {
+		t.Fatalf("Expected 1 active conn, got %d", len(conns))
+	}
+	if got := conns[0]; got.BytesIn != 9 || got.BytesOut != 9 || got.Requests != 2 {
+		t.Errorf("Expected in=9 out=9 requests=2, got %+v", got)
+	}
+
+	c.conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(closed)
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(closed) != 1 || closed[0].Duration <= 0 {
+		t.Fatalf("Expected OnConnClose with duration, got %+v", closed)
+	}
+	if st := server.Stats(); st.Active != 0 || st.BytesIn != 9 || st.BytesOut != 9 {
+		t.Errorf("Expected totals to include closed conns, got %+v", st)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ============= Server =============
+
+var ErrServerClosed = errors.New("tcp: server closed")
+
+// Handler обслуговує одне з'єднання. ctx скасовується на Shutdown;
+// ReadLine тоді повертає ErrServerClosing, коли клієнт між запитами.
+type Handler interface {
+	ServeConn(ctx context.Context, conn *Conn)
+}
+
+type HandlerFunc func(ctx context.Context, conn *Conn)
+
+func (f HandlerFunc) ServeConn(ctx context.Context, conn *Conn) {
+	f(ctx, conn)
+}
+
+type Config struct {
+	MaxConns      int
+	IdleTimeout   time.Duration // очікування наступного запиту
+	ReadTimeout   time.Duration // читання одного запиту цілком
+	WriteTimeout  time.Duration
+	MaxLineLength int
+
+	BusyMessage   string // відповідь, коли MaxConns вичерпано
+	GoAwayMessage string // останній рядок клієнту під час Shutdown
+
+	OnConnClose func(ConnStats) // наприклад, для логування
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxConns:      1000,
+		IdleTimeout:   60 * time.Second,
+		ReadTimeout:   10 * time.Second,
+		WriteTimeout:  10 * time.Second,
+		MaxLineLength: 4096,
+		BusyMessage:   "ERR BUSY too many connections",
+		GoAwayMessage: "GOING_AWAY server is shutting down",
+	}
+}
+
+// ServerStats - агреговані лічильники; закриті з'єднання теж враховані
+type ServerStats struct {
+	Active   int
+	Accepted int64
+	Rejected int64
+	BytesIn  int64
+	BytesOut int64
+}
+
+type Server struct {
+	cfg     Config
+	handler Handler
+
+	sem    chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*Conn]struct{}
+
+	shuttingDown atomic.Bool
+	accepted     atomic.Int64
+	rejected     atomic.Int64
+	closedIn     atomic.Int64
+	closedOut    atomic.Int64
+}
+
+func NewServer(cfg Config, handler Handler) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		cfg:       cfg,
+		handler:   handler,
+		sem:       make(chan struct{}, cfg.MaxConns),
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*Conn]struct{}),
+	}
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve приймає з'єднання до Shutdown і тоді повертає ErrServerClosed
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.trackListener(listener) {
+		return ErrServerClosed
+	}
+	defer s.untrackListener(listener)
+
+	var backoff time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.shuttingDown.Load() {
+				return ErrServerClosed
+			}
+			// Тимчасові помилки (наприклад, EMFILE) - пауза, а не вихід
+			if isTemporary(err) {
+				backoff = min(max(2*backoff, 5*time.Millisecond), time.Second)
+				log.Printf("accept error: %v; retrying in %v", err, backoff)
+				time.Sleep(backoff)
+				continue
+			}
+			return err
+		}
+		backoff = 0
+
+		select {
+		case s.sem <- struct{}{}:
+			// Перевірка і wg.Add під локом, який бере Shutdown: інакше Add
+			// міг би статися вже під час wg.Wait
+			s.mu.Lock()
+			if s.shuttingDown.Load() {
+				s.mu.Unlock()
+				<-s.sem
+				conn.Close()
+				return ErrServerClosed
+			}
+			s.wg.Add(1)
+			s.mu.Unlock()
+			s.accepted.Add(1)
+			go s.serveConn(conn)
+		default:
+			s.rejected.Add(1)
+			go s.reject(conn)
+		}
+	}
+}
+
+// isTemporary: вичерпані дескриптори (EMFILE, ENFILE) і клієнт, що
+// відвалився ще в черзі (ECONNABORTED), минають самі - Serve не повинен
+// через них назавжди перестати приймати з'єднання
+func isTemporary(err error) bool {
+	if errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) || errors.Is(err, syscall.ECONNABORTED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// reject відповідає одразу, не займаючи слот: зайнятий сервер
+// не повинен мовчки тримати клієнта в черзі
+func (s *Server) reject(conn net.Conn) {
+	defer conn.Close()
+	if s.cfg.BusyMessage != "" {
+		conn.SetWriteDeadline(time.Now().Add(time.Second))
+		conn.Write([]byte(s.cfg.BusyMessage + "\n"))
+	}
+}
+
+func (s *Server) serveConn(netConn net.Conn) {
+	c := newConn(netConn, s.cfg)
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic serving %s: %v", c.RemoteAddr(), r)
+		}
+		if s.shuttingDown.Load() && s.cfg.GoAwayMessage != "" {
+			c.WriteLine(s.cfg.GoAwayMessage)
+		}
+		c.Close()
+
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+
+		stats := c.Stats()
+		s.closedIn.Add(stats.BytesIn)
+		s.closedOut.Add(stats.BytesOut)
+		if s.cfg.OnConnClose != nil {
+			s.cfg.OnConnClose(stats)
+		}
+
+		<-s.sem
+		s.wg.Done()
+	}()
+
+	// З'єднання, прийняте в момент Shutdown, теж має отримати сигнал
+	if s.shuttingDown.Load() {
+		c.beginShutdown()
+	}
+	s.handler.ServeConn(s.ctx, c)
+}
+
+// Shutdown: припинити Accept, попередити клієнтів і дочекатись
+// обробників. Якщо ctx закінчився раніше - з'єднання закриваються примусово.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+	s.cancel()
+
+	s.mu.Lock()
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.beginShutdown()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.conns {
+			c.Close()
+		}
+		s.mu.Unlock()
+		// Як і http.Server.Shutdown - не чекаємо обробник, що завис не на сокеті
+		return ctx.Err()
+	}
+}
+
+// Conns - статистика активних з'єднань
+func (s *Server) Conns() []ConnStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]ConnStats, 0, len(s.conns))
+	for c := range s.conns {
+		stats = append(stats, c.Stats())
+	}
+	return stats
+}
+
+func (s *Server) Stats() ServerStats {
+	stats := ServerStats{
+		Accepted: s.accepted.Load(),
+		Rejected: s.rejected.Load(),
+		BytesIn:  s.closedIn.Load(),
+		BytesOut: s.closedOut.Load(),
+	}
+	for _, c := range s.Conns() {
+		stats.Active++
+		stats.BytesIn += c.BytesIn
+		stats.BytesOut += c.BytesOut
+	}
+	return stats
+}
+
+func (s *Server) trackListener(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shuttingDown.Load() {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackListener(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, l)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+)
+
+// ============= Identity =============
+
+// Identity - хто підключився, за даними перевіреного клієнтського сертифіката
+type Identity struct {
+	CommonName   string   `json:"common_name"`
+	Organization []string `json:"organization,omitempty"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	URIs         []string `json:"uris,omitempty"` // SPIFFE ID тощо
+	Emails       []string `json:"emails,omitempty"`
+	Serial       string   `json:"serial"`
+}
+
+// Name - основне ім'я: URI SAN (SPIFFE), DNS SAN, email, і лише потім CN.
+// SAN важливіші за CN - CN для імен вважається застарілим (RFC 6125).
+func (id Identity) Name() string {
+	switch {
+	case len(id.URIs) > 0:
+		return id.URIs[0]
+	case len(id.DNSNames) > 0:
+		return id.DNSNames[0]
+	case len(id.Emails) > 0:
+		return id.Emails[0]
+	default:
+		return id.CommonName
+	}
+}
+
+func IdentityFromCert(cert *x509.Certificate) Identity {
+	id := Identity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+		Emails:       cert.EmailAddresses,
+		Serial:       cert.SerialNumber.Text(16),
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	return id
+}
+
+// IdentityFromState - з tls.ConnectionState після handshake.
+// VerifiedChains непорожній лише якщо сертифікат перевірено проти ClientCAs.
+func IdentityFromState(state tls.ConnectionState) (Identity, bool) {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+	return IdentityFromCert(state.VerifiedChains[0][0]), true
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// ============= HTTP middleware =============
+
+// IdentityMiddleware кладе Identity в контекст запиту
+func IdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			if id, ok := IdentityFromState(*r.TLS); ok {
+				r = r.WithContext(WithIdentity(r.Context(), id))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireIdentity - 401 без клієнтського сертифіката; потрібен,
+// коли сервер працює з OptionalClientCert
+func RequireIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := IdentityFromContext(r.Context()); !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "client certificate required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func whoamiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /whoami", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := IdentityFromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(id)
+	})
+	return mux
+}
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   TLS & mTLS: TCP + HTTP               ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	dir, err := os.MkdirTemp("", "tls-demo")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 1. Сертифікати (у реальному житті - від cert-manager, Vault тощо)
+	fmt.Println("\n🔐 1. In-memory CA:")
+	ca := must(NewCA("Demo CA"))
+	caFile := filepath.Join(dir, "ca.crt")
+	os.WriteFile(caFile, ca.CertPEM, 0o644)
+
+	serverLeaf := must(ca.Issue(LeafOptions{
+		CommonName: "localhost",
+		DNSNames:   []string{"localhost"},
+		IPs:        []net.IP{net.ParseIP("127.0.0.1")},
+		Server:     true,
+	}))
+	certFile, keyFile, err := serverLeaf.WriteFiles(dir, "server")
+	if err != nil {
+		panic(err)
+	}
+
+	clientLeaf := must(ca.Issue(LeafOptions{
+		CommonName:   "orders-service",
+		Organization: "Shop",
+		URIs:         []string{"spiffe://shop.local/orders"},
+		Client:       true,
+	}))
+	clientCert := must(clientLeaf.TLSCertificate())
+	fmt.Printf("   CA, server (localhost), client (spiffe://shop.local/orders) -> %s\n", dir)
+
+	reloader := must(NewCertReloader(Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}))
+	// Між записом key і cert пара тимчасово не збігається - такий reload
+	// завершиться помилкою, і reloader лишить старий сертифікат
+	reloaded := make(chan struct{}, 1)
+	reloader.OnReload = func(err error) {
+		if err == nil {
+			select {
+			case reloaded <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 100*time.Millisecond)
+
+	// 2. HTTPS з mTLS
+	fmt.Println("\n🌐 2. HTTPS + mTLS:")
+	httpListener := must(net.Listen("tcp", "127.0.0.1:0"))
+	httpServer := NewHTTPServer("", whoamiHandler(), reloader)
+	go httpServer.ServeTLS(httpListener, "", "")
+	defer httpServer.Close()
+
+	url := "https://" + httpListener.Addr().String() + "/whoami"
+	withCert := httpClient(ca, &clientCert)
+	resp, err := withCert.Get(url)
+	if err == nil {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("   з сертифікатом: %d %s", resp.StatusCode, body)
+	}
+
+	_, err = httpClient(ca, nil).Get(url)
+	fmt.Printf("   без сертифіката: %v\n", err != nil)
+
+	// 3. TCP echo поверх TLS
+	fmt.Println("\n🔌 3. TCP + mTLS:")
+	tcpListener := must(net.Listen("tcp", "127.0.0.1:0"))
+	go NewTCPServer(reloader, EchoHandler).Serve(tcpListener)
+	defer tcpListener.Close()
+
+	conn := must(tls.Dial("tcp", tcpListener.Addr().String(), clientTLS(ca, &clientCert)))
+	fmt.Fprintln(conn, "hello over tls")
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	fmt.Printf("   %s", line)
+	conn.Close()
+
+	// 4. Ротація сертифіката без перезапуску
+	fmt.Println("\n🔄 4. Hot reload:")
+	fmt.Printf("   serial до:    %s\n", servedSerial(withCert, url))
+	rotated := must(ca.Issue(LeafOptions{CommonName: "localhost", DNSNames: []string{"localhost"},
+		IPs: []net.IP{net.ParseIP("127.0.0.1")}, Server: true}))
+	rotated.WriteFiles(dir, "server")
+
+	select {
+	case <-reloaded:
+		fmt.Println("   файли змінились -> сертифікат перезавантажено")
+	case <-time.After(2 * time.Second):
+		fmt.Println("   reload не відбувся")
+	}
+	withCert.CloseIdleConnections() // старі з'єднання тримають старий сертифікат
+	fmt.Printf("   serial після: %s\n", servedSerial(withCert, url))
+
+	fmt.Println("\n✅ Demo completed!")
+}
+
+func clientTLS(ca *CA, cert *tls.Certificate) *tls.Config {
+	cfg := &tls.Config{RootCAs: ca.CertPool(), MinVersion: tls.VersionTLS12}
+	if cert != nil {
+		cfg.Certificates = []tls.Certificate{*cert}
+	}
+	return cfg
+}
+
+func httpClient(ca *CA, cert *tls.Certificate) *http.Client {
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: clientTLS(ca, cert)},
+	}
+}
+
+// servedSerial - серійний номер сертифіката, який показав сервер
+func servedSerial(client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err.Error()
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.TLS.PeerCertificates[0].SerialNumber.Text(16)
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// pki - тестовий набір: CA, файли серверного сертифіката і клієнт
+type pki struct {
+	ca       *CA
+	dir      string
+	certFile string
+	keyFile  string
+	caFile   string
+}
+
+func newPKI(t *testing.T) *pki {
+	t.Helper()
+	ca, err := NewCA("Test CA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &pki{ca: ca, dir: t.TempDir()}
+	p.caFile = filepath.Join(p.dir, "ca.crt")
+	if err := os.WriteFile(p.caFile, ca.CertPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p.rotateServer(t, "localhost")
+	return p
+}
+
+func (p *pki) rotateServer(t *testing.T, cn string) {
+	t.Helper()
+	leaf, err := p.ca.Issue(LeafOptions{
+		CommonName: cn,
+		DNSNames:   []string{"localhost"},
+		IPs:        []net.IP{net.ParseIP("127.0.0.1")},
+		Server:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.certFile, p.keyFile, err = leaf.WriteFiles(p.dir, "server"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (p *pki) client(t *testing.T, ca *CA, opts LeafOptions) *tls.Config {
+	t.Helper()
+	cfg := &tls.Config{RootCAs: p.ca.CertPool()}
+	if ca != nil {
+		opts.Client = true
+		leaf, err := ca.Issue(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := leaf.TLSCertificate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg
+}
+
+func startHTTPS(t *testing.T, reloader *CertReloader, handler http.Handler) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewHTTPServer("", handler, reloader)
+	server.ErrorLog = log.New(io.Discard, "", 0) // очікувані handshake-помилки
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+	return "https://" + listener.Addr().String()
+}
+
+func getIdentity(t *testing.T, cfg *tls.Config, url string) (Identity, int, error) {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}, Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return Identity{}, 0, err
+	}
+	defer resp.Body.Close()
+
+	var id Identity
+	json.NewDecoder(resp.Body).Decode(&id)
+	return id, resp.StatusCode, nil
+}
+
+func TestHTTP_MutualTLS(t *testing.T) {
+	p := newPKI(t)
+	reloader, err := NewCertReloader(Config{CertFile: p.certFile, KeyFile: p.keyFile, ClientCAFile: p.caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := startHTTPS(t, reloader, whoamiHandler()) + "/whoami"
+
+	t.Run("valid client certificate", func(t *testing.T) {
+		cfg := p.client(t, p.ca, LeafOptions{
+			CommonName:   "billing",
+			Organization: "Shop",
+			URIs:         []string{"spiffe://shop.local/billing"},
+		})
+		id, status, err := getIdentity(t, cfg, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusOK || id.CommonName != "billing" || id.Name() != "spiffe://shop.local/billing" {
+			t.Errorf("Unexpected identity %d %+v", status, id)
+		}
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		if _, _, err := getIdentity(t, p.client(t, nil, LeafOptions{}), url); err == nil {
+			t.Error("Expected handshake to fail without client certificate")
+		}
+	})
+
+	t.Run("certificate from another CA", func(t *testing.T) {
+		rogue, err := NewCA("Rogue CA")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := p.client(t, rogue, LeafOptions{CommonName: "billing"})
+		if _, _, err := getIdentity(t, cfg, url); err == nil {
+			t.Error("Expected handshake to fail with untrusted client certificate")
+		}
+	})
+}
+
+func TestHTTP_OptionalClientCert(t *testing.T) {
+	p := newPKI(t)
+	reloader, err := NewCertReloader(Config{
+		CertFile: p.certFile, KeyFile: p.keyFile,
+		ClientCAFile: p.caFile, OptionalClientCert: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	whoami := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := IdentityFromContext(r.Context())
+		json.NewEncoder(w).Encode(id)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("GET /public", whoami)
+	mux.Handle("GET /private", RequireIdentity(whoami))
+	base := startHTTPS(t, reloader, mux)
+
+	anonymous := p.client(t, nil, LeafOptions{})
+	if id, status, err := getIdentity(t, anonymous, base+"/public"); err != nil || status != http.StatusOK || id.CommonName != "" {
+		t.Errorf("Expected anonymous 200 on /public, got %d %+v %v", status, id, err)
+	}
+	if _, status, err := getIdentity(t, anonymous, base+"/private"); err != nil || status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous on /private, got %d %v", status, err)
+	}
+
+	authed := p.client(t, p.ca, LeafOptions{CommonName: "admin"})
+	if id, status, err := getIdentity(t, authed, base+"/private"); err != nil || status != http.StatusOK || id.CommonName != "admin" {
+		t.Errorf("Expected admin on /private, got %d %+v %v", status, id, err)
+	}
+}
+
+func TestTCP_MutualTLS(t *testing.T) {
+	p := newPKI(t)
+	reloader, err := NewCertReloader(Config{CertFile: p.certFile, KeyFile: p.keyFile, ClientCAFile: p.caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	server := NewTCPServer(reloader, EchoHandler)
+	server.HandshakeTimeout = 500 * time.Millisecond
+	go server.Serve(listener)
+
+	cfg := p.client(t, p.ca, LeafOptions{CommonName: "worker", DNSNames: []string{"worker.shop.local"}})
+	conn, err := tls.Dial("tcp", listener.Addr().String(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("hello\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "worker.shop.local: HELLO\n" {
+		t.Errorf("Expected identity from DNS SAN, got %q", line)
+	}
+
+	// Клієнт, що не починає handshake, не тримає goroutine довше HandshakeTimeout
+	raw, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	raw.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := raw.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected stalled handshake to be closed, got %v", err)
+	}
+}
+
+func TestCertReloader_HotReload(t *testing.T) {
+	p := newPKI(t)
+	reloader, err := NewCertReloader(Config{CertFile: p.certFile, KeyFile: p.keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloads := make(chan error, 10)
+	reloader.OnReload = func(err error) {
+		select {
+		case reloads <- err:
+		default: // зіпсований файл повторюється на кожному тіку
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 20*time.Millisecond)
+
+	servedCN := func() string {
+		conn, err := tls.Dial("tcp", startTLS(t, reloader), &tls.Config{RootCAs: p.ca.CertPool()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+
+	if cn := servedCN(); cn != "localhost" {
+		t.Fatalf("Expected initial certificate, got %q", cn)
+	}
+
+	p.rotateServer(t, "rotated")
+	waitReload(t, reloads)
+	if cn := servedCN(); cn != "rotated" {
+		t.Errorf("Expected rotated certificate after file change, got %q", cn)
+	}
+
+	// Зіпсований файл - помилка, але сервер працює зі старим сертифікатом
+	os.WriteFile(p.certFile, []byte("not a certificate"), 0o644)
+	select {
+	case err := <-reloads:
+		if err == nil {
+			t.Error("Expected reload error for broken file")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected reload attempt for broken file")
+	}
+	if cn := servedCN(); cn != "rotated" {
+		t.Errorf("Expected previous certificate to stay, got %q", cn)
+	}
+}
+
+func TestIdentityFromCert_Name(t *testing.T) {
+	ca, err := NewCA("Test CA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts LeafOptions
+		want string
+	}{
+		{"uri wins", LeafOptions{CommonName: "cn", DNSNames: []string{"dns"}, URIs: []string{"spiffe://x/y"}}, "spiffe://x/y"},
+		{"dns before cn", LeafOptions{CommonName: "cn", DNSNames: []string{"dns.local"}}, "dns.local"},
+		{"email before cn", LeafOptions{CommonName: "cn", Emails: []string{"a@b.c"}}, "a@b.c"},
+		{"cn fallback", LeafOptions{CommonName: "cn"}, "cn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf, err := ca.Issue(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cert, err := leaf.TLSCertificate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := IdentityFromCert(cert.Leaf).Name(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// startTLS - найпростіший TLS listener з конфігом reloader-а
+func startTLS(t *testing.T, reloader *CertReloader) string {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", reloader.TLSConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+				io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// waitReload чекає першого успішного reload (проміжні помилки - поки
+// key вже новий, а cert ще старий - допустимі)
+func waitReload(t *testing.T, reloads <-chan error) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case err := <-reloads:
+			if err == nil {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Expected certificate reload")
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ============= Config & hot reload =============
+
+type Config struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile вмикає mTLS: клієнт мусить мати сертифікат від цього CA
+	ClientCAFile string
+	// OptionalClientCert - сертифікат перевіряється, якщо є, але не обов'язковий
+	OptionalClientCert bool
+}
+
+// CertReloader тримає поточні сертифікати і підміняє їх, коли файли
+// змінюються. Handshake бере значення через GetConfigForClient, тож
+// нові з'єднання одразу отримують новий сертифікат, а старі живуть далі.
+type CertReloader struct {
+	cfg Config
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    map[string][sha256.Size]byte
+
+	OnReload func(err error) // після кожної спроби перезавантаження
+}
+
+func NewCertReloader(cfg Config) (*CertReloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls: CertFile and KeyFile are required")
+	}
+
+	r := &CertReloader{cfg: cfg}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload читає файли заново. Якщо нова пара невалідна (наприклад,
+// cert вже оновлено, а key ще ні), лишаються старі сертифікати.
+func (r *CertReloader) Reload() error {
+	stamps := r.currentStamps()
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load key pair: %w", err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return fmt.Errorf("tls: parse certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("tls: no certificates in %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = pool
+	r.stamps = stamps
+	r.mu.Unlock()
+	return nil
+}
+
+// Watch перевіряє файли кожні interval і перезавантажує при зміні.
+// Polling замість inotify - працює однаково на всіх ОС і з Kubernetes
+// secret volumes, де файли підміняються через symlink.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			err := r.Reload()
+			if err != nil {
+				log.Printf("certificate reload failed, keeping previous: %v", err)
+			}
+			if r.OnReload != nil {
+				r.OnReload(err)
+			}
+		}
+	}
+}
+
+func (r *CertReloader) changed() bool {
+	current := r.currentStamps()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for path, stamp := range current {
+		if r.stamps[path] != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// currentStamps хешує вміст: файли маленькі, а mtime може не змінитись
+// при швидкому перезаписі або при підміні symlink на файл з тим самим часом
+func (r *CertReloader) currentStamps() map[string][sha256.Size]byte {
+	stamps := make(map[string][sha256.Size]byte, 3)
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			stamps[path] = sha256.Sum256(data)
+		}
+	}
+	return stamps
+}
+
+// Certificate - поточний серверний сертифікат
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// TLSConfig повертає конфіг для tls.NewListener або http.Server.TLSConfig.
+// nextProtos - ALPN, наприклад "h2", "http/1.1" для HTTP.
+func (r *CertReloader) TLSConfig(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				NextProtos:   nextProtos,
+			}
+			if r.clientCAs != nil {
+				cfg.ClientCAs = r.clientCAs
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				if r.cfg.OptionalClientCert {
+					cfg.ClientAuth = tls.VerifyClientCertIfGiven
+				}
+			}
+			return cfg, nil
+		},
+	}
+}
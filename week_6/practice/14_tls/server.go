@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ============= HTTP =============
+
+// NewHTTPServer - http.Server з TLS від reloader і Identity в контексті.
+// Запуск: server.ListenAndServeTLS("", "") - файли беруться з TLSConfig.
+func NewHTTPServer(addr string, handler http.Handler, reloader *CertReloader) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           IdentityMiddleware(handler),
+		TLSConfig:         reloader.TLSConfig("h2", "http/1.1"),
+		ReadHeaderTimeout: 5 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+}
+
+// ============= TCP =============
+
+// TCPHandler отримує з'єднання після успішного handshake;
+// Identity клієнта (якщо є) лежить у ctx
+type TCPHandler func(ctx context.Context, conn *tls.Conn)
+
+type TCPServer struct {
+	TLSConfig        *tls.Config
+	Handler          TCPHandler
+	HandshakeTimeout time.Duration
+}
+
+func NewTCPServer(reloader *CertReloader, handler TCPHandler) *TCPServer {
+	return &TCPServer{
+		TLSConfig:        reloader.TLSConfig(),
+		Handler:          handler,
+		HandshakeTimeout: 10 * time.Second,
+	}
+}
+
+func (s *TCPServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+func (s *TCPServer) handleConnection(conn net.Conn) {
+	tlsConn := tls.Server(conn, s.TLSConfig)
+	defer tlsConn.Close()
+
+	// Handshake явно і з таймаутом: інакше він відбудеться на першому
+	// Read, і клієнт, що не завершує handshake, тримав би goroutine вічно
+	ctx, cancel := context.WithTimeout(context.Background(), s.HandshakeTimeout)
+	err := tlsConn.HandshakeContext(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("tls handshake with %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+
+	ctx = context.Background()
+	if id, ok := IdentityFromState(tlsConn.ConnectionState()); ok {
+		ctx = WithIdentity(ctx, id)
+	}
+	s.Handler(ctx, tlsConn)
+}
+
+// EchoHandler - протокол 05_networking/tcp_server.go поверх TLS;
+// відповідь підписана ім'ям клієнта з сертифіката
+func EchoHandler(ctx context.Context, conn *tls.Conn) {
+	name := "anonymous"
+	if id, ok := IdentityFromContext(ctx); ok {
+		name = id.Name()
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if _, err := conn.Write([]byte(name + ": " + strings.ToUpper(scanner.Text()) + "\n")); err != nil {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Printf("%s: %v", conn.RemoteAddr(), err)
+	}
+}
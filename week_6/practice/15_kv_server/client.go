@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// ============= Client =============
+
+// Client - мінімальний RESP-клієнт для демо і тестів. Для реального коду
+// підійде будь-який Redis-клієнт (go-redis, redis-cli).
+type Client struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *Reader
+	w    *Writer
+}
+
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, r: NewReader(conn), w: NewWriter(conn)}, nil
+}
+
+// Do виконує одну команду. Відповідь-помилка сервера ("-ERR ...")
+// повертається як Value з Kind '-', а не як error.
+func (c *Client) Do(args ...string) (Value, error) {
+	replies, err := c.Pipeline([][]string{args})
+	if err != nil {
+		return Value{}, err
+	}
+	return replies[0], nil
+}
+
+// Pipeline пише всі команди одним Write і лише потім читає відповіді
+func (c *Client) Pipeline(cmds [][]string) ([]Value, error) {
+	if len(cmds) == 0 {
+		return nil, errors.New("empty pipeline")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, args := range cmds {
+		c.w.WriteCommand(args...)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]Value, len(cmds))
+	for i := range replies {
+		v, err := c.r.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = v
+	}
+	return replies, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,103 @@
+package main
+
+// ============= Glob =============
+// path.Match не підходить: у ньому * не перетинає '/', а ключі Redis
+// на кшталт "user:1/profile" мають матчитися по "user:*".
+//
+//	*      будь-яка послідовність
+//	?      один символ
+//	[abc]  [a-z]  [^a]  клас символів
+//	\x     символ x буквально
+
+func globMatch(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	return matchRunes(p, str)
+}
+
+// matchRunes - ітеративний матчер: пам'ятає лише останню '*' і позицію
+// в s, з якої її пробувати розширити. Рекурсія по кожній '*' на шаблоні
+// "*a*a*a*a*b" працювала б експоненційно, а KEYS виконується під s.mu.
+// Так - O(len(p)*len(s)).
+func matchRunes(p, s []rune) bool {
+	px, sx := 0, 0
+	starPx, starSx := -1, 0
+	for px < len(p) || sx < len(s) {
+		if px < len(p) {
+			if p[px] == '*' {
+				starPx, starSx = px, sx
+				px++
+				continue
+			}
+			if sx < len(s) {
+				if next, ok := matchOne(p, px, s[sx]); ok {
+					px, sx = next, sx+1
+					continue
+				}
+			}
+		}
+		// Невдача: остання '*' з'їдає ще один символ
+		if starPx >= 0 && starSx < len(s) {
+			starSx++
+			px, sx = starPx+1, starSx
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// matchOne зіставляє один елемент шаблону p[px] (не '*') з символом c;
+// повертає позицію наступного елемента
+func matchOne(p []rune, px int, c rune) (next int, ok bool) {
+	switch p[px] {
+	case '?':
+		return px + 1, true
+
+	case '[':
+		matched, rest, ok := matchClass(p[px+1:], c)
+		if !ok {
+			// Незакритий '[' - звичайний символ
+			return px + 1, c == '['
+		}
+		return len(p) - len(rest), matched
+
+	case '\\':
+		if px+1 < len(p) {
+			px++
+		}
+	}
+	return px + 1, p[px] == c
+}
+
+// matchClass розбирає клас після '['; повертає решту шаблону після ']'
+func matchClass(p []rune, c rune) (matched bool, rest []rune, ok bool) {
+	negate := false
+	if len(p) > 0 && p[0] == '^' {
+		negate = true
+		p = p[1:]
+	}
+
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == ']' && i > 0:
+			return matched != negate, p[i+1:], true
+		case p[i] == '\\' && i+1 < len(p):
+			i++
+			if p[i] == c {
+				matched = true
+			}
+		case i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']':
+			lo, hi := p[i], p[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if lo <= c && c <= hi {
+				matched = true
+			}
+			i += 2
+		case p[i] == c:
+			matched = true
+		}
+	}
+	return false, nil, false
+}
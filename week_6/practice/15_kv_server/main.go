@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	serve := len(os.Args) > 1 && os.Args[1] == "serve"
+	addr := "127.0.0.1:0"
+	if serve {
+		addr = ":6380" // поруч зі справжнім Redis на 6379
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		panic(err)
+	}
+	defer listener.Close()
+
+	server := NewServer(NewMemoryStorage())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Janitor(ctx, time.Second)
+
+	if serve {
+		fmt.Println("KV server listening on", listener.Addr(), "- redis-cli -p 6380")
+		server.Serve(listener)
+		return
+	}
+	go server.Serve(listener)
+
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   KV Server (RESP2 subset)             ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	client, err := Dial(listener.Addr().String())
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	run := func(args ...string) {
+		v, err := client.Do(args...)
+		if err != nil {
+			fmt.Printf("   %-32s -> error: %v\n", strings.Join(args, " "), err)
+			return
+		}
+		fmt.Printf("   %-32s -> %s\n", strings.Join(args, " "), v)
+	}
+
+	fmt.Println("\n🔑 1. Базові команди:")
+	run("PING")
+	run("SET", "user:1", "Alice")
+	run("SET", "user:2", "Bob")
+	run("GET", "user:1")
+	run("MGET", "user:1", "user:2", "user:3")
+	run("EXISTS", "user:1", "user:3")
+	run("KEYS", "user:*")
+
+	fmt.Println("\n⏱️  2. TTL:")
+	run("SET", "session", "abc", "EX", "60")
+	run("TTL", "session")
+	run("TTL", "user:1")
+	run("SET", "lock", "owner-1", "NX", "PX", "100")
+	run("SET", "lock", "owner-2", "NX")
+	time.Sleep(150 * time.Millisecond)
+	run("GET", "lock")
+
+	fmt.Println("\n➕ 3. INCR:")
+	run("INCR", "visits")
+	run("INCR", "visits")
+	run("INCR", "user:1")
+
+	fmt.Println("\n🚀 4. Pipelining (100 команд одним write):")
+	cmds := make([][]string, 100)
+	for i := range cmds {
+		cmds[i] = []string{"INCR", "counter"}
+	}
+	start := time.Now()
+	replies, err := client.Pipeline(cmds)
+	if err == nil {
+		fmt.Printf("   остання відповідь %s за %v\n", replies[len(replies)-1], time.Since(start).Round(time.Microsecond))
+	}
+
+	fmt.Println("\n🧹 5. DEL:")
+	run("DEL", "user:1", "user:2", "nope")
+	run("KEYS", "*")
+
+	fmt.Println("\n✅ Demo completed! Сервер для redis-cli: go run . serve")
+}
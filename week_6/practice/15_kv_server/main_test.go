@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, store Storage) (*Server, *Client) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := NewServer(store)
+	go server.Serve(listener)
+
+	client, err := Dial(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+// do виконує команду і повертає відповідь у форматі Value.String()
+func do(t *testing.T, c *Client, args ...string) string {
+	t.Helper()
+	v, err := c.Do(args...)
+	if err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return v.String()
+}
+
+func TestCommands(t *testing.T) {
+	_, c := startServer(t, NewMemoryStorage())
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"PING"}, `"PONG"`},
+		{[]string{"ping", "hi"}, `"hi"`},
+		{[]string{"GET", "missing"}, "(nil)"},
+		{[]string{"SET", "a", "1"}, `"OK"`},
+		{[]string{"SET", "b", "two words"}, `"OK"`},
+		{[]string{"GET", "b"}, `"two words"`},
+		{[]string{"SET", "a", "x", "NX"}, "(nil)"},
+		{[]string{"SET", "c", "x", "XX"}, "(nil)"},
+		{[]string{"SET", "a", "10", "XX"}, `"OK"`},
+		{[]string{"INCR", "a"}, "(integer) 11"},
+		{[]string{"INCR", "new"}, "(integer) 1"},
+		{[]string{"INCR", "b"}, "(error) ERR value is not an integer or out of range"},
+		{[]string{"MGET", "a", "nope", "new"}, `["11", (nil), "1"]`},
+		{[]string{"EXISTS", "a", "a", "nope"}, "(integer) 2"},
+		{[]string{"DEL", "a", "nope"}, "(integer) 1"},
+		{[]string{"EXISTS", "a"}, "(integer) 0"},
+		{[]string{"SET", "a", "1", "NX", "XX"}, "(error) ERR syntax error"},
+		{[]string{"SET", "a", "1", "EX", "0"}, "(error) ERR invalid expire time in 'set' command"},
+		{[]string{"SET", "a", "1", "EX"}, "(error) ERR syntax error"},
+		{[]string{"GET"}, "(error) ERR wrong number of arguments for 'get' command"},
+		{[]string{"FLUSHALL"}, "(error) ERR unknown command 'flushall'"},
+	}
+
+	for _, tt := range tests {
+		if got := do(t, c, tt.args...); got != tt.want {
+			t.Errorf("%v: Expected %s, got %s", tt.args, tt.want, got)
+		}
+	}
+}
+
+func TestKeysGlob(t *testing.T) {
+	_, c := startServer(t, NewMemoryStorage())
+	for _, key := range []string{"user:1", "user:2", "user:10", "user:1/profile", "order:1", "h?llo", "hello", "hallo"} {
+		do(t, c, "SET", key, "v")
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"*", `["h?llo", "hallo", "hello", "order:1", "user:1", "user:1/profile", "user:10", "user:2"]`},
+		{"user:*", `["user:1", "user:1/profile", "user:10", "user:2"]`},
+		{"user:?", `["user:1", "user:2"]`},
+		{"h[ae]llo", `["hallo", "hello"]`},
+		{"h[^e]llo", `["h?llo", "hallo"]`},
+		{"h[a-b]llo", `["hallo"]`},
+		{`h\?llo`, `["h?llo"]`},
+		{"nothing*", `[]`},
+	}
+	for _, tt := range tests {
+		if got := do(t, c, "KEYS", tt.pattern); got != tt.want {
+			t.Errorf("KEYS %s: Expected %s, got %s", tt.pattern, tt.want, got)
+		}
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"", "", true},
+		{"*", "", true},
+		{"a*", "abc", true},
+		{"*c", "abc", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"**a", "ba", true},
+		{"?b", "ab", true},
+		{"?", "", false},
+		{"[", "[", true},
+		{"a[", "ab", false},
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{`a\`, `a\`, true},
+		{"h[a-b]*o", "hbllo", true},
+		{"*[0-9]", "user:x", false},
+		{"*[0-9]", "user:1", true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q): Expected %v, got %v", tt.pattern, tt.s, tt.want, got)
+		}
+	}
+}
+
+// Рекурсивний матчер на такому шаблоні працював би роками
+func TestGlobMatchNoBacktrackingBlowup(t *testing.T) {
+	pattern := strings.Repeat("*a", 20) + "*b"
+	key := strings.Repeat("a", 100)
+
+	start := time.Now()
+	if globMatch(pattern, key) {
+		t.Error("Expected no match")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected fast match, took %v", elapsed)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	server, c := startServer(t, NewMemoryStorage())
+	now := time.Now()
+	server.mu.Lock()
+	server.now = func() time.Time { return now }
+	server.mu.Unlock()
+	advance := func(d time.Duration) {
+		server.mu.Lock()
+		now = now.Add(d)
+		server.mu.Unlock()
+	}
+
+	do(t, c, "SET", "session", "abc", "EX", "10")
+	do(t, c, "SET", "counter", "5", "PX", "1500")
+	do(t, c, "SET", "forever", "x")
+
+	if got := do(t, c, "TTL", "session"); got != "(integer) 10" {
+		t.Errorf("Expected TTL 10, got %s", got)
+	}
+	if got := do(t, c, "TTL", "forever"); got != "(integer) -1" {
+		t.Errorf("Expected -1 for key without TTL, got %s", got)
+	}
+	if got := do(t, c, "TTL", "missing"); got != "(integer) -2" {
+		t.Errorf("Expected -2 for missing key, got %s", got)
+	}
+
+	// INCR зберігає TTL, SET без EX - знімає
+	do(t, c, "INCR", "counter")
+	advance(time.Second)
+	if got := do(t, c, "GET", "counter"); got != `"6"` {
+		t.Errorf("Expected counter alive, got %s", got)
+	}
+	advance(time.Second)
+	if got := do(t, c, "GET", "counter"); got != "(nil)" {
+		t.Errorf("Expected counter expired, got %s", got)
+	}
+
+	do(t, c, "SET", "session", "new")
+	if got := do(t, c, "TTL", "session"); got != "(integer) -1" {
+		t.Errorf("Expected plain SET to clear TTL, got %s", got)
+	}
+
+	if got := do(t, c, "EXPIRE", "forever", "5"); got != "(integer) 1" {
+		t.Errorf("Expected EXPIRE 1, got %s", got)
+	}
+	if got := do(t, c, "EXPIRE", "missing", "5"); got != "(integer) 0" {
+		t.Errorf("Expected EXPIRE 0 for missing key, got %s", got)
+	}
+	advance(5 * time.Second)
+	if got := do(t, c, "KEYS", "*"); got != `["session"]` {
+		t.Errorf("Expected expired keys hidden from KEYS, got %s", got)
+	}
+
+	do(t, c, "EXPIRE", "session", "-1")
+	if got := do(t, c, "EXISTS", "session"); got != "(integer) 0" {
+		t.Errorf("Expected negative EXPIRE to delete, got %s", got)
+	}
+}
+
+func TestPipelining(t *testing.T) {
+	_, c := startServer(t, NewMemoryStorage())
+
+	cmds := [][]string{{"SET", "k", "v"}}
+	for i := 0; i < 1000; i++ {
+		cmds = append(cmds, []string{"INCR", "n"})
+	}
+	cmds = append(cmds, []string{"GET", "k"}, []string{"BAD"})
+
+	replies, err := c.Pipeline(cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != len(cmds) {
+		t.Fatalf("Expected %d replies, got %d", len(cmds), len(replies))
+	}
+	if got := replies[1000].String(); got != "(integer) 1000" {
+		t.Errorf("Expected replies in order, got %s", got)
+	}
+	if got := replies[1001].String(); got != `"v"` {
+		t.Errorf("Expected \"v\", got %s", got)
+	}
+	if replies[1002].Kind != '-' {
+		t.Errorf("Expected error for unknown command, got %s", replies[1002])
+	}
+}
+
+func TestInlineAndProtocolErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	server := NewServer(NewMemoryStorage())
+	server.MaxBulkLen = 16
+	go server.Serve(listener)
+
+	send := func(raw string) string {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.Write([]byte(raw))
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+		var out strings.Builder
+		buf := make([]byte, 256)
+		for {
+			n, err := conn.Read(buf)
+			out.Write(buf[:n])
+			if err != nil || strings.Count(out.String(), "\r\n") >= 2 {
+				return out.String()
+			}
+		}
+	}
+
+	// telnet-стиль: inline-команди
+	if got := send("SET greeting hello\r\nGET greeting\r\n"); got != "+OK\r\n$5\r\nhello\r\n" {
+		t.Errorf("Unexpected inline reply %q", got)
+	}
+
+	// bulk більший за ліміт: помилка і закриття, без виділення пам'яті
+	if got := send("*2\r\n$3\r\nGET\r\n$999999999\r\n"); !strings.HasPrefix(got, "-ERR protocol error") {
+		t.Errorf("Expected protocol error, got %q", got)
+	}
+}
+
+// Вкладені масиви: сервер не рекурсує в ReadCommand, клієнт обмежений MaxDepth
+func TestNestedArrays(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go NewServer(NewMemoryStorage()).Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go conn.Write([]byte(strings.Repeat("*1\r\n", 1<<20)))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	reply, err := NewReader(conn).ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(reply.Str, "ERR protocol error") {
+		t.Errorf("Expected protocol error, got %v", reply)
+	}
+
+	tests := []struct {
+		depth int
+		ok    bool
+	}{
+		{MaxDepth, true},
+		{MaxDepth + 1, false},
+		{1 << 16, false},
+	}
+	for _, tt := range tests {
+		raw := strings.Repeat("*1\r\n", tt.depth) + ":1\r\n"
+		_, err := NewReader(strings.NewReader(raw)).ReadValue()
+		if (err == nil) != tt.ok {
+			t.Errorf("depth %d: Expected ok=%v, got %v", tt.depth, tt.ok, err)
+		}
+		if err != nil && !errors.Is(err, ErrProtocol) {
+			t.Errorf("depth %d: Expected ErrProtocol, got %v", tt.depth, err)
+		}
+	}
+}
+
+func TestAnyStorage(t *testing.T) {
+	stores := map[string]Storage{
+		"MemoryStorage": NewMemoryStorage(),
+		"CacheStorage":  NewCacheStorage(NewCache()),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			_, c := startServer(t, store)
+			do(t, c, "SET", "a", "1")
+			do(t, c, "INCR", "a")
+			if got := do(t, c, "MGET", "a", "b"); got != `["2", (nil)]` {
+				t.Errorf("Expected [2, nil], got %s", got)
+			}
+			if v, _ := store.Load("a"); v != "2" {
+				t.Errorf("Expected value in underlying storage, got %q", v)
+			}
+		})
+	}
+}
+
+func TestConcurrentIncr(t *testing.T) {
+	server, _ := startServer(t, NewMemoryStorage())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go server.Serve(listener)
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			c, err := Dial(listener.Addr().String())
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer c.Close()
+			for j := 0; j < 100; j++ {
+				if _, err := c.Do("INCR", "n"); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, _ := Dial(listener.Addr().String())
+	defer c.Close()
+	if got := do(t, c, "GET", "n"); got != fmt.Sprintf("%q", "1000") {
+		t.Errorf("Expected 1000 (INCR atomic), got %s", got)
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	store := NewMemoryStorage()
+	server, c := startServer(t, store)
+	do(t, c, "SET", "short", "v", "PX", "20")
+	do(t, c, "SET", "long", "v")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Janitor(ctx, 10*time.Millisecond)
+
+	// Ключ ніхто не читає, але з Storage він зникає
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		server.mu.Lock()
+		exists := store.Exists("short")
+		server.mu.Unlock()
+		if !exists {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if store.Exists("short") || !store.Exists("long") {
+		t.Errorf("Expected janitor to remove only expired keys, got %v", store.Keys())
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ============= RESP2 =============
+// Протокол Redis: кожне значення починається з байта типу і закінчується \r\n.
+//
+//	+OK\r\n                  simple string
+//	-ERR message\r\n         error
+//	:42\r\n                  integer
+//	$5\r\nhello\r\n          bulk string ($-1\r\n - null)
+//	*2\r\n$3\r\nGET\r\n...   array (*-1\r\n - null)
+//
+// Клієнт шле команди масивом bulk strings. Для telnet/nc підтримуються
+// також inline-команди: "SET key value\r\n".
+
+const (
+	DefaultMaxBulkLen  = 16 << 20 // 16 MB
+	DefaultMaxArrayLen = 1 << 20
+	// MaxDepth - вкладеність масивів у ReadValue. Без ліміту "*1\r\n*1\r\n..."
+	// з кількох байт на рівень переповнює стек, і це fatal, не panic.
+	MaxDepth = 32
+)
+
+var ErrProtocol = errors.New("protocol error")
+
+type Value struct {
+	Kind  byte // '+', '-', ':', '$', '*'
+	Str   string
+	Int   int64
+	Array []Value
+	Null  bool
+}
+
+func (v Value) String() string {
+	switch {
+	case v.Null:
+		return "(nil)"
+	case v.Kind == ':':
+		return "(integer) " + strconv.FormatInt(v.Int, 10)
+	case v.Kind == '-':
+		return "(error) " + v.Str
+	case v.Kind == '*':
+		parts := make([]string, len(v.Array))
+		for i, item := range v.Array {
+			parts[i] = item.String()
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		return strconv.Quote(v.Str)
+	}
+}
+
+// ============= Reader =============
+
+type Reader struct {
+	r           *bufio.Reader
+	MaxBulkLen  int
+	MaxArrayLen int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:           bufio.NewReader(r),
+		MaxBulkLen:  DefaultMaxBulkLen,
+		MaxArrayLen: DefaultMaxArrayLen,
+	}
+}
+
+// Buffered - скільки байт уже прочитано з сокета і чекає розбору.
+// Сервер не робить Flush, поки є наступна pipelined-команда.
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+// ReadCommand читає одну команду: масив bulk strings або inline-рядок
+func (r *Reader) ReadCommand() ([]string, error) {
+	prefix, err := r.r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if prefix[0] != '*' {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		return strings.Fields(line), nil
+	}
+
+	// Команда - завжди плоский масив bulk strings, тож розбираємо її
+	// тут, не заходячи в рекурсивний ReadValue
+	r.r.Discard(1) // '*' уже перевірено через Peek
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	n, err := r.readLength(line, r.MaxArrayLen)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%w: null command", ErrProtocol)
+	}
+	args := make([]string, 0, min(n, 1024))
+	for range n {
+		kind, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if kind != '$' {
+			return nil, fmt.Errorf("%w: command arguments must be bulk strings", ErrProtocol)
+		}
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		item, err := r.readBulk(line)
+		if err != nil {
+			return nil, err
+		}
+		if item.Null {
+			return nil, fmt.Errorf("%w: command arguments must be bulk strings", ErrProtocol)
+		}
+		args = append(args, item.Str)
+	}
+	return args, nil
+}
+
+// ReadValue читає довільне значення (відповідь сервера); масиви
+// глибші за MaxDepth - ErrProtocol
+func (r *Reader) ReadValue() (Value, error) {
+	return r.readValue(0)
+}
+
+func (r *Reader) readValue(depth int) (Value, error) {
+	kind, err := r.r.ReadByte()
+	if err != nil {
+		return Value{}, err
+	}
+	line, err := r.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch kind {
+	case '+', '-':
+		return Value{Kind: kind, Str: line}, nil
+
+	case ':':
+		n, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("%w: invalid integer %q", ErrProtocol, line)
+		}
+		return Value{Kind: kind, Int: n}, nil
+
+	case '$':
+		return r.readBulk(line)
+
+	case '*':
+		if depth >= MaxDepth {
+			return Value{}, fmt.Errorf("%w: arrays nested deeper than %d", ErrProtocol, MaxDepth)
+		}
+		n, err := r.readLength(line, r.MaxArrayLen)
+		if err != nil || n < 0 {
+			return Value{Kind: kind, Null: true}, err
+		}
+		v := Value{Kind: kind, Array: make([]Value, 0, min(n, 1024))}
+		for range n {
+			item, err := r.readValue(depth + 1)
+			if err != nil {
+				return Value{}, err
+			}
+			v.Array = append(v.Array, item)
+		}
+		return v, nil
+
+	default:
+		return Value{}, fmt.Errorf("%w: unknown type byte %q", ErrProtocol, kind)
+	}
+}
+
+// readBulk читає тіло bulk string, заголовок якого ($n) уже прочитано
+func (r *Reader) readBulk(line string) (Value, error) {
+	n, err := r.readLength(line, r.MaxBulkLen)
+	if err != nil || n < 0 {
+		return Value{Kind: '$', Null: true}, err
+	}
+	buf := make([]byte, n+2)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return Value{}, err
+	}
+	if buf[n] != '\r' || buf[n+1] != '\n' {
+		return Value{}, fmt.Errorf("%w: bulk string not terminated by CRLF", ErrProtocol)
+	}
+	return Value{Kind: '$', Str: string(buf[:n])}, nil
+}
+
+// readLength: -1 - null; розмір перевіряється до виділення пам'яті
+func (r *Reader) readLength(line string, limit int) (int, error) {
+	n, err := strconv.Atoi(line)
+	switch {
+	case err != nil || n < -1:
+		return 0, fmt.Errorf("%w: invalid length %q", ErrProtocol, line)
+	case n > limit:
+		return 0, fmt.Errorf("%w: length %d exceeds limit %d", ErrProtocol, n, limit)
+	}
+	return n, nil
+}
+
+func (r *Reader) readLine() (string, error) {
+	line, err := r.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return "", fmt.Errorf("%w: line too long", ErrProtocol)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// ============= Writer =============
+
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) WriteSimple(s string) {
+	w.w.WriteByte('+')
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteError(s string) {
+	w.w.WriteByte('-')
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteInt(n int64) {
+	w.w.WriteByte(':')
+	w.w.WriteString(strconv.FormatInt(n, 10))
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteBulk(s string) {
+	w.w.WriteByte('$')
+	w.w.WriteString(strconv.Itoa(len(s)))
+	w.w.WriteString("\r\n")
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteNull() {
+	w.w.WriteString("$-1\r\n")
+}
+
+// WriteArray пише лише заголовок; елементи пишуться окремими викликами
+func (w *Writer) WriteArray(n int) {
+	w.w.WriteByte('*')
+	w.w.WriteString(strconv.Itoa(n))
+	w.w.WriteString("\r\n")
+}
+
+// WriteCommand - клієнтська сторона: масив bulk strings
+func (w *Writer) WriteCommand(args ...string) {
+	w.WriteArray(len(args))
+	for _, arg := range args {
+		w.WriteBulk(arg)
+	}
+}
+
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
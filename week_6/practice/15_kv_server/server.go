@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============= KV Server =============
+
+const (
+	errSyntax     = "ERR syntax error"
+	errNotInteger = "ERR value is not an integer or out of range"
+	errInvalidTTL = "ERR invalid expire time in 'set' command"
+)
+
+// Server виконує команди по одній під mutex - як однопотоковий Redis.
+// Тому INCR і SET NX атомарні навіть поверх непотокобезпечного Storage.
+// Storage не знає про TTL, тож терміни зберігаються поруч у expires.
+type Server struct {
+	mu      sync.Mutex
+	store   Storage
+	expires map[string]time.Time
+	now     func() time.Time
+
+	MaxBulkLen  int
+	MaxArrayLen int
+}
+
+func NewServer(store Storage) *Server {
+	return &Server{
+		store:       store,
+		expires:     make(map[string]time.Time),
+		now:         time.Now,
+		MaxBulkLen:  DefaultMaxBulkLen,
+		MaxArrayLen: DefaultMaxArrayLen,
+	}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+func (s *Server) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	r := NewReader(conn)
+	r.MaxBulkLen = s.MaxBulkLen
+	r.MaxArrayLen = s.MaxArrayLen
+	w := NewWriter(conn)
+
+	for {
+		args, err := r.ReadCommand()
+		if err != nil {
+			if errors.Is(err, ErrProtocol) {
+				// Після помилки протоколу потік не синхронізувати - як і Redis, закриваємо
+				w.WriteError("ERR " + err.Error())
+				w.Flush()
+			} else if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("%s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.execute(w, args)
+
+		// Pipelining: поки в буфері є наступні команди, відповіді
+		// накопичуються і йдуть одним write
+		if r.Buffered() == 0 || quit {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+		if quit {
+			return
+		}
+	}
+}
+
+// execute повертає true для QUIT
+func (s *Server) execute(w *Writer, args []string) bool {
+	cmd := strings.ToUpper(args[0])
+	args = args[1:]
+
+	if cmd == "QUIT" {
+		w.WriteSimple("OK")
+		return true
+	}
+
+	spec, ok := commands[cmd]
+	if !ok {
+		w.WriteError("ERR unknown command '" + strings.ToLower(cmd) + "'")
+		return false
+	}
+	if len(args) < spec.minArgs || (spec.maxArgs >= 0 && len(args) > spec.maxArgs) {
+		w.WriteError("ERR wrong number of arguments for '" + strings.ToLower(cmd) + "' command")
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spec.fn(s, w, args)
+	return false
+}
+
+type command struct {
+	minArgs int
+	maxArgs int // -1 - без обмеження
+	fn      func(s *Server, w *Writer, args []string)
+}
+
+var commands = map[string]command{
+	"PING":    {0, 1, (*Server).cmdPing},
+	"GET":     {1, 1, (*Server).cmdGet},
+	"SET":     {2, -1, (*Server).cmdSet},
+	"DEL":     {1, -1, (*Server).cmdDel},
+	"EXISTS":  {1, -1, (*Server).cmdExists},
+	"KEYS":    {1, 1, (*Server).cmdKeys},
+	"EXPIRE":  {2, 2, (*Server).cmdExpire},
+	"TTL":     {1, 1, (*Server).cmdTTL},
+	"INCR":    {1, 1, (*Server).cmdIncr},
+	"MGET":    {1, -1, (*Server).cmdMGet},
+	"COMMAND": {0, -1, (*Server).cmdCommand},
+}
+
+// ============= Commands =============
+
+func (s *Server) cmdPing(w *Writer, args []string) {
+	if len(args) == 1 {
+		w.WriteBulk(args[0])
+		return
+	}
+	w.WriteSimple("PONG")
+}
+
+func (s *Server) cmdGet(w *Writer, args []string) {
+	value, ok := s.get(args[0])
+	if !ok {
+		w.WriteNull()
+		return
+	}
+	w.WriteBulk(value)
+}
+
+// SET key value [EX seconds | PX milliseconds] [NX | XX]
+func (s *Server) cmdSet(w *Writer, args []string) {
+	key, value := args[0], args[1]
+	var ttl time.Duration
+	var nx, xx bool
+
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if ttl != 0 || i+1 == len(args) {
+				w.WriteError(errSyntax)
+				return
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				w.WriteError(errNotInteger)
+				return
+			}
+			unit := time.Second
+			if opt == "PX" {
+				unit = time.Millisecond
+			}
+			if n <= 0 || n > math.MaxInt64/int64(unit) {
+				w.WriteError(errInvalidTTL)
+				return
+			}
+			ttl = time.Duration(n) * unit
+		default:
+			w.WriteError(errSyntax)
+			return
+		}
+	}
+	if nx && xx {
+		w.WriteError(errSyntax)
+		return
+	}
+
+	_, exists := s.get(key)
+	if (nx && exists) || (xx && !exists) {
+		w.WriteNull()
+		return
+	}
+
+	if err := s.store.Save(key, value); err != nil {
+		w.WriteError("ERR " + err.Error())
+		return
+	}
+	// SET без EX знімає попередній TTL, як у Redis
+	delete(s.expires, key)
+	if ttl > 0 {
+		s.expires[key] = s.now().Add(ttl)
+	}
+	w.WriteSimple("OK")
+}
+
+func (s *Server) cmdDel(w *Writer, args []string) {
+	var deleted int64
+	for _, key := range args {
+		if _, ok := s.get(key); ok {
+			s.remove(key)
+			deleted++
+		}
+	}
+	w.WriteInt(deleted)
+}
+
+// EXISTS рахує повтори: EXISTS a a -> 2, як у Redis
+func (s *Server) cmdExists(w *Writer, args []string) {
+	var count int64
+	for _, key := range args {
+		if _, ok := s.get(key); ok {
+			count++
+		}
+	}
+	w.WriteInt(count)
+}
+
+func (s *Server) cmdKeys(w *Writer, args []string) {
+	var matched []string
+	for _, key := range s.store.Keys() {
+		if s.expired(key) {
+			s.remove(key)
+			continue
+		}
+		if globMatch(args[0], key) {
+			matched = append(matched, key)
+		}
+	}
+	sort.Strings(matched)
+
+	w.WriteArray(len(matched))
+	for _, key := range matched {
+		w.WriteBulk(key)
+	}
+}
+
+func (s *Server) cmdExpire(w *Writer, args []string) {
+	seconds, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || seconds > math.MaxInt64/int64(time.Second) {
+		w.WriteError(errNotInteger)
+		return
+	}
+	if _, ok := s.get(args[0]); !ok {
+		w.WriteInt(0)
+		return
+	}
+
+	// Непозитивний TTL - ключ видаляється одразу
+	if seconds <= 0 {
+		s.remove(args[0])
+	} else {
+		s.expires[args[0]] = s.now().Add(time.Duration(seconds) * time.Second)
+	}
+	w.WriteInt(1)
+}
+
+// TTL: -2 - ключа немає, -1 - без терміну
+func (s *Server) cmdTTL(w *Writer, args []string) {
+	if _, ok := s.get(args[0]); !ok {
+		w.WriteInt(-2)
+		return
+	}
+	at, ok := s.expires[args[0]]
+	if !ok {
+		w.WriteInt(-1)
+		return
+	}
+	w.WriteInt(int64(at.Sub(s.now()).Round(time.Second) / time.Second))
+}
+
+func (s *Server) cmdIncr(w *Writer, args []string) {
+	key := args[0]
+	var n int64
+	if value, ok := s.get(key); ok {
+		var err error
+		if n, err = strconv.ParseInt(value, 10, 64); err != nil {
+			w.WriteError(errNotInteger)
+			return
+		}
+	}
+	if n == math.MaxInt64 {
+		w.WriteError("ERR increment or decrement would overflow")
+		return
+	}
+	n++
+
+	// INCR зберігає TTL ключа
+	if err := s.store.Save(key, strconv.FormatInt(n, 10)); err != nil {
+		w.WriteError("ERR " + err.Error())
+		return
+	}
+	w.WriteInt(n)
+}
+
+func (s *Server) cmdMGet(w *Writer, args []string) {
+	w.WriteArray(len(args))
+	for _, key := range args {
+		if value, ok := s.get(key); ok {
+			w.WriteBulk(value)
+		} else {
+			w.WriteNull()
+		}
+	}
+}
+
+// COMMAND / COMMAND DOCS шле redis-cli при підключенні - порожня відповідь
+func (s *Server) cmdCommand(w *Writer, args []string) {
+	w.WriteArray(0)
+}
+
+// ============= Expiry =============
+
+// get - Load з лінивим видаленням простроченого ключа
+func (s *Server) get(key string) (string, bool) {
+	if s.expired(key) {
+		s.remove(key)
+		return "", false
+	}
+	if !s.store.Exists(key) {
+		return "", false
+	}
+	value, err := s.store.Load(key)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+func (s *Server) expired(key string) bool {
+	at, ok := s.expires[key]
+	return ok && !s.now().Before(at)
+}
+
+func (s *Server) remove(key string) {
+	s.store.Delete(key)
+	delete(s.expires, key)
+}
+
+// Janitor періодично видаляє прострочені ключі, до яких ніхто
+// не звертається - інакше вони жили б у Storage вічно
+func (s *Server) Janitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			for key := range s.expires {
+				if s.expired(key) {
+					s.remove(key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ============= Storage Interface =============
+// Той самий інтерфейс, що й у week_2/solutions/solution_3.go. Сервер
+// серіалізує команди сам, тому реалізаціям не обов'язково бути потокобезпечними.
+
+type Storage interface {
+	Save(key, value string) error
+	Load(key string) (string, error)
+	Delete(key string) error
+	Exists(key string) bool
+	Keys() []string
+	Clear() error
+}
+
+// ============= Memory Storage =============
+
+type MemoryStorage struct {
+	data map[string]string
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		data: make(map[string]string),
+	}
+}
+
+func (m *MemoryStorage) Save(key, value string) error {
+	m.data[key] = value
+	return nil
+}
+
+func (m *MemoryStorage) Load(key string) (string, error) {
+	value, exists := m.data[key]
+	if !exists {
+		return "", fmt.Errorf("key not found: %s", key)
+	}
+	return value, nil
+}
+
+func (m *MemoryStorage) Delete(key string) error {
+	if !m.Exists(key) {
+		return fmt.Errorf("key not found: %s", key)
+	}
+	delete(m.data, key)
+	return nil
+}
+
+func (m *MemoryStorage) Exists(key string) bool {
+	_, exists := m.data[key]
+	return exists
+}
+
+func (m *MemoryStorage) Keys() []string {
+	keys := make([]string, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (m *MemoryStorage) Clear() error {
+	m.data = make(map[string]string)
+	return nil
+}
+
+// ============= Cache adapter =============
+
+// Cache - з week_23/maps/18_cache.go
+type Cache struct {
+	mu    sync.RWMutex
+	items map[string]string
+}
+
+func NewCache() *Cache {
+	return &Cache{items: make(map[string]string)}
+}
+
+func (c *Cache) Get(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.items[key]
+	return v, ok
+}
+
+func (c *Cache) Set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+}
+
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+// CacheStorage - Adapter: Cache не має Keys/Clear, тож адаптер додає їх
+// через прямий доступ до items (той самий пакет)
+type CacheStorage struct {
+	cache *Cache
+}
+
+func NewCacheStorage(cache *Cache) *CacheStorage {
+	return &CacheStorage{cache: cache}
+}
+
+func (s *CacheStorage) Save(key, value string) error {
+	s.cache.Set(key, value)
+	return nil
+}
+
+func (s *CacheStorage) Load(key string) (string, error) {
+	value, ok := s.cache.Get(key)
+	if !ok {
+		return "", fmt.Errorf("key not found: %s", key)
+	}
+	return value, nil
+}
+
+func (s *CacheStorage) Delete(key string) error {
+	s.cache.Delete(key)
+	return nil
+}
+
+func (s *CacheStorage) Exists(key string) bool {
+	_, ok := s.cache.Get(key)
+	return ok
+}
+
+func (s *CacheStorage) Keys() []string {
+	s.cache.mu.RLock()
+	defer s.cache.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.cache.items))
+	for key := range s.cache.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *CacheStorage) Clear() error {
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+	s.cache.items = make(map[string]string)
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ============= Balancer =============
+// Strategy: алгоритм вибору upstream підміняється без зміни Proxy.
+
+type Balancer interface {
+	// Pick обирає upstream з pool, для якого usable повертає true.
+	// nil - жоден не підходить.
+	Pick(r *http.Request, pool []*Upstream, usable func(*Upstream) bool) *Upstream
+}
+
+// rebuilder - балансувальники зі станом, залежним від складу пулу
+type rebuilder interface {
+	Rebuild(pool []*Upstream)
+}
+
+// ============= Round Robin =============
+
+type RoundRobin struct {
+	next atomic.Uint64
+}
+
+func (rr *RoundRobin) Pick(r *http.Request, pool []*Upstream, usable func(*Upstream) bool) *Upstream {
+	n := uint64(len(pool))
+	start := rr.next.Add(1) - 1
+	for i := range n {
+		if u := pool[(start+i)%n]; usable(u) {
+			return u
+		}
+	}
+	return nil
+}
+
+// ============= Least Connections =============
+// Найменше активних запитів. Для повільних або нерівних бекендів
+// краще за round-robin: туди, де черга, нові запити не йдуть.
+
+type LeastConn struct {
+	rr RoundRobin // порядок обходу, щоб при рівності не бити завжди в перший
+}
+
+func (lc *LeastConn) Pick(r *http.Request, pool []*Upstream, usable func(*Upstream) bool) *Upstream {
+	n := uint64(len(pool))
+	start := lc.rr.next.Add(1) - 1
+
+	var best *Upstream
+	for i := range n {
+		u := pool[(start+i)%n]
+		if usable(u) && (best == nil || u.Active() < best.Active()) {
+			best = u
+		}
+	}
+	return best
+}
+
+// ============= Consistent Hash =============
+// Запити з однаковим значенням заголовка (X-User-ID, сесія) йдуть на той
+// самий upstream - зручно для локальних кешів. Кільце з віртуальними
+// вузлами: при зміні пулу переїжджає лише ~1/n ключів, а якщо upstream
+// недоступний, береться наступний за годинниковою стрілкою.
+
+type ConsistentHash struct {
+	Header   string
+	Replicas int // віртуальних вузлів на upstream; 0 - 100
+
+	fallback RoundRobin // запит без заголовка
+
+	mu     sync.RWMutex
+	hashes []uint32
+	owners map[uint32]*Upstream
+}
+
+func NewConsistentHash(header string) *ConsistentHash {
+	return &ConsistentHash{Header: header, Replicas: 100}
+}
+
+func (ch *ConsistentHash) Rebuild(pool []*Upstream) {
+	replicas := ch.Replicas
+	if replicas <= 0 {
+		replicas = 100
+	}
+
+	owners := make(map[uint32]*Upstream, len(pool)*replicas)
+	hashes := make([]uint32, 0, len(pool)*replicas)
+	for _, u := range pool {
+		for i := range replicas {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + "#" + u.URL.String()))
+			if _, taken := owners[h]; taken {
+				continue
+			}
+			owners[h] = u
+			hashes = append(hashes, h)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	ch.mu.Lock()
+	ch.hashes, ch.owners = hashes, owners
+	ch.mu.Unlock()
+}
+
+func (ch *ConsistentHash) Pick(r *http.Request, pool []*Upstream, usable func(*Upstream) bool) *Upstream {
+	key := r.Header.Get(ch.Header)
+	if key == "" {
+		return ch.fallback.Pick(r, pool, usable)
+	}
+
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if len(ch.hashes) == 0 {
+		return nil
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(ch.hashes), func(i int) bool { return ch.hashes[i] >= h })
+
+	for i := range len(ch.hashes) {
+		if u := ch.owners[ch.hashes[(start+i)%len(ch.hashes)]]; usable(u) {
+			return u
+		}
+	}
+	return nil
+}
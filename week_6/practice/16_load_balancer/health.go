@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============= Health checks =============
+
+// HealthCheckConfig - активна перевірка: GET Path кожні Interval
+type HealthCheckConfig struct {
+	Path               string // "" - вимкнено
+	Interval           time.Duration
+	Timeout            time.Duration
+	HealthyThreshold   int // успіхів підряд, щоб повернути
+	UnhealthyThreshold int // невдач підряд, щоб прибрати
+}
+
+// PassiveConfig - пасивна перевірка по живому трафіку: MaxFails помилок
+// підряд (транспорт або 502/503/504) - upstream викидається на EjectDuration
+type PassiveConfig struct {
+	MaxFails      int // 0 - вимкнено
+	EjectDuration time.Duration
+}
+
+// RunHealthChecks перевіряє всі upstream поточного пулу, поки ctx живий
+func (p *Proxy) RunHealthChecks(ctx context.Context) {
+	cfg := p.cfg.HealthCheck
+	if cfg.Path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		p.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Proxy) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, u := range p.Upstreams() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.check(ctx, u)
+			if u.reportCheck(err, p.cfg.HealthCheck, time.Now()) {
+				p.logf("health: %s healthy=%v (%v)", u.URL, err == nil, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *Proxy) check(ctx context.Context, u *Upstream) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.HealthCheck.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.URL.JoinPath(p.cfg.HealthCheck.Path).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
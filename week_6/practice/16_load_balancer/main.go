@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============= User API backend =============
+// Спрощений User API з week_6/practice/02_http_server - кілька
+// екземплярів, які і будемо балансувати
+
+type UserAPI struct {
+	Name  string
+	Delay time.Duration
+	sick  atomic.Bool // /health повертає 503
+}
+
+func (api *UserAPI) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		if api.sick.Load() {
+			http.Error(w, "unhealthy", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(api.Delay)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Instance", api.Name)
+		json.NewEncoder(w).Encode(map[string]string{"id": r.PathValue("id"), "served_by": api.Name})
+	})
+	return mux
+}
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════╗")
+	fmt.Println("║   Reverse Proxy / Load Balancer        ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	apis := []*UserAPI{{Name: "users-1"}, {Name: "users-2"}, {Name: "users-3"}}
+	var urls []string
+	var servers []*httptest.Server
+	for _, api := range apis {
+		srv := httptest.NewServer(api.Handler())
+		defer srv.Close()
+		servers = append(servers, srv)
+		urls = append(urls, srv.URL)
+	}
+
+	cfg := DefaultConfig()
+	cfg.HealthCheck.Interval = 100 * time.Millisecond
+	cfg.Passive = PassiveConfig{MaxFails: 1, EjectDuration: time.Minute}
+	cfg.Headers.SetResponse = map[string]string{"Server": "lb"}
+	cfg.Logf = func(format string, args ...any) { fmt.Printf("   📝 "+format+"\n", args...) }
+
+	proxy, err := New(cfg, urls...)
+	if err != nil {
+		panic(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go proxy.RunHealthChecks(ctx)
+
+	front := httptest.NewServer(proxy)
+	defer front.Close()
+
+	get := func(path string, header ...string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, front.URL+path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, err.Error()
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode, resp.Header.Get("X-Instance")
+	}
+
+	fmt.Println("\n🔄 1. Round-robin:")
+	var served []string
+	for i := range 6 {
+		_, instance := get(fmt.Sprintf("/users/%d", i))
+		served = append(served, instance)
+	}
+	fmt.Println("  ", strings.Join(served, " -> "))
+
+	fmt.Println("\n💥 2. users-2 падає: GET повторюється на іншому upstream:")
+	servers[1].Close()
+	for i := range 3 {
+		code, instance := get(fmt.Sprintf("/users/%d", i))
+		fmt.Printf("   GET /users/%d -> %d від %s\n", i, code, instance)
+	}
+
+	fmt.Println("\n🩺 3. Активна перевірка: users-3 звітує 503 на /health:")
+	apis[2].sick.Store(true)
+	time.Sleep(300 * time.Millisecond)
+	for _, s := range proxy.Status() {
+		fmt.Printf("   %s healthy=%v ejected=%v\n", s.URL, s.Healthy, s.Ejected)
+	}
+	apis[2].sick.Store(false)
+
+	fmt.Println("\n🔁 4. Reload: новий список upstream без перезапуску:")
+	api4 := &UserAPI{Name: "users-4", Delay: 20 * time.Millisecond}
+	srv4 := httptest.NewServer(api4.Handler())
+	defer srv4.Close()
+	if err := proxy.SetUpstreams([]string{urls[0], urls[2], srv4.URL}); err != nil {
+		panic(err)
+	}
+	time.Sleep(300 * time.Millisecond) // users-3 одужує після HealthyThreshold перевірок
+	fmt.Printf("   у пулі %d upstream\n", len(proxy.Upstreams()))
+
+	fmt.Println("\n#️⃣  5. Consistent hash за X-User-ID:")
+	hashCfg := cfg
+	hashCfg.Balancer = NewConsistentHash("X-User-ID")
+	hashProxy, _ := New(hashCfg, urls[0], urls[2], srv4.URL)
+	hashFront := httptest.NewServer(hashProxy)
+	defer hashFront.Close()
+	front = hashFront
+	for _, user := range []string{"user-1", "user-2", "user-3", "user-4", "user-1", "user-2", "user-3", "user-4"} {
+		_, instance := get("/users/me", "X-User-ID", user)
+		fmt.Printf("   %-6s -> %s\n", user, instance)
+	}
+
+	fmt.Println("\n⚖️  6. Least-connections: 5 клієнтів по 20 запитів, users-4 повільний (20ms):")
+	lcCfg := cfg
+	lcCfg.Balancer = &LeastConn{}
+	lcProxy, _ := New(lcCfg, urls[0], urls[2], srv4.URL)
+	lcFront := httptest.NewServer(lcProxy)
+	defer lcFront.Close()
+	front = lcFront
+
+	var mu sync.Mutex
+	counts := map[string]int{}
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 20 {
+				_, instance := get(fmt.Sprintf("/users/%d", i))
+				mu.Lock()
+				counts[instance]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("   %s: %d\n", name, counts[name])
+	}
+
+	fmt.Println("\n✅ Demo completed!")
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.HealthCheck.Path = ""
+	cfg.Passive = PassiveConfig{}
+	cfg.UpstreamHeader = "X-Upstream"
+	cfg.Logf = nil
+	return cfg
+}
+
+// backend відповідає своїм ім'ям; status != 0 - цим кодом
+func backend(t *testing.T, name string, status int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s:%s", name, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// deadURL - адреса, на якій ніхто не слухає
+func deadURL() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func newTestProxy(t *testing.T, cfg Config, urls ...string) *Proxy {
+	t.Helper()
+	p, err := New(cfg, urls...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func do(p http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body == "" {
+		req.Body = http.NoBody
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Add(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRoundRobin(t *testing.T) {
+	a, b := backend(t, "a", 0), backend(t, "b", 0)
+	p := newTestProxy(t, testConfig(), a.URL, b.URL)
+
+	var got []string
+	for range 4 {
+		got = append(got, do(p, "GET", "/", "").Body.String())
+	}
+	if want := "a:,b:,a:,b:"; strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+}
+
+func TestLeastConn(t *testing.T) {
+	var pool []*Upstream
+	for i := range 3 {
+		u, _ := NewUpstream(fmt.Sprintf("http://10.0.0.%d", i))
+		pool = append(pool, u)
+	}
+	pool[0].active.Store(5)
+	pool[1].active.Store(1)
+	pool[2].active.Store(3)
+
+	lc := &LeastConn{}
+	all := func(*Upstream) bool { return true }
+	req := httptest.NewRequest("GET", "/", nil)
+	for range 3 {
+		if got := lc.Pick(req, pool, all); got != pool[1] {
+			t.Fatalf("Expected %s, got %s", pool[1].URL, got.URL)
+		}
+	}
+
+	// Найменш завантажений недоступний - наступний за навантаженням
+	if got := lc.Pick(req, pool, func(u *Upstream) bool { return u != pool[1] }); got != pool[2] {
+		t.Errorf("Expected %s, got %s", pool[2].URL, got.URL)
+	}
+}
+
+func TestConsistentHash(t *testing.T) {
+	var pool []*Upstream
+	for i := range 4 {
+		u, _ := NewUpstream(fmt.Sprintf("http://10.0.0.%d:8080", i))
+		pool = append(pool, u)
+	}
+	ch := NewConsistentHash("X-User-ID")
+	ch.Rebuild(pool)
+
+	pick := func(user string, usable func(*Upstream) bool) *Upstream {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-User-ID", user)
+		return ch.Pick(req, pool, usable)
+	}
+	all := func(*Upstream) bool { return true }
+
+	before := map[string]*Upstream{}
+	used := map[*Upstream]bool{}
+	for i := range 1000 {
+		user := fmt.Sprint("user-", i)
+		before[user] = pick(user, all)
+		used[before[user]] = true
+		if pick(user, all) != before[user] {
+			t.Fatalf("Expected stable mapping for %s", user)
+		}
+	}
+	if len(used) != 4 {
+		t.Errorf("Expected keys on all 4 upstreams, got %d", len(used))
+	}
+
+	// Недоступний upstream: переїжджають лише його ключі
+	down := pool[2]
+	for user, prev := range before {
+		got := pick(user, func(u *Upstream) bool { return u != down })
+		if prev != down && got != prev {
+			t.Fatalf("Expected %s to stay on %s, moved to %s", user, prev.URL, got.URL)
+		}
+		if got == down {
+			t.Fatalf("Expected %s to leave unavailable upstream", user)
+		}
+	}
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	good := backend(t, "good", 0)
+	cfg := testConfig()
+	cfg.Passive = PassiveConfig{MaxFails: 1, EjectDuration: time.Minute}
+	dead := deadURL()
+	p := newTestProxy(t, cfg, dead, good.URL)
+
+	// Перший запит іде на мертвий upstream і повторюється на good
+	rec := do(p, "GET", "/users/1", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "good:" {
+		t.Fatalf("Expected 200 from good, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// Мертвий викинуто пасивно: далі навіть не пробуємо
+	status := p.Status()
+	if !status[0].Ejected || status[0].Failures != 1 {
+		t.Errorf("Expected dead upstream ejected, got %+v", status[0])
+	}
+	for range 4 {
+		if rec := do(p, "GET", "/", ""); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", rec.Code)
+		}
+	}
+	if got := p.Status()[0].Requests; got != 1 {
+		t.Errorf("Expected 1 request to ejected upstream, got %d", got)
+	}
+}
+
+func TestNoRetryForPost(t *testing.T) {
+	good := backend(t, "good", 0)
+
+	tests := []struct {
+		name   string
+		header []string
+		want   int
+	}{
+		{"plain POST is not retried", nil, http.StatusBadGateway},
+		{"POST with Idempotency-Key is retried", []string{"Idempotency-Key", "k1"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(), deadURL(), good.URL)
+			if rec := do(p, "POST", "/users", `{"name":"a"}`, tt.header...); rec.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	unavailable := backend(t, "unavailable", http.StatusServiceUnavailable)
+	echo := backend(t, "echo", 0)
+	p := newTestProxy(t, testConfig(), unavailable.URL, echo.URL)
+
+	rec := do(p, "PUT", "/users/1", `{"name":"Alice"}`)
+	if want := `echo:{"name":"Alice"}`; rec.Body.String() != want {
+		t.Errorf("Expected %s, got %s", want, rec.Body.String())
+	}
+}
+
+func TestAllUpstreamsFailing(t *testing.T) {
+	a := backend(t, "a", http.StatusServiceUnavailable)
+	b := backend(t, "b", http.StatusServiceUnavailable)
+	p := newTestProxy(t, testConfig(), a.URL, b.URL)
+
+	// Остання 5xx віддається як є, а не підміняється на 502
+	if rec := do(p, "GET", "/", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", rec.Code)
+	}
+
+	p.SetUpstreams(nil)
+	rec := do(p, "GET", "/", "")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), ErrNoUpstream.Error()) {
+		t.Errorf("Expected 503 %q, got %d %q", ErrNoUpstream, rec.Code, rec.Body.String())
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("Connection", "X-Internal")
+		w.Header().Set("X-Internal", "secret")
+		w.Header().Set("X-Powered-By", "go")
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.Headers = HeaderRewrite{
+		SetRequest:     map[string]string{"X-Env": "local"},
+		RemoveRequest:  []string{"Cookie"},
+		SetResponse:    map[string]string{"Server": "lb"},
+		RemoveResponse: []string{"X-Powered-By"},
+	}
+	p := newTestProxy(t, cfg, srv.URL)
+
+	rec := do(p, "GET", "http://api.local/users", "",
+		"X-Forwarded-For", "203.0.113.7",
+		"X-Forwarded-For", "198.51.100.2",
+		"Connection", "X-Debug",
+		"X-Debug", "1",
+		"Cookie", "session=1",
+	)
+
+	requestTests := []struct{ name, want string }{
+		{"X-Forwarded-For", "203.0.113.7, 198.51.100.2, 192.0.2.1"}, // RemoteAddr httptest.NewRequest
+		{"X-Forwarded-Host", "api.local"},
+		{"X-Forwarded-Proto", "http"},
+		{"X-Env", "local"},
+		{"X-Debug", ""}, // перелічений у Connection - hop-by-hop
+		{"Cookie", ""},
+	}
+	for _, tt := range requestTests {
+		if got := received.Get(tt.name); got != tt.want {
+			t.Errorf("request %s: Expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	responseTests := []struct{ name, want string }{
+		{"Server", "lb"},
+		{"X-Powered-By", ""},
+		{"X-Internal", ""},
+		{"X-Upstream", strings.TrimPrefix(srv.URL, "http://")},
+	}
+	for _, tt := range responseTests {
+		if got := rec.Header().Get(tt.name); got != tt.want {
+			t.Errorf("response %s: Expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestEncodedPathPreserved(t *testing.T) {
+	var uri string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uri = r.RequestURI
+	}))
+	defer srv.Close()
+
+	tests := []struct{ base, path, want string }{
+		{srv.URL, "/files/a%2Fb?x=1", "/files/a%2Fb?x=1"},
+		{srv.URL + "/api/", "/files/a%2Fb", "/api/files/a%2Fb"},
+		{srv.URL + "/v%2F1", "/users", "/v%2F1/users"},
+		{srv.URL + "/api", "/users/1", "/api/users/1"},
+	}
+	for _, tt := range tests {
+		p := newTestProxy(t, testConfig(), tt.base)
+		if rec := do(p, "GET", tt.path, ""); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		if uri != tt.want {
+			t.Errorf("Expected %s, got %s", tt.want, uri)
+		}
+	}
+}
+
+func TestActiveHealthCheck(t *testing.T) {
+	var sick atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && sick.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.HealthCheck = HealthCheckConfig{Path: "/health", Interval: time.Hour, Timeout: time.Second, HealthyThreshold: 2, UnhealthyThreshold: 2}
+	p := newTestProxy(t, cfg, srv.URL)
+	ctx := context.Background()
+
+	steps := []struct {
+		sick    bool
+		healthy bool
+	}{
+		{true, true},   // одна невдача - ще здоровий
+		{true, false},  // друга підряд - прибрано
+		{false, false}, // один успіх - ще ні
+		{false, true},  // другий - повернуто
+	}
+	for i, step := range steps {
+		sick.Store(step.sick)
+		p.checkAll(ctx)
+		if got := p.Status()[0].Healthy; got != step.healthy {
+			t.Fatalf("step %d: Expected healthy=%v, got %v", i, step.healthy, got)
+		}
+		if rec := do(p, "GET", "/", ""); (rec.Code == http.StatusOK) != step.healthy {
+			t.Errorf("step %d: Expected routing to follow health, got %d", i, rec.Code)
+		}
+	}
+}
+
+func TestSetUpstreamsKeepsState(t *testing.T) {
+	a, b := backend(t, "a", 0), backend(t, "b", 0)
+	p := newTestProxy(t, testConfig(), a.URL)
+	do(p, "GET", "/", "")
+
+	old := p.Upstreams()[0]
+	if err := p.SetUpstreams([]string{b.URL, a.URL, a.URL}); err != nil {
+		t.Fatal(err)
+	}
+	ups := p.Upstreams()
+	if len(ups) != 2 || ups[1] != old || old.requests.Load() != 1 {
+		t.Errorf("Expected existing upstream to be kept with its stats")
+	}
+
+	p.SetUpstreams([]string{b.URL})
+	if old.Available(time.Now()) {
+		t.Error("Expected removed upstream to be unavailable")
+	}
+	if rec := do(p, "GET", "/", ""); rec.Body.String() != "b:" {
+		t.Errorf("Expected b:, got %s", rec.Body.String())
+	}
+
+	if err := p.SetUpstreams([]string{"not a url"}); err == nil {
+		t.Error("Expected error for relative URL")
+	}
+	if len(p.Upstreams()) != 1 {
+		t.Error("Expected pool unchanged after invalid reload")
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	a, b := backend(t, "a", 0), backend(t, "b", 0)
+	path := filepath.Join(t.TempDir(), "upstreams.txt")
+	os.WriteFile(path, []byte("# user API\n"+a.URL+"\n"), 0o644)
+
+	p := newTestProxy(t, testConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.WatchFile(ctx, path, 10*time.Millisecond)
+
+	waitBody := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if do(p, "GET", "/", "").Body.String() == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Expected %s", want)
+	}
+	waitBody("a:")
+
+	os.WriteFile(path, []byte(b.URL+" # new instance\n"), 0o644)
+	waitBody("b:")
+}
+
+func TestAdminHandler(t *testing.T) {
+	a, b := backend(t, "a", 0), backend(t, "b", 0)
+	p := newTestProxy(t, testConfig(), a.URL)
+	admin := p.AdminHandler()
+
+	body, _ := json.Marshal([]string{a.URL, b.URL})
+	rec := do(admin, "PUT", "/upstreams", string(body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var status []UpstreamStatus
+	json.NewDecoder(do(admin, "GET", "/upstreams", "").Body).Decode(&status)
+	if len(status) != 2 || status[1].URL != b.URL || !status[1].Healthy {
+		t.Errorf("Expected 2 healthy upstreams, got %+v", status)
+	}
+
+	if rec := do(admin, "PUT", "/upstreams", `["/relative"]`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============= Reverse Proxy =============
+// Той самий Proxy з design_patterns/structural/proxy, тільки Subject -
+// http.Handler, а RealSubject - кілька екземплярів бекенду за мережею.
+// Клієнт не знає, скільки їх і який із них відповів.
+
+var (
+	ErrNoUpstream  = errors.New("no available upstream")
+	errNotAbsolute = errors.New("upstream URL must be absolute")
+)
+
+// HeaderRewrite: спочатку Remove, потім Set. Порожнє значення в Set
+// рівнозначне видаленню.
+type HeaderRewrite struct {
+	SetRequest     map[string]string
+	RemoveRequest  []string
+	SetResponse    map[string]string
+	RemoveResponse []string
+}
+
+type Config struct {
+	Balancer    Balancer
+	HealthCheck HealthCheckConfig
+	Passive     PassiveConfig
+
+	// MaxAttempts - скільки різних upstream пробувати для ідемпотентного запиту
+	MaxAttempts int
+	// MaxRetryBody - тіло більшого розміру не буферизується, і запит
+	// не повторюється: прочитаний потік не перемотати
+	MaxRetryBody int64
+
+	Headers HeaderRewrite
+	// UpstreamHeader - якщо задано, у відповідь додається адреса upstream
+	UpstreamHeader string
+
+	Transport http.RoundTripper
+	Logf      func(format string, args ...any)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Balancer: &RoundRobin{},
+		HealthCheck: HealthCheckConfig{
+			Path:               "/health",
+			Interval:           5 * time.Second,
+			Timeout:            time.Second,
+			HealthyThreshold:   2,
+			UnhealthyThreshold: 2,
+		},
+		Passive:      PassiveConfig{MaxFails: 3, EjectDuration: 10 * time.Second},
+		MaxAttempts:  3,
+		MaxRetryBody: 1 << 20,
+		Transport:    http.DefaultTransport,
+		Logf:         log.Printf,
+	}
+}
+
+type Proxy struct {
+	cfg       Config
+	transport http.RoundTripper
+
+	reloadMu sync.Mutex
+	pool     atomic.Pointer[[]*Upstream]
+	now      func() time.Time
+}
+
+func New(cfg Config, urls ...string) (*Proxy, error) {
+	if cfg.Balancer == nil {
+		cfg.Balancer = &RoundRobin{}
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+	cfg.MaxAttempts = max(cfg.MaxAttempts, 1)
+
+	p := &Proxy{cfg: cfg, transport: cfg.Transport, now: time.Now}
+	p.pool.Store(&[]*Upstream{})
+	if err := p.SetUpstreams(urls); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	retryable := isIdempotent(r)
+
+	// Для повтору тіло має бути в пам'яті
+	var body []byte
+	if retryable && r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, p.cfg.MaxRetryBody+1))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > p.cfg.MaxRetryBody {
+			retryable = false
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			body = nil
+		}
+	}
+
+	attempts := 1
+	if retryable {
+		attempts = p.cfg.MaxAttempts
+	}
+
+	tried := make(map[*Upstream]bool)
+	var lastResp *http.Response
+	var lastUpstream *Upstream
+	var lastErr error
+
+	for range attempts {
+		u := p.pick(r, tried)
+		if u == nil {
+			break
+		}
+		tried[u] = true
+
+		u.active.Add(1)
+		u.requests.Add(1)
+		resp, err := p.transport.RoundTrip(p.outbound(r, u, body))
+
+		clientGone := r.Context().Err() != nil
+		failed := err != nil || isFailureStatus(resp.StatusCode)
+		if !clientGone && u.reportResult(!failed, p.cfg.Passive, p.now()) {
+			p.logf("passive: %s ejected for %v", u.URL, p.cfg.Passive.EjectDuration)
+		}
+
+		if err == nil && (!failed || !retryable) {
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			p.copyResponse(w, resp, u)
+			u.active.Add(-1)
+			return
+		}
+		u.active.Add(-1)
+
+		// Невдача. Останню 5xx-відповідь тримаємо: якщо інші upstream
+		// теж не допоможуть, краще віддати її, ніж 502.
+		if err == nil {
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			lastResp, lastUpstream = resp, u
+		} else {
+			lastErr = err
+		}
+		if !retryable || clientGone {
+			break
+		}
+		p.logf("proxy: %s %s via %s failed (%s), retrying", r.Method, r.URL.Path, u.URL.Host, describe(resp, err))
+	}
+
+	switch {
+	case lastResp != nil:
+		p.copyResponse(w, lastResp, lastUpstream)
+	case len(tried) == 0:
+		http.Error(w, ErrNoUpstream.Error(), http.StatusServiceUnavailable)
+	default:
+		p.logf("proxy: %s %s: %v", r.Method, r.URL.Path, lastErr)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+	}
+}
+
+func (p *Proxy) pick(r *http.Request, tried map[*Upstream]bool) *Upstream {
+	now := p.now()
+	return p.cfg.Balancer.Pick(r, *p.pool.Load(), func(u *Upstream) bool {
+		return !tried[u] && u.Available(now)
+	})
+}
+
+// outbound - копія запиту, спрямована на upstream
+func (p *Proxy) outbound(r *http.Request, u *Upstream, body []byte) *http.Request {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Host = ""
+	out.URL.Scheme = u.URL.Scheme
+	out.URL.Host = u.URL.Host
+	out.URL.Path = strings.TrimSuffix(u.URL.Path, "/") + r.URL.Path
+	out.URL.RawPath = ""
+	if r.URL.RawPath != "" || u.URL.RawPath != "" {
+		// без RawPath /a%2Fb пішов би на upstream як /a/b
+		out.URL.RawPath = strings.TrimSuffix(u.URL.EscapedPath(), "/") + r.URL.EscapedPath()
+	}
+
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+
+	removeHopHeaders(out.Header)
+
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		// проксі перед нами можуть додати окремі заголовки, а не дописати в один
+		if prior := out.Header.Values("X-Forwarded-For"); len(prior) > 0 {
+			ip = strings.Join(prior, ", ") + ", " + ip
+		}
+		out.Header.Set("X-Forwarded-For", ip)
+	}
+	out.Header.Set("X-Forwarded-Host", r.Host)
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+	out.Header.Set("X-Forwarded-Proto", proto)
+
+	rewrite(out.Header, p.cfg.Headers.RemoveRequest, p.cfg.Headers.SetRequest)
+	return out
+}
+
+func (p *Proxy) copyResponse(w http.ResponseWriter, resp *http.Response, u *Upstream) {
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	header := w.Header()
+	for name, values := range resp.Header {
+		header[name] = values
+	}
+	rewrite(header, p.cfg.Headers.RemoveResponse, p.cfg.Headers.SetResponse)
+	if p.cfg.UpstreamHeader != "" {
+		header.Set(p.cfg.UpstreamHeader, u.URL.Host)
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	// Відповідь невідомої довжини (SSE, chunked) - flush після кожного шматка
+	if resp.ContentLength != -1 {
+		io.Copy(w, resp.Body)
+		return
+	}
+	rc := http.NewResponseController(w)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *Proxy) logf(format string, args ...any) {
+	if p.cfg.Logf != nil {
+		p.cfg.Logf(format, args...)
+	}
+}
+
+// ============= Helpers =============
+
+// isIdempotent: повтор безпечний за RFC 9110 або клієнт дав Idempotency-Key
+func isIdempotent(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.Header.Get("Idempotency-Key") != ""
+}
+
+// isFailureStatus - відповіді, які означають "цей upstream зараз не може",
+// а не "запит поганий". 500 сюди не входить: це скоріше баг, повтор не допоможе.
+func isFailureStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// Hop-by-hop заголовки стосуються одного з'єднання і не пересилаються (RFC 9110 7.6.1)
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, value := range h.Values("Connection") {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+func rewrite(h http.Header, remove []string, set map[string]string) {
+	for _, name := range remove {
+		h.Del(name)
+	}
+	for name, value := range set {
+		if value == "" {
+			h.Del(name)
+		} else {
+			h.Set(name, value)
+		}
+	}
+}
+
+func describe(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
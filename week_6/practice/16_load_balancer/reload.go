@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ============= Runtime reload =============
+
+// SetUpstreams атомарно замінює пул. Upstream з тією самою адресою
+// зберігає стан (здоров'я, лічильники), нові стартують здоровими,
+// прибрані дообслуговують запити в процесі, але нових не отримують.
+func (p *Proxy) SetUpstreams(urls []string) error {
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	current := make(map[string]*Upstream)
+	for _, u := range *p.pool.Load() {
+		current[u.URL.String()] = u
+	}
+
+	next := make([]*Upstream, 0, len(urls))
+	seen := make(map[string]bool)
+	for _, raw := range urls {
+		u, err := NewUpstream(raw)
+		if err != nil {
+			return err
+		}
+		key := u.URL.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if existing, ok := current[key]; ok {
+			u = existing
+		}
+		next = append(next, u)
+	}
+
+	if rb, ok := p.cfg.Balancer.(rebuilder); ok {
+		rb.Rebuild(next)
+	}
+	p.pool.Store(&next)
+
+	for key, u := range current {
+		if !seen[key] {
+			u.removed.Store(true)
+		}
+	}
+	return nil
+}
+
+func (p *Proxy) Upstreams() []*Upstream {
+	return *p.pool.Load()
+}
+
+func (p *Proxy) Status() []UpstreamStatus {
+	now := p.now()
+	var statuses []UpstreamStatus
+	for _, u := range p.Upstreams() {
+		statuses = append(statuses, u.Status(now))
+	}
+	return statuses
+}
+
+// ParseUpstreams - один URL на рядок, # - коментар
+func ParseUpstreams(data []byte) []string {
+	var urls []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			urls = append(urls, line)
+		}
+	}
+	return urls
+}
+
+// WatchFile перечитує список upstream з файлу, коли змінюється його вміст.
+// Помилка в новому файлі логується, а пул лишається попереднім.
+func (p *Proxy) WatchFile(ctx context.Context, path string, interval time.Duration) error {
+	var lastSum [sha256.Size]byte
+	load := func() error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		if sum == lastSum {
+			return nil
+		}
+		if err := p.SetUpstreams(ParseUpstreams(data)); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		lastSum = sum
+		p.logf("reload: %d upstreams from %s", len(p.Upstreams()), path)
+		return nil
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := load(); err != nil {
+				p.logf("reload: %v", err)
+			}
+		}
+	}
+}
+
+// AdminHandler: GET /upstreams - стан пулу, PUT /upstreams - новий список (JSON-масив URL).
+// Вішати лише на внутрішній порт: хто має доступ, той керує трафіком.
+func (p *Proxy) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /upstreams", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.Status())
+	})
+	mux.HandleFunc("PUT /upstreams", func(w http.ResponseWriter, r *http.Request) {
+		var urls []string
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&urls); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := p.SetUpstreams(urls); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.Status())
+	})
+	return mux
+}
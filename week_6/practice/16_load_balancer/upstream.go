@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============= Upstream =============
+// Upstream - один екземпляр бекенду. У термінах патерну Proxy це
+// RealSubject, тільки за мережею і не один.
+
+type Upstream struct {
+	URL *url.URL
+
+	active   atomic.Int64 // запити в процесі - для least-connections
+	requests atomic.Int64
+	failures atomic.Int64
+	removed  atomic.Bool // прибраний з пулу під час Reload
+
+	mu            sync.Mutex
+	healthy       bool // результат активної перевірки
+	checkStreak   int  // >0 - успіхи підряд, <0 - невдачі підряд
+	consecutive   int  // пасивні помилки підряд
+	ejectedUntil  time.Time
+	lastCheckErr  string
+	lastCheckTime time.Time
+}
+
+func NewUpstream(rawURL string) (*Upstream, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, &url.Error{Op: "parse", URL: rawURL, Err: errNotAbsolute}
+	}
+	// Новий upstream вважається здоровим, доки перевірка не скаже інакше
+	return &Upstream{URL: u, healthy: true}, nil
+}
+
+// Available: здоровий, не викинутий пасивно і не прибраний з пулу
+func (u *Upstream) Available(now time.Time) bool {
+	if u.removed.Load() {
+		return false
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.healthy && !now.Before(u.ejectedUntil)
+}
+
+func (u *Upstream) Active() int64 {
+	return u.active.Load()
+}
+
+// reportResult - пасивна перевірка: MaxFails помилок підряд -> ejection
+func (u *Upstream) reportResult(ok bool, cfg PassiveConfig, now time.Time) (ejected bool) {
+	if !ok {
+		u.failures.Add(1)
+	}
+	if cfg.MaxFails <= 0 {
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if ok {
+		u.consecutive = 0
+		return false
+	}
+	u.consecutive++
+	if u.consecutive >= cfg.MaxFails {
+		u.consecutive = 0
+		u.ejectedUntil = now.Add(cfg.EjectDuration)
+		return true
+	}
+	return false
+}
+
+// reportCheck - активна перевірка з порогами, щоб один таймаут
+// не викидав бекенд, а один успіх не повертав хворий
+func (u *Upstream) reportCheck(err error, cfg HealthCheckConfig, now time.Time) (changed bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.lastCheckTime = now
+	u.lastCheckErr = ""
+	if err != nil {
+		u.lastCheckErr = err.Error()
+		u.checkStreak = min(u.checkStreak, 0) - 1
+		if u.healthy && -u.checkStreak >= cfg.UnhealthyThreshold {
+			u.healthy = false
+			return true
+		}
+		return false
+	}
+
+	u.checkStreak = max(u.checkStreak, 0) + 1
+	if !u.healthy && u.checkStreak >= cfg.HealthyThreshold {
+		u.healthy = true
+		u.ejectedUntil = time.Time{}
+		return true
+	}
+	return false
+}
+
+type UpstreamStatus struct {
+	URL       string `json:"url"`
+	Healthy   bool   `json:"healthy"`
+	Ejected   bool   `json:"ejected"`
+	Active    int64  `json:"active"`
+	Requests  int64  `json:"requests"`
+	Failures  int64  `json:"failures"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+func (u *Upstream) Status(now time.Time) UpstreamStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return UpstreamStatus{
+		URL:       u.URL.String(),
+		Healthy:   u.healthy,
+		Ejected:   now.Before(u.ejectedUntil),
+		Active:    u.active.Load(),
+		Requests:  u.requests.Load(),
+		Failures:  u.failures.Load(),
+		LastError: u.lastCheckErr,
+	}
+}
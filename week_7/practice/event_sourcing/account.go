@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ============= BankAccount events =============
+// Ті самі події, що в week_18/senior/03_event_sourcing.md
+
+type AccountCreated struct {
+	Owner string `json:"owner"`
+}
+
+type MoneyDeposited struct {
+	Amount int `json:"amount"`
+}
+
+type MoneyWithdrawn struct {
+	Amount int `json:"amount"`
+}
+
+type AccountClosed struct{}
+
+func (AccountCreated) EventType() string { return "AccountCreated" }
+func (MoneyDeposited) EventType() string { return "MoneyDeposited" }
+func (MoneyWithdrawn) EventType() string { return "MoneyWithdrawn" }
+func (AccountClosed) EventType() string  { return "AccountClosed" }
+
+func RegisterAccountEvents(r *Registry) {
+	Register[AccountCreated](r)
+	Register[MoneyDeposited](r)
+	Register[MoneyWithdrawn](r)
+	Register[AccountClosed](r)
+}
+
+// ============= BankAccount aggregate =============
+// На відміну від BankAccount з week_25/02_mutex.go, баланс не
+// змінюється "на місці": Deposit породжує MoneyDeposited, а баланс -
+// наслідок Apply. Паралельні зміни розводить версія потоку, а не mutex.
+
+var (
+	ErrAccountExists     = errors.New("account already exists")
+	ErrAccountNotOpen    = errors.New("account is not open")
+	ErrAccountClosed     = errors.New("account is closed")
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+type AccountStatus string
+
+const (
+	StatusNew    AccountStatus = ""
+	StatusActive AccountStatus = "active"
+	StatusClosed AccountStatus = "closed"
+)
+
+type BankAccount struct {
+	AggregateBase
+	state accountState
+}
+
+type accountState struct {
+	Owner   string        `json:"owner"`
+	Balance int           `json:"balance"`
+	Status  AccountStatus `json:"status"`
+}
+
+func NewBankAccount(id string) *BankAccount {
+	return &BankAccount{AggregateBase: AggregateBase{id: id}}
+}
+
+func (a *BankAccount) Owner() string         { return a.state.Owner }
+func (a *BankAccount) Balance() int          { return a.state.Balance }
+func (a *BankAccount) Status() AccountStatus { return a.state.Status }
+
+func (a *BankAccount) String() string {
+	return fmt.Sprintf("%s (%s): $%d, %s, v%d", a.ID(), a.state.Owner, a.state.Balance, a.state.Status, a.Version())
+}
+
+// ============= Commands =============
+
+func (a *BankAccount) Open(owner string) error {
+	if a.state.Status != StatusNew {
+		return fmt.Errorf("%w: %s", ErrAccountExists, a.ID())
+	}
+	if owner == "" {
+		return errors.New("owner cannot be empty")
+	}
+	return a.raise(AccountCreated{Owner: owner})
+}
+
+func (a *BankAccount) Deposit(amount int) error {
+	if err := a.ensureActive(); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	return a.raise(MoneyDeposited{Amount: amount})
+}
+
+func (a *BankAccount) Withdraw(amount int) error {
+	if err := a.ensureActive(); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if amount > a.state.Balance {
+		return fmt.Errorf("%w: balance %d, requested %d", ErrInsufficientFunds, a.state.Balance, amount)
+	}
+	return a.raise(MoneyWithdrawn{Amount: amount})
+}
+
+func (a *BankAccount) Close() error {
+	if err := a.ensureActive(); err != nil {
+		return err
+	}
+	return a.raise(AccountClosed{})
+}
+
+func (a *BankAccount) ensureActive() error {
+	switch a.state.Status {
+	case StatusNew:
+		return fmt.Errorf("%w: %s", ErrAccountNotOpen, a.ID())
+	case StatusClosed:
+		return fmt.Errorf("%w: %s", ErrAccountClosed, a.ID())
+	}
+	return nil
+}
+
+func (a *BankAccount) raise(e Event) error {
+	if err := a.Apply(e); err != nil {
+		return err
+	}
+	a.track(e)
+	return nil
+}
+
+// Apply лише змінює стан - без перевірок: подія вже сталася
+func (a *BankAccount) Apply(e Event) error {
+	switch e := e.(type) {
+	case AccountCreated:
+		a.state.Owner = e.Owner
+		a.state.Status = StatusActive
+	case MoneyDeposited:
+		a.state.Balance += e.Amount
+	case MoneyWithdrawn:
+		a.state.Balance -= e.Amount
+	case AccountClosed:
+		a.state.Status = StatusClosed
+	default:
+		return fmt.Errorf("%w: %s for BankAccount", ErrUnknownEvent, e.EventType())
+	}
+	return nil
+}
+
+func (a *BankAccount) SnapshotState() (json.RawMessage, error) {
+	return json.Marshal(a.state)
+}
+
+func (a *BankAccount) RestoreSnapshot(state json.RawMessage) error {
+	var s accountState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	a.state = s
+	return nil
+}
+
+// ============= Read models =============
+
+type AccountView struct {
+	ID      string
+	Owner   string
+	Balance int
+	Status  AccountStatus
+}
+
+// BalanceProjection - баланси всіх рахунків для запитів без replay
+type BalanceProjection struct {
+	mu       sync.RWMutex
+	accounts map[string]*AccountView
+	last     uint64 // для ідемпотентності при повторній доставці
+}
+
+func NewBalanceProjection() *BalanceProjection {
+	return &BalanceProjection{accounts: make(map[string]*AccountView)}
+}
+
+func (p *BalanceProjection) Name() string { return "balances" }
+
+func (p *BalanceProjection) Handle(ctx context.Context, env Envelope) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if env.Position <= p.last {
+		return nil
+	}
+	p.last = env.Position
+
+	view := p.accounts[env.StreamID]
+	if view == nil {
+		view = &AccountView{ID: env.StreamID}
+		p.accounts[env.StreamID] = view
+	}
+	switch e := env.Event.(type) {
+	case AccountCreated:
+		view.Owner, view.Status = e.Owner, StatusActive
+	case MoneyDeposited:
+		view.Balance += e.Amount
+	case MoneyWithdrawn:
+		view.Balance -= e.Amount
+	case AccountClosed:
+		view.Status = StatusClosed
+	}
+	return nil
+}
+
+func (p *BalanceProjection) Get(id string) (AccountView, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	view, ok := p.accounts[id]
+	if !ok {
+		return AccountView{}, false
+	}
+	return *view, true
+}
+
+// Richest - топ n рахунків за балансом
+func (p *BalanceProjection) Richest(n int) []AccountView {
+	p.mu.RLock()
+	views := make([]AccountView, 0, len(p.accounts))
+	for _, v := range p.accounts {
+		views = append(views, *v)
+	}
+	p.mu.RUnlock()
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Balance != views[j].Balance {
+			return views[i].Balance > views[j].Balance
+		}
+		return views[i].ID < views[j].ID
+	})
+	return views[:min(n, len(views))]
+}
+
+// TotalsProjection - загальні суми по банку
+type TotalsProjection struct {
+	mu        sync.Mutex
+	last      uint64
+	deposited int
+	withdrawn int
+	open      int
+}
+
+func (p *TotalsProjection) Name() string { return "totals" }
+
+func (p *TotalsProjection) Handle(ctx context.Context, env Envelope) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if env.Position <= p.last {
+		return nil
+	}
+	p.last = env.Position
+	switch e := env.Event.(type) {
+	case AccountCreated:
+		p.open++
+	case MoneyDeposited:
+		p.deposited += e.Amount
+	case MoneyWithdrawn:
+		p.withdrawn += e.Amount
+	case AccountClosed:
+		p.open--
+	}
+	return nil
+}
+
+func (p *TotalsProjection) Totals() (deposited, withdrawn, open int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.deposited, p.withdrawn, p.open
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ============= Aggregate =============
+// Агрегат не зберігає стан напряму: команда перевіряє інваріанти і
+// породжує подію, Apply змінює стан за подією. Той самий Apply
+// використовується і для нових подій, і для відновлення з логу.
+
+type Aggregate interface {
+	ID() string
+	Version() uint64
+	Apply(e Event) error
+	base() *AggregateBase
+}
+
+// AggregateBase вбудовується в агрегат: id, версія і ще не збережені події
+type AggregateBase struct {
+	id       string
+	version  uint64  // версія з урахуванням changes
+	changes  []Event // породжені, але ще не дописані в стор
+	position uint64  // глобальна позиція останнього Save
+}
+
+func (b *AggregateBase) ID() string           { return b.id }
+func (b *AggregateBase) Version() uint64      { return b.version }
+func (b *AggregateBase) Changes() []Event     { return b.changes }
+func (b *AggregateBase) base() *AggregateBase { return b }
+
+// Position - позиція в глобальному лозі після останнього Save (0 - ще не
+// зберігався). Projector.WaitFor(ctx, a.Position()) - "read your writes".
+func (b *AggregateBase) Position() uint64 { return b.position }
+
+// committed - версія потоку, на якій агрегат був завантажений
+func (b *AggregateBase) committed() uint64 {
+	return b.version - uint64(len(b.changes))
+}
+
+func (b *AggregateBase) track(e Event) {
+	b.changes = append(b.changes, e)
+	b.version++
+}
+
+// Snapshotter - агрегат, який уміє зберегти і відновити свій стан
+type Snapshotter interface {
+	SnapshotState() (json.RawMessage, error)
+	RestoreSnapshot(state json.RawMessage) error
+}
+
+// ============= Repository =============
+
+const MaxUpdateAttempts = 10
+
+type Repository[A Aggregate] struct {
+	store EventStore
+	newFn func(id string) A
+
+	// SnapshotEvery - знімок після кожних N подій потоку (0 - без знімків)
+	SnapshotEvery uint64
+
+	// Logf отримує помилки знімків: Save через них не падає
+	Logf func(format string, args ...any)
+}
+
+func NewRepository[A Aggregate](store EventStore, newFn func(id string) A) *Repository[A] {
+	return &Repository[A]{store: store, newFn: newFn, Logf: log.Printf}
+}
+
+// Load відновлює агрегат: знімок (якщо є) + події після нього.
+// ErrStreamNotFound - потоку немає.
+func (r *Repository[A]) Load(ctx context.Context, id string) (A, error) {
+	return r.load(ctx, id, 0)
+}
+
+// LoadAt - стан на версії version ("time travel"); знімки не використовуються
+func (r *Repository[A]) LoadAt(ctx context.Context, id string, version uint64) (A, error) {
+	if version == 0 {
+		var zero A
+		return zero, fmt.Errorf("%w: %s at version 0", ErrStreamNotFound, id)
+	}
+	return r.load(ctx, id, version)
+}
+
+func (r *Repository[A]) load(ctx context.Context, id string, toVersion uint64) (A, error) {
+	var zero A
+	a := r.fresh(id)
+	if toVersion == 0 {
+		restored, err := r.fromSnapshot(ctx, id)
+		if err != nil {
+			return zero, err
+		}
+		a = restored
+	}
+
+	b := a.base()
+	events, err := r.store.Load(ctx, id, b.version+1, toVersion)
+	if err != nil {
+		return zero, err
+	}
+	for _, env := range events {
+		if err := a.Apply(env.Event); err != nil {
+			return zero, fmt.Errorf("replay %s@%d: %w", id, env.Version, err)
+		}
+		b.version = env.Version
+	}
+	if b.version == 0 {
+		return zero, fmt.Errorf("%w: %s", ErrStreamNotFound, id)
+	}
+	return a, nil
+}
+
+func (r *Repository[A]) fresh(id string) A {
+	a := r.newFn(id)
+	a.base().id = id
+	return a
+}
+
+// fromSnapshot - агрегат зі знімка або порожній. Знімок, що не
+// читається (битий файл, змінилась структура стану), логується і
+// ігнорується: агрегат просто відновиться з усіх подій.
+func (r *Repository[A]) fromSnapshot(ctx context.Context, id string) (A, error) {
+	a := r.fresh(id)
+	snapper, ok := any(a).(Snapshotter)
+	if !ok || r.SnapshotEvery == 0 {
+		return a, nil
+	}
+	snap, found, err := r.store.LoadSnapshot(ctx, id)
+	if ctx.Err() != nil {
+		return a, ctx.Err()
+	}
+	if err == nil && found {
+		err = snapper.RestoreSnapshot(snap.State)
+	}
+	if err != nil {
+		if r.Logf != nil {
+			r.Logf("snapshot %s ignored: %v", id, err)
+		}
+		return r.fresh(id), nil
+	}
+	if !found {
+		return a, nil
+	}
+	a.base().version = snap.Version
+	return a, nil
+}
+
+// Save дописує нові події з перевіркою версії: якщо потік змінився
+// після Load - ErrConflict, і агрегат треба завантажити знову.
+// Помилка знімка лише логується: події вже в потоці, і помилка від Save
+// змусила б Update повторити команду - гроші зарахувались би двічі.
+func (r *Repository[A]) Save(ctx context.Context, a A) error {
+	b := a.base()
+	if len(b.changes) == 0 {
+		return nil
+	}
+	before := b.committed()
+	res, err := r.store.Append(ctx, b.id, int64(before), b.changes...)
+	if err != nil {
+		return err
+	}
+	b.changes = nil
+	b.version, b.position = res.Version, res.Position
+	version := res.Version
+
+	if r.SnapshotEvery > 0 && version/r.SnapshotEvery > before/r.SnapshotEvery {
+		if snapper, ok := any(a).(Snapshotter); ok {
+			state, err := snapper.SnapshotState()
+			if err == nil {
+				err = r.store.SaveSnapshot(ctx, Snapshot{StreamID: b.id, Version: version, State: state})
+			}
+			// Події вже збережені; знімок - лише оптимізація
+			if err != nil && r.Logf != nil {
+				r.Logf("snapshot %s@%d failed: %v", b.id, version, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Update - Load + fn + Save з повтором на ErrConflict, як storage.Update
+func (r *Repository[A]) Update(ctx context.Context, id string, fn func(A) error) error {
+	for range MaxUpdateAttempts {
+		a, err := r.Load(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+		err = r.Save(ctx, a)
+		if !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: gave up after %d attempts", ErrConflict, MaxUpdateAttempts)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ============= Events =============
+// Подія - незмінний факт ("MoneyDeposited"), а не команда. Типи
+// реєструються в Registry: у лог пишеться ім'я типу і JSON, а при
+// читанні з імені відновлюється конкретна Go-структура.
+
+type Event interface {
+	EventType() string
+}
+
+var (
+	ErrUnknownEvent   = errors.New("unknown event type")
+	ErrConflict       = errors.New("stream version conflict")
+	ErrStreamNotFound = errors.New("stream not found")
+	ErrClosed         = errors.New("event store is closed")
+	ErrCorrupt        = errors.New("event log is corrupt")
+)
+
+// ConflictError - optimistic locking: хтось дописав у потік раніше
+type ConflictError struct {
+	StreamID string
+	Expected int64
+	Actual   uint64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("stream %q: expected version %d, actual %d", e.StreamID, e.Expected, e.Actual)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]reflect.Type)}
+}
+
+// Register[E] реєструє тип події; ім'я береться з EventType нульового E
+func Register[E Event](r *Registry) {
+	var zero E
+	name := zero.EventType()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.types[name]; ok && existing != reflect.TypeFor[E]() {
+		panic(fmt.Sprintf("event type %q registered twice: %s and %s", name, existing, reflect.TypeFor[E]()))
+	}
+	r.types[name] = reflect.TypeFor[E]()
+}
+
+func (r *Registry) encode(e Event) (json.RawMessage, error) {
+	r.mu.RLock()
+	t, ok := r.types[e.EventType()]
+	r.mu.RUnlock()
+	if !ok || t != reflect.TypeOf(e) {
+		return nil, fmt.Errorf("%w: %s (%T)", ErrUnknownEvent, e.EventType(), e)
+	}
+	return json.Marshal(e)
+}
+
+func (r *Registry) decode(name string, data json.RawMessage) (Event, error) {
+	r.mu.RLock()
+	t, ok := r.types[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, name)
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return ptr.Elem().Interface().(Event), nil
+}
+
+// ============= Records =============
+
+// Record - подія в лозі. Version - номер у потоці (1, 2, 3...),
+// Position - глобальний номер у всьому сторі (для проекцій).
+type Record struct {
+	StreamID string          `json:"stream"`
+	Version  uint64          `json:"version"`
+	Position uint64          `json:"position"`
+	Type     string          `json:"type"`
+	Data     json.RawMessage `json:"data"`
+	Time     time.Time       `json:"time"`
+}
+
+// Envelope - Record з уже декодованою подією
+type Envelope struct {
+	Record
+	Event Event
+}
+
+type Snapshot struct {
+	StreamID string          `json:"stream"`
+	Version  uint64          `json:"version"`
+	State    json.RawMessage `json:"state"`
+	Time     time.Time       `json:"time"`
+}
+
+// AnyVersion - дописати без перевірки версії; 0 - потік ще не існує
+const AnyVersion int64 = -1
+
+// AppendResult - куди лягла пачка: нова версія потоку і глобальна
+// позиція її останньої події (до неї можна чекати Projector.WaitFor)
+type AppendResult struct {
+	Version  uint64
+	Position uint64
+}
+
+// EventStore - те, що потрібно Repository і Projector. FileEventStore -
+// перший бекенд; Postgres-таблиця events з UNIQUE (stream, version)
+// лягла б на той самий інтерфейс.
+type EventStore interface {
+	Append(ctx context.Context, streamID string, expected int64, events ...Event) (AppendResult, error)
+	Load(ctx context.Context, streamID string, fromVersion, toVersion uint64) ([]Envelope, error)
+	ReadAll(ctx context.Context, fromPosition uint64, limit int) ([]Envelope, error)
+	Changed() <-chan struct{}
+	SaveSnapshot(ctx context.Context, snap Snapshot) error
+	LoadSnapshot(ctx context.Context, streamID string) (Snapshot, bool, error)
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ============= FileEventStore =============
+// Один append-only файл events.log. Кожен Append - один фрейм
+//
+//	crc32c(4) | len(4) | JSON-масив Record
+//
+// тож пачка подій або записана вся, або (обірваний хвіст) відкидається
+// при відкритті. Після запису - fsync: Append повертається лише тоді,
+// коли події на диску. Індекс (потік -> позиції) і самі записи
+// тримаються в пам'яті й відновлюються з логу при відкритті.
+// Знімки - окремі файли snapshots/<stream>.json (tmp + rename).
+
+const logFile = "events.log"
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type FileEventStore struct {
+	dir      string
+	registry *Registry
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	file    *os.File
+	records []Record            // records[i].Position == i+1
+	streams map[string][]uint64 // потік -> позиції його подій
+	changed chan struct{}       // закривається на кожен Append
+	closed  bool
+	broken  error // невдалий запис не вдалося відкотити - лог у невідомому стані
+}
+
+func OpenFileEventStore(dir string, registry *Registry) (*FileEventStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "snapshots"), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileEventStore{
+		dir:      dir,
+		registry: registry,
+		now:      time.Now,
+		file:     file,
+		streams:  make(map[string][]uint64),
+		changed:  make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover читає фрейми до кінця. Неповний або битий останній фрейм -
+// обірваний запис, його відрізаємо; битий фрейм посередині - ErrCorrupt.
+func (s *FileEventStore) recover() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	r := bufio.NewReader(s.file)
+	var offset int64
+	header := make([]byte, 8)
+
+	for offset < size {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return s.truncate(offset)
+		}
+		sum := binary.BigEndian.Uint32(header[:4])
+		length := int64(binary.BigEndian.Uint32(header[4:]))
+		end := offset + 8 + length
+		if end > size {
+			return s.truncate(offset)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return s.truncate(offset)
+		}
+		if crc32.Checksum(payload, castagnoli) != sum {
+			if end == size {
+				return s.truncate(offset)
+			}
+			return fmt.Errorf("%w: bad checksum at offset %d", ErrCorrupt, offset)
+		}
+
+		var batch []Record
+		if err := json.Unmarshal(payload, &batch); err != nil {
+			return fmt.Errorf("%w: offset %d: %v", ErrCorrupt, offset, err)
+		}
+		for _, rec := range batch {
+			if rec.Position != uint64(len(s.records))+1 || rec.Version != uint64(len(s.streams[rec.StreamID]))+1 {
+				return fmt.Errorf("%w: out of order record %s@%d (position %d)", ErrCorrupt, rec.StreamID, rec.Version, rec.Position)
+			}
+			s.index(rec)
+		}
+		offset = end
+	}
+	_, err = s.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (s *FileEventStore) truncate(offset int64) error {
+	if err := s.file.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileEventStore) rollback(offset int64) {
+	if err := s.truncate(offset); err != nil {
+		s.broken = err
+	}
+}
+
+func (s *FileEventStore) index(rec Record) {
+	s.records = append(s.records, rec)
+	s.streams[rec.StreamID] = append(s.streams[rec.StreamID], rec.Position)
+}
+
+// Append дописує події в потік, якщо його версія дорівнює expected
+// (AnyVersion - без перевірки). Повертає нову версію потоку і позицію останньої події в лозі.
+func (s *FileEventStore) Append(ctx context.Context, streamID string, expected int64, events ...Event) (AppendResult, error) {
+	if err := ctx.Err(); err != nil {
+		return AppendResult{}, err
+	}
+	if streamID == "" {
+		return AppendResult{}, errors.New("empty stream id")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return AppendResult{}, ErrClosed
+	}
+	if s.broken != nil {
+		return AppendResult{}, fmt.Errorf("event store is broken: %w", s.broken)
+	}
+	current := uint64(len(s.streams[streamID]))
+	if expected != AnyVersion && uint64(expected) != current {
+		return AppendResult{}, &ConflictError{StreamID: streamID, Expected: expected, Actual: current}
+	}
+	if len(events) == 0 {
+		res := AppendResult{Version: current}
+		if current > 0 {
+			res.Position = s.streams[streamID][current-1]
+		}
+		return res, nil
+	}
+
+	now := s.now().UTC()
+	batch := make([]Record, len(events))
+	for i, e := range events {
+		data, err := s.registry.encode(e)
+		if err != nil {
+			return AppendResult{}, err
+		}
+		batch[i] = Record{
+			StreamID: streamID,
+			Version:  current + uint64(i) + 1,
+			Position: uint64(len(s.records)) + uint64(i) + 1,
+			Type:     e.EventType(),
+			Data:     data,
+			Time:     now,
+		}
+	}
+
+	payload, err := json.Marshal(batch)
+	if err != nil {
+		return AppendResult{}, err
+	}
+	frame := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint32(frame[:4], crc32.Checksum(payload, castagnoli))
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(payload)))
+	copy(frame[8:], payload)
+
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return AppendResult{}, err
+	}
+	if _, err := s.file.Write(frame); err != nil {
+		s.rollback(offset)
+		return AppendResult{}, err
+	}
+	// Без fsync Append не вважається успішним: фрейм прибираємо, інакше
+	// наступна пачка отримала б ті самі позиції
+	if err := s.file.Sync(); err != nil {
+		s.rollback(offset)
+		return AppendResult{}, err
+	}
+
+	for _, rec := range batch {
+		s.index(rec)
+	}
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return AppendResult{Version: current + uint64(len(events)), Position: batch[len(batch)-1].Position}, nil
+}
+
+// Load - події потоку з версіями [fromVersion, toVersion]; toVersion 0 - до кінця
+func (s *FileEventStore) Load(ctx context.Context, streamID string, fromVersion, toVersion uint64) ([]Envelope, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	positions := s.streams[streamID]
+	if toVersion == 0 || toVersion > uint64(len(positions)) {
+		toVersion = uint64(len(positions))
+	}
+	fromVersion = max(fromVersion, 1)
+	var records []Record
+	for v := fromVersion; v <= toVersion; v++ {
+		records = append(records, s.records[positions[v-1]-1])
+	}
+	s.mu.RUnlock()
+	return s.decode(records)
+}
+
+// ReadAll - до limit подій усіх потоків, починаючи з fromPosition
+func (s *FileEventStore) ReadAll(ctx context.Context, fromPosition uint64, limit int) ([]Envelope, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	start := min(max(fromPosition, 1)-1, uint64(len(s.records)))
+	end := uint64(len(s.records))
+	if limit > 0 {
+		end = min(end, start+uint64(limit))
+	}
+	records := append([]Record(nil), s.records[start:end]...)
+	s.mu.RUnlock()
+	return s.decode(records)
+}
+
+func (s *FileEventStore) decode(records []Record) ([]Envelope, error) {
+	out := make([]Envelope, len(records))
+	for i, rec := range records {
+		e, err := s.registry.decode(rec.Type, rec.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s@%d: %w", rec.StreamID, rec.Version, err)
+		}
+		out[i] = Envelope{Record: rec, Event: e}
+	}
+	return out, nil
+}
+
+// Version - поточна версія потоку (0 - не існує)
+func (s *FileEventStore) Version(streamID string) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return uint64(len(s.streams[streamID]))
+}
+
+// Position - глобальна позиція останньої події
+func (s *FileEventStore) Position() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return uint64(len(s.records))
+}
+
+// Changed закривається після наступного Append. Брати канал треба до
+// читання, інакше подію між ReadAll і очікуванням можна проґавити.
+func (s *FileEventStore) Changed() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.changed
+}
+
+// ============= Snapshots =============
+
+func (s *FileEventStore) snapshotPath(streamID string) string {
+	// id потоку може містити "/" - у назві файлу лише base64url
+	name := base64.RawURLEncoding.EncodeToString([]byte(streamID))
+	return filepath.Join(s.dir, "snapshots", name+".json")
+}
+
+func (s *FileEventStore) SaveSnapshot(ctx context.Context, snap Snapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if snap.Version > s.Version(snap.StreamID) {
+		return fmt.Errorf("snapshot of %q at version %d is ahead of the stream", snap.StreamID, snap.Version)
+	}
+	if snap.Time.IsZero() {
+		snap.Time = s.now().UTC()
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.snapshotPath(snap.StreamID), data)
+}
+
+func (s *FileEventStore) LoadSnapshot(ctx context.Context, streamID string) (Snapshot, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Snapshot{}, false, err
+	}
+	data, err := os.ReadFile(s.snapshotPath(streamID))
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, false, err
+	}
+	return snap, true, nil
+}
+
+// writeFileAtomic - tmp + fsync + rename: після збою лишається старий
+// або новий файл, але не половина
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileEventStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.closed = true
+	return s.file.Close()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+func main() {
+	fmt.Println("╔════════════════════════════════════════════════╗")
+	fmt.Println("║   Event Sourcing: BankAccount                  ║")
+	fmt.Println("╚════════════════════════════════════════════════╝")
+
+	dir, err := os.MkdirTemp("", "event-sourcing-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	registry := NewRegistry()
+	RegisterAccountEvents(registry)
+	store, err := OpenFileEventStore(dir, registry)
+	if err != nil {
+		panic(err)
+	}
+	accounts := NewRepository(store, NewBankAccount)
+	accounts.SnapshotEvery = 50
+
+	fmt.Println("\n💰 1. Команди породжують події:")
+	account := NewBankAccount("acc-123")
+	account.Open("John Doe")
+	account.Deposit(1000)
+	account.Deposit(500)
+	account.Withdraw(300)
+	fmt.Printf("   %d нових подій, баланс $%d\n", len(account.Changes()), account.Balance())
+	if err := accounts.Save(ctx, account); err != nil {
+		panic(err)
+	}
+	events, _ := store.Load(ctx, "acc-123", 1, 0)
+	for _, env := range events {
+		fmt.Printf("   v%d #%d %-15s %s\n", env.Version, env.Position, env.Type, env.Data)
+	}
+
+	fmt.Println("\n🚫 2. Інваріанти перевіряє агрегат:")
+	fmt.Println("  ", account.Withdraw(2000))
+	fmt.Println("  ", account.Deposit(-5))
+
+	fmt.Println("\n🔁 3. Відновлення з подій і time travel:")
+	loaded, _ := accounts.Load(ctx, "acc-123")
+	fmt.Println("   зараз:   ", loaded)
+	past, _ := accounts.LoadAt(ctx, "acc-123", 2)
+	fmt.Println("   версія 2:", past)
+
+	fmt.Println("\n⚔️  4. Optimistic concurrency:")
+	first, _ := accounts.Load(ctx, "acc-123")
+	second, _ := accounts.Load(ctx, "acc-123")
+	first.Deposit(100)
+	second.Withdraw(1200)
+	fmt.Println("   перший Save:", accounts.Save(ctx, first))
+	err = accounts.Save(ctx, second)
+	fmt.Println("   другий Save:", err, errors.Is(err, ErrConflict))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			accounts.Update(ctx, "acc-123", func(a *BankAccount) error { return a.Deposit(10) })
+		}()
+	}
+	wg.Wait()
+	loaded, _ = accounts.Load(ctx, "acc-123")
+	fmt.Println("   10 паралельних Update по $10:", loaded)
+
+	fmt.Println("\n📸 5. Знімки (кожні 50 подій):")
+	for range 120 {
+		accounts.Update(ctx, "acc-123", func(a *BankAccount) error { return a.Deposit(1) })
+	}
+	snap, _, _ := store.LoadSnapshot(ctx, "acc-123")
+	loaded, _ = accounts.Load(ctx, "acc-123")
+	fmt.Printf("   знімок на v%d, Load дочитав %d подій: %s\n", snap.Version, loaded.Version()-snap.Version, loaded)
+
+	fmt.Println("\n📊 6. Асинхронні проекції з checkpoint:")
+	for i, owner := range []string{"Jane Smith", "Bob Wilson"} {
+		a := NewBankAccount(fmt.Sprintf("acc-%d", 200+i))
+		a.Open(owner)
+		a.Deposit(700 * (i + 1))
+		accounts.Save(ctx, a)
+	}
+	checkpoints, err := NewFileCheckpoints(filepath.Join(dir, "checkpoints"))
+	if err != nil {
+		panic(err)
+	}
+	balances := NewBalanceProjection()
+	totals := &TotalsProjection{}
+	runCtx, stop := context.WithCancel(ctx)
+	balanceProjector := NewProjector(store, NewMemoryCheckpoints(), balances)
+	totalsProjector := NewProjector(store, checkpoints, totals)
+	for _, p := range []*Projector{balanceProjector, totalsProjector} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.Run(runCtx); err != nil {
+				fmt.Println("   projector:", err)
+			}
+		}()
+	}
+	balanceProjector.WaitFor(ctx, store.Position())
+	totalsProjector.WaitFor(ctx, store.Position())
+	for _, v := range balances.Richest(3) {
+		fmt.Printf("   %-8s %-11s $%d\n", v.ID, v.Owner, v.Balance)
+	}
+	deposited, withdrawn, open := totals.Totals()
+	fmt.Printf("   усього: +$%d -$%d, відкритих рахунків: %d\n", deposited, withdrawn, open)
+	stop()
+	wg.Wait()
+	saved, _ := checkpoints.Load("totals")
+	fmt.Printf("   checkpoint totals: %d з %d\n", saved, store.Position())
+
+	fmt.Println("\n🔌 7. Перезапуск:")
+	store.Close()
+	store, err = OpenFileEventStore(dir, registry)
+	if err != nil {
+		panic(err)
+	}
+	defer store.Close()
+	accounts = NewRepository(store, NewBankAccount)
+	loaded, _ = accounts.Load(ctx, "acc-123")
+	fmt.Println("   з логу:", loaded)
+
+	fmt.Println("\n🔒 8. Закритий рахунок:")
+	accounts.Update(ctx, "acc-200", func(a *BankAccount) error { return a.Close() })
+	err = accounts.Update(ctx, "acc-200", func(a *BankAccount) error { return a.Deposit(100) })
+	fmt.Println("  ", err)
+
+	fmt.Println("\n✅ Demo completed!")
+}
@@ -0,0 +1,712 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ============= Helpers =============
+
+func newRegistry() *Registry {
+	r := NewRegistry()
+	RegisterAccountEvents(r)
+	return r
+}
+
+func openStore(t testing.TB, dir string) *FileEventStore {
+	t.Helper()
+	store, err := OpenFileEventStore(dir, newRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func newAccounts(t testing.TB) (*FileEventStore, *Repository[*BankAccount]) {
+	t.Helper()
+	store := openStore(t, t.TempDir())
+	return store, NewRepository(store, NewBankAccount)
+}
+
+func openAccount(t *testing.T, repo *Repository[*BankAccount], id string, deposits ...int) {
+	t.Helper()
+	a := NewBankAccount(id)
+	if err := a.Open("owner of " + id); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range deposits {
+		if err := a.Deposit(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Save(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countingStore рахує, скільки подій Repository читає з потоку
+type countingStore struct {
+	EventStore
+	mu     sync.Mutex
+	loaded int
+}
+
+func (s *countingStore) Load(ctx context.Context, id string, from, to uint64) ([]Envelope, error) {
+	events, err := s.EventStore.Load(ctx, id, from, to)
+	s.mu.Lock()
+	s.loaded += len(events)
+	s.mu.Unlock()
+	return events, err
+}
+
+// ============= BankAccount (тести з 03_event_sourcing.md) =============
+
+func TestBankAccountScenario(t *testing.T) {
+	ctx := context.Background()
+	store, repo := newAccounts(t)
+
+	account := NewBankAccount("acc-1")
+	account.Open("John")
+	account.Deposit(1000)
+	if account.Balance() != 1000 {
+		t.Errorf("Expected balance 1000, got %d", account.Balance())
+	}
+
+	account.Deposit(500)
+	account.Withdraw(300)
+	if account.Balance() != 1200 {
+		t.Errorf("Expected balance 1200, got %d", account.Balance())
+	}
+
+	if err := account.Withdraw(2000); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected ErrInsufficientFunds, got %v", err)
+	}
+	if len(account.Changes()) != 4 {
+		t.Errorf("Expected failed command to add no events, got %d", len(account.Changes()))
+	}
+
+	if err := repo.Save(ctx, account); err != nil {
+		t.Fatal(err)
+	}
+	if len(account.Changes()) != 0 || account.Version() != 4 || store.Version("acc-1") != 4 {
+		t.Errorf("Expected saved account at v4, got v%d with %d pending", account.Version(), len(account.Changes()))
+	}
+
+	rebuilt, err := repo.Load(ctx, "acc-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt.Balance() != account.Balance() || rebuilt.Owner() != "John" || rebuilt.Version() != 4 {
+		t.Errorf("Expected rebuilt %v, got %v", account, rebuilt)
+	}
+
+	account.Close()
+	if err := account.Deposit(100); !errors.Is(err, ErrAccountClosed) {
+		t.Errorf("Expected ErrAccountClosed, got %v", err)
+	}
+}
+
+func TestEventOrderMatters(t *testing.T) {
+	ctx := context.Background()
+	store, repo := newAccounts(t)
+	_, err := store.Append(ctx, "acc-6", 0, AccountCreated{Owner: "John"}, MoneyDeposited{Amount: 1000}, MoneyWithdrawn{Amount: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := repo.Load(ctx, "acc-6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance() != 700 {
+		t.Errorf("Expected balance 700, got %d", account.Balance())
+	}
+}
+
+func TestInvalidCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(a *BankAccount)
+		cmd   func(a *BankAccount) error
+		want  error
+	}{
+		{"deposit before open", func(a *BankAccount) {}, func(a *BankAccount) error { return a.Deposit(1) }, ErrAccountNotOpen},
+		{"open twice", func(a *BankAccount) { a.Open("x") }, func(a *BankAccount) error { return a.Open("y") }, ErrAccountExists},
+		{"zero deposit", func(a *BankAccount) { a.Open("x") }, func(a *BankAccount) error { return a.Deposit(0) }, ErrInvalidAmount},
+		{"negative withdraw", func(a *BankAccount) { a.Open("x") }, func(a *BankAccount) error { return a.Withdraw(-1) }, ErrInvalidAmount},
+		{"withdraw after close", func(a *BankAccount) { a.Open("x"); a.Deposit(5); a.Close() },
+			func(a *BankAccount) error { return a.Withdraw(1) }, ErrAccountClosed},
+		{"close twice", func(a *BankAccount) { a.Open("x"); a.Close() }, func(a *BankAccount) error { return a.Close() }, ErrAccountClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewBankAccount("acc")
+			tt.setup(a)
+			before := len(a.Changes())
+			if err := tt.cmd(a); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+			if len(a.Changes()) != before {
+				t.Errorf("Expected no new events, got %d", len(a.Changes())-before)
+			}
+		})
+	}
+}
+
+// ============= Event store =============
+
+func TestAppendVersionCheck(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, t.TempDir())
+
+	if res, err := store.Append(ctx, "s", 0, AccountCreated{Owner: "a"}); err != nil || res.Version != 1 || res.Position != 1 {
+		t.Fatalf("Expected version 1 at position 1, got %+v, %v", res, err)
+	}
+	_, err := store.Append(ctx, "s", 0, MoneyDeposited{Amount: 1})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if conflict.Expected != 0 || conflict.Actual != 1 {
+		t.Errorf("Expected 0 vs 1, got %d vs %d", conflict.Expected, conflict.Actual)
+	}
+	if res, err := store.Append(ctx, "s", AnyVersion, MoneyDeposited{Amount: 1}, MoneyDeposited{Amount: 2}); err != nil || res.Version != 3 {
+		t.Errorf("Expected version 3 with AnyVersion, got %+v, %v", res, err)
+	}
+	if res, _ := store.Append(ctx, "other", 0, AccountCreated{Owner: "b"}); res.Version != 1 || res.Position != 4 {
+		t.Errorf("Expected other@1 at position 4, got %+v", res)
+	}
+	if res, _ := store.Append(ctx, "s", 3); res.Version != 3 || res.Position != 3 {
+		t.Errorf("Expected empty append to report s@3 at position 3, got %+v", res)
+	}
+
+	events, _ := store.Load(ctx, "s", 2, 0)
+	if len(events) != 2 || events[0].Version != 2 || events[1].Event.(MoneyDeposited).Amount != 2 {
+		t.Errorf("Expected versions 2..3, got %+v", events)
+	}
+	if events, _ := store.Load(ctx, "s", 1, 1); len(events) != 1 {
+		t.Errorf("Expected 1 event up to version 1, got %d", len(events))
+	}
+	if events, _ := store.Load(ctx, "missing", 1, 0); len(events) != 0 {
+		t.Errorf("Expected no events for missing stream, got %d", len(events))
+	}
+
+	all, _ := store.ReadAll(ctx, 1, 0)
+	if len(all) != 4 || all[3].StreamID != "other" || all[3].Position != 4 {
+		t.Errorf("Expected 4 events in global order, got %+v", all)
+	}
+	if page, _ := store.ReadAll(ctx, 2, 2); len(page) != 2 || page[0].Position != 2 {
+		t.Errorf("Expected positions 2..3, got %+v", page)
+	}
+}
+
+type Unregistered struct{}
+
+func (Unregistered) EventType() string { return "Unregistered" }
+
+// Той самий EventType, але інший Go-тип
+type FakeDeposit struct{ Amount int }
+
+func (FakeDeposit) EventType() string { return "MoneyDeposited" }
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := openStore(t, dir)
+
+	if _, err := store.Append(ctx, "s", AnyVersion, Unregistered{}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Expected ErrUnknownEvent, got %v", err)
+	}
+	if _, err := store.Append(ctx, "s", AnyVersion, FakeDeposit{Amount: 1}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Expected ErrUnknownEvent for wrong Go type, got %v", err)
+	}
+	if store.Version("s") != 0 {
+		t.Error("Expected rejected batch to write nothing")
+	}
+
+	store.Append(ctx, "s", 0, AccountCreated{Owner: "a"})
+	store.Close()
+
+	// Читач, що не знає типу, отримує помилку, а не порожню подію
+	partial := NewRegistry()
+	Register[MoneyDeposited](partial)
+	reopened, err := OpenFileEventStore(dir, partial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.Load(ctx, "s", 1, 0); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Expected ErrUnknownEvent on decode, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on conflicting registration")
+		}
+	}()
+	Register[FakeDeposit](partial)
+}
+
+func TestReopenAndTornTail(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := OpenFileEventStore(dir, newRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Append(ctx, "acc", 0, AccountCreated{Owner: "a"}, MoneyDeposited{Amount: 10})
+	store.Append(ctx, "acc", 2, MoneyDeposited{Amount: 5})
+	store.Close()
+
+	// Обірваний запис: заголовок обіцяє більше, ніж є у файлі
+	path := filepath.Join(dir, logFile)
+	good, _ := os.Stat(path)
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.Write([]byte{0, 0, 0, 1, 0, 0, 1, 0, '[', '{'})
+	f.Close()
+
+	store = openStore(t, dir)
+	if store.Version("acc") != 3 || store.Position() != 3 {
+		t.Fatalf("Expected 3 events after recovery, got v%d", store.Version("acc"))
+	}
+	if info, _ := os.Stat(path); info.Size() != good.Size() {
+		t.Errorf("Expected torn tail to be truncated to %d, got %d", good.Size(), info.Size())
+	}
+	if res, err := store.Append(ctx, "acc", 3, MoneyWithdrawn{Amount: 1}); err != nil || res.Version != 4 {
+		t.Errorf("Expected append after recovery, got %+v, %v", res, err)
+	}
+
+	repo := NewRepository(store, NewBankAccount)
+	account, err := repo.Load(ctx, "acc")
+	if err != nil || account.Balance() != 14 {
+		t.Errorf("Expected balance 14, got %v, %v", account, err)
+	}
+}
+
+func TestCorruptionInTheMiddle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, _ := OpenFileEventStore(dir, newRegistry())
+	store.Append(ctx, "acc", 0, AccountCreated{Owner: "a"})
+	store.Append(ctx, "acc", 1, MoneyDeposited{Amount: 10})
+	store.Close()
+
+	path := filepath.Join(dir, logFile)
+	data, _ := os.ReadFile(path)
+	data[12] ^= 0xff // байт у payload першого фрейму
+	os.WriteFile(path, data, 0o644)
+
+	if _, err := OpenFileEventStore(dir, newRegistry()); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestClosedStore(t *testing.T) {
+	store, _ := OpenFileEventStore(t.TempDir(), newRegistry())
+	store.Close()
+	if _, err := store.Append(context.Background(), "s", AnyVersion, AccountClosed{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if err := store.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed on second Close, got %v", err)
+	}
+}
+
+// ============= Repository =============
+
+func TestRepositoryLoad(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newAccounts(t)
+
+	if _, err := repo.Load(ctx, "nope"); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("Expected ErrStreamNotFound, got %v", err)
+	}
+
+	openAccount(t, repo, "acc", 100, 200, 300)
+	tests := []struct {
+		version uint64
+		balance int
+	}{
+		{1, 0}, {2, 100}, {3, 300}, {4, 600}, {99, 600},
+	}
+	for _, tt := range tests {
+		a, err := repo.LoadAt(ctx, "acc", tt.version)
+		if err != nil || a.Balance() != tt.balance {
+			t.Errorf("LoadAt(%d): expected balance %d, got %v, %v", tt.version, tt.balance, a, err)
+		}
+	}
+	if _, err := repo.LoadAt(ctx, "acc", 0); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("Expected ErrStreamNotFound for version 0, got %v", err)
+	}
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newAccounts(t)
+	openAccount(t, repo, "acc", 100)
+
+	first, _ := repo.Load(ctx, "acc")
+	second, _ := repo.Load(ctx, "acc")
+	first.Withdraw(100)
+	second.Withdraw(100)
+	if err := repo.Save(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	// Без перевірки версії обидва зняли б по 100 з балансу 100
+	if err := repo.Save(ctx, second); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+
+	err := repo.Update(ctx, "acc", func(a *BankAccount) error { return a.Withdraw(100) })
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected Update to re-check against fresh state, got %v", err)
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newAccounts(t)
+	openAccount(t, repo, "acc")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				err := repo.Update(ctx, "acc", func(a *BankAccount) error { return a.Deposit(10) })
+				if err != nil && !errors.Is(err, ErrConflict) {
+					t.Error(err)
+				}
+				if err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	a, _ := repo.Load(ctx, "acc")
+	if a.Balance() != succeeded*10 || a.Version() != uint64(succeeded)+1 {
+		t.Errorf("Expected balance %d at v%d, got %v", succeeded*10, succeeded+1, a)
+	}
+	if succeeded == 0 {
+		t.Error("Expected at least some updates to succeed")
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	base := openStore(t, t.TempDir())
+	store := &countingStore{EventStore: base}
+	repo := NewRepository[*BankAccount](store, NewBankAccount)
+	repo.SnapshotEvery = 10
+
+	openAccount(t, repo, "acc")
+	for range 24 {
+		if err := repo.Update(ctx, "acc", func(a *BankAccount) error { return a.Deposit(1) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap, found, err := base.LoadSnapshot(ctx, "acc")
+	if err != nil || !found || snap.Version != 20 {
+		t.Fatalf("Expected snapshot at v20, got %+v %v %v", snap, found, err)
+	}
+
+	store.loaded = 0
+	a, err := repo.Load(ctx, "acc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Balance() != 24 || a.Version() != 25 {
+		t.Errorf("Expected balance 24 at v25, got %v", a)
+	}
+	if store.loaded != 5 {
+		t.Errorf("Expected 5 events after snapshot, got %d", store.loaded)
+	}
+
+	// LoadAt ігнорує знімок
+	store.loaded = 0
+	if past, _ := repo.LoadAt(ctx, "acc", 15); past.Balance() != 14 || store.loaded != 15 {
+		t.Errorf("Expected balance 14 from 15 events, got %v after %d", past, store.loaded)
+	}
+
+	// Нечитабельний знімок - повний replay, а не помилка
+	base.SaveSnapshot(ctx, Snapshot{StreamID: "acc", Version: 20, State: []byte(`"not an object"`)})
+	store.loaded = 0
+	a, err = repo.Load(ctx, "acc")
+	if err != nil || a.Balance() != 24 || store.loaded != 25 {
+		t.Errorf("Expected fallback to full replay, got %v after %d events, %v", a, store.loaded, err)
+	}
+
+	if err := base.SaveSnapshot(ctx, Snapshot{StreamID: "acc", Version: 99}); err == nil {
+		t.Error("Expected error for snapshot ahead of the stream")
+	}
+}
+
+// Битий файл знімка не заважає завантажити агрегат: усі події в потоці
+func TestCorruptSnapshotFile(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, t.TempDir())
+	repo := NewRepository[*BankAccount](store, NewBankAccount)
+	repo.SnapshotEvery = 2
+	var logged []string
+	repo.Logf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+
+	openAccount(t, repo, "acc", 100)
+	if err := repo.Update(ctx, "acc", func(a *BankAccount) error { return a.Deposit(50) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(store.snapshotPath("acc"), []byte("{garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := repo.Load(ctx, "acc")
+	if err != nil {
+		t.Fatalf("Expected load from events, got %v", err)
+	}
+	if a.Balance() != 150 || a.Version() != 3 {
+		t.Errorf("Expected 150 at v3, got %v", a)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "snapshot acc ignored") {
+		t.Errorf("Expected corrupt snapshot logged, got %q", logged)
+	}
+}
+
+// failingSnapshots - стор, у якого знімки не пишуться (диск повний тощо)
+type failingSnapshots struct {
+	EventStore
+}
+
+func (failingSnapshots) SaveSnapshot(ctx context.Context, snap Snapshot) error {
+	return errors.New("disk full")
+}
+
+func TestSnapshotFailureDoesNotFailSave(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, t.TempDir())
+	repo := NewRepository[*BankAccount](failingSnapshots{store}, NewBankAccount)
+	repo.SnapshotEvery = 2
+	var logged []string
+	repo.Logf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+
+	openAccount(t, repo, "acc", 100)
+	err := repo.Update(ctx, "acc", func(a *BankAccount) error { return a.Deposit(50) })
+	if err != nil {
+		t.Fatalf("Expected Update to succeed without snapshot, got %v", err)
+	}
+
+	a, _ := repo.Load(ctx, "acc")
+	if a.Balance() != 150 || a.Version() != 3 {
+		t.Errorf("Expected deposit applied once (150 at v3), got %v", a)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "disk full") {
+		t.Errorf("Expected snapshot failure at v2 logged, got %q", logged)
+	}
+}
+
+// ============= Projections =============
+
+type recordingProjection struct {
+	mu        sync.Mutex
+	positions []uint64
+	failAt    uint64
+}
+
+func (p *recordingProjection) Name() string { return "recording" }
+
+func (p *recordingProjection) Handle(ctx context.Context, env Envelope) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if env.Position == p.failAt {
+		return errors.New("boom")
+	}
+	p.positions = append(p.positions, env.Position)
+	return nil
+}
+
+func (p *recordingProjection) seen() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]uint64(nil), p.positions...)
+}
+
+func runProjector(t *testing.T, p *Projector) (stop func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx) }()
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func waitFor(t *testing.T, p *Projector, position uint64) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := p.WaitFor(ctx, position); err != nil {
+		t.Fatalf("Expected projector to reach %d, at %d: %v", position, p.Position(), err)
+	}
+}
+
+func TestProjectorCatchUpAndLive(t *testing.T) {
+	ctx := context.Background()
+	store, repo := newAccounts(t)
+	openAccount(t, repo, "a", 100, 50)
+	openAccount(t, repo, "b", 300)
+
+	balances := NewBalanceProjection()
+	totals := &TotalsProjection{}
+	p1 := NewProjector(store, NewMemoryCheckpoints(), balances)
+	p2 := NewProjector(store, NewMemoryCheckpoints(), totals)
+	p2.BatchSize = 2
+	stop1, stop2 := runProjector(t, p1), runProjector(t, p2)
+
+	waitFor(t, p1, store.Position())
+	if v, _ := balances.Get("a"); v.Balance != 150 || v.Status != StatusActive {
+		t.Errorf("Expected a with 150 after catch-up, got %+v", v)
+	}
+
+	// Read your writes: чекаємо саме на позицію свого Save
+	a, _ := repo.Load(ctx, "a")
+	a.Withdraw(150)
+	if err := repo.Save(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, p1, a.Position())
+	if v, _ := balances.Get("a"); v.Balance != 0 || a.Position() != store.Position() {
+		t.Errorf("Expected a with 0 at position %d, got %+v", a.Position(), v)
+	}
+
+	// Нові події після старту - без перезапуску
+	repo.Update(ctx, "b", func(a *BankAccount) error { return a.Close() })
+	waitFor(t, p1, store.Position())
+	waitFor(t, p2, store.Position())
+
+	if v, _ := balances.Get("b"); v.Status != StatusClosed {
+		t.Errorf("Expected b closed, got %+v", v)
+	}
+	if top := balances.Richest(1); len(top) != 1 || top[0].ID != "b" {
+		t.Errorf("Expected b to be richest, got %+v", top)
+	}
+	if dep, wd, open := totals.Totals(); dep != 450 || wd != 150 || open != 1 {
+		t.Errorf("Expected +450 -150 open 1, got +%d -%d open %d", dep, wd, open)
+	}
+
+	if err := stop1(); err != nil {
+		t.Errorf("Expected nil on cancel, got %v", err)
+	}
+	stop2()
+}
+
+func TestProjectorResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	store, repo := newAccounts(t)
+	checkpoints, err := NewFileCheckpoints(filepath.Join(t.TempDir(), "checkpoints"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	openAccount(t, repo, "a", 1, 2, 3)
+
+	first := &recordingProjection{}
+	p := NewProjector(store, checkpoints, first)
+	stop := runProjector(t, p)
+	waitFor(t, p, 4)
+	stop()
+	if pos, _ := checkpoints.Load("recording"); pos != 4 {
+		t.Fatalf("Expected checkpoint 4, got %d", pos)
+	}
+
+	repo.Update(ctx, "a", func(a *BankAccount) error { return a.Deposit(4) })
+
+	second := &recordingProjection{}
+	p = NewProjector(store, checkpoints, second)
+	stop = runProjector(t, p)
+	waitFor(t, p, 5)
+	stop()
+	if seen := second.seen(); len(seen) != 1 || seen[0] != 5 {
+		t.Errorf("Expected only position 5 after restart, got %v", seen)
+	}
+}
+
+func TestProjectorStopsOnHandlerError(t *testing.T) {
+	store, repo := newAccounts(t)
+	openAccount(t, repo, "a", 1, 2, 3, 4)
+	checkpoints := NewMemoryCheckpoints()
+
+	projection := &recordingProjection{failAt: 4}
+	p := NewProjector(store, checkpoints, projection)
+	p.BatchSize = 2
+	err := p.Run(context.Background())
+	if err == nil || p.Position() != 2 {
+		t.Fatalf("Expected error with checkpoint at 2, got %v at %d", err, p.Position())
+	}
+	if pos, _ := checkpoints.Load("recording"); pos != 2 {
+		t.Errorf("Expected saved checkpoint 2, got %d", pos)
+	}
+
+	// Після виправлення пачка 3..4 доставляється знову - at-least-once
+	projection.failAt = 0
+	stop := runProjector(t, p)
+	waitFor(t, p, 5)
+	stop()
+	want := []uint64{1, 2, 3, 3, 4, 5}
+	if seen := projection.seen(); !slices.Equal(seen, want) {
+		t.Errorf("Expected %v with 3 redelivered, got %v", want, seen)
+	}
+}
+
+func TestProjectionIdempotent(t *testing.T) {
+	ctx := context.Background()
+	balances := NewBalanceProjection()
+	events := []Envelope{
+		{Record: Record{StreamID: "a", Position: 1}, Event: AccountCreated{Owner: "x"}},
+		{Record: Record{StreamID: "a", Position: 2}, Event: MoneyDeposited{Amount: 10}},
+	}
+	for range 2 {
+		for _, env := range events {
+			balances.Handle(ctx, env)
+		}
+	}
+	if v, _ := balances.Get("a"); v.Balance != 10 {
+		t.Errorf("Expected redelivery to be ignored, got balance %d", v.Balance)
+	}
+}
+
+// ============= Benchmarks =============
+
+// Нефункціональна вимога з 03_event_sourcing.md: replay 1000 подій < 100ms
+func BenchmarkRehydrate1000(b *testing.B) {
+	ctx := context.Background()
+	_, repo := newAccounts(b)
+	account := NewBankAccount("acc")
+	account.Open("bench")
+	for range 999 {
+		account.Deposit(1)
+	}
+	if err := repo.Save(ctx, account); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := repo.Load(ctx, "acc"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============= Projections =============
+// Проекція - read model, який будується з потоку всіх подій
+// асинхронно від команд (CQRS). Projector читає стор з позиції
+// checkpoint+1, віддає події в Handle і після кожної пачки зберігає
+// checkpoint. Доставка at-least-once: збій між Handle і збереженням
+// checkpoint дасть повтор пачки, тож Handle має бути ідемпотентним
+// (наприклад, пропускати env.Position, які вже бачив).
+
+type Projection interface {
+	Name() string
+	Handle(ctx context.Context, env Envelope) error
+}
+
+// Checkpoints - остання оброблена позиція на кожну проекцію
+type Checkpoints interface {
+	Load(name string) (uint64, error)
+	Save(name string, position uint64) error
+}
+
+type MemoryCheckpoints struct {
+	mu        sync.Mutex
+	positions map[string]uint64
+}
+
+func NewMemoryCheckpoints() *MemoryCheckpoints {
+	return &MemoryCheckpoints{positions: make(map[string]uint64)}
+}
+
+func (c *MemoryCheckpoints) Load(name string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.positions[name], nil
+}
+
+func (c *MemoryCheckpoints) Save(name string, position uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.positions[name] = position
+	return nil
+}
+
+// FileCheckpoints - файл <name>.checkpoint з числом на проекцію
+type FileCheckpoints struct {
+	dir string
+}
+
+func NewFileCheckpoints(dir string) (*FileCheckpoints, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileCheckpoints{dir: dir}, nil
+}
+
+func (c *FileCheckpoints) path(name string) string {
+	return filepath.Join(c.dir, name+".checkpoint")
+}
+
+func (c *FileCheckpoints) Load(name string) (uint64, error) {
+	data, err := os.ReadFile(c.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func (c *FileCheckpoints) Save(name string, position uint64) error {
+	return writeFileAtomic(c.path(name), []byte(strconv.FormatUint(position, 10)+"\n"))
+}
+
+// ============= Projector =============
+
+type Projector struct {
+	store       EventStore
+	checkpoints Checkpoints
+	projection  Projection
+
+	// BatchSize - скільки подій між збереженнями checkpoint
+	BatchSize int
+
+	mu       sync.Mutex
+	position uint64
+	progress chan struct{} // закривається, коли position зросла
+}
+
+func NewProjector(store EventStore, checkpoints Checkpoints, projection Projection) *Projector {
+	return &Projector{
+		store:       store,
+		checkpoints: checkpoints,
+		projection:  projection,
+		BatchSize:   100,
+		progress:    make(chan struct{}),
+	}
+}
+
+// Run наздоганяє стор від checkpoint і далі чекає нових подій.
+// Повертає nil після скасування ctx або помилку Handle/Checkpoints -
+// checkpoint лишається на останній успішній пачці.
+func (p *Projector) Run(ctx context.Context) error {
+	name := p.projection.Name()
+	position, err := p.checkpoints.Load(name)
+	if err != nil {
+		return fmt.Errorf("projection %s: load checkpoint: %w", name, err)
+	}
+	p.advance(position)
+
+	for {
+		// Канал - до читання: Append між ReadAll і select не загубиться
+		changed := p.store.Changed()
+		batch, err := p.store.ReadAll(ctx, position+1, p.BatchSize)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("projection %s: read from %d: %w", name, position+1, err)
+		}
+
+		for _, env := range batch {
+			if err := p.projection.Handle(ctx, env); err != nil {
+				return fmt.Errorf("projection %s at position %d: %w", name, env.Position, err)
+			}
+		}
+		if len(batch) > 0 {
+			position = batch[len(batch)-1].Position
+			if err := p.checkpoints.Save(name, position); err != nil {
+				return fmt.Errorf("projection %s: save checkpoint: %w", name, err)
+			}
+			p.advance(position)
+			continue
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *Projector) advance(position uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.position = position
+	close(p.progress)
+	p.progress = make(chan struct{})
+}
+
+// Position - остання оброблена і збережена позиція
+func (p *Projector) Position() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.position
+}
+
+// WaitFor чекає, поки проекція обробить position - "read your writes"
+// після команди: Save, WaitFor(ctx, a.Position()), потім запит до проекції
+func (p *Projector) WaitFor(ctx context.Context, position uint64) error {
+	for {
+		p.mu.Lock()
+		reached, progress := p.position >= position, p.progress
+		p.mu.Unlock()
+		if reached {
+			return nil
+		}
+		select {
+		case <-progress:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}